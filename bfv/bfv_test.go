@@ -1,9 +1,13 @@
 package bfv
 
 import (
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"math"
 	"runtime"
 	"testing"
 
@@ -68,9 +72,11 @@ func TestBFV(t *testing.T) {
 		for _, testSet := range []func(testctx *testContext, t *testing.T){
 			testParameters,
 			testEncoder,
+			testDecryptor,
 			testEvaluator,
 			testEvaluatorKeySwitch,
 			testEvaluatorRotate,
+			testEvaluatorExpand,
 			testMarshaller,
 		} {
 			testSet(testctx, t)
@@ -79,6 +85,18 @@ func TestBFV(t *testing.T) {
 	}
 }
 
+func TestDefaultParametersMinSecurity(t *testing.T) {
+	for name, pls := range map[string][]ParametersLiteral{"Default": DefaultParams, "PostQuantum": DefaultPostQuantumParams} {
+		for _, pl := range pls {
+			pl.MinSecurity = 128
+			t.Run(fmt.Sprintf("%s/LogN=%d", name, pl.LogN), func(t *testing.T) {
+				_, err := NewParametersFromLiteral(pl)
+				require.NoError(t, err)
+			})
+		}
+	}
+}
+
 func genTestParams(params Parameters) (testctx *testContext, err error) {
 
 	testctx = new(testContext)
@@ -101,8 +119,12 @@ func genTestParams(params Parameters) (testctx *testContext, err error) {
 	testctx.encoder = NewEncoder(testctx.params)
 	testctx.encryptorPk = NewEncryptor(testctx.params, testctx.pk)
 	testctx.encryptorSk = NewEncryptor(testctx.params, testctx.sk)
-	testctx.decryptor = NewDecryptor(testctx.params, testctx.sk)
-	testctx.evaluator = NewEvaluator(testctx.params, rlwe.EvaluationKey{Rlk: testctx.rlk})
+	if testctx.decryptor, err = NewDecryptor(testctx.params, testctx.sk); err != nil {
+		return nil, err
+	}
+	if testctx.evaluator, err = NewEvaluator(testctx.params, rlwe.EvaluationKey{Rlk: testctx.rlk}); err != nil {
+		return nil, err
+	}
 	return
 
 }
@@ -126,6 +148,50 @@ func testParameters(testctx *testContext, t *testing.T) {
 		assert.False(t, params1.Equals(testctx.params))
 		assert.True(t, params2.Equals(testctx.params))
 	})
+
+	t.Run(testString("Parameters/Fingerprint", testctx.params), func(t *testing.T) {
+
+		params := testctx.params
+
+		// The fingerprint depends on the plaintext modulus
+		paramsT := params.CopyNew()
+		paramsT.ringT, _ = ring.NewRing(params.N(), []uint64{7})
+		require.NotEqual(t, params.Fingerprint(), paramsT.Fingerprint())
+		require.NotEqual(t, params.Fingerprint(), params.Parameters.Fingerprint())
+
+		// The fingerprint is carried by the binary encoding and checked on decoding
+		data, err := params.MarshalBinary()
+		require.NoError(t, err)
+		paramsNew := new(Parameters)
+		require.NoError(t, paramsNew.UnmarshalBinary(data))
+		require.Equal(t, params.Fingerprint(), paramsNew.Fingerprint())
+		binary.BigEndian.PutUint64(data[6:], paramsT.Fingerprint())
+		require.Error(t, new(Parameters).UnmarshalBinary(data))
+
+		// Keys generated with other parameters of the same ring degree are rejected
+		rlweParamsOther, err := rlwe.NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		paramsOther, err := NewParameters(rlweParamsOther, params.T())
+		require.NoError(t, err)
+		kgenOther := NewKeyGenerator(paramsOther)
+		skOther := kgenOther.GenSecretKey()
+
+		_, err = NewDecryptor(params, skOther)
+		require.Error(t, err)
+
+		if params.PCount() != 0 {
+			_, err = NewEvaluator(params, rlwe.EvaluationKey{Rlk: kgenOther.GenRelinearizationKey(skOther, 1)})
+			require.Error(t, err)
+			_, err = NewEvaluators(params, rlwe.EvaluationKey{Rlk: kgenOther.GenRelinearizationKey(skOther, 1)}, 2)
+			require.Error(t, err)
+			require.Panics(t, func() {
+				testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: kgenOther.GenRelinearizationKey(skOther, 1)})
+			})
+			require.Panics(t, func() {
+				testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: kgenOther.GenRotationKeysForRotations([]int{1}, false, skOther)})
+			})
+		}
+	})
 }
 
 func newTestVectorsRingQ(testctx *testContext, encryptor Encryptor, t *testing.T) (coeffs *ring.Poly, plaintext *Plaintext, ciphertext *Ciphertext) {
@@ -240,6 +306,34 @@ func testEncoder(testctx *testContext, t *testing.T) {
 	})
 }
 
+func testDecryptor(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Decryptor/NoiseBudget/", testctx.params), func(t *testing.T) {
+
+		_, plaintext, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		budget := NoiseBudget(testctx.params, testctx.decryptor, ciphertext)
+
+		noise := rlwe.MeasureNoise(testctx.params.Parameters, ciphertext.Ciphertext, testctx.sk, plaintext.Plaintext)
+
+		var logQ float64
+		for _, qi := range testctx.params.Q() {
+			logQ += math.Log2(float64(qi))
+		}
+		logT := math.Log2(float64(testctx.params.T()))
+
+		// The budget is log2(Q/(2*t*||e||)) up to the rounding of the plaintext scaling
+		require.Greater(t, budget, 0)
+		require.InDelta(t, logQ-logT-1-noise.Coefficients.Max, float64(budget), 2)
+
+		ciphertextMul := testctx.evaluator.MulNew(ciphertext, ciphertext)
+		require.Less(t, NoiseBudget(testctx.params, testctx.decryptor, ciphertextMul), budget)
+
+		ciphertextRandom := NewCiphertextRandom(testctx.prng, testctx.params, 1)
+		require.Equal(t, 0, NoiseBudget(testctx.params, testctx.decryptor, ciphertextRandom))
+	})
+}
+
 func testEvaluator(testctx *testContext, t *testing.T) {
 
 	t.Run(testString("Evaluator/Add/op1=Ciphertext/op2=Ciphertext", testctx.params), func(t *testing.T) {
@@ -562,7 +656,8 @@ func testEvaluatorKeySwitch(testctx *testContext, t *testing.T) {
 	}
 
 	sk2 := testctx.kgen.GenSecretKey()
-	decryptorSk2 := NewDecryptor(testctx.params, sk2)
+	decryptorSk2, err := NewDecryptor(testctx.params, sk2)
+	require.NoError(t, err)
 	switchKey := testctx.kgen.GenSwitchingKey(testctx.sk, sk2)
 
 	t.Run(testString("Evaluator/KeySwitch/InPlace", testctx.params), func(t *testing.T) {
@@ -629,6 +724,52 @@ func testEvaluatorRotate(testctx *testContext, t *testing.T) {
 		}
 	})
 
+	t.Run(testString("Evaluator/RotateColumns/RotationDecomposition", testctx.params), func(t *testing.T) {
+
+		rotations := []int{3, 5, 6, 7, 9, 10, 11, 12, 13, 14, 15}
+
+		keys, _ := testctx.params.RotationsCoveringSet(rotations)
+		require.Less(t, len(keys), len(rotations))
+		evaluator, err := NewEvaluator(testctx.params, rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: testctx.kgen.GenRotationKeysForRotations(keys, false, testctx.sk)}, WithRotationDecomposition())
+		require.NoError(t, err)
+		evaluator = evaluator.ShallowCopy()
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		for _, n := range rotations {
+
+			receiver := evaluator.RotateColumnsNew(ciphertext, n)
+			valuesWant := utils.RotateUint64Slots(values.Coeffs[0], n)
+
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{valuesWant}}, receiver, t)
+		}
+	})
+
+	t.Run(testString("Evaluator/RotateColumns/RotationKeyProvider/OtherParameters", testctx.params), func(t *testing.T) {
+
+		rlweParamsOther, err := rlwe.NewParameters(testctx.params.LogN(), testctx.params.Q(), testctx.params.P(), testctx.params.HammingWeight(), 2*testctx.params.Sigma(), testctx.params.RingType())
+		require.NoError(t, err)
+		paramsOther, err := NewParameters(rlweParamsOther, testctx.params.T())
+		require.NoError(t, err)
+		kgenOther := NewKeyGenerator(paramsOther)
+
+		// The keys of a RotationKeyProvider are checked when they are loaded
+		rtksOther := kgenOther.GenRotationKeysForRotations([]int{1}, false, kgenOther.GenSecretKey())
+		evaluator, err := NewEvaluator(testctx.params, rlwe.EvaluationKey{Rlk: testctx.rlk, RtksProvider: rtksOther})
+		require.NoError(t, err)
+
+		_, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		defer func() {
+			err, isError := recover().(error)
+			require.True(t, isError)
+			require.False(t, errors.Is(err, rlwe.ErrMissingRotationKey))
+			require.Contains(t, err.Error(), "generated with other parameters")
+		}()
+
+		evaluator.RotateColumnsNew(ciphertext, 1)
+	})
+
 	rotkey = testctx.kgen.GenRotationKeysForInnerSum(testctx.sk)
 	evaluator = evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
 
@@ -651,6 +792,62 @@ func testEvaluatorRotate(testctx *testContext, t *testing.T) {
 	})
 }
 
+func testEvaluatorExpand(testctx *testContext, t *testing.T) {
+
+	if testctx.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	logN := 4
+	galEls := testctx.params.GaloisElementsForExpand(logN)
+	evaluator := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rtks: testctx.kgen.GenRotationKeys(galEls, testctx.sk)}).(PermutationEvaluator)
+
+	t.Run(testString("Evaluator/Permute", testctx.params), func(t *testing.T) {
+
+		ptRt := NewPlaintextRingT(testctx.params)
+		testctx.uSampler.Read(ptRt.Value)
+
+		pt := NewPlaintext(testctx.params)
+		testctx.encoder.ScaleUp(ptRt, pt)
+
+		ct := evaluator.PermuteNew(testctx.encryptorSk.EncryptNew(pt), galEls[1])
+
+		want := testctx.ringT.NewPoly()
+		testctx.ringT.Permute(ptRt.Value, galEls[1], want)
+
+		have := NewPlaintextRingT(testctx.params)
+		testctx.encoder.ScaleDown(testctx.decryptor.DecryptNew(ct), have)
+		require.True(t, testctx.ringT.Equal(want, have.Value))
+	})
+
+	t.Run(testString("Evaluator/Expand", testctx.params), func(t *testing.T) {
+
+		T := testctx.params.T()
+
+		ptRt := NewPlaintextRingT(testctx.params)
+		values := make([]uint64, 1<<logN)
+		for j := range values {
+			values[j] = utils.RandUint64() % T
+			ptRt.Value.Coeffs[0][j] = values[j]
+		}
+
+		pt := NewPlaintext(testctx.params)
+		testctx.encoder.ScaleUp(ptRt, pt)
+
+		cts := evaluator.Expand(testctx.encryptorSk.EncryptNew(pt), logN)
+		require.Len(t, cts, 1<<logN)
+
+		have := NewPlaintextRingT(testctx.params)
+		for j, ct := range cts {
+			testctx.encoder.ScaleDown(testctx.decryptor.DecryptNew(ct), have)
+			require.Equal(t, (values[j]<<logN)%T, have.Value.Coeffs[0][0])
+			for _, c := range have.Value.Coeffs[0][1:] {
+				require.Zero(t, c)
+			}
+		}
+	})
+}
+
 func testMarshaller(testctx *testContext, t *testing.T) {
 
 	t.Run(testString("Marshaller/Parameters/Binary", testctx.params), func(t *testing.T) {
@@ -663,6 +860,21 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 		assert.Equal(t, testctx.params.MarshalBinarySize(), len(bytes))
 	})
 
+	t.Run(testString("Marshaller/Parameters/Legacy", testctx.params), func(t *testing.T) {
+
+		// Encoding of the PN12QP109 parameters produced by v3.0.1
+		data, err := hex.DecodeString("0c02010000000000000800400999999999999a000000007ffffec001000000800001600100000000400020010000000000010001")
+		require.NoError(t, err)
+
+		paramsWant, err := NewParametersFromLiteral(PN12QP109)
+		require.NoError(t, err)
+
+		var p Parameters
+		require.NoError(t, p.UnmarshalBinary(data))
+		require.True(t, paramsWant.Equals(p))
+		require.Equal(t, paramsWant.Fingerprint(), p.Fingerprint())
+	})
+
 	t.Run(testString("Marshaller/Parameters/JSON", testctx.params), func(t *testing.T) {
 		// checks that parameters can be marshalled without error
 		data, err := json.Marshal(testctx.params)
@@ -18,8 +18,13 @@ type decryptor struct {
 }
 
 // NewDecryptor instantiates a Decryptor for the BFV scheme.
-func NewDecryptor(params Parameters, sk *rlwe.SecretKey) Decryptor {
-	return &decryptor{rlwe.NewDecryptor(params.Parameters, sk), params}
+// It returns an error if the secret key cannot be used with the parameters (see rlwe.Parameters.CheckSecretKey).
+func NewDecryptor(params Parameters, sk *rlwe.SecretKey) (Decryptor, error) {
+	dec, err := rlwe.NewDecryptor(params.Parameters, sk)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptor{dec, params}, nil
 }
 
 // Decrypt decrypts the ciphertext and write the result in ptOut.
@@ -44,6 +49,7 @@ func (dec *decryptor) ShallowCopy() Decryptor {
 // WithKey creates a shallow copy of Decryptor with a new decryption key, in which all the
 // read-only data-structures are shared with the receiver and the temporary buffers
 // are reallocated. The receiver and the returned Decryptor can be used concurrently.
+// The method panics if the secret key cannot be used with the parameters (see rlwe.Parameters.CheckSecretKey).
 func (dec *decryptor) WithKey(sk *rlwe.SecretKey) Decryptor {
 	return &decryptor{dec.Decryptor.WithKey(sk), dec.params}
 }
@@ -1,6 +1,7 @@
 package bfv
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 
@@ -46,6 +47,16 @@ type Evaluator interface {
 	WithKey(rlwe.EvaluationKey) Evaluator
 }
 
+// PermutationEvaluator is an Evaluator that also applies arbitrary Galois automorphisms and expands ciphertexts
+// into the encryptions of their coefficients. The Evaluators returned by NewEvaluator, NewEvaluators, ShallowCopy
+// and WithKey implement it.
+type PermutationEvaluator interface {
+	Evaluator
+	PermuteNew(ct0 *Ciphertext, galEl uint64) (ctOut *Ciphertext)
+	Permute(ct0 *Ciphertext, galEl uint64, ctOut *Ciphertext)
+	Expand(ct0 *Ciphertext, logN int) (ctOut []*Ciphertext)
+}
+
 // evaluator is a struct that holds the necessary elements to perform the homomorphic operations between ciphertexts and/or plaintexts.
 // It also holds a small memory pool used to store intermediate computations.
 type evaluator struct {
@@ -54,7 +65,9 @@ type evaluator struct {
 	*rlwe.KeySwitcher
 
 	rlk  *rlwe.RelinearizationKey
-	rtks *rlwe.RotationKeySet
+	rtks rlwe.RotationKeyProvider
+
+	rotDecomposer *rlwe.RotationDecomposer // nil if the rotation decomposition is not enabled
 
 	basisExtenderQ1toQ2 *ring.BasisExtender
 }
@@ -113,7 +126,13 @@ func newEvaluatorBuffer(eval *evaluatorBase) *evaluatorBuffers {
 // NewEvaluator creates a new Evaluator, that can be used to do homomorphic
 // operations on ciphertexts and/or plaintexts. It stores a small pool of polynomials
 // and ciphertexts that will be used for intermediate values.
-func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) Evaluator {
+// It returns an error if the evaluation key cannot be used with the parameters (see rlwe.Parameters.CheckEvaluationKey).
+func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey, opts ...EvaluatorOption) (Evaluator, error) {
+
+	if err := params.CheckEvaluationKey(evaluationKey); err != nil {
+		return nil, err
+	}
+
 	ev := new(evaluator)
 	ev.evaluatorBase = newEvaluatorPrecomp(params)
 	ev.evaluatorBuffers = newEvaluatorBuffer(ev.evaluatorBase)
@@ -124,28 +143,50 @@ func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) Evaluator
 	}
 
 	ev.basisExtenderQ1toQ2 = ring.NewBasisExtender(ev.ringQ, ev.ringQMul)
-	if params.PCount() != 0 {
+	if params.PCount() != 0 || params.Pow2Base() != 0 {
 		ev.KeySwitcher = rlwe.NewKeySwitcher(params.Parameters)
 	}
 	ev.rlk = evaluationKey.Rlk
-	ev.rtks = evaluationKey.Rtks
-	return ev
+	ev.rtks = evaluationKey.RotationKeys()
+
+	for _, opt := range opts {
+		opt(ev)
+	}
+
+	return ev, nil
+}
+
+// EvaluatorOption is an option of NewEvaluator.
+type EvaluatorOption func(eval *evaluator)
+
+// WithRotationDecomposition is an option of NewEvaluator with which the evaluator evaluates the column rotations
+// whose rotation key is not available as a minimal sequence of available rotations (see rlwe.RotationDecomposer),
+// for example with the power-of-two rotation keys or with the keys given by Parameters.RotationsCoveringSet.
+// Each rotation of the sequence requires a key-switch. The option is kept by ShallowCopy and WithKey.
+func WithRotationDecomposition() EvaluatorOption {
+	return func(eval *evaluator) {
+		eval.rotDecomposer = eval.rotationDecomposerForKey(eval.rtks)
+	}
 }
 
 // NewEvaluators creates n evaluators sharing the same read-only data-structures.
-func NewEvaluators(params Parameters, evaluationKey rlwe.EvaluationKey, n int) []Evaluator {
+// It returns an error if the evaluation key cannot be used with the parameters (see rlwe.Parameters.CheckEvaluationKey).
+func NewEvaluators(params Parameters, evaluationKey rlwe.EvaluationKey, n int) ([]Evaluator, error) {
 	if n <= 0 {
-		return []Evaluator{}
+		return []Evaluator{}, nil
 	}
 	evas := make([]Evaluator, n, n)
 	for i := range evas {
 		if i == 0 {
-			evas[0] = NewEvaluator(params, evaluationKey)
+			var err error
+			if evas[0], err = NewEvaluator(params, evaluationKey); err != nil {
+				return nil, err
+			}
 		} else {
 			evas[i] = evas[i-1].ShallowCopy()
 		}
 	}
-	return evas
+	return evas, nil
 }
 
 // Add adds op0 to op1 and returns the result in ctOut.
@@ -583,12 +624,10 @@ func (eval *evaluator) SwitchKeysNew(ct0 *Ciphertext, switchkey *rlwe.SwitchingK
 	return
 }
 
-// RotateColumns rotates the columns of ct0 by k positions to the left and returns the result in ctOut. As an additional input it requires a RotationKeys struct:
-//
-// - it must either store all the left and right power-of-2 rotations or the specific rotation that is requested.
-//
-// If only the power-of-two rotations are stored, the numbers k and n/2-k will be decomposed in base-2 and the rotation with the lowest
-// hamming weight will be chosen; then the specific rotation will be computed as a sum of powers of two rotations.
+// RotateColumns rotates the columns of ct0 by k positions to the left and returns the result in ctOut.
+// It requires the rotation key of the specific rotation that is requested, unless the evaluator decomposes
+// the rotations (see WithRotationDecomposition), in which case the rotation is computed as a minimal sequence
+// of the available rotations (e.g., of the power-of-two rotations).
 func (eval *evaluator) RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext) {
 
 	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
@@ -603,12 +642,29 @@ func (eval *evaluator) RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext)
 
 		galElL := eval.params.GaloisElementForColumnRotationBy(k)
 		// Looks in the rotation key if the corresponding rotation has been generated or if the input is a plaintext
-		if swk, inSet := eval.rtks.GetRotationKey(galElL); inSet {
-
+		swk, err := eval.rotationKey(galElL)
+		if err == nil {
 			eval.permute(ct0, galElL, swk, ctOut)
+			return
+		}
 
-		} else {
-			panic(fmt.Errorf("evaluator has no rotation key for rotation by %d", k))
+		if eval.rotDecomposer == nil || !errors.Is(err, rlwe.ErrMissingRotationKey) {
+			panic(fmt.Errorf("cannot RotateColumns by %d: %w", k, err))
+		}
+
+		rotations, ok := eval.rotDecomposer.Decompose(k)
+		if !ok {
+			panic(fmt.Errorf("rotation by %d cannot be decomposed with the available rotation keys: %w", k, err))
+		}
+
+		ctOut.Copy(ct0.El())
+
+		for _, r := range rotations {
+			galEl := eval.params.GaloisElementForColumnRotationBy(r)
+			if swk, err = eval.rotationKey(galEl); err != nil {
+				panic(fmt.Errorf("cannot RotateColumns by %d: %w", r, err))
+			}
+			eval.permute(ctOut, galEl, swk, ctOut)
 		}
 	}
 }
@@ -629,11 +685,32 @@ func (eval *evaluator) RotateRows(ct0 *Ciphertext, ctOut *Ciphertext) {
 
 	galEl := eval.params.GaloisElementForRowRotation()
 
-	if key, inSet := eval.rtks.GetRotationKey(galEl); inSet {
-		eval.permute(ct0, galEl, key, ctOut)
-	} else {
-		panic("evaluator has no rotation key for row rotation")
+	key, err := eval.rotationKey(galEl)
+	if err != nil {
+		panic(fmt.Errorf("cannot RotateRows: %w", err))
+	}
+
+	eval.permute(ct0, galEl, key, ctOut)
+}
+
+// rotationKey returns the rotation key of the Galois element galEl, loaded from the RotationKeyProvider of the evaluator
+// and checked against the parameters of the evaluator.
+func (eval *evaluator) rotationKey(galEl uint64) (*rlwe.SwitchingKey, error) {
+
+	if eval.rtks == nil {
+		return nil, rlwe.MissingRotationKeyError(galEl)
 	}
+
+	rtk, err := eval.rtks.RotationKey(galEl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = eval.params.CheckSwitchingKey(rtk); err != nil {
+		return nil, fmt.Errorf("rotation key of Galois element %d: %w", galEl, err)
+	}
+
+	return rtk, nil
 }
 
 // RotateRowsNew rotates the rows of ct0 and returns the result a new Ciphertext.
@@ -664,6 +741,98 @@ func (eval *evaluator) InnerSum(ct0 *Ciphertext, ctOut *Ciphertext) {
 	eval.Add(ctOut, cTmp, ctOut)
 }
 
+// PermuteNew applies the automorphism X -> X^galEl on ct0 and returns the result in a new Ciphertext.
+func (eval *evaluator) PermuteNew(ct0 *Ciphertext, galEl uint64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.Permute(ct0, galEl, ctOut)
+	return
+}
+
+// Permute applies the automorphism X -> X^galEl on ct0 and returns the result in ctOut. It requires the rotation
+// key of the Galois element galEl, which can be generated with rlwe.KeyGenerator.GenRotationKeys or
+// rlwe.KeyGenerator.GenSwitchingKeyForGalois.
+func (eval *evaluator) Permute(ct0 *Ciphertext, galEl uint64, ctOut *Ciphertext) {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot Permute: input and/or output must be of degree 1")
+	}
+
+	key, err := eval.rotationKey(galEl)
+	if err != nil {
+		panic(fmt.Errorf("cannot Permute: %w", err))
+	}
+
+	eval.permute(ct0, galEl, key, ctOut)
+}
+
+// Expand expands the ciphertext ct0 into 2^logN new ciphertexts, such that the j-th ciphertext encrypts the constant
+// polynomial 2^logN * m_j, where m_j is the j-th coefficient of the plaintext of ct0 in the ring Z_T[X]/(X^N+1) (see
+// PlaintextRingT). The coefficients of index larger than or equal to 2^logN must be zero, else they are added to the
+// non-constant coefficients of the outputs. Expand is the oblivious query expansion of SealPIR (Angel et al.,
+// https://eprint.iacr.org/2017/1142): the client can pre-multiply the coefficients by 2^-logN mod T to obtain m_j.
+// It requires the rotation keys of the Galois elements given by Parameters.GaloisElementsForExpand(logN).
+func (eval *evaluator) Expand(ct0 *Ciphertext, logN int) (ctOut []*Ciphertext) {
+
+	if logN > eval.params.LogN() {
+		panic("cannot Expand: logN is larger than the log2 of the ring degree")
+	}
+
+	galEls := eval.params.GaloisElementsForExpand(logN)
+
+	ctOut = []*Ciphertext{ct0.CopyNew()}
+
+	tmp := NewCiphertext(eval.params, 1)
+
+	for i, galEl := range galEls {
+
+		ctNext := make([]*Ciphertext, 2*len(ctOut))
+
+		for j, ct := range ctOut {
+
+			// X^{k} -> (-1)^{k/2^i} X^{k} for the coefficients k multiple of 2^i
+			eval.Permute(ct, galEl, tmp)
+
+			// Coefficients k = 0 mod 2^{i+1}
+			ctNext[j] = eval.AddNew(ct, tmp)
+
+			// Coefficients k = 2^i mod 2^{i+1}, multiplied by X^{-2^i}
+			ctNext[j+len(ctOut)] = eval.SubNew(ct, tmp)
+			for _, pol := range ctNext[j+len(ctOut)].Value {
+				divByMonomial(eval.ringQ, pol, 1<<i, eval.poolQ[0][0], pol)
+			}
+		}
+
+		ctOut = ctNext
+	}
+
+	return
+}
+
+// divByMonomial sets pOut to p * X^{-k} for 0 <= k < N, using the buffer pool. p and pOut can be the same polynomial.
+func divByMonomial(ringQ *ring.Ring, p *ring.Poly, k int, pool, pOut *ring.Poly) {
+
+	N := ringQ.N
+
+	for i, qi := range ringQ.Modulus {
+
+		copy(pool.Coeffs[i], p.Coeffs[i])
+		tmp, out := pool.Coeffs[i], pOut.Coeffs[i]
+
+		// X^{j-k} = -X^{N+j-k} for j < k
+		for j := 0; j < N-k; j++ {
+			out[j] = tmp[j+k]
+		}
+
+		for j := N - k; j < N; j++ {
+			if c := tmp[j+k-N]; c != 0 {
+				out[j] = qi - c
+			} else {
+				out[j] = 0
+			}
+		}
+	}
+}
+
 // ShallowCopy creates a shallow copy of this evaluator in which the read-only data-structures are
 // shared with the receiver.
 func (eval *evaluator) ShallowCopy() Evaluator {
@@ -674,20 +843,41 @@ func (eval *evaluator) ShallowCopy() Evaluator {
 		basisExtenderQ1toQ2: eval.basisExtenderQ1toQ2.ShallowCopy(),
 		rlk:                 eval.rlk,
 		rtks:                eval.rtks,
+		rotDecomposer:       eval.rotDecomposer,
 	}
 }
 
 // WithKey creates a shallow copy of this evaluator in which the read-only data-structures are
 // shared with the receiver but the EvaluationKey is evaluationKey.
+// It panics if the evaluation key cannot be used with the parameters (see rlwe.Parameters.CheckEvaluationKey).
 func (eval *evaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+
+	if err := eval.params.CheckEvaluationKey(evaluationKey); err != nil {
+		panic(fmt.Errorf("cannot WithKey: %w", err))
+	}
+
+	rtks := evaluationKey.RotationKeys()
+	var rotDecomposer *rlwe.RotationDecomposer
+	if eval.rotDecomposer != nil {
+		rotDecomposer = eval.rotationDecomposerForKey(rtks)
+	}
 	return &evaluator{
 		evaluatorBase:       eval.evaluatorBase,
 		KeySwitcher:         eval.KeySwitcher,
 		evaluatorBuffers:    eval.evaluatorBuffers,
 		basisExtenderQ1toQ2: eval.basisExtenderQ1toQ2,
 		rlk:                 evaluationKey.Rlk,
-		rtks:                evaluationKey.Rtks,
+		rtks:                rtks,
+		rotDecomposer:       rotDecomposer,
+	}
+}
+
+func (eval *evaluator) rotationDecomposerForKey(rtks rlwe.RotationKeyProvider) *rlwe.RotationDecomposer {
+	var galEls []uint64
+	if rtks != nil {
+		galEls = rtks.GaloisElements()
 	}
+	return rlwe.NewRotationDecomposer(eval.params.Parameters, galEls)
 }
 
 // permute performs a column rotation on ct0 and returns the result in ctOut
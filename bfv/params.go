@@ -105,9 +105,11 @@ var DefaultPostQuantumParams = []ParametersLiteral{PN12QP101pq, PN13QP202pq, PN1
 // the desired moduli sizes. Users must also specify the coefficient modulus in plaintext-space
 // (T).
 //
-// Optionally, users may specify the error variance (Sigma) and secrets' density (H). If left
-// unset, standard default values for these field are substituted at parameter creation (see
-// NewParametersFromLiteral).
+// Optionally, users may specify the error variance (Sigma), the error distribution (ErrorDistribution),
+// secrets' density (H) and the power-of-two decomposition base of the key-switching (Pow2Base). If left
+// unset, standard default values for these field are substituted at parameter creation (see NewParametersFromLiteral).
+// Users may also opt-in for a strict mode by specifying the minimum estimated bit-security that the parameters
+// must achieve (MinSecurity, see rlwe.Parameters.EstimatedSecurity).
 type ParametersLiteral struct {
 	LogN  int // Log Ring degree (power of 2)
 	Q     []uint64
@@ -117,6 +119,10 @@ type ParametersLiteral struct {
 	H     int
 	Sigma float64 // Gaussian sampling standard deviation
 	T     uint64  // Plaintext modulus
+
+	ErrorDistribution rlwe.ErrorDistribution `json:",omitempty"`
+	Pow2Base          int                    `json:",omitempty"`
+	MinSecurity       int                    `json:",omitempty"`
 }
 
 // Parameters represents a parameter set for the BFV cryptosystem. Its fields are private and
@@ -156,13 +162,22 @@ func NewParameters(rlweParams rlwe.Parameters, t uint64) (p Parameters, err erro
 //
 // See `rlwe.NewParametersFromLiteral` for default values of the optional fields.
 func NewParametersFromLiteral(pl ParametersLiteral) (Parameters, error) {
-	rlweParams, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{LogN: pl.LogN, Q: pl.Q, P: pl.P, LogQ: pl.LogQ, LogP: pl.LogP, H: pl.H, Sigma: pl.Sigma})
+	rlweParams, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{LogN: pl.LogN, Q: pl.Q, P: pl.P, LogQ: pl.LogQ, LogP: pl.LogP, H: pl.H, Sigma: pl.Sigma, ErrorDistribution: pl.ErrorDistribution, Pow2Base: pl.Pow2Base, MinSecurity: pl.MinSecurity})
 	if err != nil {
 		return Parameters{}, err
 	}
 	return NewParameters(rlweParams, pl.T)
 }
 
+// WithParallelism returns a copy of the receiver whose operations are split across the given number of
+// workers (see rlwe.Parameters.WithParallelism).
+func (p Parameters) WithParallelism(workers int) Parameters {
+	p.Parameters = p.Parameters.WithParallelism(workers)
+	p.ringQMul = p.ringQMul.WithParallelism(workers)
+	p.ringT = p.ringT.WithParallelism(workers)
+	return p
+}
+
 // RingQMul returns a pointer to the ring of the extended basis for multiplication
 func (p Parameters) RingQMul() *ring.Ring {
 	return p.ringQMul
@@ -185,6 +200,16 @@ func (p Parameters) Equals(other Parameters) bool {
 	return res
 }
 
+// Fingerprint returns a stable 64-bit digest of the parameters, which extends the fingerprint of the
+// RLWE parameters (see rlwe.Parameters.Fingerprint) with T. The keys record the fingerprint of the
+// RLWE parameters, since they do not depend on T.
+func (p Parameters) Fingerprint() uint64 {
+	var data [8 + 8]byte
+	binary.BigEndian.PutUint64(data[:], p.Parameters.Fingerprint())
+	binary.BigEndian.PutUint64(data[8:], p.T())
+	return rlwe.FingerprintDigest(append([]byte("lattigo/bfv.Parameters"), data[:]...))
+}
+
 // CopyNew makes a deep copy of the receiver and returns it.
 //
 // Deprecated: Parameter is now a read-only struct, except for the UnmarshalBinary method: deep copying should only be
@@ -206,17 +231,34 @@ func (p Parameters) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
+	env, rlweBytes, err := rlwe.OpenEnvelope(rlweBytes, rlwe.KindParameters)
+	if err != nil {
+		return nil, err
+	}
+	env.Kind = rlwe.KindBFVParameters
+	env.ParametersHash = p.Fingerprint()
+
 	// len(rlweBytes) : RLWE parameters
 	// 8 byte : T
 	var tBytes [8]byte
 	binary.BigEndian.PutUint64(tBytes[:], p.T())
-	data := append(rlweBytes, tBytes[:]...)
-	return data, nil
+	return env.Seal(append(rlweBytes, tBytes[:]...)), nil
 }
 
 // UnmarshalBinary decodes a []byte into a parameter set struct.
+// It also accepts the legacy encoding without Envelope.
 func (p *Parameters) UnmarshalBinary(data []byte) (err error) {
-	if err := p.Parameters.UnmarshalBinary(data); err != nil {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindBFVParameters); err != nil {
+		return err
+	}
+
+	if len(data) < 8 {
+		return fmt.Errorf("invalid bfv.Parameter serialization")
+	}
+
+	if err := p.Parameters.UnmarshalBinary(data[:len(data)-8]); err != nil {
 		return err
 	}
 	dataBfv := data[len(data)-8:]
@@ -229,6 +271,11 @@ func (p *Parameters) UnmarshalBinary(data []byte) (err error) {
 	if p.ringT, err = ring.NewRing(p.N(), []uint64{binary.BigEndian.Uint64(dataBfv)}); err != nil {
 		return err
 	}
+
+	if env.ParametersHash != 0 && env.ParametersHash != p.Fingerprint() {
+		return fmt.Errorf("invalid bfv.Parameter serialization: fingerprint does not match the envelope")
+	}
+
 	return nil
 }
 
@@ -239,7 +286,7 @@ func (p Parameters) MarshalBinarySize() int {
 
 // MarshalJSON returns a JSON representation of this parameter set. See `Marshal` from the `encoding/json` package.
 func (p Parameters) MarshalJSON() ([]byte, error) {
-	return json.Marshal(ParametersLiteral{LogN: p.LogN(), Q: p.Q(), P: p.P(), H: p.HammingWeight(), Sigma: p.Sigma(), T: p.T()})
+	return json.Marshal(ParametersLiteral{LogN: p.LogN(), Q: p.Q(), P: p.P(), H: p.HammingWeight(), Sigma: p.Sigma(), T: p.T(), ErrorDistribution: p.ErrorDistribution(), Pow2Base: p.Pow2Base()})
 }
 
 // UnmarshalJSON reads a JSON representation of a parameter set into the receiver Parameter. See `Unmarshal` from the `encoding/json` package.
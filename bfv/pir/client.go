@@ -0,0 +1,102 @@
+package pir
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+// Client is a struct generating the queries to a PIR database and decoding the responses of the Server.
+type Client struct {
+	Parameters
+	encoder   bfv.Encoder
+	encryptor bfv.Encryptor
+	decryptor bfv.Decryptor
+}
+
+// NewClient creates a new Client for a database of parameters params, with the secret key sk.
+// It returns an error if sk cannot be used with the parameters.
+func NewClient(params Parameters, sk *rlwe.SecretKey) (*Client, error) {
+
+	decryptor, err := bfv.NewDecryptor(params.Parameters, sk)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Parameters: params,
+		encoder:    bfv.NewEncoder(params.Parameters),
+		encryptor:  bfv.NewEncryptor(params.Parameters, sk),
+		decryptor:  decryptor,
+	}, nil
+}
+
+// QueryNew returns a new query for the item of index index. The query is a single ciphertext that encrypts,
+// for each dimension k, the value 2^-LogExpansion() mod T at the coefficient offset_k + i_k, where offset_k is
+// the sum of the previous dimensions and i_k is the coordinate of the item along the dimension k.
+// It panics if the index is not in [0, NbItems()).
+func (c *Client) QueryNew(index int) *bfv.Ciphertext {
+
+	if index < 0 || index >= c.NbItems() {
+		panic(fmt.Errorf("cannot QueryNew: index %d is not in [0, %d)", index, c.NbItems()))
+	}
+
+	T := c.T()
+
+	// 2^-LogExpansion() mod T, with T odd
+	scale := uint64(1)
+	for i := 0; i < c.LogExpansion(); i++ {
+		if scale&1 == 1 {
+			scale += T
+		}
+		scale >>= 1
+	}
+
+	ptRt := bfv.NewPlaintextRingT(c.Parameters.Parameters)
+
+	offset, rest := 0, c.NbItems()
+	for _, d := range c.Dims {
+		rest /= d
+		ptRt.Value.Coeffs[0][offset+index/rest] = scale
+		index %= rest
+		offset += d
+	}
+
+	pt := bfv.NewPlaintext(c.Parameters.Parameters)
+	c.encoder.ScaleUp(ptRt, pt)
+
+	return c.encryptor.EncryptNew(pt)
+}
+
+// DecodeResponse decrypts the response of the Server and returns the N coefficients of the retrieved item.
+// It panics if the response does not have ResponseLen() ciphertexts.
+func (c *Client) DecodeResponse(response []*bfv.Ciphertext) []uint64 {
+
+	if len(response) != c.ResponseLen() {
+		panic(fmt.Errorf("cannot DecodeResponse: the response has %d ciphertexts but %d are expected", len(response), c.ResponseLen()))
+	}
+
+	pts := make([]*bfv.PlaintextRingT, len(response))
+	for i := range response {
+		pts[i] = c.decrypt(response[i])
+	}
+
+	// Recomposes the ciphertexts modulo Q0 of the previous dimension from their digits, and decrypts them
+	for width := 2 * c.Digits(); len(pts) > 1; {
+		next := make([]*bfv.PlaintextRingT, len(pts)/width)
+		for i := range next {
+			next[i] = c.decrypt(recompose(c.Parameters, pts[i*width:(i+1)*width]))
+		}
+		pts = next
+	}
+
+	return pts[0].Value.Coeffs[0]
+}
+
+// decrypt decrypts the ciphertext ct modulo Q0 and returns its plaintext in Z_T[X]/(X^N+1).
+func (c *Client) decrypt(ct *bfv.Ciphertext) (ptRt *bfv.PlaintextRingT) {
+	ptRt = bfv.NewPlaintextRingT(c.Parameters.Parameters)
+	c.encoder.ScaleDown(c.decryptor.DecryptNew(decompress(c.Parameters.Parameters, ct)), ptRt)
+	return
+}
@@ -0,0 +1,58 @@
+package pir
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+)
+
+// Database is a struct storing the items of a PIR database, encoded as bfv.PlaintextMul.
+// Items[i] is the item of index i, in row-major order with respect to the dimensions Dims, or
+// nil if the item is empty.
+type Database struct {
+	Parameters
+	Items []*bfv.PlaintextMul
+}
+
+// NewDatabase encodes the items into a new Database of parameters params. Each item is a slice of at most N
+// values in [0, T), which are the coefficients of a polynomial of Z_T[X]/(X^N+1). It returns an error if there
+// are more than params.NbItems() items or if an item is not valid.
+func NewDatabase(params Parameters, items [][]uint64) (db *Database, err error) {
+
+	if err = params.check(); err != nil {
+		return nil, err
+	}
+
+	if len(items) > params.NbItems() {
+		return nil, fmt.Errorf("the number of items (%d) is larger than the size of the database (%d)", len(items), params.NbItems())
+	}
+
+	encoder := bfv.NewEncoder(params.Parameters)
+	ptRt := bfv.NewPlaintextRingT(params.Parameters)
+
+	db = &Database{Parameters: params, Items: make([]*bfv.PlaintextMul, params.NbItems())}
+
+	for i, item := range items {
+
+		if len(item) > params.N() {
+			return nil, fmt.Errorf("item %d has more than N=%d values", i, params.N())
+		}
+
+		coeffs := ptRt.Value.Coeffs[0]
+		for j := range coeffs {
+			coeffs[j] = 0
+		}
+
+		for j, v := range item {
+			if v >= params.T() {
+				return nil, fmt.Errorf("item %d has a value larger than or equal to T=%d", i, params.T())
+			}
+			coeffs[j] = v
+		}
+
+		db.Items[i] = bfv.NewPlaintextMul(params.Parameters)
+		encoder.RingTToMul(ptRt, db.Items[i])
+	}
+
+	return
+}
@@ -0,0 +1,221 @@
+// Package pir implements a single-server private information retrieval (PIR) protocol based on the BFV scheme,
+// following SealPIR (Angel et al., https://eprint.iacr.org/2017/1142).
+//
+// The items of the database are polynomials of Z_T[X]/(X^N+1) (see bfv.PlaintextRingT), arranged in a hypercube
+// and encoded as bfv.PlaintextMul. The client sends a single ciphertext whose coefficients select one index per
+// dimension, which the server obliviously expands with bfv.PermutationEvaluator.Expand. The first dimension is
+// reduced with ciphertext-plaintext products. Each following dimension is reduced after the resulting ciphertexts are switched to
+// the first modulus and decomposed in base T into plaintexts. The response is switched to the first modulus before
+// it is returned, which compresses it.
+package pir
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+// Parameters is a struct for the parameters of a PIR database, whose items are arranged in a hypercube of
+// dimensions Dims, in row-major order.
+type Parameters struct {
+	bfv.Parameters
+	Dims []int
+}
+
+// NewParameters returns the Parameters of a database of nbItems items arranged in a hypercube of nbDims balanced
+// dimensions. It returns an error if the sum of the dimensions is larger than the ring degree.
+func NewParameters(params bfv.Parameters, nbItems, nbDims int) (p Parameters, err error) {
+
+	if nbItems < 1 || nbDims < 1 {
+		return Parameters{}, fmt.Errorf("the number of items and of dimensions must be positive")
+	}
+
+	p = Parameters{Parameters: params, Dims: make([]int, nbDims)}
+
+	// Each dimension is the ceil of the nbDims-th root of the number of remaining items
+	remaining := nbItems
+	for k := range p.Dims {
+		p.Dims[k] = int(math.Ceil(math.Pow(float64(remaining), 1/float64(nbDims-k))))
+		for p.Dims[k] > 1 && int(math.Pow(float64(p.Dims[k]-1), float64(nbDims-k))) >= remaining {
+			p.Dims[k]--
+		}
+		remaining = (remaining + p.Dims[k] - 1) / p.Dims[k]
+	}
+
+	if err = p.check(); err != nil {
+		return Parameters{}, err
+	}
+
+	return
+}
+
+// check returns an error if the dimensions are not positive or if the query cannot be expanded.
+func (p Parameters) check() error {
+
+	if len(p.Dims) == 0 {
+		return fmt.Errorf("the database must have at least one dimension")
+	}
+
+	sum := 0
+	for _, d := range p.Dims {
+		if d < 1 {
+			return fmt.Errorf("the dimensions of the database must be positive")
+		}
+		sum += d
+	}
+
+	if sum > p.N() {
+		return fmt.Errorf("the sum of the dimensions (%d) is larger than the ring degree (%d)", sum, p.N())
+	}
+
+	if p.T()&1 == 0 {
+		return fmt.Errorf("the plaintext modulus must be odd")
+	}
+
+	return nil
+}
+
+// NbItems returns the maximum number of items of the database, which is the product of its dimensions.
+func (p Parameters) NbItems() (n int) {
+	n = 1
+	for _, d := range p.Dims {
+		n *= d
+	}
+	return
+}
+
+// LogExpansion returns the log2 of the number of ciphertexts into which the query is expanded, which is the
+// smallest power of two larger than or equal to the sum of the dimensions.
+func (p Parameters) LogExpansion() int {
+	sum := 0
+	for _, d := range p.Dims {
+		sum += d
+	}
+	return bits.Len64(uint64(sum - 1))
+}
+
+// GaloisElements returns the list of the Galois elements of the rotation keys required by the Server.
+func (p Parameters) GaloisElements() []uint64 {
+	return p.GaloisElementsForExpand(p.LogExpansion())
+}
+
+// Digits returns the number of digits in base T of the integers modulo the first modulus Q0, which is the
+// number of plaintexts into which each polynomial of a ciphertext is decomposed between two dimensions.
+func (p Parameters) Digits() (digits int) {
+	q0, T := p.RingQ().Modulus[0], p.T()
+	for pow := uint64(1); pow < q0; digits++ {
+		if hi, _ := bits.Mul64(pow, T); hi != 0 {
+			return digits + 1
+		}
+		pow *= T
+	}
+	return
+}
+
+// ResponseLen returns the number of ciphertexts of a response, which is (2 * Digits())^(len(Dims)-1).
+func (p Parameters) ResponseLen() (n int) {
+	n = 1
+	for k := 1; k < len(p.Dims); k++ {
+		n *= 2 * p.Digits()
+	}
+	return
+}
+
+// GenEvaluationKey generates, from the secret key sk, the rotation keys required by a Server of parameters params.
+func GenEvaluationKey(params Parameters, sk *rlwe.SecretKey) rlwe.EvaluationKey {
+	return rlwe.EvaluationKey{Rtks: bfv.NewKeyGenerator(params.Parameters).GenRotationKeys(params.GaloisElements(), sk)}
+}
+
+// compress switches the ciphertext ct to the first modulus Q0 and returns the result in a new ciphertext
+// with a single modulus.
+func compress(params bfv.Parameters, ct *bfv.Ciphertext) (ctOut *bfv.Ciphertext) {
+
+	ringQ := params.RingQ()
+	level := ct.Level()
+
+	ctOut = &bfv.Ciphertext{Ciphertext: rlwe.NewCiphertext(params.Parameters, ct.Degree(), level)}
+
+	pool := ringQ.NewPolyLvl(level)
+	for i := range ct.Value {
+		ring.CopyValuesLvl(level, ct.Value[i], ctOut.Value[i])
+		if level > 0 {
+			ringQ.DivRoundByLastModulusManyLvl(level, level, ctOut.Value[i], pool, ctOut.Value[i])
+		}
+		ctOut.Value[i].Coeffs = ctOut.Value[i].Coeffs[:1]
+	}
+
+	return
+}
+
+// decompress maps the ciphertext ct modulo Q0, returned by compress, on a new ciphertext modulo Q that
+// decrypts to the same plaintext, by multiplying it by Q/Q0.
+func decompress(params bfv.Parameters, ct *bfv.Ciphertext) (ctOut *bfv.Ciphertext) {
+
+	ringQ := params.RingQ()
+	q0 := ringQ.Modulus[0]
+
+	// Q/Q0 mod Q0
+	QOverQ0 := uint64(1)
+	for _, qi := range ringQ.Modulus[1:] {
+		QOverQ0 = ring.BRed(QOverQ0, qi%q0, q0, ringQ.BredParams[0])
+	}
+
+	// Q/Q0 = 0 mod Qi for i > 0
+	ctOut = bfv.NewCiphertext(params, ct.Degree())
+	for i := range ct.Value {
+		ring.MulScalarMontgomeryVec(ct.Value[i].Coeffs[0], ctOut.Value[i].Coeffs[0], ring.MForm(QOverQ0, q0, ringQ.BredParams[0]), q0, ringQ.MredParams[0])
+	}
+
+	return
+}
+
+// decompose decomposes the polynomials of the ciphertext ct modulo Q0, returned by compress, in base T, and
+// returns the 2 * Digits() digits as plaintexts of Z_T[X]/(X^N+1), ordered by polynomial and by increasing weight.
+func decompose(params Parameters, ct *bfv.Ciphertext) (pts []*bfv.PlaintextRingT) {
+
+	T, digits := params.T(), params.Digits()
+
+	pts = make([]*bfv.PlaintextRingT, len(ct.Value)*digits)
+	for i := range pts {
+		pts[i] = bfv.NewPlaintextRingT(params.Parameters)
+	}
+
+	for i, pol := range ct.Value {
+		for j, c := range pol.Coeffs[0] {
+			for t := 0; t < digits; t++ {
+				pts[i*digits+t].Value.Coeffs[0][j] = c % T
+				c /= T
+			}
+		}
+	}
+
+	return
+}
+
+// recompose returns the ciphertext modulo Q0 whose polynomials have the digits pts in base T (see decompose).
+func recompose(params Parameters, pts []*bfv.PlaintextRingT) (ct *bfv.Ciphertext) {
+
+	ringQ := params.RingQ()
+	q0, bredParams := ringQ.Modulus[0], ringQ.BredParams[0]
+	T, digits := params.T(), params.Digits()
+
+	degree := len(pts)/digits - 1
+
+	ct = &bfv.Ciphertext{Ciphertext: rlwe.NewCiphertext(params.Parameters.Parameters, degree, 0)}
+
+	for i, pol := range ct.Value {
+		for j := range pol.Coeffs[0] {
+			var c uint64
+			for t := digits - 1; t >= 0; t-- {
+				c = ring.BRedAdd(ring.BRed(c, T, q0, bredParams)+pts[i*digits+t].Value.Coeffs[0][j], q0, bredParams)
+			}
+			pol.Coeffs[0][j] = c
+		}
+	}
+
+	return
+}
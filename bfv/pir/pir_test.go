@@ -0,0 +1,75 @@
+package pir
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+func TestPIR(t *testing.T) {
+
+	params, err := bfv.NewParametersFromLiteral(bfv.PN12QP109)
+	require.NoError(t, err)
+
+	sk := bfv.NewKeyGenerator(params).GenSecretKey()
+
+	t.Run("NewParameters", func(t *testing.T) {
+		p, err := NewParameters(params, 100, 2)
+		require.NoError(t, err)
+		require.Equal(t, []int{10, 10}, p.Dims)
+
+		p, err = NewParameters(params, 17, 3)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, p.NbItems(), 17)
+
+		_, err = NewParameters(params, params.N()+1, 1)
+		require.Error(t, err)
+	})
+
+	for _, nbDims := range []int{1, 2} {
+
+		pirParams, err := NewParameters(params, 10, nbDims)
+		require.NoError(t, err)
+
+		// The last item is left empty
+		items := make([][]uint64, pirParams.NbItems()-1)
+		for i := range items {
+			items[i] = make([]uint64, params.N())
+			for j := range items[i] {
+				items[i][j] = utils.RandUint64() % params.T()
+			}
+		}
+
+		db, err := NewDatabase(pirParams, items)
+		require.NoError(t, err)
+
+		client, err := NewClient(pirParams, sk)
+		require.NoError(t, err)
+		server, err := NewServer(db, GenEvaluationKey(pirParams, sk))
+		require.NoError(t, err)
+
+		for _, index := range []int{0, len(items) / 2, len(items) - 1, len(items)} {
+
+			t.Run(fmt.Sprintf("Retrieve/LogN=%d/Dims=%v/index=%d", params.LogN(), pirParams.Dims, index), func(t *testing.T) {
+
+				response := server.AnswerNew(client.QueryNew(index))
+
+				require.Len(t, response, pirParams.ResponseLen())
+				for _, ct := range response {
+					require.Equal(t, 0, ct.Level())
+				}
+
+				want := make([]uint64, params.N())
+				if index < len(items) {
+					want = items[index]
+				}
+
+				require.Equal(t, want, client.DecodeResponse(response))
+			})
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package pir
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+// Server is a struct answering the queries of a Client to a PIR database.
+type Server struct {
+	bfv.PermutationEvaluator
+	db      *Database
+	encoder bfv.Encoder
+}
+
+// NewServer creates a new Server for the database db, with the rotation keys of evk (see GenEvaluationKey).
+// It returns an error if evk cannot be used with the parameters of the database.
+func NewServer(db *Database, evk rlwe.EvaluationKey) (*Server, error) {
+
+	eval, err := bfv.NewEvaluator(db.Parameters.Parameters, evk)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		PermutationEvaluator: eval.(bfv.PermutationEvaluator),
+		db:                   db,
+		encoder:              bfv.NewEncoder(db.Parameters.Parameters),
+	}, nil
+}
+
+// ShallowCopy creates a shallow copy of this Server in which the read-only data-structures, including the
+// database, are shared with the receiver. It can be used to answer queries concurrently.
+func (s *Server) ShallowCopy() *Server {
+	return &Server{
+		PermutationEvaluator: s.PermutationEvaluator.ShallowCopy().(bfv.PermutationEvaluator),
+		db:                   s.db,
+		encoder:              s.encoder.ShallowCopy(),
+	}
+}
+
+// AnswerNew returns the response to the query, which consists of ResponseLen() ciphertexts modulo Q0.
+// It panics if the query is not a ciphertext of degree 1.
+func (s *Server) AnswerNew(query *bfv.Ciphertext) (response []*bfv.Ciphertext) {
+
+	if query.Degree() != 1 {
+		panic(fmt.Errorf("cannot AnswerNew: the query must be a ciphertext of degree 1"))
+	}
+
+	params := s.db.Parameters
+	ringQ := params.RingQ()
+
+	expanded := s.Expand(query, params.LogExpansion())
+
+	// The items are lists of plaintexts, which are single-element lists for the first dimension
+	items := make([][]*bfv.PlaintextMul, len(s.db.Items))
+	for i := range items {
+		if s.db.Items[i] != nil {
+			items[i] = []*bfv.PlaintextMul{s.db.Items[i]}
+		}
+	}
+
+	width, offset, rest := 1, 0, params.NbItems()
+
+	for k, d := range params.Dims {
+
+		rest /= d
+
+		selectors := expanded[offset : offset+d]
+		for _, ct := range selectors {
+			ringQ.NTT(ct.Value[0], ct.Value[0])
+			ringQ.NTT(ct.Value[1], ct.Value[1])
+		}
+
+		// out[r][e] = sum_j selectors[j] * items[j*rest+r][e]
+		out := make([][]*bfv.Ciphertext, rest)
+		for r := range out {
+			out[r] = make([]*bfv.Ciphertext, width)
+			for e := range out[r] {
+				ct := bfv.NewCiphertext(params.Parameters, 1)
+				for j, sel := range selectors {
+					if item := items[j*rest+r]; item != nil {
+						ringQ.MulCoeffsMontgomeryAndAdd(sel.Value[0], item[e].Value, ct.Value[0])
+						ringQ.MulCoeffsMontgomeryAndAdd(sel.Value[1], item[e].Value, ct.Value[1])
+					}
+				}
+				ringQ.InvNTT(ct.Value[0], ct.Value[0])
+				ringQ.InvNTT(ct.Value[1], ct.Value[1])
+				out[r][e] = compress(params.Parameters, ct)
+			}
+		}
+
+		offset += d
+
+		if k == len(params.Dims)-1 {
+			return out[0]
+		}
+
+		// Decomposes the ciphertexts modulo Q0 into the plaintexts of the next dimension
+		width *= 2 * params.Digits()
+		items = make([][]*bfv.PlaintextMul, rest)
+		for r := range items {
+			items[r] = make([]*bfv.PlaintextMul, 0, width)
+			for _, ct := range out[r] {
+				for _, ptRt := range decompose(params, ct) {
+					ptMul := bfv.NewPlaintextMul(params.Parameters)
+					s.encoder.RingTToMul(ptRt, ptMul)
+					items[r] = append(items[r], ptMul)
+				}
+			}
+		}
+	}
+
+	return
+}
@@ -6,6 +6,7 @@ import (
 	"math/big"
 
 	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
 )
 
 // DecryptAndPrintError decrypts a ciphertext and prints the log2 of the error.
@@ -83,3 +84,57 @@ func center(coeffs []*big.Int, Q *big.Int) {
 		}
 	}
 }
+
+// NoiseBudget returns the invariant noise budget of the ciphertext, that is the number of bits
+// of noise that can still be added to the ciphertext before it stops decrypting correctly.
+// Given the decryption x = <ct, sk> mod Q, the budget is computed as -log2(2*||v||) where
+// v = (t*x - Q*round(t*x/Q))/Q is the invariant noise. A budget of 0 means that
+// the ciphertext might not decrypt correctly anymore. The decryptor must use the secret key of the ciphertext.
+func NoiseBudget(params Parameters, decryptor Decryptor, ct *Ciphertext) int {
+
+	ringQ := params.RingQ()
+
+	level := ct.Level()
+
+	pt := &Plaintext{rlwe.NewPlaintext(params.Parameters, level)}
+	decryptor.Decrypt(ct, pt)
+
+	coeffsBigint := make([]*big.Int, ringQ.N)
+	ringQ.PolyToBigintLvl(level, pt.Value, 1, coeffsBigint)
+
+	Q := ring.NewUint(1)
+	for _, qi := range ringQ.Modulus[:level+1] {
+		Q.Mul(Q, ring.NewUint(qi))
+	}
+
+	QHalf := new(big.Int).Rsh(Q, 1)
+	T := ring.NewUint(params.T())
+
+	maxNoise := new(big.Int)
+	tmp := new(big.Int)
+	for _, c := range coeffsBigint {
+		// t*x mod Q centered in [-Q/2, Q/2), i.e. t*x - Q*round(t*x/Q)
+		c.Mul(c, T)
+		c.Mod(c, Q)
+		if c.Cmp(QHalf) >= 0 {
+			c.Sub(c, Q)
+		}
+		if tmp.Abs(c).Cmp(maxNoise) == 1 {
+			maxNoise.Set(tmp)
+		}
+	}
+
+	if maxNoise.Sign() == 0 {
+		return Q.BitLen() - 1
+	}
+
+	return int(math.Max(0, math.Floor(log2Bigint(Q)-log2Bigint(maxNoise)-1)))
+}
+
+// log2Bigint returns the log2 of a positive big.Int, without overflowing for large values.
+func log2Bigint(x *big.Int) float64 {
+	mant := new(big.Float)
+	exp := new(big.Float).SetInt(x).MantExp(mant)
+	m, _ := mant.Float64()
+	return float64(exp) + math.Log2(m)
+}
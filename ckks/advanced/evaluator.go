@@ -104,9 +104,14 @@ type evaluator struct {
 	params ckks.Parameters
 }
 
-// NewEvaluator creates a new Evaluator.
-func NewEvaluator(params ckks.Parameters, evaluationKey rlwe.EvaluationKey) Evaluator {
-	return &evaluator{ckks.NewEvaluator(params, evaluationKey), params}
+// NewEvaluator creates a new Evaluator with the options of ckks.NewEvaluator.
+// It returns an error if the evaluation key cannot be used with the parameters (see rlwe.Parameters.CheckEvaluationKey).
+func NewEvaluator(params ckks.Parameters, evaluationKey rlwe.EvaluationKey, opts ...ckks.EvaluatorOption) (Evaluator, error) {
+	eval, err := ckks.NewEvaluator(params, evaluationKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &evaluator{eval, params}, nil
 }
 
 // ShallowCopy creates a shallow copy of this evaluator in which all the read-only data-structures are
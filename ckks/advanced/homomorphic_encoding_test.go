@@ -143,7 +143,8 @@ func testCoeffsToSlots(params ckks.Parameters, t *testing.T) {
 		sk := kgen.GenSecretKey()
 		encoder := ckks.NewEncoder(params)
 		encryptor := ckks.NewEncryptor(params, sk)
-		decryptor := ckks.NewDecryptor(params, sk)
+		decryptor, err := ckks.NewDecryptor(params, sk)
+		require.NoError(t, err)
 
 		// Generates the encoding matrices
 		CoeffsToSlotMatrices := NewHomomorphicEncodingMatrixFromLiteral(CoeffsToSlotsParametersLiteral, encoder)
@@ -155,7 +156,8 @@ func testCoeffsToSlots(params ckks.Parameters, t *testing.T) {
 		rotKey := kgen.GenRotationKeysForRotations(rotations, true, sk)
 
 		// Creates an evaluator with the rotation keys
-		eval := NewEvaluator(params, rlwe.EvaluationKey{Rlk: nil, Rtks: rotKey})
+		eval, err := NewEvaluator(params, rlwe.EvaluationKey{Rlk: nil, Rtks: rotKey})
+		require.NoError(t, err)
 
 		// Generates the vector of random complex values
 		values := make([]complex128, params.Slots())
@@ -259,7 +261,8 @@ func testSlotsToCoeffs(params ckks.Parameters, t *testing.T) {
 		sk := kgen.GenSecretKey()
 		encoder := ckks.NewEncoder(params)
 		encryptor := ckks.NewEncryptor(params, sk)
-		decryptor := ckks.NewDecryptor(params, sk)
+		decryptor, err := ckks.NewDecryptor(params, sk)
+		require.NoError(t, err)
 
 		// Generates the encoding matrices
 		SlotsToCoeffsMatrix := NewHomomorphicEncodingMatrixFromLiteral(SlotsToCoeffsParametersLiteral, encoder)
@@ -271,7 +274,8 @@ func testSlotsToCoeffs(params ckks.Parameters, t *testing.T) {
 		rotKey := kgen.GenRotationKeysForRotations(rotations, true, sk)
 
 		// Creates an evaluator with the rotation keys
-		eval := NewEvaluator(params, rlwe.EvaluationKey{Rlk: nil, Rtks: rotKey})
+		eval, err := NewEvaluator(params, rlwe.EvaluationKey{Rlk: nil, Rtks: rotKey})
+		require.NoError(t, err)
 
 		// Generates the n first slots of the test vector (real part to encode)
 		valuesReal := make([]complex128, params.Slots())
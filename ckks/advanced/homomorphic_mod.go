@@ -5,6 +5,7 @@ import (
 	"math/cmplx"
 
 	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/ring"
 	"github.com/tuneinsight/lattigo/v3/utils"
 )
 
@@ -103,6 +104,23 @@ func (evp *EvalModPoly) QDiff() float64 {
 	return evp.qDiff
 }
 
+// CoeffsToSlotsScaling returns the scaling of the CoeffsToSlots step that precedes the EvalMod step, for ciphertexts
+// of 2^logSlots slots whose first modulus is q0. It includes the change of variable for the evaluation of the polynomial
+// approximation, the cancelling factor of the DFT, the scaling factor of the double angle formula and, if the scale used
+// during the EvalMod step is smaller than q0, the part of the division by q0 that cannot be done with the scale.
+func (evp *EvalModPoly) CoeffsToSlotsScaling(q0 float64, logSlots int) float64 {
+
+	K := evp.K() / evp.scFac
+	n := math.Exp2(float64(logSlots + 1))
+
+	qDiv := evp.scalingFactor / math.Exp2(math.Round(math.Log2(q0)))
+	if qDiv > 1 {
+		qDiv = 1
+	}
+
+	return qDiv / (K * n * evp.scFac * evp.qDiff)
+}
+
 // NewEvalModPolyFromLiteral generates an EvalModPoly fromt the EvalModLiteral.
 func NewEvalModPolyFromLiteral(evm EvalModLiteral) EvalModPoly {
 
@@ -189,3 +207,58 @@ func (evm *EvalModLiteral) Depth() (depth int) {
 	depth += int(math.Ceil(math.Log2(float64(evm.ArcSineDeg + 1))))
 	return depth
 }
+
+// ModUpFromQ0 extends the ciphertext ct at level 0 from the first modulus to all the moduli of params, by centering its
+// coefficients around Q0, and returns it in the NTT domain. This is the modulus raising that precedes the CoeffsToSlots
+// and EvalMod steps. The ciphertext is modified in place.
+func ModUpFromQ0(params ckks.Parameters, ct *ckks.Ciphertext) *ckks.Ciphertext {
+
+	ringQ := params.RingQ()
+	maxLevel := params.MaxLevel()
+	N := params.N()
+
+	for i := range ct.Value {
+		if ct.Value[i].IsNTT {
+			ringQ.InvNTTLvl(ct.Level(), ct.Value[i], ct.Value[i])
+		}
+	}
+
+	// Extend the ciphertext with zero polynomials.
+	for u := range ct.Value {
+		ct.Value[u].Coeffs = append(ct.Value[u].Coeffs[:1], make([][]uint64, maxLevel)...)
+		for i := 1; i < maxLevel+1; i++ {
+			ct.Value[u].Coeffs[i] = make([]uint64, N)
+		}
+	}
+
+	//Centers the values around Q0 and extends the basis from Q0 to QL
+	Q := ringQ.Modulus[0]
+	bredparams := ringQ.BredParams
+
+	var coeff, qi uint64
+	for u := range ct.Value {
+
+		for j := 0; j < N; j++ {
+
+			coeff = ct.Value[u].Coeffs[0][j]
+
+			for i := 1; i < maxLevel+1; i++ {
+
+				qi = ringQ.Modulus[i]
+
+				if coeff > (Q >> 1) {
+					ct.Value[u].Coeffs[i][j] = qi - ring.BRedAdd(Q-coeff, qi, bredparams[i])
+				} else {
+					ct.Value[u].Coeffs[i][j] = ring.BRedAdd(coeff, qi, bredparams[i])
+				}
+			}
+		}
+	}
+
+	for i := range ct.Value {
+		ringQ.NTTLvl(maxLevel, ct.Value[i], ct.Value[i])
+		ct.Value[i].IsNTT = true
+	}
+
+	return ct
+}
@@ -99,8 +99,14 @@ func testEvalMod(params ckks.Parameters, t *testing.T) {
 	rlk := kgen.GenRelinearizationKey(sk, 2)
 	encoder := ckks.NewEncoder(params)
 	encryptor := ckks.NewEncryptor(params, sk)
-	decryptor := ckks.NewDecryptor(params, sk)
-	eval := NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: nil})
+	decryptor, err := ckks.NewDecryptor(params, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eval, err := NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	t.Run("SineChebyshevWithArcSine", func(t *testing.T) {
 
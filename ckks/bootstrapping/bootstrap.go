@@ -4,7 +4,7 @@ import (
 	"math"
 
 	"github.com/tuneinsight/lattigo/v3/ckks"
-	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/ckks/advanced"
 )
 
 // Bootstrapp re-encrypt a ciphertext at lvl Q0 to a ciphertext at MaxLevel-k where k is the depth of the bootstrapping circuit.
@@ -42,7 +42,7 @@ func (btp *Bootstrapper) Bootstrapp(ctIn *ckks.Ciphertext) (ctOut *ckks.Cipherte
 	}
 
 	// Step 1 : Extend the basis from q to Q
-	ctOut = btp.modUpFromQ0(ctOut)
+	ctOut = advanced.ModUpFromQ0(btp.params, ctOut)
 
 	// Brings the ciphertext scale to EvalMod-ScalingFactor/(Q0/scale) if Q0 < EvalMod-ScalingFactor.
 	// Does it after modUp to avoid plaintext overflow as the scaling used during EvalMod can be larger than Q0.
@@ -74,50 +74,3 @@ func (btp *Bootstrapper) Bootstrapp(ctIn *ckks.Ciphertext) (ctOut *ckks.Cipherte
 
 	return
 }
-
-func (btp *Bootstrapper) modUpFromQ0(ct *ckks.Ciphertext) *ckks.Ciphertext {
-
-	ringQ := btp.params.RingQ()
-
-	for i := range ct.Value {
-		ringQ.InvNTTLvl(ct.Level(), ct.Value[i], ct.Value[i])
-	}
-
-	// Extend the ciphertext with zero polynomials.
-	for u := range ct.Value {
-		ct.Value[u].Coeffs = append(ct.Value[u].Coeffs, make([][]uint64, btp.params.MaxLevel())...)
-		for i := 1; i < btp.params.MaxLevel()+1; i++ {
-			ct.Value[u].Coeffs[i] = make([]uint64, btp.params.N())
-		}
-	}
-
-	//Centers the values around Q0 and extends the basis from Q0 to QL
-	Q := ringQ.Modulus[0]
-	bredparams := ringQ.BredParams
-
-	var coeff, qi uint64
-	for u := range ct.Value {
-
-		for j := 0; j < btp.params.N(); j++ {
-
-			coeff = ct.Value[u].Coeffs[0][j]
-
-			for i := 1; i < btp.params.MaxLevel()+1; i++ {
-
-				qi = ringQ.Modulus[i]
-
-				if coeff > (Q >> 1) {
-					ct.Value[u].Coeffs[i][j] = qi - ring.BRedAdd(Q-coeff, qi, bredparams[i])
-				} else {
-					ct.Value[u].Coeffs[i][j] = ring.BRedAdd(coeff, qi, bredparams[i])
-				}
-			}
-		}
-	}
-
-	for i := range ct.Value {
-		ringQ.NTTLvl(ct.Level(), ct.Value[i], ct.Value[i])
-	}
-
-	return ct
-}
@@ -1,11 +1,14 @@
 package bootstrapping
 
 import (
+	"fmt"
 	"math"
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/ckks/advanced"
 	"github.com/tuneinsight/lattigo/v3/rlwe"
 )
 
@@ -49,7 +52,7 @@ func BenchmarkBootstrapp(b *testing.B) {
 
 			// ModUp ct_{Q_0} -> ct_{Q_L}
 			t = time.Now()
-			ct = btp.modUpFromQ0(ct)
+			ct = advanced.ModUpFromQ0(btp.params, ct)
 			b.Log("After ModUp  :", time.Since(t), ct.Level(), ct.Scale)
 
 			//SubSum X -> (N/dslots) * Y^dslots
@@ -80,4 +83,23 @@ func BenchmarkBootstrapp(b *testing.B) {
 			b.Log("After StC    :", time.Since(t), ct0.Level(), ct0.Scale)
 		}
 	})
+
+	// Same bootstrapping circuit with the operations split across all the available cores
+	var btpParallel *Bootstrapper
+	if btpParallel, err = NewBootstrapper(params.WithParallelism(runtime.NumCPU()), btpParams, rlwe.EvaluationKey{Rlk: rlk, Rtks: rotkeys}); err != nil {
+		panic(err)
+	}
+
+	b.Run(ParamsToString(params, fmt.Sprintf("Bootstrapp/Parallel/workers=%d/", runtime.NumCPU())), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+
+			bootstrappingScale := math.Exp2(math.Round(math.Log2(btpParallel.params.QiFloat64(0) / btpParallel.evalModPoly.MessageRatio())))
+
+			b.StopTimer()
+			ct := ckks.NewCiphertext(params, 1, 0, bootstrappingScale)
+			b.StartTimer()
+
+			btpParallel.Bootstrapp(ct)
+		}
+	})
 }
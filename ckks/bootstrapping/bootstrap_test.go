@@ -97,7 +97,10 @@ func testbootstrap(params ckks.Parameters, btpParams Parameters, t *testing.T) {
 		rlk := kgen.GenRelinearizationKey(sk, 2)
 		encoder := ckks.NewEncoder(params)
 		encryptor := ckks.NewEncryptor(params, sk)
-		decryptor := ckks.NewDecryptor(params, sk)
+		decryptor, err := ckks.NewDecryptor(params, sk)
+		if err != nil {
+			panic(err)
+		}
 
 		rotations := btpParams.RotationsForBootstrapping(params.LogN(), params.LogSlots())
 		rotkeys := kgen.GenRotationKeysForRotations(rotations, true, sk)
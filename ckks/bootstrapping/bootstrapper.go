@@ -56,7 +56,9 @@ func NewBootstrapper(params ckks.Parameters, btpParams Parameters, btpKey rlwe.E
 		return nil, fmt.Errorf("invalid bootstrapping key: %w", err)
 	}
 
-	btp.Evaluator = advanced.NewEvaluator(params, btpKey)
+	if btp.Evaluator, err = advanced.NewEvaluator(params, btpKey); err != nil {
+		return nil, fmt.Errorf("invalid bootstrapping key: %w", err)
+	}
 
 	return
 }
@@ -78,10 +80,16 @@ func (bb *bootstrapperBase) CheckKeys(btpKey rlwe.EvaluationKey) (err error) {
 		return fmt.Errorf("relinearization key is nil")
 	}
 
-	if btpKey.Rtks == nil {
+	rtks := btpKey.RotationKeys()
+	if rtks == nil {
 		return fmt.Errorf("rotation key is nil")
 	}
 
+	galEls := make(map[uint64]bool)
+	for _, galEl := range rtks.GaloisElements() {
+		galEls[galEl] = true
+	}
+
 	rotKeyIndex := []int{}
 	rotKeyIndex = append(rotKeyIndex, bb.params.RotationsForTrace(bb.params.LogSlots(), bb.params.MaxLogSlots())...)
 	rotKeyIndex = append(rotKeyIndex, bb.CoeffsToSlotsParameters.Rotations(bb.params.LogN(), bb.params.LogSlots())...)
@@ -90,7 +98,7 @@ func (bb *bootstrapperBase) CheckKeys(btpKey rlwe.EvaluationKey) (err error) {
 	rotMissing := []int{}
 	for _, i := range rotKeyIndex {
 		galEl := bb.params.GaloisElementForColumnRotationBy(int(i))
-		if _, generated := btpKey.Rtks.Keys[galEl]; !generated {
+		if !galEls[galEl] {
 			rotMissing = append(rotMissing, i)
 		}
 	}
@@ -116,34 +124,19 @@ func newBootstrapperBase(params ckks.Parameters, btpParams Parameters, btpKey rl
 
 	bb.evalModPoly = advanced.NewEvalModPolyFromLiteral(btpParams.EvalModParameters)
 
-	scFac := bb.evalModPoly.ScFac()
-	K := bb.evalModPoly.K() / scFac
-	n := float64(2 * params.Slots())
-
-	// Correcting factor for approximate division by Q
-	// The second correcting factor for approximate multiplication by Q is included in the coefficients of the EvalMod polynomials
-	qDiff := bb.evalModPoly.QDiff()
-
 	// Q0/|m|
 	bb.q0OverMessageRatio = math.Exp2(math.Round(math.Log2(params.QiFloat64(0) / bb.evalModPoly.MessageRatio())))
 
-	// If the scale used during the EvalMod step is smaller than Q0, then we cannot increase the scale during
-	// the EvalMod step to get a free division by MessageRatio, and we need to do this division (totally or partly)
-	// during the CoeffstoSlots step
-	qDiv := btpParams.EvalModParameters.ScalingFactor / math.Exp2(math.Round(math.Log2(params.QiFloat64(0))))
-
-	// Sets qDiv to 1 if there is enough room for the division to happen using scale manipulation.
-	if qDiv > 1 {
-		qDiv = 1
-	}
-
 	encoder := ckks.NewEncoder(bb.params)
 
 	// CoeffsToSlots vectors
 	// Change of variable for the evaluation of the Chebyshev polynomial + cancelling factor for the DFT and SubSum + eventual scaling factor for the double angle formula
+	// If the scale used during the EvalMod step is smaller than Q0, then we cannot increase the scale during
+	// the EvalMod step to get a free division by MessageRatio, and we need to do this division (totally or partly)
+	// during the CoeffstoSlots step
 	bb.CoeffsToSlotsParameters.LogN = params.LogN()
 	bb.CoeffsToSlotsParameters.LogSlots = params.LogSlots()
-	bb.CoeffsToSlotsParameters.Scaling = qDiv / (K * n * scFac * qDiff)
+	bb.CoeffsToSlotsParameters.Scaling = bb.evalModPoly.CoeffsToSlotsScaling(params.QiFloat64(0), params.LogSlots())
 	bb.ctsMatrices = advanced.NewHomomorphicEncodingMatrixFromLiteral(bb.CoeffsToSlotsParameters, encoder)
 
 	// SlotsToCoeffs vectors
@@ -67,7 +67,7 @@ func (ct *Ciphertext) CopyNew() (ctc *Ciphertext) {
 	return
 }
 
-// GetDataLen returns the length in bytes of the target Ciphertext.
+// GetDataLen returns the length in bytes of the target Ciphertext, without its Envelope.
 func (ct *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
 	// MetaData is :
 	// 8 byte : Scale
@@ -81,28 +81,48 @@ func (ct *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
 }
 
 // MarshalBinary encodes a Ciphertext on a byte slice. The total size
-// in byte is 4 + 8* N * numberModuliQ * (degree + 1).
+// in byte is rlwe.EnvelopeHeaderLen + 8 + 1 + (4 + 8 * N * numberModuliQ) * (degree + 1).
 func (ct *Ciphertext) MarshalBinary() (data []byte, err error) {
 
-	dataScale := make([]byte, 8)
-
-	binary.LittleEndian.PutUint64(dataScale, math.Float64bits(ct.Scale))
-
 	var dataCt []byte
 	if dataCt, err = ct.Ciphertext.MarshalBinary(); err != nil {
 		return nil, err
 	}
 
-	return append(dataScale, dataCt...), nil
+	env, dataCt, err := rlwe.OpenEnvelope(dataCt, rlwe.KindCiphertext)
+	if err != nil {
+		return nil, err
+	}
+	env.Kind = rlwe.KindCKKSCiphertext
+
+	data = make([]byte, rlwe.EnvelopeHeaderLen+8+len(dataCt))
+	binary.LittleEndian.PutUint64(data[rlwe.EnvelopeHeaderLen:], math.Float64bits(ct.Scale))
+	copy(data[rlwe.EnvelopeHeaderLen+8:], dataCt)
+	env.WriteHeader(data)
+
+	return data, nil
 }
 
 // UnmarshalBinary decodes a previously marshaled Ciphertext on the target Ciphertext.
+// It also accepts the legacy encoding without Envelope.
 func (ct *Ciphertext) UnmarshalBinary(data []byte) (err error) {
-	if len(data) < 10 { // cf. ct.GetDataLen()
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindCKKSCiphertext); err != nil {
+		return err
+	}
+
+	if len(data) < 18 { // cf. ct.GetDataLen()
 		return errors.New("too small bytearray")
 	}
 
 	ct.Scale = math.Float64frombits(binary.LittleEndian.Uint64(data[0:8]))
 	ct.Ciphertext = new(rlwe.Ciphertext)
-	return ct.Ciphertext.UnmarshalBinary(data[8:])
+	if err = ct.Ciphertext.UnmarshalBinary(data[8:]); err != nil {
+		return err
+	}
+
+	ct.ParametersFingerprint = env.ParametersHash
+
+	return env.CheckPoly(ct.Value[0], ct.Degree())
 }
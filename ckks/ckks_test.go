@@ -1,7 +1,11 @@
 package ckks
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"math"
@@ -116,6 +120,126 @@ func TestCKKS(t *testing.T) {
 	}
 }
 
+func TestDefaultParametersMinSecurity(t *testing.T) {
+	for name, pls := range map[string][]ParametersLiteral{
+		"Default":                       DefaultParams,
+		"ConjugateInvariant":            DefaultConjugateInvariantParams,
+		"PostQuantum":                   DefaultPostQuantumParams,
+		"PostQuantumConjugateInvariant": DefaultPostQuantumConjugateInvariantParams,
+	} {
+		for _, pl := range pls {
+			pl.MinSecurity = 128
+			t.Run(fmt.Sprintf("%s/LogN=%d", name, pl.LogN), func(t *testing.T) {
+				_, err := NewParametersFromLiteral(pl)
+				require.NoError(t, err)
+			})
+		}
+	}
+}
+
+func TestHoistedRotationsWithoutP(t *testing.T) {
+
+	pl := PN12QP109
+	pl.P = []uint64{}
+	pl.Pow2Base = 16
+
+	params, err := NewParametersFromLiteral(pl)
+	require.NoError(t, err)
+
+	tc, err := genTestParams(params)
+	require.NoError(t, err)
+
+	rots := []int{1, 5}
+	eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: tc.kgen.GenRotationKeysForRotations(rots, false, tc.sk)}).(*evaluator)
+
+	_, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+	level := ciphertext.Level()
+	eval.DecomposeNTT(level, params.PCount()-1, params.PCount(), ciphertext.Value[1], eval.PoolDecompQP)
+
+	// Without P, the hoisted rotations perform the same operations as the rotations
+	t.Run(GetTestName(params, "PermuteNTTHoisted"), func(t *testing.T) {
+		ctOut := NewCiphertext(params, 1, level, ciphertext.Scale)
+		for _, k := range rots {
+			ctWant := eval.RotateNew(ciphertext, k)
+			eval.PermuteNTTHoisted(level, ciphertext.Value[0], ciphertext.Value[1], eval.PoolDecompQP, k, ctOut.Value[0], ctOut.Value[1])
+			require.True(t, params.RingQ().EqualLvl(level, ctWant.Value[0], ctOut.Value[0]))
+			require.True(t, params.RingQ().EqualLvl(level, ctWant.Value[1], ctOut.Value[1]))
+		}
+	})
+
+	t.Run(GetTestName(params, "RotateHoistedNoModDownNew"), func(t *testing.T) {
+
+		// The results are mod Q and are not scaled by P
+		cOut := eval.RotateHoistedNoModDownNew(level, rots, ciphertext.Value[0], eval.PoolDecompQP)
+
+		for _, k := range rots {
+			ctWant := eval.RotateNew(ciphertext, k)
+			require.Nil(t, cOut[k][0].P)
+			require.Nil(t, cOut[k][1].P)
+			require.True(t, params.RingQ().EqualLvl(level, ctWant.Value[0], cOut[k][0].Q))
+			require.True(t, params.RingQ().EqualLvl(level, ctWant.Value[1], cOut[k][1].Q))
+		}
+	})
+}
+
+func TestGenParametersFromCircuit(t *testing.T) {
+
+	for _, ringType := range []ring.Type{ring.Standard, ring.ConjugateInvariant} {
+
+		circuit := CircuitLiteral{Depth: 3, LogPrecision: 20, LogSlots: 10, KeySwitchingDigits: 2, RingType: ringType}
+
+		t.Run(fmt.Sprintf("GenParametersFromCircuit/RingType=%s", ringType), func(t *testing.T) {
+
+			pl, report, err := GenParametersFromCircuit(circuit)
+			require.NoError(t, err)
+
+			params, err := NewParametersFromLiteral(pl)
+			require.NoError(t, err)
+
+			require.Equal(t, circuit.Depth, params.MaxLevel())
+			require.Equal(t, circuit.LogSlots, params.LogSlots())
+			require.Equal(t, circuit.KeySwitchingDigits, params.Beta())
+			require.GreaterOrEqual(t, params.EstimatedSecurity(), float64(DefaultSecurity))
+			require.Equal(t, NewParametersReport(params), report)
+			require.Equal(t, 2*params.N()*params.QCount()*8, report.CiphertextSize)
+
+			// The parameters of the next smaller ring degree are insecure
+			plSmaller := pl
+			plSmaller.LogN--
+			_, err = NewParametersFromLiteral(plSmaller)
+			require.Error(t, err)
+
+			tc, err := genTestParams(params)
+			require.NoError(t, err)
+
+			values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+			for i := 0; i < circuit.Depth; i++ {
+				tc.evaluator.MulRelin(ciphertext, ciphertext, ciphertext)
+				require.NoError(t, tc.evaluator.Rescale(ciphertext, params.DefaultScale(), ciphertext))
+				for j := range values {
+					values[j] *= values[j]
+				}
+			}
+
+			require.Equal(t, 0, ciphertext.Level())
+
+			precStats := GetPrecisionStats(params, tc.encoder, tc.decryptor, values, ciphertext, params.LogSlots(), 0)
+			require.GreaterOrEqual(t, precStats.MeanPrecision.Real, float64(circuit.LogPrecision))
+		})
+	}
+
+	t.Run("GenParametersFromCircuit/Invalid", func(t *testing.T) {
+		_, _, err := GenParametersFromCircuit(CircuitLiteral{Depth: 3})
+		require.Error(t, err)
+		_, _, err = GenParametersFromCircuit(CircuitLiteral{Depth: 3, LogPrecision: 50})
+		require.Error(t, err)
+		_, _, err = GenParametersFromCircuit(CircuitLiteral{Depth: rlwe.MaxModuliCount, LogPrecision: 20})
+		require.Error(t, err)
+	})
+}
+
 func genTestParams(defaultParam Parameters) (tc *testContext, err error) {
 
 	tc = new(testContext)
@@ -140,9 +264,13 @@ func genTestParams(defaultParam Parameters) (tc *testContext, err error) {
 
 	tc.encryptorPk = NewEncryptor(tc.params, tc.pk)
 	tc.encryptorSk = NewEncryptor(tc.params, tc.sk)
-	tc.decryptor = NewDecryptor(tc.params, tc.sk)
+	if tc.decryptor, err = NewDecryptor(tc.params, tc.sk); err != nil {
+		return nil, err
+	}
 
-	tc.evaluator = NewEvaluator(tc.params, rlwe.EvaluationKey{Rlk: tc.rlk})
+	if tc.evaluator, err = NewEvaluator(tc.params, rlwe.EvaluationKey{Rlk: tc.rlk}); err != nil {
+		return nil, err
+	}
 
 	return tc, nil
 
@@ -221,6 +349,47 @@ func testParameters(tc *testContext, t *testing.T) {
 		assert.True(t, params2.Equals(tc.params))
 	})
 
+	t.Run(GetTestName(tc.params, "Parameters/Fingerprint"), func(t *testing.T) {
+
+		params := tc.params
+
+		// The fingerprint depends on the number of slots and on the default scale
+		paramsScale := params.CopyNew()
+		paramsScale.defaultScale *= 2
+		paramsSlots := params.CopyNew()
+		paramsSlots.logSlots--
+		require.NotEqual(t, params.Fingerprint(), paramsScale.Fingerprint())
+		require.NotEqual(t, params.Fingerprint(), paramsSlots.Fingerprint())
+		require.NotEqual(t, params.Fingerprint(), params.Parameters.Fingerprint())
+
+		// The fingerprint is carried by the binary encoding and checked on decoding
+		data, err := params.MarshalBinary()
+		require.NoError(t, err)
+		paramsNew := new(Parameters)
+		require.NoError(t, paramsNew.UnmarshalBinary(data))
+		require.Equal(t, params.Fingerprint(), paramsNew.Fingerprint())
+		binary.BigEndian.PutUint64(data[6:], paramsScale.Fingerprint())
+		require.Error(t, new(Parameters).UnmarshalBinary(data))
+
+		// Keys generated with other parameters of the same ring degree are rejected
+		rlweParamsOther, err := rlwe.NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		kgenOther := rlwe.NewKeyGenerator(rlweParamsOther)
+		skOther := kgenOther.GenSecretKey()
+
+		_, err = NewDecryptor(params, skOther)
+		require.Error(t, err)
+
+		if params.PCount() != 0 {
+			_, err = NewEvaluator(params, rlwe.EvaluationKey{Rlk: kgenOther.GenRelinearizationKey(skOther, 1)})
+			require.Error(t, err)
+			require.Panics(t, func() { tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: kgenOther.GenRelinearizationKey(skOther, 1)}) })
+			require.Panics(t, func() {
+				tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: kgenOther.GenRotationKeysForRotations([]int{1}, false, skOther)})
+			})
+		}
+	})
+
 	t.Run(GetTestName(tc.params, "Parameters/StandardRing"), func(t *testing.T) {
 		params, err := tc.params.StandardParameters()
 		switch tc.params.RingType() {
@@ -995,8 +1164,10 @@ func testSwitchKeys(tc *testContext, t *testing.T) {
 	var switchingKey *rlwe.SwitchingKey
 
 	if tc.params.PCount() != 0 {
+		var err error
 		sk2 = tc.kgen.GenSecretKey()
-		decryptorSk2 = NewDecryptor(tc.params, sk2)
+		decryptorSk2, err = NewDecryptor(tc.params, sk2)
+		require.NoError(t, err)
 		switchingKey = tc.kgen.GenSwitchingKey(tc.sk, sk2)
 	}
 
@@ -1044,9 +1215,11 @@ func testBridge(tc *testContext, t *testing.T) {
 
 		stdKeyGen := NewKeyGenerator(stdParams)
 		stdSK := stdKeyGen.GenSecretKey()
-		stdDecryptor := NewDecryptor(stdParams, stdSK)
+		stdDecryptor, err := NewDecryptor(stdParams, stdSK)
+		require.NoError(t, err)
 		stdEncoder := NewEncoder(stdParams)
-		stdEvaluator := NewEvaluator(stdParams, rlwe.EvaluationKey{Rlk: nil, Rtks: nil})
+		stdEvaluator, err := NewEvaluator(stdParams, rlwe.EvaluationKey{Rlk: nil, Rtks: nil})
+		require.NoError(t, err)
 
 		swkCtR, swkRtC := stdKeyGen.GenSwitchingKeysForBridge(stdSK, tc.sk)
 
@@ -1160,6 +1333,119 @@ func testAutomorphisms(tc *testContext, t *testing.T) {
 			verifyTestVectors(tc.params, tc.encoder, tc.decryptor, utils.RotateComplex128Slice(values1, n), ciphertexts[n], tc.params.LogSlots(), 0, t)
 		}
 	})
+
+	t.Run(GetTestName(tc.params, "Rotate/RotationKeyProvider"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		buff := new(bytes.Buffer)
+		require.NoError(t, rlwe.WriteRotationKeys(buff, rotKey))
+
+		reader, err := rlwe.NewRotationKeyReader(bytes.NewReader(buff.Bytes()))
+		require.NoError(t, err)
+
+		cache := rlwe.NewRotationKeyCache(params.Parameters, reader, 2)
+
+		evaluator := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, RtksProvider: cache})
+
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for _, n := range rots {
+			verifyTestVectors(tc.params, tc.encoder, tc.decryptor, utils.RotateComplex128Slice(values1, n), evaluator.RotateNew(ciphertext1, n), tc.params.LogSlots(), 0, t)
+			require.LessOrEqual(t, cache.Len(), 2)
+		}
+
+		defer func() {
+			err, isError := recover().(error)
+			require.True(t, isError)
+			require.True(t, errors.Is(err, rlwe.ErrMissingRotationKey))
+		}()
+
+		evaluator.RotateNew(ciphertext1, 5)
+	})
+
+	t.Run(GetTestName(tc.params, "Rotate/RotationKeyProvider/NonComparable"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		// WithKey must not compare the providers, whose dynamic type can be non-comparable
+		evaluator := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, RtksProvider: mapRotationKeyProvider(rotKey.Keys)})
+		evaluator = evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, RtksProvider: mapRotationKeyProvider(rotKey.Keys)})
+
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for _, n := range rots {
+			verifyTestVectors(tc.params, tc.encoder, tc.decryptor, utils.RotateComplex128Slice(values1, n), evaluator.RotateNew(ciphertext1, n), tc.params.LogSlots(), 0, t)
+		}
+	})
+
+	t.Run(GetTestName(tc.params, "Rotate/RotationKeyProvider/OtherParameters"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		rlweParamsOther, err := rlwe.NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		paramsOther, err := NewParameters(rlweParamsOther, params.LogSlots(), params.DefaultScale())
+		require.NoError(t, err)
+		kgenOther := NewKeyGenerator(paramsOther)
+
+		// The keys of a RotationKeyProvider are checked when they are loaded
+		rtksOther := kgenOther.GenRotationKeysForRotations([]int{1}, false, kgenOther.GenSecretKey())
+		evaluator := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, RtksProvider: rtksOther})
+
+		_, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		defer func() {
+			err, isError := recover().(error)
+			require.True(t, isError)
+			require.False(t, errors.Is(err, rlwe.ErrMissingRotationKey))
+			require.Contains(t, err.Error(), "generated with other parameters")
+		}()
+
+		evaluator.RotateNew(ciphertext1, 1)
+	})
+
+	t.Run(GetTestName(tc.params, "Rotate/RotationDecomposition"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		rotations := []int{3, -5, 7, 13, 100}
+
+		// Only the power-of-two rotation keys are generated
+		keys := []int{}
+		for k := 1; k < params.Slots(); k <<= 1 {
+			keys = append(keys, k)
+		}
+		rotKey := tc.kgen.GenRotationKeysForRotations(keys, false, tc.sk)
+
+		// The option is kept by WithKey
+		evaluator, err := NewEvaluator(tc.params, rlwe.EvaluationKey{Rlk: tc.rlk}, WithRotationDecomposition())
+		require.NoError(t, err)
+		evaluator = evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for _, n := range rotations {
+			verifyTestVectors(tc.params, tc.encoder, tc.decryptor, utils.RotateComplex128Slice(values1, n), evaluator.RotateNew(ciphertext1, n), tc.params.LogSlots(), 0, t)
+		}
+
+		// Without the decomposition, the missing keys are not composed
+		defer func() {
+			err, isError := recover().(error)
+			require.True(t, isError)
+			require.True(t, errors.Is(err, rlwe.ErrMissingRotationKey))
+		}()
+
+		tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey}).RotateNew(ciphertext1, 13)
+	})
 }
 
 func testInnerSum(tc *testContext, t *testing.T) {
@@ -1389,6 +1675,40 @@ func testLinearTransform(tc *testContext, t *testing.T) {
 		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
 	})
 
+	t.Run(GetTestName(tc.params, "LinearTransform/BSGS/Parallel"), func(t *testing.T) {
+
+		params := tc.params
+
+		_, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		diagMatrix := make(map[int][]complex128)
+		for _, k := range []int{-15, -4, -1, 0, 1, 2, 3, 4, 15} {
+			diagMatrix[k] = make([]complex128, params.Slots())
+			for i := range diagMatrix[k] {
+				diagMatrix[k][i] = utils.RandComplex128(-1, 1)
+			}
+		}
+
+		linTransf := GenLinearTransformBSGS(tc.encoder, diagMatrix, params.MaxLevel(), params.DefaultScale(), 1.0, params.logSlots)
+
+		evk := rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: tc.kgen.GenRotationKeysForRotations(linTransf.Rotations(), false, tc.sk)}
+
+		ctWant := NewCiphertext(params, 1, ciphertext.Level(), ciphertext.Scale)
+		ctHave := NewCiphertext(params, 1, ciphertext.Level(), ciphertext.Scale)
+
+		eval, err := NewEvaluator(params, evk)
+		require.NoError(t, err)
+		eval.LinearTransform(ciphertext, linTransf, []*Ciphertext{ctWant})
+
+		evalParallel, err := NewEvaluator(params.WithParallelism(4), evk)
+		require.NoError(t, err)
+		evalParallel.LinearTransform(ciphertext, linTransf, []*Ciphertext{ctHave})
+
+		for i := range ctWant.Value {
+			require.True(t, params.RingQ().EqualLvl(ctWant.Level(), ctWant.Value[i], ctHave.Value[i]))
+		}
+	})
+
 	t.Run(GetTestName(tc.params, "LinearTransform/Naive"), func(t *testing.T) {
 
 		params := tc.params
@@ -1439,6 +1759,21 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 		assert.Equal(t, testctx.params.MarshalBinarySize(), len(bytes))
 	})
 
+	t.Run(GetTestName(testctx.params, "Marshaller/Parameters/Legacy"), func(t *testing.T) {
+
+		// Encoding of the PN12QP109 parameters produced by v3.0.1
+		data, err := hex.DecodeString("0c02010000000000000800400999999999999a00000000200000e00100000001000060010000003ffffea0010b41f0000000000000")
+		require.NoError(t, err)
+
+		paramsWant, err := NewParametersFromLiteral(PN12QP109)
+		require.NoError(t, err)
+
+		var p Parameters
+		require.NoError(t, p.UnmarshalBinary(data))
+		require.True(t, paramsWant.Equals(p))
+		require.Equal(t, paramsWant.Fingerprint(), p.Fingerprint())
+	})
+
 	t.Run(GetTestName(testctx.params, "Marshaller/Parameters/JSON"), func(t *testing.T) {
 		// checks that parameters can be marshalled without error
 		data, err := json.Marshal(testctx.params)
@@ -1493,10 +1828,43 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 			require.Equal(t, ciphertextWant.Degree(), ciphertextTest.Degree())
 			require.Equal(t, ciphertextWant.Level(), ciphertextTest.Level())
 			require.Equal(t, ciphertextWant.Scale, ciphertextTest.Scale)
+			require.Equal(t, testctx.params.Parameters.Fingerprint(), ciphertextTest.ParametersFingerprint)
 
 			for i := range ciphertextWant.Value {
 				require.True(t, testctx.ringQ.EqualLvl(ciphertextWant.Level(), ciphertextWant.Value[i], ciphertextTest.Value[i]))
 			}
+
+			// A CKKS ciphertext is not a generic rlwe.Ciphertext
+			require.Error(t, new(rlwe.Ciphertext).UnmarshalBinary(marshalledCiphertext))
+			require.NoError(t, testctx.params.CheckEnvelope(marshalledCiphertext))
+
+			// A ciphertext of other parameters with the same ring degree is rejected by UnmarshalObject
+			require.NoError(t, testctx.params.UnmarshalObject(marshalledCiphertext, new(Ciphertext)))
+			paramsOther, err := rlwe.NewParameters(testctx.params.LogN(), testctx.params.Q(), testctx.params.P(), testctx.params.HammingWeight(), 2*testctx.params.Sigma(), testctx.params.RingType())
+			require.NoError(t, err)
+			require.Error(t, paramsOther.UnmarshalObject(marshalledCiphertext, new(Ciphertext)))
+		})
+
+		t.Run(GetTestName(testctx.params, "Legacy"), func(t *testing.T) {
+
+			ciphertextWant := NewCiphertextRandom(testctx.prng, testctx.params, 1, testctx.params.MaxLevel(), testctx.params.DefaultScale())
+
+			marshalledCiphertext, err := ciphertextWant.MarshalBinary()
+			require.NoError(t, err)
+
+			// The legacy encoding is the payload of the envelope
+			ciphertextTest := new(Ciphertext)
+			require.NoError(t, ciphertextTest.UnmarshalBinary(marshalledCiphertext[rlwe.EnvelopeHeaderLen:]))
+			require.Equal(t, ciphertextWant.Scale, ciphertextTest.Scale)
+			for i := range ciphertextWant.Value {
+				require.True(t, testctx.ringQ.Equal(ciphertextWant.Value[i], ciphertextTest.Value[i]))
+			}
+
+			marshalledParams, err := testctx.params.MarshalBinary()
+			require.NoError(t, err)
+			var paramsLegacy Parameters
+			require.NoError(t, paramsLegacy.UnmarshalBinary(marshalledParams[rlwe.EnvelopeHeaderLen:]))
+			require.True(t, testctx.params.Equals(paramsLegacy))
 		})
 
 		t.Run(GetTestName(testctx.params, "Minimal"), func(t *testing.T) {
@@ -1517,3 +1885,14 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 		})
 	})
 }
+
+// mapRotationKeyProvider is a RotationKeyProvider whose type is not comparable.
+type mapRotationKeyProvider map[uint64]*rlwe.SwitchingKey
+
+func (p mapRotationKeyProvider) GaloisElements() []uint64 {
+	return (&rlwe.RotationKeySet{Keys: p}).GaloisElements()
+}
+
+func (p mapRotationKeyProvider) RotationKey(galEl uint64) (*rlwe.SwitchingKey, error) {
+	return (&rlwe.RotationKeySet{Keys: p}).RotationKey(galEl)
+}
@@ -165,8 +165,9 @@ type evaluator struct {
 	*rlwe.KeySwitcher
 
 	rlk             *rlwe.RelinearizationKey
-	rtks            *rlwe.RotationKeySet
+	rtks            rlwe.RotationKeyProvider
 	permuteNTTIndex map[uint64][]uint64
+	rotDecomposer   *rlwe.RotationDecomposer // nil if the rotation decomposition is not enabled
 }
 
 type evaluatorBase struct {
@@ -176,6 +177,36 @@ type evaluatorBase struct {
 type evaluatorBuffers struct {
 	poolQMul [3]*ring.Poly // Memory pool in order : for MForm(c0), MForm(c1), c2
 	ctxpool  *Ciphertext   // Memory pool for ciphertext that need to be scaled up (to be removed eventually)
+
+	bsgsWorkers []*bsgsWorker // Per-worker state of the parallel MultiplyByDiagMatrixBSGS (allocated on first use)
+}
+
+// bsgsWorker stores the key-switcher and the buffers of a worker of the parallel MultiplyByDiagMatrixBSGS.
+type bsgsWorker struct {
+	ks                         *rlwe.KeySwitcher
+	tmp0QP, tmp1QP, c0QP, c1QP rlwe.PolyQP
+	acc0QP, acc1QP             rlwe.PolyQP
+}
+
+// bsgsWorkerPool returns the state of the given number of workers for the parallel MultiplyByDiagMatrixBSGS,
+// allocating it if needed. The workers do not themselves split their operations.
+func (eval *evaluator) bsgsWorkerPool(workers int) []*bsgsWorker {
+	if len(eval.bsgsWorkers) < workers {
+		params := eval.params.Parameters.WithParallelism(1)
+		ringQP := params.RingQP()
+		for len(eval.bsgsWorkers) < workers {
+			eval.bsgsWorkers = append(eval.bsgsWorkers, &bsgsWorker{
+				ks:     rlwe.NewKeySwitcher(params),
+				tmp0QP: ringQP.NewPoly(),
+				tmp1QP: ringQP.NewPoly(),
+				c0QP:   ringQP.NewPoly(),
+				c1QP:   ringQP.NewPoly(),
+				acc0QP: ringQP.NewPoly(),
+				acc1QP: ringQP.NewPoly(),
+			})
+		}
+	}
+	return eval.bsgsWorkers[:workers]
 }
 
 // PoolQMul returns a pointer to internal memory pool poolQMul.
@@ -206,35 +237,80 @@ func newEvaluatorBuffers(evalBase *evaluatorBase) *evaluatorBuffers {
 // NewEvaluator creates a new Evaluator, that can be used to do homomorphic
 // operations on the Ciphertexts and/or Plaintexts. It stores a small pool of polynomials
 // and Ciphertexts that will be used for intermediate values.
-func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) Evaluator {
+// It returns an error if the evaluation key cannot be used with the parameters (see rlwe.Parameters.CheckEvaluationKey).
+func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey, opts ...EvaluatorOption) (Evaluator, error) {
+
+	if err := params.CheckEvaluationKey(evaluationKey); err != nil {
+		return nil, err
+	}
+
 	eval := new(evaluator)
 	eval.evaluatorBase = newEvaluatorBase(params)
 	eval.evaluatorBuffers = newEvaluatorBuffers(eval.evaluatorBase)
 
 	eval.rlk = evaluationKey.Rlk
-	eval.rtks = evaluationKey.Rtks
+	eval.rtks = evaluationKey.RotationKeys()
 	if eval.rtks != nil {
 		eval.permuteNTTIndex = *eval.permuteNTTIndexesForKey(eval.rtks)
 	}
 
-	if params.PCount() != 0 {
+	if params.PCount() != 0 || params.Pow2Base() != 0 {
 		eval.KeySwitcher = rlwe.NewKeySwitcher(params.Parameters)
 	}
 
-	return eval
+	for _, opt := range opts {
+		opt(eval)
+	}
+
+	return eval, nil
+}
+
+// EvaluatorOption is an option of NewEvaluator.
+type EvaluatorOption func(eval *evaluator)
+
+// WithRotationDecomposition is an option of NewEvaluator with which the evaluator evaluates the rotations whose
+// rotation key is not available as a minimal sequence of rotations whose rotation key is available (see
+// rlwe.RotationDecomposer), for example with the power-of-two rotation keys or with the keys given by
+// Parameters.RotationsCoveringSet. Each rotation of the sequence requires a key-switch. Only Rotate and
+// RotateNew decompose the rotations. The option is kept by ShallowCopy and WithKey.
+func WithRotationDecomposition() EvaluatorOption {
+	return func(eval *evaluator) {
+		eval.rotDecomposer = eval.rotationDecomposerForKey(eval.rtks)
+	}
 }
 
-func (eval *evaluator) permuteNTTIndexesForKey(rtks *rlwe.RotationKeySet) *map[uint64][]uint64 {
+func (eval *evaluator) permuteNTTIndexesForKey(rtks rlwe.RotationKeyProvider) *map[uint64][]uint64 {
 	if rtks == nil {
 		return &map[uint64][]uint64{}
 	}
-	permuteNTTIndex := make(map[uint64][]uint64, len(rtks.Keys))
-	for galEl := range rtks.Keys {
+	galEls := rtks.GaloisElements()
+	permuteNTTIndex := make(map[uint64][]uint64, len(galEls))
+	for _, galEl := range galEls {
 		permuteNTTIndex[galEl] = eval.params.RingQ().PermuteNTTIndex(galEl)
 	}
 	return &permuteNTTIndex
 }
 
+// rotationKey returns the rotation key of the Galois element galEl, loaded from the RotationKeyProvider
+// of the evaluator. It panics if the key cannot be provided or does not match the parameters of the evaluator.
+func (eval *evaluator) rotationKey(galEl uint64) *rlwe.SwitchingKey {
+
+	if eval.rtks == nil {
+		panic(fmt.Sprintf("rotation key k=%d not available: evaluator has no rotation keys", eval.params.InverseGaloisElement(galEl)))
+	}
+
+	rtk, err := eval.rtks.RotationKey(galEl)
+	if err != nil {
+		panic(fmt.Errorf("rotation key k=%d not available: %w", eval.params.InverseGaloisElement(galEl), err))
+	}
+
+	if err = eval.params.CheckSwitchingKey(rtk); err != nil {
+		panic(fmt.Errorf("rotation key k=%d: %w", eval.params.InverseGaloisElement(galEl), err))
+	}
+
+	return rtk
+}
+
 // GetKeySwitcher returns a pointer to the internal rlwe.KeySwither.
 func (eval *evaluator) GetKeySwitcher() *rlwe.KeySwitcher {
 	return eval.KeySwitcher
@@ -1420,7 +1496,8 @@ func (eval *evaluator) RotateNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext) {
 }
 
 // Rotate rotates the columns of ct0 by k positions to the left and returns the result in ctOut.
-// If the provided element is a Ciphertext, a key-switching operation is necessary and a rotation key for the specific rotation needs to be provided.
+// If the provided element is a Ciphertext, a key-switching operation is necessary and a rotation key for the specific rotation needs to be provided,
+// unless the evaluator decomposes the rotations (see WithRotationDecomposition).
 func (eval *evaluator) Rotate(ct0 *Ciphertext, k int, ctOut *Ciphertext) {
 
 	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
@@ -1435,7 +1512,25 @@ func (eval *evaluator) Rotate(ct0 *Ciphertext, k int, ctOut *Ciphertext) {
 
 		galEl := eval.params.GaloisElementForColumnRotationBy(k)
 
-		eval.permuteNTT(ct0, galEl, ctOut)
+		if _, available := eval.permuteNTTIndex[galEl]; available || eval.rotDecomposer == nil {
+			eval.permuteNTT(ct0, galEl, ctOut)
+			return
+		}
+
+		rotations, ok := eval.rotDecomposer.Decompose(k)
+		if !ok {
+			panic(fmt.Errorf("cannot Rotate: rotation by %d cannot be decomposed with the available rotation keys: %w", k, rlwe.ErrMissingRotationKey))
+		}
+
+		if len(rotations) == 0 {
+			ctOut.Copy(ct0)
+			return
+		}
+
+		eval.permuteNTT(ct0, eval.params.GaloisElementForColumnRotationBy(rotations[0]), ctOut)
+		for _, r := range rotations[1:] {
+			eval.permuteNTT(ctOut, eval.params.GaloisElementForColumnRotationBy(r), ctOut)
+		}
 	}
 }
 
@@ -1472,10 +1567,7 @@ func (eval *evaluator) Conjugate(ct0 *Ciphertext, ctOut *Ciphertext) {
 
 func (eval *evaluator) permuteNTT(ct0 *Ciphertext, galEl uint64, ctOut *Ciphertext) {
 
-	rtk, generated := eval.rtks.GetRotationKey(galEl)
-	if !generated {
-		panic(fmt.Sprintf("rotation key k=%d not available", eval.params.InverseGaloisElement(galEl)))
-	}
+	rtk := eval.rotationKey(galEl)
 
 	level := utils.MinInt(ct0.Level(), ctOut.Level())
 	index := eval.permuteNTTIndex[galEl]
@@ -1491,14 +1583,17 @@ func (eval *evaluator) permuteNTT(ct0 *Ciphertext, galEl uint64, ctOut *Cipherte
 	ringQ.PermuteNTTWithIndexLvl(level, pool3Q, index, ctOut.Value[1])
 }
 
+// RotateHoistedNoModDownNew rotates the ciphertext (c0, c1), given c0 and the decomposition c2DecompQP of c1,
+// by each of the rotations, and returns the results mod QP, scaled by P, without dividing them by P.
+// If the modulus P is empty, the results are mod Q and not scaled, and their P parts are nil.
 func (eval *evaluator) RotateHoistedNoModDownNew(level int, rotations []int, c0 *ring.Poly, c2DecompQP []rlwe.PolyQP) (cOut map[int][2]rlwe.PolyQP) {
-	ringQ := eval.params.RingQ()
-	ringP := eval.params.RingP()
+	ringQP := eval.params.RingQP()
+	levelP := eval.params.PCount() - 1
 	cOut = make(map[int][2]rlwe.PolyQP)
 	for _, i := range rotations {
 
 		if i != 0 {
-			cOut[i] = [2]rlwe.PolyQP{{Q: ringQ.NewPolyLvl(level), P: ringP.NewPoly()}, {Q: ringQ.NewPolyLvl(level), P: ringP.NewPoly()}}
+			cOut[i] = [2]rlwe.PolyQP{ringQP.NewPolyLvl(level, levelP), ringQP.NewPolyLvl(level, levelP)}
 			eval.PermuteNTTHoistedNoModDown(level, c0, c2DecompQP, i, cOut[i][0].Q, cOut[i][1].Q, cOut[i][0].P, cOut[i][1].P)
 		}
 	}
@@ -1506,6 +1601,9 @@ func (eval *evaluator) RotateHoistedNoModDownNew(level int, rotations []int, c0
 	return
 }
 
+// PermuteNTTHoistedNoModDown rotates the ciphertext (c0, c1), given c0 and the decomposition c2DecompQP of c1,
+// by k positions, and returns the result mod QP, scaled by P, without dividing it by P.
+// If the modulus P is empty, the result is mod Q and not scaled, and ct0OutP and ct1OutP are not used.
 func (eval *evaluator) PermuteNTTHoistedNoModDown(level int, c0 *ring.Poly, c2DecompQP []rlwe.PolyQP, k int, ct0OutQ, ct1OutQ, ct0OutP, ct1OutP *ring.Poly) {
 
 	pool2Q := eval.Pool[0].Q
@@ -1519,11 +1617,7 @@ func (eval *evaluator) PermuteNTTHoistedNoModDown(level int, c0 *ring.Poly, c2De
 
 	galEl := eval.params.GaloisElementForColumnRotationBy(k)
 
-	rtk, generated := eval.rtks.Keys[galEl]
-	if !generated {
-		fmt.Println(k)
-		panic("switching key not available")
-	}
+	rtk := eval.rotationKey(galEl)
 	index := eval.permuteNTTIndex[galEl]
 
 	eval.KeyswitchHoistedNoModDown(levelQ, c2DecompQP, rtk, pool2Q, pool3Q, pool2P, pool3P)
@@ -1531,6 +1625,14 @@ func (eval *evaluator) PermuteNTTHoistedNoModDown(level int, c0 *ring.Poly, c2De
 	ringQ := eval.params.RingQ()
 
 	ringQ.PermuteNTTWithIndexLvl(levelQ, pool3Q, index, ct1OutQ)
+
+	// Without P, the key-switched polynomials are not scaled by P and c0 is added as is.
+	if levelP == -1 {
+		ringQ.AddLvl(levelQ, pool2Q, c0, pool2Q)
+		ringQ.PermuteNTTWithIndexLvl(levelQ, pool2Q, index, ct0OutQ)
+		return
+	}
+
 	ringQ.PermuteNTTWithIndexLvl(levelP, pool3P, index, ct1OutP)
 
 	ringQ.MulScalarBigintLvl(levelQ, c0, eval.params.RingP().ModulusBigint, pool3Q)
@@ -1549,10 +1651,7 @@ func (eval *evaluator) PermuteNTTHoisted(level int, c0, c1 *ring.Poly, c2DecompQ
 	}
 
 	galEl := eval.params.GaloisElementForColumnRotationBy(k)
-	rtk, generated := eval.rtks.Keys[galEl]
-	if !generated {
-		panic(fmt.Sprintf("specific rotation has not been generated: %d", k))
-	}
+	rtk := eval.rotationKey(galEl)
 
 	index := eval.permuteNTTIndex[galEl]
 
@@ -1583,24 +1682,60 @@ func (eval *evaluator) ShallowCopy() Evaluator {
 		rlk:              eval.rlk,
 		rtks:             eval.rtks,
 		permuteNTTIndex:  eval.permuteNTTIndex,
+		rotDecomposer:    eval.rotDecomposer,
 	}
 }
 
 // WithKey creates a shallow copy of the receiver Evaluator for which the new EvaluationKey is evaluationKey
 // and where the temporary buffers are shared. The receiver and the returned Evaluators cannot be used concurrently.
+// It panics if the evaluation key cannot be used with the parameters (see rlwe.Parameters.CheckEvaluationKey).
 func (eval *evaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+
+	if err := eval.params.CheckEvaluationKey(evaluationKey); err != nil {
+		panic(fmt.Errorf("cannot WithKey: %w", err))
+	}
+
 	var indexes map[uint64][]uint64
-	if evaluationKey.Rtks == eval.rtks {
+	rotDecomposer := eval.rotDecomposer
+	rtks := evaluationKey.RotationKeys()
+	if sameRotationKeys(rtks, eval.rtks) {
 		indexes = eval.permuteNTTIndex
 	} else {
-		indexes = *eval.permuteNTTIndexesForKey(evaluationKey.Rtks)
+		indexes = *eval.permuteNTTIndexesForKey(rtks)
+		if rotDecomposer != nil {
+			rotDecomposer = eval.rotationDecomposerForKey(rtks)
+		}
 	}
 	return &evaluator{
 		KeySwitcher:      eval.KeySwitcher,
 		evaluatorBase:    eval.evaluatorBase,
 		evaluatorBuffers: eval.evaluatorBuffers,
 		rlk:              evaluationKey.Rlk,
-		rtks:             evaluationKey.Rtks,
+		rtks:             rtks,
 		permuteNTTIndex:  indexes,
+		rotDecomposer:    rotDecomposer,
+	}
+}
+
+// sameRotationKeys returns true if a and b are the same RotationKeySet or the same RotationKeyCache.
+// The interface values are not compared directly, as the comparison panics on non-comparable dynamic types.
+func sameRotationKeys(a, b rlwe.RotationKeyProvider) bool {
+	switch a := a.(type) {
+	case *rlwe.RotationKeySet:
+		b, ok := b.(*rlwe.RotationKeySet)
+		return ok && a == b
+	case *rlwe.RotationKeyCache:
+		b, ok := b.(*rlwe.RotationKeyCache)
+		return ok && a == b
+	default:
+		return false
+	}
+}
+
+func (eval *evaluator) rotationDecomposerForKey(rtks rlwe.RotationKeyProvider) *rlwe.RotationDecomposer {
+	var galEls []uint64
+	if rtks != nil {
+		galEls = rtks.GaloisElements()
 	}
+	return rlwe.NewRotationDecomposer(eval.params.Parameters, galEls)
 }
@@ -2,6 +2,7 @@ package ckks
 
 import (
 	"runtime"
+	"sort"
 
 	"github.com/tuneinsight/lattigo/v3/ring"
 	"github.com/tuneinsight/lattigo/v3/rlwe"
@@ -770,7 +771,7 @@ func (eval *evaluator) Replicate(ctIn *Ciphertext, batchSize, n int, ctOut *Ciph
 
 // MultiplyByDiagMatrix multiplies the ciphertext "ctIn" by the plaintext matrix "matrix" and returns the result on the ciphertext
 // "ctOut". Memory pools for the decomposed ciphertext PoolDecompQ, PoolDecompP must be provided, those are list of poly of ringQ and ringP
-// respectively, each of size params.Beta().
+// respectively, each of size params.Beta()*params.DecompPw2().
 // The naive approach is used (single hoisting and no baby-step giant-step), which is faster than MultiplyByDiagMatrixBSGS
 // for matrix of only a few non-zero diagonals but uses more keys.
 func (eval *evaluator) MultiplyByDiagMatrix(ctIn *Ciphertext, matrix LinearTransform, PoolDecompQP []rlwe.PolyQP, ctOut *Ciphertext) {
@@ -815,10 +816,7 @@ func (eval *evaluator) MultiplyByDiagMatrix(ctIn *Ciphertext, matrix LinearTrans
 
 			galEl := eval.params.GaloisElementForColumnRotationBy(k)
 
-			rtk, generated := eval.rtks.Keys[galEl]
-			if !generated {
-				panic("switching key not available")
-			}
+			rtk := eval.rotationKey(galEl)
 
 			index := eval.permuteNTTIndex[galEl]
 
@@ -874,7 +872,7 @@ func (eval *evaluator) MultiplyByDiagMatrix(ctIn *Ciphertext, matrix LinearTrans
 
 // MultiplyByDiagMatrixBSGS multiplies the ciphertext "ctIn" by the plaintext matrix "matrix" and returns the result on the ciphertext
 // "ctOut". Memory pools for the decomposed ciphertext PoolDecompQ, PoolDecompP must be provided, those are list of poly of ringQ and ringP
-// respectively, each of size params.Beta().
+// respectively, each of size params.Beta()*params.DecompPw2().
 // The BSGS approach is used (double hoisting with baby-step giant-step), which is faster than MultiplyByDiagMatrix
 // for matrix with more than a few non-zero diagonals and uses much less keys.
 func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix LinearTransform, PoolDecompQP []rlwe.PolyQP, ctOut *Ciphertext) {
@@ -889,9 +887,6 @@ func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix LinearT
 	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:levelQ+1]
 	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:levelQ+1]
 
-	QiOverF := eval.params.QiOverflowMargin(levelQ) >> 1
-	PiOverF := eval.params.PiOverflowMargin(levelP) >> 1
-
 	// Computes the N2 rotations indexes of the non-zero rows of the diagonalized DFT matrix for the baby-step giang-step algorithm
 
 	index, _, rotN2 := BsgsIndex(matrix.Vec, 1<<matrix.LogSlots, matrix.N1)
@@ -903,14 +898,6 @@ func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix LinearT
 	// Pre-rotates ciphertext for the baby-step giant-step algorithm, does not divide by P yet
 	ctInRotQP := eval.RotateHoistedNoModDownNew(levelQ, rotN2, ctInTmp0, eval.PoolDecompQP)
 
-	// Accumulator inner loop
-	tmp0QP := eval.Pool[1]
-	tmp1QP := eval.Pool[2]
-
-	// Accumulator outer loop
-	c0QP := eval.Pool[3]
-	c1QP := eval.Pool[4]
-
 	// Result in QP
 	c0OutQP := rlwe.PolyQP{Q: ctOut.Value[0], P: eval.Pool[5].Q}
 	c1OutQP := rlwe.PolyQP{Q: ctOut.Value[1], P: eval.Pool[5].P}
@@ -918,9 +905,63 @@ func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix LinearT
 	ringQ.MulScalarBigintLvl(levelQ, ctInTmp0, ringP.ModulusBigint, ctInTmp0) // P*c0
 	ringQ.MulScalarBigintLvl(levelQ, ctInTmp1, ringP.ModulusBigint, ctInTmp1) // P*c1
 
+	giantSteps := make([]int, 0, len(index))
+	for j := range index {
+		giantSteps = append(giantSteps, j)
+	}
+	sort.Ints(giantSteps)
+
+	if workers := utils.MinInt(eval.params.Parallelism(), len(giantSteps)); workers > 1 {
+
+		// The giant steps are split among the workers, each accumulating its own partial result
+		// which are then summed. Since the partial results are fully reduced, the result is identical
+		// to the sequential one.
+		pool := eval.bsgsWorkerPool(workers)
+
+		utils.ParallelFor(workers, workers, func(w int) {
+			var steps []int
+			for k := w; k < len(giantSteps); k += workers {
+				steps = append(steps, giantSteps[k])
+			}
+			worker := pool[w]
+			eval.multiplyByDiagMatrixBSGSGiantSteps(levelQ, levelP, steps, index, matrix, ctInTmp0, ctInTmp1, ctInRotQP, worker.ks, worker.tmp0QP, worker.tmp1QP, worker.c0QP, worker.c1QP, worker.acc0QP, worker.acc1QP)
+		})
+
+		ringQP.CopyValuesLvl(levelQ, levelP, pool[0].acc0QP, c0OutQP)
+		ringQP.CopyValuesLvl(levelQ, levelP, pool[0].acc1QP, c1OutQP)
+		for _, worker := range pool[1:workers] {
+			ringQP.AddLvl(levelQ, levelP, c0OutQP, worker.acc0QP, c0OutQP)
+			ringQP.AddLvl(levelQ, levelP, c1OutQP, worker.acc1QP, c1OutQP)
+		}
+
+	} else {
+		eval.multiplyByDiagMatrixBSGSGiantSteps(levelQ, levelP, giantSteps, index, matrix, ctInTmp0, ctInTmp1, ctInRotQP, eval.KeySwitcher, eval.Pool[1], eval.Pool[2], eval.Pool[3], eval.Pool[4], c0OutQP, c1OutQP)
+	}
+
+	eval.BasisExtender.ModDownQPtoQNTT(levelQ, levelP, ctOut.Value[0], c0OutQP.P, ctOut.Value[0]) // sum(phi(c0 * P + d0_QP))/P
+	eval.BasisExtender.ModDownQPtoQNTT(levelQ, levelP, ctOut.Value[1], c1OutQP.P, ctOut.Value[1]) // sum(phi(d1_QP))/P
+
+	ctOut.Scale = matrix.Scale * ctIn.Scale
+
+	ctInRotQP = nil
+	runtime.GC()
+
+}
+
+// multiplyByDiagMatrixBSGSGiantSteps evaluates the given giant steps of MultiplyByDiagMatrixBSGS and writes the sum of
+// their results on (c0OutQP, c1OutQP), using the provided key-switcher and buffers. The result is fully reduced.
+func (eval *evaluator) multiplyByDiagMatrixBSGSGiantSteps(levelQ, levelP int, giantSteps []int, index map[int][]int, matrix LinearTransform, ctInTmp0, ctInTmp1 *ring.Poly, ctInRotQP map[int][2]rlwe.PolyQP, ks *rlwe.KeySwitcher, tmp0QP, tmp1QP, c0QP, c1QP, c0OutQP, c1OutQP rlwe.PolyQP) {
+
+	ringQ := eval.params.RingQ()
+	ringP := eval.params.RingP()
+	ringQP := rlwe.RingQP{RingQ: ringQ, RingP: ringP}
+
+	QiOverF := eval.params.QiOverflowMargin(levelQ) >> 1
+	PiOverF := eval.params.PiOverflowMargin(levelP) >> 1
+
 	// OUTER LOOP
 	var cnt0 int
-	for j := range index {
+	for _, j := range giantSteps {
 		// INNER LOOP
 		var cnt1 int
 		for _, i := range index[j] {
@@ -971,19 +1012,16 @@ func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix LinearT
 		if j != 0 {
 
 			// Hoisting of the ModDown of sum(sum(phi(d1) * plaintext))
-			eval.BasisExtender.ModDownQPtoQNTT(levelQ, levelP, tmp1QP.Q, tmp1QP.P, tmp1QP.Q) // c1 * plaintext + sum(phi(d1) * plaintext) + phi(c1) * plaintext mod Q
+			ks.BasisExtender.ModDownQPtoQNTT(levelQ, levelP, tmp1QP.Q, tmp1QP.P, tmp1QP.Q) // c1 * plaintext + sum(phi(d1) * plaintext) + phi(c1) * plaintext mod Q
 
 			galEl := eval.params.GaloisElementForColumnRotationBy(j)
 
-			rtk, generated := eval.rtks.Keys[galEl]
-			if !generated {
-				panic("switching key not available")
-			}
+			rtk := eval.rotationKey(galEl)
 
 			rotIndex := eval.permuteNTTIndex[galEl]
 
 			tmp1QP.Q.IsNTT = true
-			eval.SwitchKeysInPlaceNoModDown(levelQ, tmp1QP.Q, rtk, c0QP.Q, c0QP.P, c1QP.Q, c1QP.P) // Switchkey(P*phi(tmpRes_1)) = (d0, d1) in base QP
+			ks.SwitchKeysInPlaceNoModDown(levelQ, tmp1QP.Q, rtk, c0QP.Q, c0QP.P, c1QP.Q, c1QP.P) // Switchkey(P*phi(tmpRes_1)) = (d0, d1) in base QP
 			ringQP.AddLvl(levelQ, levelP, c0QP, tmp0QP, c0QP)
 
 			// Outer loop rotations
@@ -1007,8 +1045,8 @@ func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix LinearT
 		}
 
 		if cnt0%QiOverF == QiOverF-1 {
-			ringQ.ReduceLvl(levelQ, ctOut.Value[0], ctOut.Value[0])
-			ringQ.ReduceLvl(levelQ, ctOut.Value[1], ctOut.Value[1])
+			ringQ.ReduceLvl(levelQ, c0OutQP.Q, c0OutQP.Q)
+			ringQ.ReduceLvl(levelQ, c1OutQP.Q, c1OutQP.Q)
 		}
 
 		if cnt0%PiOverF == PiOverF-1 {
@@ -1020,21 +1058,12 @@ func (eval *evaluator) MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix LinearT
 	}
 
 	if cnt0%QiOverF != 0 {
-		ringQ.ReduceLvl(levelQ, ctOut.Value[0], ctOut.Value[0])
-		ringQ.ReduceLvl(levelQ, ctOut.Value[1], ctOut.Value[1])
+		ringQ.ReduceLvl(levelQ, c0OutQP.Q, c0OutQP.Q)
+		ringQ.ReduceLvl(levelQ, c1OutQP.Q, c1OutQP.Q)
 	}
 
 	if cnt0%PiOverF != 0 {
 		ringP.ReduceLvl(levelP, c0OutQP.P, c0OutQP.P)
 		ringP.ReduceLvl(levelP, c1OutQP.P, c1OutQP.P)
 	}
-
-	eval.BasisExtender.ModDownQPtoQNTT(levelQ, levelP, ctOut.Value[0], c0OutQP.P, ctOut.Value[0]) // sum(phi(c0 * P + d0_QP))/P
-	eval.BasisExtender.ModDownQPtoQNTT(levelQ, levelP, ctOut.Value[1], c1OutQP.P, ctOut.Value[1]) // sum(phi(d1_QP))/P
-
-	ctOut.Scale = matrix.Scale * ctIn.Scale
-
-	ctInRotQP = nil
-	runtime.GC()
-
 }
@@ -312,9 +312,11 @@ var (
 // the Q and P fields to the desired moduli chain, or by setting the LogQ and LogP fields to
 // the desired moduli sizes (in log_2). Users must also specify a default initial scale for the plaintexts.
 //
-// Optionally, users may specify the error variance (Sigma), the secrets' density (H), the ring
-// type (RingType) and the number of slots (in log_2, LogSlots). If left unset, standard default values for
-// these field are substituted at parameter creation (see NewParametersFromLiteral).
+// Optionally, users may specify the error variance (Sigma), the error distribution (ErrorDistribution), the
+// secrets' density (H), the ring type (RingType), the number of slots (in log_2, LogSlots) and the power-of-two
+// decomposition base of the key-switching (Pow2Base). If left unset, standard default values for these field
+// are substituted at parameter creation (see NewParametersFromLiteral). Users may also opt-in for a strict mode
+// by specifying the minimum estimated bit-security that the parameters must achieve (MinSecurity).
 type ParametersLiteral struct {
 	LogN         int // Ring degree (power of 2)
 	Q            []uint64
@@ -326,6 +328,10 @@ type ParametersLiteral struct {
 	LogSlots     int
 	DefaultScale float64
 	RingType     ring.Type
+
+	ErrorDistribution rlwe.ErrorDistribution `json:",omitempty"`
+	Pow2Base          int                    `json:",omitempty"`
+	MinSecurity       int                    `json:",omitempty"`
 }
 
 // DefaultParams is a set of default CKKS parameters ensuring 128 bit security in a classic setting.
@@ -371,7 +377,7 @@ func NewParameters(rlweParams rlwe.Parameters, logSlots int, defaultScale float6
 //
 // See `rlwe.NewParametersFromLiteral` for default values of the other optional fields.
 func NewParametersFromLiteral(pl ParametersLiteral) (Parameters, error) {
-	rlweParams, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{LogN: pl.LogN, Q: pl.Q, P: pl.P, LogQ: pl.LogQ, LogP: pl.LogP, H: pl.H, Sigma: pl.Sigma, RingType: pl.RingType})
+	rlweParams, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{LogN: pl.LogN, Q: pl.Q, P: pl.P, LogQ: pl.LogQ, LogP: pl.LogP, H: pl.H, Sigma: pl.Sigma, RingType: pl.RingType, ErrorDistribution: pl.ErrorDistribution, Pow2Base: pl.Pow2Base, MinSecurity: pl.MinSecurity})
 	if err != nil {
 		return Parameters{}, err
 	}
@@ -400,6 +406,14 @@ func (p Parameters) StandardParameters() (pckks Parameters, err error) {
 	return
 }
 
+// WithParallelism returns a copy of the receiver whose operations are split across the given number of
+// workers (see rlwe.Parameters.WithParallelism). The evaluators instantiated from the returned parameters
+// additionally split the giant steps of the BSGS linear transformations across the workers.
+func (p Parameters) WithParallelism(workers int) Parameters {
+	p.Parameters = p.Parameters.WithParallelism(workers)
+	return p
+}
+
 // LogSlots returns the log of the number of slots
 func (p Parameters) LogSlots() int {
 	return p.logSlots
@@ -545,6 +559,18 @@ func (p Parameters) Equals(other Parameters) bool {
 	return res
 }
 
+// Fingerprint returns a stable 64-bit digest of the parameters, which extends the fingerprint of the
+// RLWE parameters (see rlwe.Parameters.Fingerprint) with LogSlots and DefaultScale. The keys record
+// the fingerprint of the RLWE parameters, since they do not depend on LogSlots and DefaultScale.
+func (p Parameters) Fingerprint() uint64 {
+	b := utils.NewBuffer(make([]byte, 0, 40))
+	b.WriteUint8Slice([]byte("lattigo/ckks.Parameters"))
+	b.WriteUint64(p.Parameters.Fingerprint())
+	b.WriteUint8(uint8(p.logSlots))
+	b.WriteUint64(math.Float64bits(p.defaultScale))
+	return rlwe.FingerprintDigest(b.Bytes())
+}
+
 // CopyNew makes a deep copy of the receiver and returns it.
 //
 // Deprecated: Parameter is now a read-only struct, except for the UnmarshalBinary method: deep copying should only be
@@ -566,26 +592,54 @@ func (p Parameters) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
+	env, rlweBytes, err := rlwe.OpenEnvelope(rlweBytes, rlwe.KindParameters)
+	if err != nil {
+		return nil, err
+	}
+	env.Kind = rlwe.KindCKKSParameters
+	env.ParametersHash = p.Fingerprint()
+
 	// len(rlweBytes) : RLWE parameters
 	// 1 byte : logSlots
 	// 8 byte : defaultScale
-	b := utils.NewBuffer(make([]byte, 0, p.MarshalBinarySize()))
+	b := utils.NewBuffer(make([]byte, 0, p.MarshalBinarySize()-rlwe.EnvelopeHeaderLen))
 	b.WriteUint8Slice(rlweBytes)
 	b.WriteUint8(uint8(p.logSlots))
 	b.WriteUint64(math.Float64bits(p.defaultScale))
-	return b.Bytes(), nil
+	return env.Seal(b.Bytes()), nil
 }
 
-// UnmarshalBinary decodes a []byte into a parameter set struct
+// UnmarshalBinary decodes a []byte into a parameter set struct.
+// It also accepts the legacy encoding without Envelope.
 func (p *Parameters) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindCKKSParameters); err != nil {
+		return err
+	}
+
+	if len(data) < 9 {
+		return fmt.Errorf("invalid ckks.Parameter serialization")
+	}
+
 	var rlweParams rlwe.Parameters
-	if err := rlweParams.UnmarshalBinary(data); err != nil {
+	if err := rlweParams.UnmarshalBinary(data[:len(data)-9]); err != nil {
 		return err
 	}
 	logSlots := int(data[len(data)-9])
 	scale := math.Float64frombits(binary.BigEndian.Uint64(data[len(data)-8:]))
-	*p, err = NewParameters(rlweParams, logSlots, scale)
-	return err
+
+	var params Parameters
+	if params, err = NewParameters(rlweParams, logSlots, scale); err != nil {
+		return err
+	}
+
+	if env.ParametersHash != 0 && env.ParametersHash != params.Fingerprint() {
+		return fmt.Errorf("invalid ckks.Parameter serialization: fingerprint does not match the envelope")
+	}
+
+	*p = params
+	return nil
 }
 
 // MarshalBinarySize returns the length of the []byte encoding of the receiver.
@@ -595,7 +649,7 @@ func (p Parameters) MarshalBinarySize() int {
 
 // MarshalJSON returns a JSON representation of this parameter set. See `Marshal` from the `encoding/json` package.
 func (p Parameters) MarshalJSON() ([]byte, error) {
-	return json.Marshal(ParametersLiteral{LogN: p.LogN(), Q: p.Q(), P: p.P(), H: p.HammingWeight(), Sigma: p.Sigma(), LogSlots: p.logSlots, DefaultScale: p.defaultScale, RingType: p.RingType()})
+	return json.Marshal(ParametersLiteral{LogN: p.LogN(), Q: p.Q(), P: p.P(), H: p.HammingWeight(), Sigma: p.Sigma(), LogSlots: p.logSlots, DefaultScale: p.defaultScale, RingType: p.RingType(), ErrorDistribution: p.ErrorDistribution(), Pow2Base: p.Pow2Base()})
 }
 
 // UnmarshalJSON reads a JSON representation of a parameter set into the receiver Parameter. See `Unmarshal` from the `encoding/json` package.
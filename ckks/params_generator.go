@@ -0,0 +1,180 @@
+package ckks
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// DefaultSecurity is the default minimum security, in bits, of the parameters generated by GenParametersFromCircuit.
+const DefaultSecurity = 128
+
+// CircuitLiteral is a literal description of a CKKS circuit, from which a set of parameters
+// can be generated with GenParametersFromCircuit.
+//
+// Users must set the multiplicative depth of the circuit (Depth) and the number of bits of
+// precision expected on the output (LogPrecision).
+//
+// Optionally, users may specify the bound (in log2) on the absolute value of the messages (LogMessage),
+// the number of slots (in log2, LogSlots), the number of digits of the key-switching decomposition
+// (KeySwitchingDigits), the minimum security in bits (Security) and the ring type (RingType).
+type CircuitLiteral struct {
+	Depth              int // Number of rescales of the circuit
+	LogPrecision       int // Bits of precision of the output
+	LogMessage         int // Bound on the absolute value of the messages, in log2
+	LogSlots           int // Number of slots, in log2 (maximum number of slots if unset)
+	KeySwitchingDigits int // Number of digits of the key-switching decomposition, i.e. Beta (#Qi if unset)
+	Security           int // Minimum estimated security in bits (DefaultSecurity if unset)
+	RingType           ring.Type
+}
+
+// ParametersReport reports the estimated security and the memory footprint of a set of CKKS parameters.
+// All sizes are given in bytes and do not include the metadata of the serialized objects.
+type ParametersReport struct {
+	LogQP             int
+	EstimatedSecurity float64
+	PlaintextSize     int // Size of a plaintext at the maximum level
+	CiphertextSize    int // Size of a ciphertext of degree 1 at the maximum level
+	SwitchingKeySize  int // Size of a switching key, i.e. of the relinearization key or of a single rotation key
+}
+
+func (r ParametersReport) String() string {
+	return fmt.Sprintf("LogQP: %d, Security: %.1f bits, Plaintext: %s, Ciphertext: %s, SwitchingKey: %s",
+		r.LogQP, r.EstimatedSecurity, byteSize(r.PlaintextSize), byteSize(r.CiphertextSize), byteSize(r.SwitchingKeySize))
+}
+
+// NewParametersReport returns the ParametersReport of the target parameters.
+func NewParametersReport(params Parameters) ParametersReport {
+	polySize := params.N() * 8
+	return ParametersReport{
+		LogQP:             params.LogQP(),
+		EstimatedSecurity: params.EstimatedSecurity(),
+		PlaintextSize:     polySize * params.QCount(),
+		CiphertextSize:    2 * polySize * params.QCount(),
+		SwitchingKeySize:  params.Beta() * params.DecompPw2() * 2 * polySize * (params.QCount() + params.PCount()),
+	}
+}
+
+// GenParametersFromCircuit generates a set of CKKS parameters for the circuit described by the CircuitLiteral.
+// It returns the smallest ring degree for which the parameters reach the target security, along with the
+// report of their memory footprint, or an error if the circuit cannot be instantiated.
+//
+// The generated parameters have:
+//
+//   - Depth scaling primes of LogScale = LogPrecision + LogN bits, where LogN bits account for the error
+//     introduced by the encryption and by the rescaling;
+//   - a first modulus of LogScale + LogMessage + 2 bits that stores the message after the last rescale;
+//   - ceil((Depth+1)/KeySwitchingDigits) special primes of the size of the largest Qi, so that P is larger
+//     than each digit of the key-switching decomposition.
+//
+// The DefaultScale is set to 2^LogScale and the MinSecurity of the returned literal is set to the target security.
+func GenParametersFromCircuit(circuit CircuitLiteral) (pl ParametersLiteral, report ParametersReport, err error) {
+
+	if circuit.Depth < 0 || circuit.LogPrecision <= 0 || circuit.LogMessage < 0 || circuit.LogSlots < 0 || circuit.KeySwitchingDigits < 0 || circuit.Security < 0 {
+		return ParametersLiteral{}, ParametersReport{}, fmt.Errorf("invalid circuit literal: negative field or unset LogPrecision")
+	}
+
+	if circuit.Depth+1 > rlwe.MaxModuliCount {
+		return ParametersLiteral{}, ParametersReport{}, fmt.Errorf("invalid circuit literal: Depth+1=%d is larger than the maximum number of moduli %d", circuit.Depth+1, rlwe.MaxModuliCount)
+	}
+
+	security := circuit.Security
+	if security == 0 {
+		security = DefaultSecurity
+	}
+
+	logNMin := circuit.LogSlots
+	switch circuit.RingType {
+	case ring.Standard:
+		logNMin++
+	case ring.ConjugateInvariant:
+	default:
+		return ParametersLiteral{}, ParametersReport{}, fmt.Errorf("invalid circuit literal: invalid ring type")
+	}
+
+	logNMin = utils.MaxInt(logNMin, 10)
+
+	// lastErr is the last MinSecurity rejection of NewParametersFromLiteral.
+	var lastErr error
+	for logN := logNMin; logN <= rlwe.MaxLogN; logN++ {
+
+		logScale := circuit.LogPrecision + logN
+		logQ0 := logScale + circuit.LogMessage + 2
+
+		if logQ0 > rlwe.MaxModuliSize {
+			return ParametersLiteral{}, ParametersReport{}, fmt.Errorf("cannot generate parameters: the first modulus would require %d bits, which is more than %d", logQ0, rlwe.MaxModuliSize)
+		}
+
+		logQ := make([]int, circuit.Depth+1)
+		logQ[0] = logQ0
+		for i := 1; i < len(logQ); i++ {
+			logQ[i] = logScale
+		}
+
+		beta := circuit.KeySwitchingDigits
+		if beta == 0 || beta > len(logQ) {
+			beta = len(logQ)
+		}
+
+		logP := make([]int, (len(logQ)+beta-1)/beta)
+		for i := range logP {
+			logP[i] = logQ0
+		}
+
+		if len(logP) > rlwe.MaxModuliCount {
+			return ParametersLiteral{}, ParametersReport{}, fmt.Errorf("cannot generate parameters: #Pi=%d is larger than %d", len(logP), rlwe.MaxModuliCount)
+		}
+
+		var logQP int
+		for _, qi := range append(logQ, logP...) {
+			logQP += qi
+		}
+
+		// The moduli are generated close to 2^logQi, hence the estimation
+		// is refined on the actual moduli by the MinSecurity check.
+		if rlwe.EstimateSecurity(logN, float64(logQP), rlwe.DefaultSigma, 1<<(logN-1)) < float64(security) {
+			continue
+		}
+
+		pl = ParametersLiteral{
+			LogN:         logN,
+			LogQ:         logQ,
+			LogP:         logP,
+			LogSlots:     circuit.LogSlots,
+			DefaultScale: math.Exp2(float64(logScale)),
+			RingType:     circuit.RingType,
+			MinSecurity:  security,
+		}
+
+		var params Parameters
+		if params, err = NewParametersFromLiteral(pl); err != nil {
+			if errors.Is(err, rlwe.ErrInsufficientSecurity) {
+				lastErr = err
+				continue
+			}
+			return ParametersLiteral{}, ParametersReport{}, fmt.Errorf("cannot generate parameters: %w", err)
+		}
+
+		return pl, NewParametersReport(params), nil
+	}
+
+	if lastErr != nil {
+		return ParametersLiteral{}, ParametersReport{}, fmt.Errorf("cannot generate parameters: no ring degree up to 2^%d reaches %d bits of security: %w", rlwe.MaxLogN, security, lastErr)
+	}
+
+	return ParametersLiteral{}, ParametersReport{}, fmt.Errorf("cannot generate parameters: no ring degree up to 2^%d reaches %d bits of security", rlwe.MaxLogN, security)
+}
+
+// byteSize returns a human readable representation of a size in bytes.
+func byteSize(size int) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	s, i := float64(size), 0
+	for ; s >= 1024 && i < len(units)-1; i++ {
+		s /= 1024
+	}
+	return fmt.Sprintf("%.1f %s", s, units[i])
+}
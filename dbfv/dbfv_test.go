@@ -92,6 +92,7 @@ func Test_DBFV(t *testing.T) {
 			testRotKeyGenRotRows,
 			testRotKeyGenRotCols,
 			testEncToShares,
+			testPublicKeyEncToShares,
 			testRefresh,
 			testRefreshAndPermutation,
 			testMarshalling,
@@ -119,7 +120,9 @@ func gentestContext(params bfv.Parameters) (testCtx *testContext, err error) {
 	testCtx.uniformSampler = ring.NewUniformSampler(prng, params.RingQ())
 
 	testCtx.encoder = bfv.NewEncoder(testCtx.params)
-	testCtx.evaluator = bfv.NewEvaluator(testCtx.params, rlwe.EvaluationKey{})
+	if testCtx.evaluator, err = bfv.NewEvaluator(testCtx.params, rlwe.EvaluationKey{}); err != nil {
+		return nil, err
+	}
 
 	kgen := bfv.NewKeyGenerator(testCtx.params)
 
@@ -143,8 +146,12 @@ func gentestContext(params bfv.Parameters) (testCtx *testContext, err error) {
 	testCtx.pk1 = kgen.GenPublicKey(testCtx.sk1)
 
 	testCtx.encryptorPk0 = bfv.NewEncryptor(testCtx.params, testCtx.pk0)
-	testCtx.decryptorSk0 = bfv.NewDecryptor(testCtx.params, testCtx.sk0)
-	testCtx.decryptorSk1 = bfv.NewDecryptor(testCtx.params, testCtx.sk1)
+	if testCtx.decryptorSk0, err = bfv.NewDecryptor(testCtx.params, testCtx.sk0); err != nil {
+		return nil, err
+	}
+	if testCtx.decryptorSk1, err = bfv.NewDecryptor(testCtx.params, testCtx.sk1); err != nil {
+		return nil, err
+	}
 
 	return
 }
@@ -546,6 +553,98 @@ func testEncToShares(testCtx *testContext, t *testing.T) {
 	})
 }
 
+func testPublicKeyEncToShares(testCtx *testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	coeffs, _, ciphertext := newTestVectors(testCtx, testCtx.encryptorPk0, t)
+
+	// The receivers are the parties holding the shards of sk1, each identified by its individual public-key
+	kgen := bfv.NewKeyGenerator(params)
+	receivers := make([]*rlwe.PublicKey, parties)
+	for i := range receivers {
+		receivers[i] = kgen.GenPublicKey(testCtx.sk1Shards[i])
+	}
+
+	type Party struct {
+		e2s   *PublicKeyE2SProtocol
+		sk    *rlwe.SecretKey
+		share *PublicKeyE2SShare
+	}
+
+	P := make([]Party, parties)
+	for i := range P {
+		if i == 0 {
+			P[i].e2s = NewPublicKeyE2SProtocol(params, receivers, 3.2)
+		} else {
+			P[i].e2s = P[0].e2s.ShallowCopy()
+		}
+		P[i].sk = testCtx.sk0Shards[i]
+		P[i].share = P[i].e2s.AllocateShare()
+	}
+
+	for i, p := range P {
+		p.e2s.GenShare(p.sk, ciphertext.Value[1], p.share)
+		if i > 0 {
+			p.e2s.AggregateShare(P[0].share, p.share, P[0].share)
+		}
+	}
+
+	secretShares := make([]*rlwe.AdditiveShare, parties)
+	for i := range secretShares {
+		secretShares[i] = rlwe.NewAdditiveShare(params.Parameters)
+		P[0].e2s.GetShare(testCtx.sk1Shards[i], i, P[0].share, ciphertext, secretShares[i])
+	}
+
+	t.Run(testString("PublicKeyE2SProtocol", parties, testCtx.params), func(t *testing.T) {
+
+		rec := rlwe.NewAdditiveShare(params.Parameters)
+		for _, share := range secretShares {
+			testCtx.ringT.Add(&rec.Value, &share.Value, &rec.Value)
+		}
+
+		ptRt := bfv.NewPlaintextRingT(testCtx.params)
+		ptRt.Value.Copy(&rec.Value)
+
+		assert.True(t, utils.EqualSliceUint64(coeffs, testCtx.encoder.DecodeUintNew(ptRt)))
+	})
+
+	t.Run(testString("PublicKeyS2EProtocol", parties, testCtx.params), func(t *testing.T) {
+
+		s2e := NewPublicKeyS2EProtocol(params, testCtx.pk1)
+
+		shares := make([]*drlwe.PCKSShare, parties)
+		for i := range shares {
+			shares[i] = s2e.AllocateShare()
+			s2e.GenShare(secretShares[i], shares[i])
+			if i > 0 {
+				s2e.AggregateShare(shares[0], shares[i], shares[0])
+			}
+		}
+
+		ctRec := bfv.NewCiphertext(testCtx.params, 1)
+		s2e.GetEncryption(shares[0], ctRec)
+
+		verifyTestVectors(testCtx, testCtx.decryptorSk1, coeffs, ctRec, t)
+	})
+
+	t.Run(testString("MarshallingPublicKeyE2SShare", parties, testCtx.params), func(t *testing.T) {
+
+		data, err := P[0].share.MarshalBinary()
+		require.NoError(t, err)
+
+		shareRec := new(PublicKeyE2SShare)
+		require.NoError(t, shareRec.UnmarshalBinary(data))
+
+		require.True(t, testCtx.ringQ.Equal(P[0].share.DecryptionShare.Value, shareRec.DecryptionShare.Value))
+		require.Equal(t, len(P[0].share.MaskShares), len(shareRec.MaskShares))
+		for i := range shareRec.MaskShares {
+			require.True(t, testCtx.ringQ.Equal(P[0].share.MaskShares[i].Value[0], shareRec.MaskShares[i].Value[0]))
+			require.True(t, testCtx.ringQ.Equal(P[0].share.MaskShares[i].Value[1], shareRec.MaskShares[i].Value[1]))
+		}
+	})
+}
+
 func testRefresh(testCtx *testContext, t *testing.T) {
 
 	encryptorPk0 := testCtx.encryptorPk0
@@ -764,6 +863,14 @@ func testMarshalling(testCtx *testContext, t *testing.T) {
 		if err != nil {
 			t.Fatal("Could not unmarshal RefreshShare", err)
 		}
+
+		// The share records the fingerprint of its parameters
+		params := testCtx.params
+		paramsOther, err := rlwe.NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		require.NoError(t, params.UnmarshalObject(data, new(MaskedTransformShare)))
+		require.Error(t, paramsOther.UnmarshalObject(data, new(MaskedTransformShare)))
+
 		for i, r := range refreshshare.e2sShare.Value.Coeffs {
 			if !utils.EqualSliceUint64(resRefreshShare.e2sShare.Value.Coeffs[i], r) {
 				t.Fatal("Resulting of marshalling not the same as original : RefreshShare")
@@ -0,0 +1,164 @@
+package psi
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/dbfv"
+	"github.com/tuneinsight/lattigo/v3/drlwe"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// Evaluator is a struct evaluating the PSI protocols on the encrypted sets of the parties, and aggregating their shares.
+type Evaluator struct {
+	bfv.Evaluator
+	params  Parameters
+	encoder bfv.Encoder
+	pcks    *dbfv.PCKSProtocol
+	e2s     *dbfv.E2SProtocol
+	prng    utils.PRNG
+}
+
+// NewEvaluator creates a new Evaluator from the collective relinearization key rlk.
+// It returns an error if rlk cannot be used with the parameters.
+func NewEvaluator(params Parameters, rlk *rlwe.RelinearizationKey) (*Evaluator, error) {
+
+	eval, err := bfv.NewEvaluator(params.Parameters, rlwe.EvaluationKey{Rlk: rlk})
+	if err != nil {
+		return nil, err
+	}
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Evaluator{
+		Evaluator: eval,
+		params:    params,
+		encoder:   bfv.NewEncoder(params.Parameters),
+		pcks:      dbfv.NewPCKSProtocol(params.Parameters, params.SigmaSmudging),
+		e2s:       dbfv.NewE2SProtocol(params.Parameters, params.SigmaSmudging),
+		prng:      prng,
+	}, nil
+}
+
+// ShallowCopy creates a shallow copy of this Evaluator in which the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// Evaluator can be used concurrently.
+func (eval *Evaluator) ShallowCopy() *Evaluator {
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	return &Evaluator{
+		Evaluator: eval.Evaluator.ShallowCopy(),
+		params:    eval.params,
+		encoder:   eval.encoder.ShallowCopy(),
+		pcks:      eval.pcks.ShallowCopy(),
+		e2s:       eval.e2s.ShallowCopy(),
+		prng:      prng,
+	}
+}
+
+// EvaluateNew evaluates the polynomials of the Senders, encrypted by Sender.EncryptSetNew, on the powers of the
+// fingerprints of the Receiver, encrypted by Receiver.EncryptSetNew, and returns a uniformly random linear combination
+// of the results. The slot of a bin is zero if the element of the Receiver in this bin belongs to the sets of all the
+// Senders, and uniformly random otherwise. It panics if the number of ciphertexts of an encrypted set is invalid.
+func (eval *Evaluator) EvaluateNew(receiverSet []*bfv.Ciphertext, senderSets [][]*bfv.Ciphertext) (ctOut *bfv.Ciphertext) {
+
+	params := eval.params
+
+	if len(receiverSet) != params.BinSize {
+		panic(fmt.Errorf("cannot EvaluateNew: the set of the Receiver must have BinSize=%d ciphertexts", params.BinSize))
+	}
+
+	ctOut = bfv.NewCiphertext(params.Parameters, 2)
+
+	tmp := bfv.NewCiphertext(params.Parameters, 2)
+	mask := bfv.NewPlaintextMul(params.Parameters)
+	values := make([]uint64, params.NbBins())
+
+	for j, senderSet := range senderSets {
+
+		if len(senderSet) != params.BinSize+1 {
+			panic(fmt.Errorf("cannot EvaluateNew: the set of the Sender %d must have BinSize+1=%d ciphertexts", j, params.BinSize+1))
+		}
+
+		// P_j(x) = sum_k c_{j,k} * x^k
+		ct := bfv.NewCiphertext(params.Parameters, 2)
+		eval.Add(ct, senderSet[0], ct)
+		for k, xPow := range receiverSet {
+			eval.Mul(senderSet[k+1], xPow, tmp)
+			eval.Add(ct, tmp, ct)
+		}
+
+		// Multiplies P_j(x) by a uniformly random non-zero mask
+		for b := range values {
+			values[b] = 1 + randInt(eval.prng, params.T()-1)
+		}
+		eval.encoder.EncodeUintMul(values, mask)
+		eval.Mul(ct, mask, ct)
+
+		eval.Add(ctOut, ct, ctOut)
+	}
+
+	eval.Relinearize(ctOut, ctOut)
+	ctOut.Resize(params.Parameters.Parameters, 1)
+
+	return
+}
+
+// KeySwitchNew aggregates the shares of the parties generated by GenPCKSShare, and returns the re-encryption of the
+// result ct of the PSI under the output public key of the Receiver.
+func (eval *Evaluator) KeySwitchNew(ct *bfv.Ciphertext, shares []*drlwe.PCKSShare) (ctOut *bfv.Ciphertext) {
+
+	agg := eval.pcks.AllocateShare()
+	for _, share := range shares {
+		eval.pcks.AggregateShare(share, agg, agg)
+	}
+
+	ctOut = bfv.NewCiphertext(eval.params.Parameters, 1)
+	eval.pcks.KeySwitch(ct, agg, ctOut)
+
+	return
+}
+
+// CardinalityNew aggregates the shares of the parties generated by GenCardinalityShare, and returns the encryption
+// under the output public key of the Receiver of the slots of the result ct of the PSI, permuted by perm, which are
+// zero for the bins of the elements of the intersection and uniformly random otherwise.
+// It panics if none of the shares has a mask, i.e., if no Sender participated.
+func (eval *Evaluator) CardinalityNew(ct *bfv.Ciphertext, shares []*CardinalityShare, perm []int) (ctOut *bfv.Ciphertext) {
+
+	params := eval.params
+
+	agg := eval.e2s.AllocateShare()
+	for _, share := range shares {
+		eval.e2s.AggregateShare(share.Share, agg, agg)
+		if share.Mask != nil {
+			if ctOut == nil {
+				ctOut = share.Mask.CopyNew()
+			} else {
+				eval.Add(ctOut, share.Mask, ctOut)
+			}
+		}
+	}
+
+	if ctOut == nil {
+		panic(fmt.Errorf("cannot CardinalityNew: at least one share must be generated by a Sender"))
+	}
+
+	// Masked slots of the result minus the sum of the masks of the Senders
+	masked := &rlwe.AdditiveShare{Value: *params.RingT().NewPoly()}
+	eval.e2s.GetShare(nil, agg, ct, masked)
+	slots := eval.encoder.DecodeUintNew(&bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: &masked.Value}})
+
+	pt := bfv.NewPlaintext(params.Parameters)
+	eval.encoder.EncodeUint(permute(slots, perm), pt)
+	eval.Add(ctOut, pt, ctOut)
+
+	return
+}
@@ -0,0 +1,290 @@
+package psi
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/dbfv"
+	"github.com/tuneinsight/lattigo/v3/drlwe"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+// CardinalityShare is a struct storing the share of a party in the PSI-cardinality protocol.
+type CardinalityShare struct {
+	Share *drlwe.CKSShare // masked decryption share of the result
+	Mask  *bfv.Ciphertext // encryption of the permuted mask under the output public key, or nil for the Receiver
+}
+
+// party is a struct storing the secret-key share of a party and the protocols shared by the Sender and the Receiver.
+type party struct {
+	params  Parameters
+	sk      *rlwe.SecretKey
+	encoder bfv.Encoder
+	pcks    *dbfv.PCKSProtocol
+}
+
+func newParty(params Parameters, sk *rlwe.SecretKey) *party {
+	return &party{
+		params:  params,
+		sk:      sk,
+		encoder: bfv.NewEncoder(params.Parameters),
+		pcks:    dbfv.NewPCKSProtocol(params.Parameters, params.SigmaSmudging),
+	}
+}
+
+// GenPCKSShare generates the share of the party in the re-encryption of the result ct of the PSI under the output
+// public key pkOut of the Receiver.
+func (p *party) GenPCKSShare(ct *bfv.Ciphertext, pkOut *rlwe.PublicKey) (share *drlwe.PCKSShare) {
+	share = p.pcks.AllocateShare()
+	p.pcks.GenShare(p.sk, pkOut, ct.Value[1], share)
+	return
+}
+
+// Sender is a party of the PSI protocols that holds a set and a share of the collective secret key.
+type Sender struct {
+	*party
+	e2s *dbfv.E2SProtocol
+}
+
+// NewSender creates a new Sender with the share sk of the collective secret key.
+func NewSender(params Parameters, sk *rlwe.SecretKey) *Sender {
+	return &Sender{
+		party: newParty(params, sk),
+		e2s:   dbfv.NewE2SProtocol(params.Parameters, params.SigmaSmudging),
+	}
+}
+
+// EncryptSetNew hashes the elements of set in their candidate bins and returns the encryptions under the collective
+// public key pk of the BinSize+1 coefficients of the polynomials whose roots are the fingerprints of each bin.
+// It returns an error if a bin has more than BinSize elements.
+func (s *Sender) EncryptSetNew(set [][]byte, pk *rlwe.PublicKey) (cts []*bfv.Ciphertext, err error) {
+
+	params := s.params
+	ringT := params.RingT()
+	T, bredParams := ringT.Modulus[0], ringT.BredParams[0]
+
+	// coeffs[k][b] is the coefficient of degree k of the polynomial of the bin b
+	coeffs := make([][]uint64, params.BinSize+1)
+	for k := range coeffs {
+		coeffs[k] = make([]uint64, params.NbBins())
+	}
+
+	degrees := make([]int, params.NbBins())
+	for b := range coeffs[0] {
+		coeffs[0][b] = 1
+	}
+
+	for _, x := range set {
+
+		bins, fingerprint := params.hash(x)
+
+		for i, b := range bins {
+
+			if inPreviousBins(bins, i) {
+				continue
+			}
+
+			if degrees[b] == params.BinSize {
+				return nil, fmt.Errorf("cannot EncryptSetNew: bin %d has more than BinSize=%d elements", b, params.BinSize)
+			}
+
+			// P(X) <- P(X) * (X - fingerprint)
+			degrees[b]++
+			for k := degrees[b]; k > 0; k-- {
+				coeffs[k][b] = ring.CRed(coeffs[k-1][b]+T-ring.BRed(coeffs[k][b], fingerprint, T, bredParams), T)
+			}
+			coeffs[0][b] = ring.BRed(coeffs[0][b], T-fingerprint, T, bredParams)
+		}
+	}
+
+	encryptor := bfv.NewEncryptor(params.Parameters, pk)
+	pt := bfv.NewPlaintext(params.Parameters)
+
+	cts = make([]*bfv.Ciphertext, len(coeffs))
+	for k := range coeffs {
+		s.encoder.EncodeUint(coeffs[k], pt)
+		cts[k] = encryptor.EncryptNew(pt)
+	}
+
+	return
+}
+
+// GenCardinalityShare generates the share of the Sender in the PSI-cardinality protocol, which re-encrypts the
+// result ct of the PSI under the output public key pkOut of the Receiver with its slots permuted by perm
+// (see NewPermutation).
+func (s *Sender) GenCardinalityShare(ct *bfv.Ciphertext, perm []int, pkOut *rlwe.PublicKey) (share *CardinalityShare) {
+
+	params := s.params
+
+	mask := &rlwe.AdditiveShare{Value: *params.RingT().NewPoly()}
+	share = &CardinalityShare{Share: s.e2s.AllocateShare()}
+	s.e2s.GenShare(s.sk, ct.Value[1], mask, share.Share)
+
+	// Encrypts the permuted slots of the mask, which are removed from the result by the E2S share
+	slots := s.encoder.DecodeUintNew(&bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: &mask.Value}})
+
+	pt := bfv.NewPlaintext(params.Parameters)
+	s.encoder.EncodeUint(permute(slots, perm), pt)
+	share.Mask = bfv.NewEncryptor(params.Parameters, pkOut).EncryptNew(pt)
+
+	return
+}
+
+// Receiver is the party of the PSI protocols that holds a set and a share of the collective secret key,
+// and obtains the result of the protocols under its output key pair.
+type Receiver struct {
+	*party
+	cks *dbfv.CKSProtocol
+
+	skOut *rlwe.SecretKey
+	pkOut *rlwe.PublicKey
+
+	set   [][]byte
+	table []int // index in set of the element in each bin, or -1 if the bin is empty
+}
+
+// NewReceiver creates a new Receiver with the share sk of the collective secret key, and generates its
+// output key pair.
+func NewReceiver(params Parameters, sk *rlwe.SecretKey) *Receiver {
+	skOut, pkOut := bfv.NewKeyGenerator(params.Parameters).GenKeyPair()
+	return &Receiver{
+		party: newParty(params, sk),
+		cks:   dbfv.NewCKSProtocol(params.Parameters, params.SigmaSmudging),
+		skOut: skOut,
+		pkOut: pkOut,
+	}
+}
+
+// PublicKey returns the output public key of the Receiver, under which the result of the protocols is re-encrypted.
+func (r *Receiver) PublicKey() *rlwe.PublicKey {
+	return r.pkOut
+}
+
+// EncryptSetNew places the elements of set in the bins with cuckoo hashing and returns the encryptions under the
+// collective public key pk of the powers 1 to BinSize of their fingerprints. The duplicate elements are ignored.
+// It returns an error if the cuckoo hashing fails, e.g., if the set has too many elements for the number of bins.
+func (r *Receiver) EncryptSetNew(set [][]byte, pk *rlwe.PublicKey) (cts []*bfv.Ciphertext, err error) {
+
+	params := r.params
+	ringT := params.RingT()
+	T, bredParams := ringT.Modulus[0], ringT.BredParams[0]
+
+	r.set = set
+	r.table = make([]int, params.NbBins())
+	for b := range r.table {
+		r.table[b] = -1
+	}
+
+	hashIndex := make([]int, len(set)) // index of the hash function of the bin of each element
+	fingerprints := make([]uint64, params.NbBins())
+
+	seen := make(map[string]bool, len(set))
+
+	for e := range set {
+
+		if seen[string(set[e])] {
+			continue
+		}
+		seen[string(set[e])] = true
+
+		// Inserts e, evicting the element of its bin which is then re-inserted in its next candidate bin
+		for it, cur := 0, e; cur != -1; it++ {
+
+			if it == MaxCuckooIterations {
+				return nil, fmt.Errorf("cannot EncryptSetNew: cuckoo hashing failed after %d evictions", MaxCuckooIterations)
+			}
+
+			bins, fingerprint := params.hash(set[cur])
+			b := bins[hashIndex[cur]]
+
+			evicted := r.table[b]
+			r.table[b], fingerprints[b] = cur, fingerprint
+
+			if evicted != -1 {
+				hashIndex[evicted] = (hashIndex[evicted] + 1) % NbHashes
+			}
+
+			cur = evicted
+		}
+	}
+
+	// The empty bins have the fingerprint 0, which is not the root of any polynomial of the Senders
+	encryptor := bfv.NewEncryptor(params.Parameters, pk)
+	pt := bfv.NewPlaintext(params.Parameters)
+
+	powers := make([]uint64, params.NbBins())
+	for b := range powers {
+		powers[b] = 1
+	}
+
+	cts = make([]*bfv.Ciphertext, params.BinSize)
+	for k := range cts {
+		for b := range powers {
+			powers[b] = ring.BRed(powers[b], fingerprints[b], T, bredParams)
+		}
+		r.encoder.EncodeUint(powers, pt)
+		cts[k] = encryptor.EncryptNew(pt)
+	}
+
+	return
+}
+
+// GenCardinalityShare generates the share of the Receiver in the PSI-cardinality protocol, which is a decryption
+// share of the result ct of the PSI without mask.
+func (r *Receiver) GenCardinalityShare(ct *bfv.Ciphertext) (share *CardinalityShare) {
+	share = &CardinalityShare{Share: r.cks.AllocateShare()}
+	r.cks.GenShare(r.sk, rlwe.NewSecretKey(r.params.Parameters.Parameters), ct.Value[1], share.Share)
+	return
+}
+
+// Intersection decrypts the result ct of the PSI protocol, re-encrypted under the output public key of the Receiver,
+// and returns the elements of the set of the Receiver that belong to the intersection, in the order of the set.
+func (r *Receiver) Intersection(ct *bfv.Ciphertext) (intersection [][]byte) {
+
+	slots := r.decrypt(ct)
+
+	isInIntersection := make([]bool, len(r.set))
+	for b, e := range r.table {
+		if e != -1 && slots[b] == 0 {
+			isInIntersection[e] = true
+		}
+	}
+
+	for e, ok := range isInIntersection {
+		if ok {
+			intersection = append(intersection, r.set[e])
+		}
+	}
+
+	return
+}
+
+// Cardinality decrypts the result ct of the PSI-cardinality protocol, re-encrypted under the output public key of
+// the Receiver, and returns the size of the intersection.
+func (r *Receiver) Cardinality(ct *bfv.Ciphertext) (cardinality int) {
+	for _, v := range r.decrypt(ct) {
+		if v == 0 {
+			cardinality++
+		}
+	}
+	return
+}
+
+func (r *Receiver) decrypt(ct *bfv.Ciphertext) []uint64 {
+	dec, err := bfv.NewDecryptor(r.params.Parameters, r.skOut)
+	if err != nil {
+		panic(err) // skOut is generated by NewReceiver from the parameters
+	}
+	return r.encoder.DecodeUintNew(dec.DecryptNew(ct))
+}
+
+// inPreviousBins returns true if bins[i] is equal to one of the bins[j] for j < i.
+func inPreviousBins(bins [NbHashes]int, i int) bool {
+	for j := 0; j < i; j++ {
+		if bins[j] == bins[i] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+// Package psi implements multiparty private set intersection (PSI) and PSI-cardinality protocols based on the
+// multiparty BFV scheme (see the dbfv package), following the PSI example of "Multiparty Homomorphic Encryption:
+// From Theory to Practice" (https://eprint.iacr.org/2020/304).
+//
+// The elements of the sets are byte strings, which are hashed into the N slots (the bins) of the BFV plaintexts.
+// The Receiver places its elements in the bins with cuckoo hashing, and encrypts the powers of their fingerprints.
+// Each Sender places its elements in all their candidate bins (simple hashing), and encrypts the coefficients of the
+// polynomials whose roots are the fingerprints of each bin. The Evaluator, e.g., a cloud, evaluates these polynomials
+// on the fingerprints of the Receiver and computes a random linear combination of the results, whose slots are zero
+// if and only if the element of the Receiver in the corresponding bin belongs to all the sets (with a false-positive
+// probability of about 1/T per bin). The result is re-encrypted under the output public key of the Receiver with the
+// dbfv.PCKSProtocol. For the PSI-cardinality, the Senders instead jointly re-encrypt the result with its slots shuffled
+// by a permutation that is unknown to the Receiver.
+//
+// The parties are assumed to be semi-honest, and the Evaluator is assumed not to collude with the Receiver.
+package psi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// NbHashes is the number of hash functions of the cuckoo hashing, i.e., the number of candidate bins of an element.
+const NbHashes = 3
+
+// MaxCuckooIterations is the maximum number of evictions of the cuckoo hashing before it fails.
+const MaxCuckooIterations = 1 << 10
+
+// Parameters is a struct for the parameters of the PSI protocols.
+type Parameters struct {
+	bfv.Parameters
+	BinSize       int     // maximum number of elements of a Sender in a bin, i.e., the degree of the polynomials of the Senders
+	SigmaSmudging float64 // standard deviation of the smudging noise of the decryption shares
+	Seed          []byte  // public seed of the hash functions
+}
+
+// NewParameters creates a new Parameters struct. It returns an error if the bin size is not positive.
+// The plaintext modulus T of params should be prime, so that the fingerprints of the elements are invertible.
+func NewParameters(params bfv.Parameters, binSize int, sigmaSmudging float64, seed []byte) (p Parameters, err error) {
+
+	if binSize < 1 {
+		return Parameters{}, fmt.Errorf("the bin size must be positive")
+	}
+
+	return Parameters{Parameters: params, BinSize: binSize, SigmaSmudging: sigmaSmudging, Seed: append([]byte{}, seed...)}, nil
+}
+
+// NbBins returns the number of bins, which is the number of slots N.
+func (p Parameters) NbBins() int {
+	return p.N()
+}
+
+// hash returns the NbHashes candidate bins of the element x and its fingerprint in [1, T).
+func (p Parameters) hash(x []byte) (bins [NbHashes]int, fingerprint uint64) {
+
+	digest := blake2b.Sum512(append(append([]byte{}, p.Seed...), x...))
+
+	for i := range bins {
+		bins[i] = int(binary.BigEndian.Uint64(digest[8*i:]) % uint64(p.NbBins()))
+	}
+
+	return bins, 1 + binary.BigEndian.Uint64(digest[8*NbHashes:])%(p.T()-1)
+}
+
+// NewPermutation samples from the prng a uniformly random permutation of the NbBins() slots, to be used in the
+// PSI-cardinality protocol. The Senders and the Evaluator must use the same permutation, which must remain
+// unknown to the Receiver: for example, the Senders can sample it from a shared secret seed.
+func NewPermutation(params Parameters, prng utils.PRNG) (perm []int) {
+
+	perm = make([]int, params.NbBins())
+	for i := range perm {
+		perm[i] = i
+	}
+
+	// Fisher-Yates shuffle
+	for i := len(perm) - 1; i > 0; i-- {
+		j := int(randInt(prng, uint64(i+1)))
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+
+	return
+}
+
+// randInt samples a uniform integer in [0, v) from the prng.
+func randInt(prng utils.PRNG, v uint64) uint64 {
+	return ring.RandUniform(prng, v, (1<<bits.Len64(v-1))-1)
+}
+
+// permute returns the slice whose index perm[i] is the value of index i of values.
+func permute(values []uint64, perm []int) (out []uint64) {
+	out = make([]uint64, len(values))
+	for i, j := range perm {
+		out[j] = values[i]
+	}
+	return
+}
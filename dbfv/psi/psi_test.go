@@ -0,0 +1,175 @@
+package psi
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/dbfv"
+	"github.com/tuneinsight/lattigo/v3/drlwe"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// TestPSIParams are the parameters PN13QP218 with a 30-bit plaintext modulus, which reduces the
+// probability of false positives.
+var TestPSIParams = bfv.ParametersLiteral{
+	LogN:  13,
+	T:     0x3fff4001,
+	Q:     bfv.PN13QP218.Q,
+	P:     bfv.PN13QP218.P,
+	Sigma: rlwe.DefaultSigma,
+}
+
+// genCollectiveKeys simulates the generation of the collective public and relinearization keys
+// of the secret-key shares sks with the dbfv.CKGProtocol and dbfv.RKGProtocol.
+func genCollectiveKeys(params bfv.Parameters, sks []*rlwe.SecretKey) (pk *rlwe.PublicKey, rlk *rlwe.RelinearizationKey) {
+
+	crs, err := utils.NewKeyedPRNG([]byte{'p', 's', 'i'})
+	if err != nil {
+		panic(err)
+	}
+
+	ckg := dbfv.NewCKGProtocol(params)
+	ckgCRP := ckg.SampleCRP(crs)
+	ckgAgg := ckg.AllocateShare()
+	ckgShare := ckg.AllocateShare()
+	for _, sk := range sks {
+		ckg.GenShare(sk, ckgCRP, ckgShare)
+		ckg.AggregateShare(ckgShare, ckgAgg, ckgAgg)
+	}
+	pk = bfv.NewPublicKey(params)
+	ckg.GenPublicKey(ckgAgg, ckgCRP, pk)
+
+	rkg := dbfv.NewRKGProtocol(params)
+	rkgCRP := rkg.SampleCRP(crs)
+	ephSks := make([]*rlwe.SecretKey, len(sks))
+	rkgShares := make([]*drlwe.RKGShare, len(sks))
+	_, rkgAgg1, rkgAgg2 := rkg.AllocateShare()
+	for i, sk := range sks {
+		var share *drlwe.RKGShare
+		ephSks[i], share, rkgShares[i] = rkg.AllocateShare()
+		rkg.GenShareRoundOne(sk, rkgCRP, ephSks[i], share)
+		rkg.AggregateShare(share, rkgAgg1, rkgAgg1)
+	}
+	for i, sk := range sks {
+		rkg.GenShareRoundTwo(ephSks[i], sk, rkgAgg1, rkgShares[i])
+		rkg.AggregateShare(rkgShares[i], rkgAgg2, rkgAgg2)
+	}
+	rlk = bfv.NewRelinearizationKey(params, 1)
+	rkg.GenRelinearizationKey(rkgAgg1, rkgAgg2, rlk)
+
+	return
+}
+
+func TestPSI(t *testing.T) {
+
+	if runtime.GOARCH == "wasm" {
+		t.Skip("skipping PSI tests for GOARCH=wasm")
+	}
+
+	paramsBFV, err := bfv.NewParametersFromLiteral(TestPSIParams)
+	require.NoError(t, err)
+
+	params, err := NewParameters(paramsBFV, 6, 3.19, []byte("lattigo"))
+	require.NoError(t, err)
+
+	t.Run("NewParameters/Invalid", func(t *testing.T) {
+		_, err := NewParameters(paramsBFV, 0, 3.19, nil)
+		require.Error(t, err)
+	})
+
+	const setSize, intersectionSize = 128, 24
+
+	for _, nbParties := range []int{3, 10} {
+
+		sks := make([]*rlwe.SecretKey, nbParties)
+		for i := range sks {
+			sks[i] = bfv.NewKeyGenerator(paramsBFV).GenSecretKey()
+		}
+
+		pk, rlk := genCollectiveKeys(paramsBFV, sks)
+
+		// The elements of the intersection are in all the sets, and the other elements in a single set
+		sets := make([][][]byte, nbParties)
+		want := make([][]byte, intersectionSize)
+		for i := range sets {
+			sets[i] = make([][]byte, setSize)
+			for j := range sets[i] {
+				if j < intersectionSize {
+					sets[i][j] = []byte(fmt.Sprintf("common-%d", j))
+				} else {
+					sets[i][j] = []byte(fmt.Sprintf("party-%d-%d", i, j))
+				}
+			}
+		}
+		copy(want, sets[0][:intersectionSize])
+
+		receiver := NewReceiver(params, sks[0])
+		senders := make([]*Sender, nbParties-1)
+		for i := range senders {
+			senders[i] = NewSender(params, sks[i+1])
+		}
+
+		eval, err := NewEvaluator(params, rlk)
+		require.NoError(t, err)
+
+		receiverSet, err := receiver.EncryptSetNew(sets[0], pk)
+		require.NoError(t, err)
+
+		senderSets := make([][]*bfv.Ciphertext, len(senders))
+		for i := range senders {
+			senderSets[i], err = senders[i].EncryptSetNew(sets[i+1], pk)
+			require.NoError(t, err)
+		}
+
+		ct := eval.EvaluateNew(receiverSet, senderSets)
+
+		t.Run(fmt.Sprintf("Intersection/parties=%d", nbParties), func(t *testing.T) {
+
+			shares := []*drlwe.PCKSShare{receiver.GenPCKSShare(ct, receiver.PublicKey())}
+			for _, sender := range senders {
+				shares = append(shares, sender.GenPCKSShare(ct, receiver.PublicKey()))
+			}
+
+			have := receiver.Intersection(eval.KeySwitchNew(ct, shares))
+
+			sortBytes(want)
+			sortBytes(have)
+			require.Equal(t, want, have)
+		})
+
+		t.Run(fmt.Sprintf("Cardinality/parties=%d", nbParties), func(t *testing.T) {
+
+			prng, err := utils.NewKeyedPRNG([]byte("senders"))
+			require.NoError(t, err)
+			perm := NewPermutation(params, prng)
+
+			shares := []*CardinalityShare{receiver.GenCardinalityShare(ct)}
+			for _, sender := range senders {
+				shares = append(shares, sender.GenCardinalityShare(ct, perm, receiver.PublicKey()))
+			}
+
+			require.Equal(t, intersectionSize, receiver.Cardinality(eval.CardinalityNew(ct, shares, perm)))
+		})
+	}
+
+	t.Run("EncryptSetNew/BinOverflow", func(t *testing.T) {
+		paramsSmall, err := NewParameters(paramsBFV, 1, 3.19, nil)
+		require.NoError(t, err)
+		set := make([][]byte, paramsBFV.N())
+		for i := range set {
+			set[i] = []byte(fmt.Sprintf("%d", i))
+		}
+		_, err = NewSender(paramsSmall, nil).EncryptSetNew(set, nil)
+		require.Error(t, err)
+	})
+}
+
+func sortBytes(s [][]byte) {
+	sort.Slice(s, func(i, j int) bool { return string(s[i]) < string(s[j]) })
+}
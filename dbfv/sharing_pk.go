@@ -0,0 +1,323 @@
+package dbfv
+
+import (
+	"errors"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/drlwe"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// PublicKeyE2SShare is a party's share in the public-key encryption-to-shares protocol.
+// It stores the masked decryption share of the party and, for each receiver, an encryption
+// under the receiver's public-key of the mask destined to this receiver.
+type PublicKeyE2SShare struct {
+	DecryptionShare *drlwe.CKSShare
+	MaskShares      []*bfv.Ciphertext
+}
+
+// PublicKeyE2SProtocol is the structure storing the parameters and temporary buffers
+// required by the public-key encryption-to-shares protocol. In this variant of the
+// encryption-to-shares protocol, the set of parties receiving the additive shares is
+// defined by their individual public-keys and does not need to participate in the
+// protocol. This enables, for example, the re-sharing of a ciphertext to a new committee.
+type PublicKeyE2SProtocol struct {
+	CKSProtocol
+	params bfv.Parameters
+
+	maskSampler *ring.UniformSampler
+	encoder     bfv.Encoder
+	encryptors  []bfv.Encryptor
+
+	zero              *rlwe.SecretKey
+	tmpMask           *ring.Poly
+	tmpMaskSum        *ring.Poly
+	tmpPoly           *ring.Poly
+	tmpPlaintextRingT *bfv.PlaintextRingT
+	tmpPlaintext      *bfv.Plaintext
+}
+
+// NewPublicKeyE2SProtocol creates a new PublicKeyE2SProtocol struct from the passed BFV parameters
+// and the public-keys of the receivers of the additive shares.
+func NewPublicKeyE2SProtocol(params bfv.Parameters, receivers []*rlwe.PublicKey, sigmaSmudging float64) *PublicKeyE2SProtocol {
+
+	if len(receivers) == 0 {
+		panic("cannot NewPublicKeyE2SProtocol: at least one receiver public-key is required")
+	}
+
+	e2s := new(PublicKeyE2SProtocol)
+	e2s.CKSProtocol = *NewCKSProtocol(params, sigmaSmudging)
+	e2s.params = params
+	e2s.encoder = bfv.NewEncoder(params)
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	e2s.maskSampler = ring.NewUniformSampler(prng, params.RingT())
+
+	e2s.encryptors = make([]bfv.Encryptor, len(receivers))
+	for i, pk := range receivers {
+		e2s.encryptors[i] = bfv.NewEncryptor(params, pk)
+	}
+
+	e2s.zero = rlwe.NewSecretKey(params.Parameters)
+	e2s.tmpMask = params.RingT().NewPoly()
+	e2s.tmpMaskSum = params.RingT().NewPoly()
+	e2s.tmpPoly = params.RingQ().NewPoly()
+	e2s.tmpPlaintextRingT = bfv.NewPlaintextRingT(params)
+	e2s.tmpPlaintext = bfv.NewPlaintext(params)
+	return e2s
+}
+
+// ShallowCopy creates a shallow copy of PublicKeyE2SProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// PublicKeyE2SProtocol can be used concurrently.
+func (e2s *PublicKeyE2SProtocol) ShallowCopy() *PublicKeyE2SProtocol {
+
+	params := e2s.params
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	encryptors := make([]bfv.Encryptor, len(e2s.encryptors))
+	for i := range encryptors {
+		encryptors[i] = e2s.encryptors[i].ShallowCopy()
+	}
+
+	return &PublicKeyE2SProtocol{
+		CKSProtocol:       *e2s.CKSProtocol.ShallowCopy(),
+		params:            params,
+		maskSampler:       ring.NewUniformSampler(prng, params.RingT()),
+		encoder:           e2s.encoder.ShallowCopy(),
+		encryptors:        encryptors,
+		zero:              e2s.zero,
+		tmpMask:           params.RingT().NewPoly(),
+		tmpMaskSum:        params.RingT().NewPoly(),
+		tmpPoly:           params.RingQ().NewPoly(),
+		tmpPlaintextRingT: bfv.NewPlaintextRingT(params),
+		tmpPlaintext:      bfv.NewPlaintext(params),
+	}
+}
+
+// AllocateShare allocates a party's share in the public-key encryption-to-shares protocol.
+func (e2s *PublicKeyE2SProtocol) AllocateShare() *PublicKeyE2SShare {
+	share := &PublicKeyE2SShare{
+		DecryptionShare: e2s.CKSProtocol.AllocateShare(),
+		MaskShares:      make([]*bfv.Ciphertext, len(e2s.encryptors)),
+	}
+	for i := range share.MaskShares {
+		share.MaskShares[i] = bfv.NewCiphertext(e2s.params, 1)
+	}
+	return share
+}
+
+// GenShare generates a party's share in the public-key encryption-to-shares protocol. For each receiver,
+// the party samples a uniform mask in R_t and encrypts it under the receiver's public-key. The masked
+// decryption share of ct1 minus the sum of all the masks is written in shareOut.DecryptionShare.
+// ct1 is degree 1 element of a bfv.Ciphertext, i.e. bfv.Ciphertext.Value[1].
+func (e2s *PublicKeyE2SProtocol) GenShare(sk *rlwe.SecretKey, ct1 *ring.Poly, shareOut *PublicKeyE2SShare) {
+
+	if len(shareOut.MaskShares) != len(e2s.encryptors) {
+		panic("cannot GenShare: number of mask shares does not match the number of receivers")
+	}
+
+	ringT := e2s.params.RingT()
+
+	e2s.CKSProtocol.GenShare(sk, e2s.zero, ct1, shareOut.DecryptionShare)
+
+	e2s.tmpMaskSum.Zero()
+	for i, encryptor := range e2s.encryptors {
+		e2s.maskSampler.Read(e2s.tmpMask)
+		ringT.Add(e2s.tmpMaskSum, e2s.tmpMask, e2s.tmpMaskSum)
+		e2s.encoder.ScaleUp(&bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: e2s.tmpMask}}, e2s.tmpPlaintext)
+		encryptor.Encrypt(e2s.tmpPlaintext, shareOut.MaskShares[i])
+	}
+
+	e2s.encoder.ScaleUp(&bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: e2s.tmpMaskSum}}, e2s.tmpPlaintext)
+	e2s.params.RingQ().Sub(shareOut.DecryptionShare.Value, e2s.tmpPlaintext.Value, shareOut.DecryptionShare.Value)
+}
+
+// AggregateShare aggregates two parties' shares in the public-key encryption-to-shares protocol.
+func (e2s *PublicKeyE2SProtocol) AggregateShare(share1, share2, shareOut *PublicKeyE2SShare) {
+	ringQ := e2s.params.RingQ()
+	e2s.CKSProtocol.AggregateShare(share1.DecryptionShare, share2.DecryptionShare, shareOut.DecryptionShare)
+	for i := range shareOut.MaskShares {
+		ringQ.Add(share1.MaskShares[i].Value[0], share2.MaskShares[i].Value[0], shareOut.MaskShares[i].Value[0])
+		ringQ.Add(share1.MaskShares[i].Value[1], share2.MaskShares[i].Value[1], shareOut.MaskShares[i].Value[1])
+	}
+}
+
+// GetShare is the final step of the public-key encryption-to-shares protocol, executed by each receiver.
+// The receiver at position `index` in the list of receivers decrypts its aggregated mask with its secret-key `sk`
+// and writes the result in secretShareOut. The receiver at position 0 additionally performs the masked decryption
+// of the target ciphertext and adds it to its share, so that the shares of all the receivers sum to the message.
+func (e2s *PublicKeyE2SProtocol) GetShare(sk *rlwe.SecretKey, index int, aggregateShare *PublicKeyE2SShare, ct *bfv.Ciphertext, secretShareOut *rlwe.AdditiveShare) {
+
+	ringQ := e2s.params.RingQ()
+
+	maskCt := aggregateShare.MaskShares[index]
+
+	// m_index = c0 + c1 * sk
+	ringQ.NTTLazy(maskCt.Value[1], e2s.tmpPoly)
+	ringQ.MulCoeffsMontgomery(e2s.tmpPoly, sk.Value.Q, e2s.tmpPoly)
+	ringQ.InvNTT(e2s.tmpPoly, e2s.tmpPoly)
+	ringQ.Add(e2s.tmpPoly, maskCt.Value[0], e2s.tmpPlaintext.Value)
+	e2s.encoder.ScaleDown(e2s.tmpPlaintext, e2s.tmpPlaintextRingT)
+
+	if index == 0 {
+		ringQ.Add(aggregateShare.DecryptionShare.Value, ct.Value[0], e2s.tmpPlaintext.Value)
+		e2s.encoder.ScaleDown(e2s.tmpPlaintext, &bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: &secretShareOut.Value}})
+		e2s.params.RingT().Add(&secretShareOut.Value, e2s.tmpPlaintextRingT.Value, &secretShareOut.Value)
+	} else {
+		secretShareOut.Value.Copy(e2s.tmpPlaintextRingT.Value)
+	}
+}
+
+// MarshalBinary encodes a PublicKeyE2SShare on a slice of bytes.
+func (share *PublicKeyE2SShare) MarshalBinary() (data []byte, err error) {
+
+	if len(share.MaskShares) > 0xFF {
+		return nil, errors.New("PublicKeyE2SShare: uint8 overflow on number of mask shares")
+	}
+
+	dataLen := 1 + share.DecryptionShare.Value.GetDataLen(true)
+	for _, ct := range share.MaskShares {
+		dataLen += ct.Value[0].GetDataLen(true) + ct.Value[1].GetDataLen(true)
+	}
+
+	data = make([]byte, rlwe.EnvelopeHeaderLen+dataLen)
+	data[rlwe.EnvelopeHeaderLen] = uint8(len(share.MaskShares))
+
+	ptr := rlwe.EnvelopeHeaderLen + 1
+	var inc int
+	if inc, err = share.DecryptionShare.Value.WriteTo(data[ptr:]); err != nil {
+		return nil, err
+	}
+	ptr += inc
+
+	for _, ct := range share.MaskShares {
+		for _, pol := range ct.Value[:2] {
+			if inc, err = pol.WriteTo(data[ptr:]); err != nil {
+				return nil, err
+			}
+			ptr += inc
+		}
+	}
+
+	share.DecryptionShare.Envelope(rlwe.KindBFVPublicKeyE2SShare, share.DecryptionShare.Value).WriteHeader(data)
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a marshaled PublicKeyE2SShare on the target PublicKeyE2SShare.
+// It also accepts the legacy encoding without Envelope.
+func (share *PublicKeyE2SShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindBFVPublicKeyE2SShare); err != nil {
+		return err
+	}
+
+	if len(data) < 1 {
+		return errors.New("PublicKeyE2SShare: too small bytearray")
+	}
+
+	share.MaskShares = make([]*bfv.Ciphertext, data[0])
+
+	ptr := 1
+	var inc int
+
+	share.DecryptionShare = &drlwe.CKSShare{Value: new(ring.Poly)}
+	share.DecryptionShare.ReadEnvelope(env)
+	if inc, err = share.DecryptionShare.Value.DecodePolyNew(data[ptr:]); err != nil {
+		return err
+	}
+	ptr += inc
+
+	for i := range share.MaskShares {
+		share.MaskShares[i] = &bfv.Ciphertext{Ciphertext: &rlwe.Ciphertext{Value: []*ring.Poly{new(ring.Poly), new(ring.Poly)}}}
+		for _, pol := range share.MaskShares[i].Value {
+			if inc, err = pol.DecodePolyNew(data[ptr:]); err != nil {
+				return err
+			}
+			ptr += inc
+		}
+	}
+
+	if ptr != len(data) {
+		return errors.New("PublicKeyE2SShare: remaining unparsed data")
+	}
+
+	return env.CheckPoly(share.DecryptionShare.Value, 0)
+}
+
+// PublicKeyS2EProtocol is the structure storing the parameters and temporary buffers
+// required by the public-key shares-to-encryption protocol. In this variant of the
+// shares-to-encryption protocol, the parties do not need a secret-key or a common
+// reference polynomial: each party encrypts its additive share under the target
+// public-key and the encryptions are summed.
+type PublicKeyS2EProtocol struct {
+	params bfv.Parameters
+
+	encoder   bfv.Encoder
+	encryptor bfv.Encryptor
+
+	tmpPlaintext *bfv.Plaintext
+}
+
+// NewPublicKeyS2EProtocol creates a new PublicKeyS2EProtocol struct from the passed BFV parameters
+// and the public-key under which the secret-shared message will be encrypted.
+func NewPublicKeyS2EProtocol(params bfv.Parameters, pk *rlwe.PublicKey) *PublicKeyS2EProtocol {
+	s2e := new(PublicKeyS2EProtocol)
+	s2e.params = params
+	s2e.encoder = bfv.NewEncoder(params)
+	s2e.encryptor = bfv.NewEncryptor(params, pk)
+	s2e.tmpPlaintext = bfv.NewPlaintext(params)
+	return s2e
+}
+
+// ShallowCopy creates a shallow copy of PublicKeyS2EProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// PublicKeyS2EProtocol can be used concurrently.
+func (s2e *PublicKeyS2EProtocol) ShallowCopy() *PublicKeyS2EProtocol {
+	return &PublicKeyS2EProtocol{
+		params:       s2e.params,
+		encoder:      s2e.encoder.ShallowCopy(),
+		encryptor:    s2e.encryptor.ShallowCopy(),
+		tmpPlaintext: bfv.NewPlaintext(s2e.params),
+	}
+}
+
+// AllocateShare allocates a party's share in the public-key shares-to-encryption protocol.
+func (s2e *PublicKeyS2EProtocol) AllocateShare() *drlwe.PCKSShare {
+	ringQ := s2e.params.RingQ()
+	return &drlwe.PCKSShare{ShareMetadata: drlwe.NewShareMetadata(s2e.params.Parameters), Value: [2]*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+}
+
+// GenShare generates a party's share in the public-key shares-to-encryption protocol, which is
+// an encryption of the party's secret share of the message under the target public-key.
+func (s2e *PublicKeyS2EProtocol) GenShare(secretShare *rlwe.AdditiveShare, shareOut *drlwe.PCKSShare) {
+	s2e.encoder.ScaleUp(&bfv.PlaintextRingT{Plaintext: &rlwe.Plaintext{Value: &secretShare.Value}}, s2e.tmpPlaintext)
+	s2e.encryptor.Encrypt(s2e.tmpPlaintext, &bfv.Ciphertext{Ciphertext: &rlwe.Ciphertext{Value: shareOut.Value[:]}})
+}
+
+// AggregateShare aggregates two parties' shares in the public-key shares-to-encryption protocol.
+func (s2e *PublicKeyS2EProtocol) AggregateShare(share1, share2, shareOut *drlwe.PCKSShare) {
+	s2e.params.RingQ().Add(share1.Value[0], share2.Value[0], shareOut.Value[0])
+	s2e.params.RingQ().Add(share1.Value[1], share2.Value[1], shareOut.Value[1])
+}
+
+// GetEncryption computes the final encryption of the secret-shared message from the aggregation
+// of the parties' shares.
+func (s2e *PublicKeyS2EProtocol) GetEncryption(aggregateShare *drlwe.PCKSShare, ctOut *bfv.Ciphertext) {
+	if ctOut.Degree() != 1 {
+		panic("ctOut must have degree 1.")
+	}
+	ctOut.Value[0].Copy(aggregateShare.Value[0])
+	ctOut.Value[1].Copy(aggregateShare.Value[1])
+}
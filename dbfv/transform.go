@@ -55,15 +55,27 @@ func (share *MaskedTransformShare) MarshalBinary() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return append(e2sData, s2eData...), nil
+	return share.e2sShare.Envelope(rlwe.KindBFVMaskedTransformShare, share.e2sShare.Value).Seal(append(e2sData, s2eData...)), nil
 }
 
 // UnmarshalBinary decodes a marshaled RefreshShare on the target RefreshShare.
-func (share *MaskedTransformShare) UnmarshalBinary(data []byte) error {
+// It also accepts the legacy encoding without Envelope.
+func (share *MaskedTransformShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindBFVMaskedTransformShare); err != nil {
+		return err
+	}
+
 	shareLen := len(data) >> 1
-	share.e2sShare.UnmarshalBinary(data[:shareLen])
-	share.s2eShare.UnmarshalBinary(data[shareLen:])
-	return nil
+	if err = share.e2sShare.UnmarshalBinary(data[:shareLen]); err != nil {
+		return err
+	}
+	if err = share.s2eShare.UnmarshalBinary(data[shareLen:]); err != nil {
+		return err
+	}
+
+	return env.CheckPoly(share.e2sShare.Value, 0)
 }
 
 // NewMaskedTransformProtocol creates a new instance of the PermuteProtocol.
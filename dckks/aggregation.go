@@ -0,0 +1,131 @@
+package dckks
+
+import (
+	"math"
+
+	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/drlwe"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// SecureAggregationProtocol is the structure storing the parameters and state for a party in the secure
+// aggregation protocol. In this protocol, the clients encrypt their inputs under the collective public-key
+// of a set of key holders, an aggregator sums the ciphertexts and any set of at least threshold key holders
+// collectively decrypts the sum, which tolerates the dropout of the other key holders.
+//
+// The setup phase consists in the generation of the collective public-key with the CKGProtocol, followed
+// by the thresholdization of the collective secret-key: each key holder secret-shares its secret-key share
+// with GenShamirPolynomial and GenShamirSecretShare, and aggregates the shares it receives with AggregateShares
+// into its threshold share of the collective secret-key.
+type SecureAggregationProtocol struct {
+	drlwe.Thresholdizer
+	params        ckks.Parameters
+	threshold     int
+	sigmaSmudging float64
+	combiner      *drlwe.Combiner
+	cks           *drlwe.CKSProtocol
+	cksSigma      float64
+	tmpSk, zeroSk *rlwe.SecretKey
+}
+
+// NewSecureAggregationProtocol creates a new SecureAggregationProtocol instance for a threshold of key holders.
+// sigmaSmudging is the standard deviation of the smudging noise for the decryption of a single input. It is
+// scaled by the square root of the number of aggregated inputs at the decryption (see SmudgingSigma).
+func NewSecureAggregationProtocol(params ckks.Parameters, threshold int, sigmaSmudging float64) *SecureAggregationProtocol {
+	sa := new(SecureAggregationProtocol)
+	sa.Thresholdizer = *drlwe.NewThresholdizer(params.Parameters)
+	sa.params = params
+	sa.threshold = threshold
+	sa.sigmaSmudging = sigmaSmudging
+	sa.combiner = drlwe.NewCombiner(params.Parameters, threshold)
+	sa.tmpSk = ckks.NewSecretKey(params)
+	sa.zeroSk = ckks.NewSecretKey(params)
+	return sa
+}
+
+// ShallowCopy creates a shallow copy of SecureAggregationProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// SecureAggregationProtocol can be used concurrently.
+func (sa *SecureAggregationProtocol) ShallowCopy() *SecureAggregationProtocol {
+	return NewSecureAggregationProtocol(sa.params, sa.threshold, sa.sigmaSmudging)
+}
+
+// SmudgingSigma returns the standard deviation of the smudging noise used in the decryption of the sum of nInputs
+// ciphertexts. Since the standard deviation of the noise of the sum grows with the square root of the number of
+// inputs, the smudging noise is scaled accordingly.
+func (sa *SecureAggregationProtocol) SmudgingSigma(nInputs int) float64 {
+	return sa.sigmaSmudging * math.Sqrt(float64(utils.MaxInt(nInputs, 1)))
+}
+
+// GenShamirPolynomial generates the secret ShamirPolynomial with which a key holder secret-shares its
+// secret-key share among the key holders.
+func (sa *SecureAggregationProtocol) GenShamirPolynomial(sk *rlwe.SecretKey) (*drlwe.ShamirPolynomial, error) {
+	return sa.Thresholdizer.GenShamirPolynomial(sa.threshold, sk)
+}
+
+// Aggregate sums the input ciphertexts and writes the result on ctOut. The input ciphertexts must have
+// the same scale. The level of ctOut is set to the minimum level of the inputs.
+func (sa *SecureAggregationProtocol) Aggregate(ctIn []*ckks.Ciphertext, ctOut *ckks.Ciphertext) {
+
+	if len(ctIn) == 0 {
+		panic("cannot Aggregate: no input ciphertext")
+	}
+
+	level := ctOut.Level()
+	for _, ct := range ctIn {
+		if ct.Degree() != 1 {
+			panic("cannot Aggregate: input ciphertexts must be of degree 1")
+		}
+		if ct.Scale != ctIn[0].Scale {
+			panic("cannot Aggregate: input ciphertexts must have the same scale")
+		}
+		level = utils.MinInt(level, ct.Level())
+	}
+
+	ringQ := sa.params.RingQ()
+	for j := range ctOut.Value {
+		ring.CopyValuesLvl(level, ctIn[0].Value[j], ctOut.Value[j])
+		for _, ct := range ctIn[1:] {
+			ringQ.AddLvl(level, ctOut.Value[j], ct.Value[j], ctOut.Value[j])
+		}
+		ctOut.Value[j].Coeffs = ctOut.Value[j].Coeffs[:level+1]
+	}
+
+	ctOut.Scale = ctIn[0].Scale
+}
+
+// AllocateDecryptionShare allocates a key holder's share in the collective decryption of the aggregate.
+func (sa *SecureAggregationProtocol) AllocateDecryptionShare(level int) *drlwe.CKSShare {
+	return &drlwe.CKSShare{ShareMetadata: drlwe.NewShareMetadata(sa.params.Parameters), Value: sa.params.RingQ().NewPolyLvl(level)}
+}
+
+// GenDecryptionShare generates the share of the key holder of public point ownPoint in the collective decryption of the
+// aggregate ct of nInputs ciphertexts, given the set of active key holders and its threshold share of the collective
+// secret-key. The set of active key holders must contain ownPoint and at least threshold points.
+func (sa *SecureAggregationProtocol) GenDecryptionShare(activePoints []drlwe.ShamirPublicPoint, ownPoint drlwe.ShamirPublicPoint, tsk *drlwe.ShamirSecretShare, nInputs int, ct *ckks.Ciphertext, shareOut *drlwe.CKSShare) {
+
+	if sigma := sa.SmudgingSigma(nInputs); sa.cks == nil || sa.cksSigma != sigma {
+		sa.cks = drlwe.NewCKSProtocol(sa.params.Parameters, sigma)
+		sa.cksSigma = sigma
+	}
+
+	sa.combiner.GenAdditiveShare(activePoints, ownPoint, tsk, sa.tmpSk)
+	sa.cks.GenShare(sa.tmpSk, sa.zeroSk, ct.Value[1], shareOut)
+}
+
+// AggregateDecryptionShares aggregates two decryption shares.
+func (sa *SecureAggregationProtocol) AggregateDecryptionShares(share1, share2, shareOut *drlwe.CKSShare) {
+	sa.params.RingQ().AddLvl(share1.Value.Level(), share1.Value, share2.Value, shareOut.Value)
+}
+
+// GetAggregate finalizes the collective decryption of the aggregate ct from the aggregation of the decryption
+// shares of the active key holders, and writes the resulting plaintext on ptOut.
+func (sa *SecureAggregationProtocol) GetAggregate(ct *ckks.Ciphertext, combined *drlwe.CKSShare, ptOut *ckks.Plaintext) {
+	level := utils.MinInt(utils.MinInt(ct.Level(), combined.Value.Level()), ptOut.Level())
+	sa.params.RingQ().AddLvl(level, ct.Value[0], combined.Value, ptOut.Value)
+	ptOut.Value.Coeffs = ptOut.Value.Coeffs[:level+1]
+	ptOut.Value.IsNTT = ct.Value[0].IsNTT
+	ptOut.Scale = ct.Scale
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/ckks/bootstrapping"
 	"github.com/tuneinsight/lattigo/v3/drlwe"
 	"github.com/tuneinsight/lattigo/v3/ring"
 	"github.com/tuneinsight/lattigo/v3/rlwe"
@@ -20,6 +21,7 @@ var flagLongTest = flag.Bool("long", false, "run the long test suite (all parame
 var flagPostQuantum = flag.Bool("pq", false, "run post quantum test suite (does not run non-PQ parameters).")
 var flagParamString = flag.String("params", "", "specify the test cryptographic parameters as a JSON string. Overrides -short and -long.")
 var printPrecisionStats = flag.Bool("print-precision", false, "print precision stats")
+var flagTestBootstrapping = flag.Bool("test-bootstrapping", false, "run the bootstrapping tests (memory intensive)")
 var minPrec float64 = 15.0
 var parties int = 3
 
@@ -106,6 +108,8 @@ func TestDCKKS(t *testing.T) {
 			testRotKeyGenConjugate,
 			testRotKeyGenCols,
 			testE2SProtocol,
+			testPublicKeyE2SProtocol,
+			testSecureAggregation,
 			testRefresh,
 			testRefreshAndTransform,
 			testMarshalling,
@@ -130,7 +134,9 @@ func genTestParams(params ckks.Parameters) (testCtx *testContext, err error) {
 	testCtx.uniformSampler = ring.NewUniformSampler(prng, params.RingQ())
 
 	testCtx.encoder = ckks.NewEncoder(testCtx.params)
-	testCtx.evaluator = ckks.NewEvaluator(testCtx.params, rlwe.EvaluationKey{})
+	if testCtx.evaluator, err = ckks.NewEvaluator(testCtx.params, rlwe.EvaluationKey{}); err != nil {
+		return nil, err
+	}
 
 	kgen := ckks.NewKeyGenerator(testCtx.params)
 
@@ -153,8 +159,12 @@ func genTestParams(params ckks.Parameters) (testCtx *testContext, err error) {
 	testCtx.pk1 = kgen.GenPublicKey(testCtx.sk1)
 
 	testCtx.encryptorPk0 = ckks.NewEncryptor(testCtx.params, testCtx.pk0)
-	testCtx.decryptorSk0 = ckks.NewDecryptor(testCtx.params, testCtx.sk0)
-	testCtx.decryptorSk1 = ckks.NewDecryptor(testCtx.params, testCtx.sk1)
+	if testCtx.decryptorSk0, err = ckks.NewDecryptor(testCtx.params, testCtx.sk0); err != nil {
+		return nil, err
+	}
+	if testCtx.decryptorSk1, err = ckks.NewDecryptor(testCtx.params, testCtx.sk1); err != nil {
+		return nil, err
+	}
 
 	return
 }
@@ -597,6 +607,180 @@ func testE2SProtocol(testCtx *testContext, t *testing.T) {
 	})
 }
 
+func testPublicKeyE2SProtocol(testCtx *testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	t.Run(testString("PublicKeyE2SProtocol", parties, params), func(t *testing.T) {
+
+		// The masks of each party for each receiver are summed, and we take one additional level of margin
+		// since the sum of parties*parties masks is close to the bound.
+		var minLevel, logBound int
+		var ok bool
+		if minLevel, logBound, ok = GetMinimumLevelForBootstrapping(128, params.DefaultScale(), parties*parties, params.Q()); ok != true || minLevel+2 > params.MaxLevel() {
+			t.Skip("Not enough levels to ensure correcness and 128 security")
+		}
+		minLevel++
+
+		// The receivers are the parties holding the shards of sk1, each identified by its individual public-key
+		kgen := ckks.NewKeyGenerator(params)
+		receivers := make([]*rlwe.PublicKey, parties)
+		for i := range receivers {
+			receivers[i] = kgen.GenPublicKey(testCtx.sk1Shards[i])
+		}
+
+		type Party struct {
+			e2s   *PublicKeyE2SProtocol
+			sk    *rlwe.SecretKey
+			share *PublicKeyE2SShare
+		}
+
+		coeffs, _, ciphertext := newTestVectors(testCtx, testCtx.encryptorPk0, -1, 1, t)
+
+		testCtx.evaluator.DropLevel(ciphertext, ciphertext.Level()-minLevel-1)
+
+		P := make([]Party, parties)
+		for i := range P {
+			if i == 0 {
+				P[i].e2s = NewPublicKeyE2SProtocol(params, receivers, 3.2)
+			} else {
+				P[i].e2s = P[0].e2s.ShallowCopy()
+			}
+			P[i].sk = testCtx.sk0Shards[i]
+			P[i].share = P[i].e2s.AllocateShare(minLevel)
+		}
+
+		for i, p := range P {
+			p.e2s.GenShare(p.sk, logBound, params.LogSlots(), ciphertext.Value[1], p.share)
+			if i > 0 {
+				p.e2s.AggregateShare(P[0].share, p.share, P[0].share)
+			}
+		}
+
+		secretShares := make([]*rlwe.AdditiveShareBigint, parties)
+		rec := NewAdditiveShareBigint(params, params.LogSlots())
+		for i := range secretShares {
+			secretShares[i] = NewAdditiveShareBigint(params, params.LogSlots())
+			P[0].e2s.GetShare(testCtx.sk1Shards[i], i, P[0].share, params.LogSlots(), ciphertext, secretShares[i])
+			for j := range rec.Value {
+				rec.Value[j].Add(rec.Value[j], secretShares[i].Value[j])
+			}
+		}
+
+		pt := ckks.NewPlaintext(params, ciphertext.Level(), ciphertext.Scale)
+		pt.Value.IsNTT = false
+		testCtx.ringQ.SetCoefficientsBigintLvl(pt.Level(), rec.Value, pt.Value)
+
+		verifyTestVectors(testCtx, nil, coeffs, pt, t)
+
+		// The new committee re-encrypts the message under its collective public-key
+		s2e := NewPublicKeyS2EProtocol(params, testCtx.pk1)
+
+		shares := make([]*drlwe.PCKSShare, parties)
+		for i := range shares {
+			shares[i] = s2e.AllocateShare(params.MaxLevel())
+			s2e.GenShare(params.LogSlots(), secretShares[i], shares[i])
+			if i > 0 {
+				s2e.AggregateShare(shares[0], shares[i], shares[0])
+			}
+		}
+
+		ctRec := ckks.NewCiphertext(params, 1, params.MaxLevel(), ciphertext.Scale)
+		s2e.GetEncryption(shares[0], ctRec)
+
+		verifyTestVectors(testCtx, testCtx.decryptorSk1, coeffs, ctRec, t)
+
+		data, err := P[0].share.MarshalBinary()
+		require.NoError(t, err)
+
+		shareRec := new(PublicKeyE2SShare)
+		require.NoError(t, shareRec.UnmarshalBinary(data))
+
+		require.True(t, testCtx.ringQ.EqualLvl(minLevel, P[0].share.DecryptionShare.Value, shareRec.DecryptionShare.Value))
+		require.Equal(t, len(P[0].share.MaskShares), len(shareRec.MaskShares))
+		for i := range shareRec.MaskShares {
+			require.True(t, testCtx.ringQ.Equal(P[0].share.MaskShares[i].Value[0], shareRec.MaskShares[i].Value[0]))
+			require.True(t, testCtx.ringQ.Equal(P[0].share.MaskShares[i].Value[1], shareRec.MaskShares[i].Value[1]))
+		}
+	})
+}
+
+func testSecureAggregation(testCtx *testContext, t *testing.T) {
+
+	params := testCtx.params
+	threshold := parties - 1
+	nInputs := 4
+
+	t.Run(testString(fmt.Sprintf("SecureAggregation/threshold=%d/inputs=%d", threshold, nInputs), parties, params), func(t *testing.T) {
+
+		type KeyHolder struct {
+			*SecureAggregationProtocol
+			point drlwe.ShamirPublicPoint
+			tsk   *drlwe.ShamirSecretShare
+			share *drlwe.CKSShare
+		}
+
+		keyHolders := make([]*KeyHolder, parties)
+		for i := range keyHolders {
+			kh := new(KeyHolder)
+			if i == 0 {
+				kh.SecureAggregationProtocol = NewSecureAggregationProtocol(params, threshold, 3.2)
+			} else {
+				kh.SecureAggregationProtocol = keyHolders[0].ShallowCopy()
+			}
+			kh.point = drlwe.ShamirPublicPoint(i + 1)
+			kh.tsk = kh.AllocateThresholdSecretShare()
+			kh.share = kh.AllocateDecryptionShare(params.MaxLevel())
+			keyHolders[i] = kh
+		}
+
+		// Setup: each key holder secret-shares its share of the collective secret-key
+		shamirShare := keyHolders[0].AllocateThresholdSecretShare()
+		for i, sender := range keyHolders {
+			poly, err := sender.GenShamirPolynomial(testCtx.sk0Shards[i])
+			require.NoError(t, err)
+			for _, receiver := range keyHolders {
+				sender.GenShamirSecretShare(receiver.point, poly, shamirShare)
+				receiver.AggregateShares(receiver.tsk, shamirShare, receiver.tsk)
+			}
+		}
+
+		// Clients encrypt their inputs under the collective public-key
+		sum := make([]complex128, params.Slots())
+		inputs := make([]*ckks.Ciphertext, nInputs)
+		for i := range inputs {
+			var values []complex128
+			values, _, inputs[i] = newTestVectors(testCtx, testCtx.encryptorPk0, -1, 1, t)
+			for j := range sum {
+				sum[j] += values[j]
+			}
+		}
+
+		aggregator := keyHolders[0]
+		aggregate := ckks.NewCiphertext(params, 1, params.MaxLevel(), params.DefaultScale())
+		aggregator.Aggregate(inputs, aggregate)
+
+		// One key holder drops out before the decryption
+		online := keyHolders[1:]
+		activePoints := make([]drlwe.ShamirPublicPoint, len(online))
+		for i, kh := range online {
+			activePoints[i] = kh.point
+		}
+
+		for i, kh := range online {
+			kh.GenDecryptionShare(activePoints, kh.point, kh.tsk, nInputs, aggregate, kh.share)
+			if i > 0 {
+				aggregator.AggregateDecryptionShares(online[0].share, kh.share, online[0].share)
+			}
+		}
+
+		pt := ckks.NewPlaintext(params, params.MaxLevel(), 0)
+		aggregator.GetAggregate(aggregate, online[0].share, pt)
+
+		verifyTestVectors(testCtx, nil, sum, pt, t)
+	})
+}
+
 func testRefresh(testCtx *testContext, t *testing.T) {
 
 	encryptorPk0 := testCtx.encryptorPk0
@@ -767,6 +951,12 @@ func testMarshalling(testCtx *testContext, t *testing.T) {
 			t.Fatal("Could not unmarshal RefreshShare", err)
 		}
 
+		// The share records the fingerprint of its parameters
+		paramsOther, err := rlwe.NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		require.NoError(t, params.UnmarshalObject(data, new(MaskedTransformShare)))
+		require.Error(t, paramsOther.UnmarshalObject(data, new(MaskedTransformShare)))
+
 		for i, r := range refreshshare.e2sShare.Value.Coeffs {
 			if !utils.EqualSliceUint64(resRefreshShare.e2sShare.Value.Coeffs[i], r) {
 				t.Fatal("Resulting of marshalling not the same as original : RefreshShare")
@@ -814,3 +1004,124 @@ func verifyTestVectors(testCtx *testContext, decryptor ckks.Decryptor, valuesWan
 	require.GreaterOrEqual(t, precStats.MeanPrecision.Real, minPrec)
 	require.GreaterOrEqual(t, precStats.MeanPrecision.Imag, minPrec)
 }
+
+func TestDCKKSBootstrapping(t *testing.T) {
+
+	if runtime.GOARCH == "wasm" {
+		t.Skip("skipping bootstrapping tests for GOARCH=wasm")
+	}
+
+	paramSet := 0
+
+	ckksParams := bootstrapping.DefaultCKKSParameters[paramSet]
+	btpParams := bootstrapping.DefaultParameters[paramSet]
+
+	// Insecure params for fast testing only
+	switch {
+	case !*flagTestBootstrapping:
+		// Reduced ring degree and number of slots, which limit the number of rotation keys, for the short suite
+		ckksParams.LogN = 12
+		ckksParams.LogSlots = 5
+	case !*flagLongTest:
+		ckksParams.LogN = 13
+		ckksParams.LogSlots = 12
+	}
+
+	params, err := ckks.NewParametersFromLiteral(ckksParams)
+	if err != nil {
+		panic(err)
+	}
+
+	testBootstrappingCollectiveKeyGen(params, btpParams, t)
+}
+
+func testBootstrappingCollectiveKeyGen(params ckks.Parameters, btpParams bootstrapping.Parameters, t *testing.T) {
+
+	t.Run(testString("Bootstrapping/CollectiveKeyGen", parties, params), func(t *testing.T) {
+
+		ringQP := params.RingQP()
+		levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+		// Each party samples a sparse secret-key share so that the collective secret-key
+		// has the Hamming weight expected by the bootstrapping parameters.
+		kgen := ckks.NewKeyGenerator(params)
+		hw := BootstrappingSecretShareHammingWeight(params, parties)
+		skShares := make([]*rlwe.SecretKey, parties)
+		skIdeal := ckks.NewSecretKey(params)
+		for i := range skShares {
+			skShares[i] = kgen.GenSecretKeyWithHammingWeight(hw)
+			ringQP.AddLvl(levelQ, levelP, skIdeal.Value, skShares[i].Value, skIdeal.Value)
+		}
+
+		btpkg := NewBootstrappingKeyGenProtocol(params, btpParams)
+
+		crs, _ := utils.NewKeyedPRNG([]byte{'t', 'e', 's', 't'})
+		crp := btpkg.SampleCRP(crs)
+
+		// The shares are aggregated as they are generated to limit the memory footprint.
+		ephSks := make([]*rlwe.SecretKey, parties)
+		ephSk, round1, round2 := btpkg.AllocateShare()
+		_, tmpRound1, tmpRound2 := btpkg.AllocateShare()
+
+		// ROUND 1
+		for i := range skShares {
+			ephSks[i] = ephSk.CopyNew()
+			if i == 0 {
+				btpkg.GenShareRoundOne(skShares[i], crp, ephSks[i], round1)
+			} else {
+				btpkg.GenShareRoundOne(skShares[i], crp, ephSks[i], tmpRound1)
+				btpkg.AggregateShareRoundOne(round1, tmpRound1, round1)
+			}
+		}
+
+		data, err := round1.MarshalBinary()
+		require.NoError(t, err)
+		round1 = new(BootstrappingKeyGenShare)
+		require.NoError(t, round1.UnmarshalBinary(data))
+		data = nil
+
+		// ROUND 2
+		for i := range skShares {
+			if i == 0 {
+				btpkg.GenShareRoundTwo(ephSks[i], skShares[i], round1, round2)
+			} else {
+				btpkg.GenShareRoundTwo(ephSks[i], skShares[i], round1, tmpRound2)
+				btpkg.AggregateShareRoundTwo(round2, tmpRound2, round2)
+			}
+		}
+
+		tmpRound1, tmpRound2 = nil, nil
+
+		btpKey := btpkg.GenBootstrappingKey(round1, round2, crp)
+
+		round1, round2 = nil, nil
+		runtime.GC()
+
+		btp, err := bootstrapping.NewBootstrapper(params, btpParams, btpKey)
+		require.NoError(t, err)
+
+		encoder := ckks.NewEncoder(params)
+		encryptor := ckks.NewEncryptor(params, skIdeal)
+		decryptor, err := ckks.NewDecryptor(params, skIdeal)
+		require.NoError(t, err)
+
+		values := make([]complex128, params.Slots())
+		for i := range values {
+			values[i] = utils.RandComplex128(-1, 1)
+		}
+
+		plaintext := ckks.NewPlaintext(params, 0, params.DefaultScale())
+		encoder.Encode(values, plaintext, params.LogSlots())
+
+		ciphertext := btp.Bootstrapp(encryptor.EncryptNew(plaintext))
+
+		precStats := ckks.GetPrecisionStats(params, encoder, decryptor, values, ciphertext, params.LogSlots(), 0)
+
+		if *printPrecisionStats {
+			t.Log(precStats.String())
+		}
+
+		require.GreaterOrEqual(t, precStats.MeanPrecision.Real, minPrec)
+		require.GreaterOrEqual(t, precStats.MeanPrecision.Imag, minPrec)
+	})
+}
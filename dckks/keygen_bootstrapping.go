@@ -0,0 +1,194 @@
+package dckks
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/ckks/bootstrapping"
+	"github.com/tuneinsight/lattigo/v3/drlwe"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// BootstrappingKeyGenProtocol is the structure storing the parameters and state for a party in the collective
+// generation of the evaluation keys required to instantiate a bootstrapping.Bootstrapper. It runs, in two rounds,
+// the relinearization-key generation protocol together with a batched rotation-key generation protocol over all
+// the Galois elements used by the bootstrapping circuit.
+//
+// The bootstrapping circuit is parameterized by the Hamming weight H of the secret-key (see the K parameter of
+// the advanced.EvalModLiteral), whereas the collective secret-key is the sum of the secret-key shares of all
+// the parties. Its Hamming weight, and more generally its L1 norm, is therefore bounded by the sum of the
+// Hamming weights of the shares. To keep the collective secret-key within the distribution assumed by the
+// bootstrapping parameters, the parties should generate their secret-key share with
+// KeyGenerator.GenSecretKeyWithHammingWeight(BootstrappingSecretShareHammingWeight(params, nParties)).
+type BootstrappingKeyGenProtocol struct {
+	params ckks.Parameters
+	galEls []uint64
+
+	RKG *RKGProtocol
+	RTG *RTGProtocol
+}
+
+// BootstrappingKeyGenShare is a party's share of the first round of the BootstrappingKeyGenProtocol.
+type BootstrappingKeyGenShare struct {
+	RKG *drlwe.RKGShare
+	RTG *drlwe.RTGMultiShare
+}
+
+// BootstrappingKeyGenCRP is a type for the common reference polynomials in the BootstrappingKeyGenProtocol.
+type BootstrappingKeyGenCRP struct {
+	RKG drlwe.RKGCRP
+	RTG drlwe.RTGMultiCRP
+}
+
+// BootstrappingSecretShareHammingWeight returns the Hamming weight with which each of the nParties parties
+// should sample its secret-key share, so that the L1 norm of the collective secret-key is at most params.HammingWeight().
+func BootstrappingSecretShareHammingWeight(params ckks.Parameters, nParties int) int {
+	return utils.MaxInt(1, params.HammingWeight()/nParties)
+}
+
+// NewBootstrappingKeyGenProtocol creates a new BootstrappingKeyGenProtocol instance generating the evaluation keys
+// of the bootstrapping.Bootstrapper instantiated with the given parameters.
+func NewBootstrappingKeyGenProtocol(params ckks.Parameters, btpParams bootstrapping.Parameters) (btpkg *BootstrappingKeyGenProtocol) {
+	btpkg = new(BootstrappingKeyGenProtocol)
+	btpkg.params = params
+	btpkg.RKG = NewRKGProtocol(params)
+	btpkg.RTG = NewRotKGProtocol(params)
+
+	rotations := btpParams.RotationsForBootstrapping(params.LogN(), params.LogSlots())
+	rotations = append(rotations, params.RotationsForTrace(params.LogSlots(), params.MaxLogSlots())...)
+
+	btpkg.galEls = []uint64{params.GaloisElementForRowRotation()}
+	for _, k := range rotations {
+		galEl := params.GaloisElementForColumnRotationBy(k)
+		if !utils.IsInSliceUint64(galEl, btpkg.galEls) {
+			btpkg.galEls = append(btpkg.galEls, galEl)
+		}
+	}
+
+	return
+}
+
+// ShallowCopy creates a shallow copy of BootstrappingKeyGenProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// BootstrappingKeyGenProtocol can be used concurrently.
+func (btpkg *BootstrappingKeyGenProtocol) ShallowCopy() *BootstrappingKeyGenProtocol {
+	return &BootstrappingKeyGenProtocol{
+		params: btpkg.params,
+		galEls: btpkg.galEls,
+		RKG:    btpkg.RKG.ShallowCopy(),
+		RTG:    btpkg.RTG.ShallowCopy(),
+	}
+}
+
+// GaloisElements returns the Galois elements of the rotation keys generated by the protocol.
+func (btpkg *BootstrappingKeyGenProtocol) GaloisElements() []uint64 {
+	galEls := make([]uint64, len(btpkg.galEls))
+	copy(galEls, btpkg.galEls)
+	return galEls
+}
+
+// AllocateShare allocates the ephemeral secret-key and the shares of both rounds of the protocol.
+func (btpkg *BootstrappingKeyGenProtocol) AllocateShare() (ephSk *rlwe.SecretKey, r1 *BootstrappingKeyGenShare, r2 *drlwe.RKGShare) {
+	var rkgR1 *drlwe.RKGShare
+	ephSk, rkgR1, r2 = btpkg.RKG.AllocateShare()
+	r1 = &BootstrappingKeyGenShare{RKG: rkgR1, RTG: btpkg.RTG.AllocateMultiShare(btpkg.galEls)}
+	return
+}
+
+// SampleCRP samples the common random polynomials of the protocol from the provided common reference string.
+func (btpkg *BootstrappingKeyGenProtocol) SampleCRP(crs drlwe.CRS) BootstrappingKeyGenCRP {
+	return BootstrappingKeyGenCRP{
+		RKG: btpkg.RKG.SampleCRP(crs),
+		RTG: btpkg.RTG.SampleMultiCRP(btpkg.galEls, crs),
+	}
+}
+
+// GenShareRoundOne is the first of the two rounds of the protocol. Each party generates an ephemeral secret-key
+// for the relinearization-key generation and its shares of all the rotation keys.
+func (btpkg *BootstrappingKeyGenProtocol) GenShareRoundOne(sk *rlwe.SecretKey, crp BootstrappingKeyGenCRP, ephSkOut *rlwe.SecretKey, shareOut *BootstrappingKeyGenShare) {
+	btpkg.RKG.GenShareRoundOne(sk, crp.RKG, ephSkOut, shareOut.RKG)
+	btpkg.RTG.GenMultiShare(sk, crp.RTG, shareOut.RTG)
+}
+
+// GenShareRoundTwo is the second of the two rounds of the protocol. Each party uses its ephemeral secret-key
+// and the aggregation of the first-round shares to generate its share of the relinearization key.
+func (btpkg *BootstrappingKeyGenProtocol) GenShareRoundTwo(ephSk, sk *rlwe.SecretKey, round1 *BootstrappingKeyGenShare, shareOut *drlwe.RKGShare) {
+	btpkg.RKG.GenShareRoundTwo(ephSk, sk, round1.RKG, shareOut)
+}
+
+// AggregateShareRoundOne aggregates two first-round shares.
+func (btpkg *BootstrappingKeyGenProtocol) AggregateShareRoundOne(share1, share2, shareOut *BootstrappingKeyGenShare) {
+	btpkg.RKG.AggregateShare(share1.RKG, share2.RKG, shareOut.RKG)
+	btpkg.RTG.AggregateMultiShare(share1.RTG, share2.RTG, shareOut.RTG)
+}
+
+// AggregateShareRoundTwo aggregates two second-round shares.
+func (btpkg *BootstrappingKeyGenProtocol) AggregateShareRoundTwo(share1, share2, shareOut *drlwe.RKGShare) {
+	btpkg.RKG.AggregateShare(share1, share2, shareOut)
+}
+
+// GenBootstrappingKey finalizes the protocol and returns the evaluation key to be given to bootstrapping.NewBootstrapper.
+func (btpkg *BootstrappingKeyGenProtocol) GenBootstrappingKey(round1 *BootstrappingKeyGenShare, round2 *drlwe.RKGShare, crp BootstrappingKeyGenCRP) rlwe.EvaluationKey {
+	rlk := ckks.NewRelinearizationKey(btpkg.params)
+	btpkg.RKG.GenRelinearizationKey(round1.RKG, round2, rlk)
+
+	rtks := ckks.NewRotationKeySet(btpkg.params, btpkg.galEls)
+	btpkg.RTG.GenRotationKeySet(round1.RTG, crp.RTG, rtks)
+
+	return rlwe.EvaluationKey{Rlk: rlk, Rtks: rtks}
+}
+
+// MarshalBinary encodes the target element on a slice of bytes.
+func (share *BootstrappingKeyGenShare) MarshalBinary() (data []byte, err error) {
+
+	var rkgData, rtgData []byte
+	if rkgData, err = share.RKG.MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	if rtgData, err = share.RTG.MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	data = make([]byte, rlwe.EnvelopeHeaderLen+4, rlwe.EnvelopeHeaderLen+4+len(rkgData)+len(rtgData))
+	binary.BigEndian.PutUint32(data[rlwe.EnvelopeHeaderLen:], uint32(len(rkgData)))
+	data = append(data, rkgData...)
+	data = append(data, rtgData...)
+
+	share.RKG.Envelope(rlwe.KindCKKSBootstrappingKeyGenShare, share.RKG.Value[0][0].Q).WriteHeader(data)
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+// It also accepts the legacy encoding without Envelope.
+func (share *BootstrappingKeyGenShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindCKKSBootstrappingKeyGenShare); err != nil {
+		return err
+	}
+
+	if len(data) < 4 {
+		return errors.New("BootstrappingKeyGenShare: too small bytearray")
+	}
+
+	rkgLen := int(binary.BigEndian.Uint32(data))
+	if len(data) < 4+rkgLen {
+		return errors.New("BootstrappingKeyGenShare: too small bytearray")
+	}
+
+	share.RKG = new(drlwe.RKGShare)
+	if err = share.RKG.UnmarshalBinary(data[4 : 4+rkgLen]); err != nil {
+		return err
+	}
+
+	share.RTG = new(drlwe.RTGMultiShare)
+	if err = share.RTG.UnmarshalBinary(data[4+rkgLen:]); err != nil {
+		return err
+	}
+
+	return env.CheckPoly(share.RKG.Value[0][0].Q, 0)
+}
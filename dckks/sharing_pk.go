@@ -0,0 +1,398 @@
+package dckks
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/drlwe"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// PublicKeyE2SShare is a party's share in the public-key encryption-to-shares protocol.
+// It stores the masked decryption share of the party and, for each receiver, an encryption
+// under the receiver's public-key of the mask destined to this receiver.
+type PublicKeyE2SShare struct {
+	DecryptionShare *drlwe.CKSShare
+	MaskShares      []*ckks.Ciphertext
+}
+
+// PublicKeyE2SProtocol is the structure storing the parameters and temporary buffers
+// required by the public-key encryption-to-shares protocol. In this variant of the
+// encryption-to-shares protocol, the set of parties receiving the additive shares is
+// defined by their individual public-keys and does not need to participate in the
+// protocol. This enables, for example, the re-sharing of a ciphertext to a new committee.
+type PublicKeyE2SProtocol struct {
+	CKSProtocol
+	params ckks.Parameters
+
+	encryptors []rlwe.Encryptor
+
+	zero       *rlwe.SecretKey
+	maskBigint []*big.Int
+	maskSum    []*big.Int
+	pool       *ring.Poly
+}
+
+// NewPublicKeyE2SProtocol creates a new PublicKeyE2SProtocol struct from the passed CKKS parameters
+// and the public-keys of the receivers of the additive shares.
+func NewPublicKeyE2SProtocol(params ckks.Parameters, receivers []*rlwe.PublicKey, sigmaSmudging float64) *PublicKeyE2SProtocol {
+
+	if len(receivers) == 0 {
+		panic("cannot NewPublicKeyE2SProtocol: at least one receiver public-key is required")
+	}
+
+	e2s := new(PublicKeyE2SProtocol)
+	e2s.CKSProtocol = *NewCKSProtocol(params, sigmaSmudging)
+	e2s.params = params
+
+	e2s.encryptors = make([]rlwe.Encryptor, len(receivers))
+	for i, pk := range receivers {
+		e2s.encryptors[i] = rlwe.NewEncryptor(params.Parameters, pk)
+	}
+
+	e2s.zero = rlwe.NewSecretKey(params.Parameters)
+	e2s.maskBigint = make([]*big.Int, params.N())
+	e2s.maskSum = make([]*big.Int, params.N())
+	for i := range e2s.maskBigint {
+		e2s.maskBigint[i] = new(big.Int)
+		e2s.maskSum[i] = new(big.Int)
+	}
+	e2s.pool = params.RingQ().NewPoly()
+	return e2s
+}
+
+// ShallowCopy creates a shallow copy of PublicKeyE2SProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// PublicKeyE2SProtocol can be used concurrently.
+func (e2s *PublicKeyE2SProtocol) ShallowCopy() *PublicKeyE2SProtocol {
+
+	encryptors := make([]rlwe.Encryptor, len(e2s.encryptors))
+	for i := range encryptors {
+		encryptors[i] = e2s.encryptors[i].ShallowCopy()
+	}
+
+	maskBigint := make([]*big.Int, len(e2s.maskBigint))
+	maskSum := make([]*big.Int, len(e2s.maskSum))
+	for i := range maskBigint {
+		maskBigint[i] = new(big.Int)
+		maskSum[i] = new(big.Int)
+	}
+
+	return &PublicKeyE2SProtocol{
+		CKSProtocol: *e2s.CKSProtocol.ShallowCopy(),
+		params:      e2s.params,
+		encryptors:  encryptors,
+		zero:        e2s.zero,
+		maskBigint:  maskBigint,
+		maskSum:     maskSum,
+		pool:        e2s.params.RingQ().NewPoly(),
+	}
+}
+
+// AllocateShare allocates a party's share in the public-key encryption-to-shares protocol.
+// The decryption share is allocated at level `level` and the mask encryptions at the maximum level.
+func (e2s *PublicKeyE2SProtocol) AllocateShare(level int) *PublicKeyE2SShare {
+	share := &PublicKeyE2SShare{
+		DecryptionShare: e2s.CKSProtocol.AllocateShare(level),
+		MaskShares:      make([]*ckks.Ciphertext, len(e2s.encryptors)),
+	}
+	share.DecryptionShare.Value.IsNTT = true
+	for i := range share.MaskShares {
+		share.MaskShares[i] = ckks.NewCiphertext(e2s.params, 1, e2s.params.MaxLevel(), 1)
+	}
+	return share
+}
+
+// GenShare generates a party's share in the public-key encryption-to-shares protocol. For each receiver,
+// the party samples a mask of logBound bits and encrypts it under the receiver's public-key. The masked
+// decryption share of ct1 minus the sum of all the masks is written in shareOut.DecryptionShare.
+// This protocol requires additional inputs which are :
+// logBound : the bit length of the masks
+// logSlots : the bit length of the number of slots
+// ct1      : the degree 1 element the ciphertext to share, i.e. ct1 = ckk.Ciphetext.Value[1].
+// Since the masks of all the parties and all the receivers are summed, the method "GetMinimumLevelForBootstrapping"
+// should be called with the number of parties times the number of receivers to get the minimum level at which
+// the protocol can be called, as well as the value for logBound.
+func (e2s *PublicKeyE2SProtocol) GenShare(sk *rlwe.SecretKey, logBound, logSlots int, ct1 *ring.Poly, shareOut *PublicKeyE2SShare) {
+
+	if len(shareOut.MaskShares) != len(e2s.encryptors) {
+		panic("cannot GenShare: number of mask shares does not match the number of receivers")
+	}
+
+	ringQ := e2s.params.RingQ()
+
+	levelQ := utils.MinInt(ct1.Level(), shareOut.DecryptionShare.Value.Level())
+
+	bound := ring.NewUint(1)
+	bound.Lsh(bound, uint(logBound))
+
+	boundMax := ring.NewUint(ringQ.Modulus[0])
+	for i := 1; i < levelQ+1; i++ {
+		boundMax.Mul(boundMax, ring.NewUint(ringQ.Modulus[i]))
+	}
+
+	if bound.Cmp(boundMax) == 1 {
+		panic("ciphertext level is not large enough for refresh correctness")
+	}
+
+	boundHalf := new(big.Int).Rsh(bound, 1)
+
+	dslots := 1 << logSlots
+	if ringQ.Type() == ring.Standard {
+		dslots *= 2
+	}
+
+	// Encryption of zero
+	e2s.CKSProtocol.GenShare(sk, e2s.zero, ct1, shareOut.DecryptionShare)
+
+	for i := 0; i < dslots; i++ {
+		e2s.maskSum[i].SetUint64(0)
+	}
+
+	for j, encryptor := range e2s.encryptors {
+
+		// Generates the mask of the j-th receiver in Z[Y] for Y = X^{N/(2*slots)}
+		for i := 0; i < dslots; i++ {
+			e2s.maskBigint[i] = ring.RandInt(bound)
+			if e2s.maskBigint[i].Cmp(boundHalf) > -1 {
+				e2s.maskBigint[i].Sub(e2s.maskBigint[i], bound)
+			}
+			e2s.maskSum[i].Add(e2s.maskSum[i], e2s.maskBigint[i])
+		}
+
+		// Encrypts the mask under the public-key of the j-th receiver
+		maskCt := shareOut.MaskShares[j]
+		levelMask := maskCt.Level()
+		pt := &rlwe.Plaintext{Value: &ring.Poly{Coeffs: e2s.pool.Coeffs[:levelMask+1], IsNTT: true}}
+		ringQ.SetCoefficientsBigintLvl(levelMask, e2s.maskBigint[:dslots], pt.Value)
+		ckks.NttAndMontgomeryLvl(levelMask, logSlots, ringQ, false, pt.Value)
+		encryptor.Encrypt(pt, maskCt.Ciphertext)
+	}
+
+	// Substracts the sum of the masks to the encryption of zero
+	ringQ.SetCoefficientsBigintLvl(levelQ, e2s.maskSum[:dslots], e2s.pool)
+	ckks.NttAndMontgomeryLvl(levelQ, logSlots, ringQ, false, e2s.pool)
+	ringQ.SubLvl(levelQ, shareOut.DecryptionShare.Value, e2s.pool, shareOut.DecryptionShare.Value)
+}
+
+// AggregateShare aggregates two parties' shares in the public-key encryption-to-shares protocol.
+func (e2s *PublicKeyE2SProtocol) AggregateShare(share1, share2, shareOut *PublicKeyE2SShare) {
+	ringQ := e2s.params.RingQ()
+	e2s.CKSProtocol.AggregateShare(share1.DecryptionShare, share2.DecryptionShare, shareOut.DecryptionShare)
+	for i := range shareOut.MaskShares {
+		level := utils.MinInt(share1.MaskShares[i].Level(), share2.MaskShares[i].Level())
+		ringQ.AddLvl(level, share1.MaskShares[i].Value[0], share2.MaskShares[i].Value[0], shareOut.MaskShares[i].Value[0])
+		ringQ.AddLvl(level, share1.MaskShares[i].Value[1], share2.MaskShares[i].Value[1], shareOut.MaskShares[i].Value[1])
+	}
+}
+
+// GetShare is the final step of the public-key encryption-to-shares protocol, executed by each receiver.
+// The receiver at position `index` in the list of receivers decrypts its aggregated mask with its secret-key `sk`
+// and writes the result in secretShareOut. The receiver at position 0 additionally performs the masked decryption
+// of the target ciphertext and adds it to its share, so that the shares of all the receivers sum to the message.
+func (e2s *PublicKeyE2SProtocol) GetShare(sk *rlwe.SecretKey, index int, aggregateShare *PublicKeyE2SShare, logSlots int, ct *ckks.Ciphertext, secretShareOut *rlwe.AdditiveShareBigint) {
+
+	ringQ := e2s.params.RingQ()
+
+	maskCt := aggregateShare.MaskShares[index]
+	level := maskCt.Level()
+
+	// m_index = c0 + c1 * sk
+	ringQ.MulCoeffsMontgomeryLvl(level, maskCt.Value[1], sk.Value.Q, e2s.pool)
+	ringQ.AddLvl(level, e2s.pool, maskCt.Value[0], e2s.pool)
+	ringQ.InvNTTLvl(level, e2s.pool, e2s.pool)
+
+	dslots := 1 << logSlots
+	if ringQ.Type() == ring.Standard {
+		dslots *= 2
+	}
+
+	gap := ringQ.N / dslots
+
+	ringQ.PolyToBigintCenteredLvl(level, e2s.pool, gap, e2s.maskBigint)
+
+	if index == 0 {
+
+		levelQ := utils.MinInt(ct.Level(), aggregateShare.DecryptionShare.Value.Level())
+
+		// Masked decryption of the ciphertext
+		ringQ.AddLvl(levelQ, aggregateShare.DecryptionShare.Value, ct.Value[0], e2s.pool)
+		ringQ.InvNTTLvl(levelQ, e2s.pool, e2s.pool)
+		ringQ.PolyToBigintCenteredLvl(levelQ, e2s.pool, gap, e2s.maskSum)
+
+		for i := range secretShareOut.Value[:dslots] {
+			secretShareOut.Value[i].Add(e2s.maskBigint[i], e2s.maskSum[i])
+		}
+
+	} else {
+		for i := range secretShareOut.Value[:dslots] {
+			secretShareOut.Value[i].Set(e2s.maskBigint[i])
+		}
+	}
+}
+
+// MarshalBinary encodes a PublicKeyE2SShare on a slice of bytes.
+func (share *PublicKeyE2SShare) MarshalBinary() (data []byte, err error) {
+
+	if len(share.MaskShares) > 0xFF {
+		return nil, errors.New("PublicKeyE2SShare: uint8 overflow on number of mask shares")
+	}
+
+	dataLen := 1 + share.DecryptionShare.Value.GetDataLen(true)
+	for _, ct := range share.MaskShares {
+		dataLen += ct.Value[0].GetDataLen(true) + ct.Value[1].GetDataLen(true)
+	}
+
+	data = make([]byte, rlwe.EnvelopeHeaderLen+dataLen)
+	data[rlwe.EnvelopeHeaderLen] = uint8(len(share.MaskShares))
+
+	ptr := rlwe.EnvelopeHeaderLen + 1
+	var inc int
+	if inc, err = share.DecryptionShare.Value.WriteTo(data[ptr:]); err != nil {
+		return nil, err
+	}
+	ptr += inc
+
+	for _, ct := range share.MaskShares {
+		for _, pol := range ct.Value[:2] {
+			if inc, err = pol.WriteTo(data[ptr:]); err != nil {
+				return nil, err
+			}
+			ptr += inc
+		}
+	}
+
+	share.DecryptionShare.Envelope(rlwe.KindCKKSPublicKeyE2SShare, share.DecryptionShare.Value).WriteHeader(data)
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a marshaled PublicKeyE2SShare on the target PublicKeyE2SShare.
+// It also accepts the legacy encoding without Envelope.
+// The scale of the decoded mask encryptions is set to 1.
+func (share *PublicKeyE2SShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindCKKSPublicKeyE2SShare); err != nil {
+		return err
+	}
+
+	if len(data) < 1 {
+		return errors.New("PublicKeyE2SShare: too small bytearray")
+	}
+
+	share.MaskShares = make([]*ckks.Ciphertext, data[0])
+
+	ptr := 1
+	var inc int
+
+	share.DecryptionShare = &drlwe.CKSShare{Value: new(ring.Poly)}
+	share.DecryptionShare.ReadEnvelope(env)
+	if inc, err = share.DecryptionShare.Value.DecodePolyNew(data[ptr:]); err != nil {
+		return err
+	}
+	ptr += inc
+
+	for i := range share.MaskShares {
+		share.MaskShares[i] = &ckks.Ciphertext{Ciphertext: &rlwe.Ciphertext{Value: []*ring.Poly{new(ring.Poly), new(ring.Poly)}}, Scale: 1}
+		for _, pol := range share.MaskShares[i].Value {
+			if inc, err = pol.DecodePolyNew(data[ptr:]); err != nil {
+				return err
+			}
+			ptr += inc
+		}
+	}
+
+	if ptr != len(data) {
+		return errors.New("PublicKeyE2SShare: remaining unparsed data")
+	}
+
+	return env.CheckPoly(share.DecryptionShare.Value, 0)
+}
+
+// PublicKeyS2EProtocol is the structure storing the parameters and temporary buffers
+// required by the public-key shares-to-encryption protocol. In this variant of the
+// shares-to-encryption protocol, the parties do not need a secret-key or a common
+// reference polynomial: each party encrypts its additive share under the target
+// public-key and the encryptions are summed.
+type PublicKeyS2EProtocol struct {
+	params    ckks.Parameters
+	encryptor rlwe.Encryptor
+	tmp       *ring.Poly
+}
+
+// NewPublicKeyS2EProtocol creates a new PublicKeyS2EProtocol struct from the passed CKKS parameters
+// and the public-key under which the secret-shared message will be encrypted.
+func NewPublicKeyS2EProtocol(params ckks.Parameters, pk *rlwe.PublicKey) *PublicKeyS2EProtocol {
+	s2e := new(PublicKeyS2EProtocol)
+	s2e.params = params
+	s2e.encryptor = rlwe.NewEncryptor(params.Parameters, pk)
+	s2e.tmp = params.RingQ().NewPoly()
+	return s2e
+}
+
+// ShallowCopy creates a shallow copy of PublicKeyS2EProtocol in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// PublicKeyS2EProtocol can be used concurrently.
+func (s2e *PublicKeyS2EProtocol) ShallowCopy() *PublicKeyS2EProtocol {
+	return &PublicKeyS2EProtocol{
+		params:    s2e.params,
+		encryptor: s2e.encryptor.ShallowCopy(),
+		tmp:       s2e.params.RingQ().NewPoly(),
+	}
+}
+
+// AllocateShare allocates a party's share in the public-key shares-to-encryption protocol.
+func (s2e *PublicKeyS2EProtocol) AllocateShare(level int) *drlwe.PCKSShare {
+	ringQ := s2e.params.RingQ()
+	share := &drlwe.PCKSShare{ShareMetadata: drlwe.NewShareMetadata(s2e.params.Parameters), Value: [2]*ring.Poly{ringQ.NewPolyLvl(level), ringQ.NewPolyLvl(level)}}
+	share.Value[0].IsNTT = true
+	share.Value[1].IsNTT = true
+	return share
+}
+
+// GenShare generates a party's share in the public-key shares-to-encryption protocol, which is
+// an encryption of the party's secret share of the message under the target public-key.
+func (s2e *PublicKeyS2EProtocol) GenShare(logSlots int, secretShare *rlwe.AdditiveShareBigint, shareOut *drlwe.PCKSShare) {
+
+	ringQ := s2e.params.RingQ()
+
+	level := shareOut.Value[0].Level()
+
+	dslots := 1 << logSlots
+	if ringQ.Type() == ring.Standard {
+		dslots *= 2
+	}
+
+	pt := &rlwe.Plaintext{Value: &ring.Poly{Coeffs: s2e.tmp.Coeffs[:level+1], IsNTT: true}}
+	ringQ.SetCoefficientsBigintLvl(level, secretShare.Value[:dslots], pt.Value)
+	ckks.NttAndMontgomeryLvl(level, logSlots, ringQ, false, pt.Value)
+
+	s2e.encryptor.Encrypt(pt, &rlwe.Ciphertext{Value: shareOut.Value[:]})
+}
+
+// AggregateShare aggregates two parties' shares in the public-key shares-to-encryption protocol.
+func (s2e *PublicKeyS2EProtocol) AggregateShare(share1, share2, shareOut *drlwe.PCKSShare) {
+	level := utils.MinInt(share1.Value[0].Level(), share2.Value[0].Level())
+	s2e.params.RingQ().AddLvl(level, share1.Value[0], share2.Value[0], shareOut.Value[0])
+	s2e.params.RingQ().AddLvl(level, share1.Value[1], share2.Value[1], shareOut.Value[1])
+}
+
+// GetEncryption computes the final encryption of the secret-shared message from the aggregation
+// of the parties' shares.
+func (s2e *PublicKeyS2EProtocol) GetEncryption(aggregateShare *drlwe.PCKSShare, ctOut *ckks.Ciphertext) {
+
+	if ctOut.Degree() != 1 {
+		panic("ctOut must have degree 1.")
+	}
+
+	if ctOut.Level() != aggregateShare.Value[0].Level() {
+		panic("ctOut level must be equal to the aggregated share level")
+	}
+
+	ctOut.Value[0].Copy(aggregateShare.Value[0])
+	ctOut.Value[1].Copy(aggregateShare.Value[1])
+}
@@ -4,6 +4,7 @@ import (
 	"math/big"
 
 	"encoding/binary"
+	"errors"
 
 	"github.com/tuneinsight/lattigo/v3/ckks"
 	"github.com/tuneinsight/lattigo/v3/drlwe"
@@ -68,17 +69,31 @@ func (share *MaskedTransformShare) MarshalBinary() (data []byte, err error) {
 	if s2eData, err = share.s2eShare.MarshalBinary(); err != nil {
 		return nil, err
 	}
-	data = make([]byte, 8)
-	binary.LittleEndian.PutUint64(data, uint64(len(e2sData)))
+	data = make([]byte, rlwe.EnvelopeHeaderLen+8)
+	binary.LittleEndian.PutUint64(data[rlwe.EnvelopeHeaderLen:], uint64(len(e2sData)))
 	data = append(data, e2sData...)
 	data = append(data, s2eData...)
+	share.e2sShare.Envelope(rlwe.KindCKKSMaskedTransformShare, share.e2sShare.Value).WriteHeader(data)
 	return data, nil
 }
 
 // UnmarshalBinary decodes a marshaled RefreshShare on the target RefreshShare.
-func (share *MaskedTransformShare) UnmarshalBinary(data []byte) error {
+// It also accepts the legacy encoding without Envelope.
+func (share *MaskedTransformShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindCKKSMaskedTransformShare); err != nil {
+		return err
+	}
+
+	if len(data) < 8 {
+		return errors.New("MaskedTransformShare: too small bytearray")
+	}
 
 	e2sDataLen := binary.LittleEndian.Uint64(data[:8])
+	if e2sDataLen > uint64(len(data)-8) {
+		return errors.New("MaskedTransformShare: too small bytearray")
+	}
 
 	if err := share.e2sShare.UnmarshalBinary(data[8 : e2sDataLen+8]); err != nil {
 		return err
@@ -86,7 +101,8 @@ func (share *MaskedTransformShare) UnmarshalBinary(data []byte) error {
 	if err := share.s2eShare.UnmarshalBinary(data[8+e2sDataLen:]); err != nil {
 		return err
 	}
-	return nil
+
+	return env.CheckPoly(share.e2sShare.Value, 0)
 }
 
 // NewMaskedTransformProtocol creates a new instance of the PermuteProtocol.
@@ -1,7 +1,15 @@
 package drlwe
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
 	"github.com/tuneinsight/lattigo/v3/utils"
+	"golang.org/x/crypto/blake2b"
 )
 
 // CRS is an interface for Common Reference Strings.
@@ -10,3 +18,238 @@ import (
 type CRS interface {
 	utils.PRNG
 }
+
+// CRSSeedSize is the size in bytes of the seed of a CRSDescriptor.
+const CRSSeedSize = 32
+
+// CRSDescriptor is a compact and serializable description of a common reference string.
+// It stores the seed of a utils.KeyedPRNG and the clock position from which the CRS is read.
+// The clock counts blocks of params.N() bytes, which is the granularity at which the
+// uniform samplers of the protocols read the CRS.
+//
+// A coordinator can publish a single seed for a whole setup phase, from which each protocol
+// instance derives its own CRS with Derive and a label that is unique to the instance.
+type CRSDescriptor struct {
+	Seed  [CRSSeedSize]byte
+	Clock uint64
+}
+
+// NewCRSDescriptor returns a new CRSDescriptor with a fresh random seed and its clock set to zero.
+func NewCRSDescriptor() (desc CRSDescriptor, err error) {
+	if _, err = rand.Read(desc.Seed[:]); err != nil {
+		return desc, err
+	}
+	return desc, nil
+}
+
+// Derive returns a new CRSDescriptor whose seed is derived from the seed of the receiver and the
+// given label, and whose clock is set to zero. Distinct labels yield independent CRSs, which
+// enables the derivation of the CRPs of several protocols from the same seed.
+func (desc CRSDescriptor) Derive(label string) (derived CRSDescriptor) {
+	hash, err := blake2b.New256(desc.Seed[:])
+	if err != nil {
+		panic(err)
+	}
+	hash.Write([]byte(label))
+	copy(derived.Seed[:], hash.Sum(nil))
+	return
+}
+
+// NewCRS instantiates the CRS described by the receiver for the given parameters, i.e., a
+// utils.KeyedPRNG keyed with the seed and advanced to the clock position of the receiver.
+func (desc CRSDescriptor) NewCRS(params rlwe.Parameters) (crs CRS, err error) {
+	var prng *utils.KeyedPRNG
+	if prng, err = utils.NewKeyedPRNG(desc.Seed[:]); err != nil {
+		return nil, err
+	}
+	if err = prng.SetClock(make([]byte, params.N()), desc.Clock); err != nil {
+		return nil, err
+	}
+	return prng, nil
+}
+
+// MarshalBinary encodes the target CRSDescriptor on a slice of bytes.
+func (desc CRSDescriptor) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, rlwe.EnvelopeHeaderLen+CRSSeedSize+8)
+	copy(data[rlwe.EnvelopeHeaderLen:], desc.Seed[:])
+	binary.BigEndian.PutUint64(data[rlwe.EnvelopeHeaderLen+CRSSeedSize:], desc.Clock)
+	rlwe.NewEnvelope(rlwe.KindCRSDescriptor, nil, 0).WriteHeader(data)
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target CRSDescriptor.
+func (desc *CRSDescriptor) UnmarshalBinary(data []byte) (err error) {
+	if _, data, err = openEnvelope(data, rlwe.KindCRSDescriptor); err != nil {
+		return err
+	}
+	if len(data) != CRSSeedSize+8 {
+		return errors.New("CRSDescriptor: invalid bytearray length")
+	}
+	copy(desc.Seed[:], data[:CRSSeedSize])
+	desc.Clock = binary.BigEndian.Uint64(data[CRSSeedSize:])
+	return nil
+}
+
+// The CRPs can be shipped in their expanded form to the parties that cannot regenerate them
+// from the CRS, with the methods below. As the CRSDescriptor, they are encoded in an rlwe.Envelope,
+// which does not record the fingerprint of their parameters.
+
+// MarshalBinary encodes the target CKGCRP on a slice of bytes.
+func (crp CKGCRP) MarshalBinary() (data []byte, err error) {
+	return marshalPolyQPSlice(rlwe.KindCKGCRP, []rlwe.PolyQP{rlwe.PolyQP(crp)})
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target CKGCRP.
+func (crp *CKGCRP) UnmarshalBinary(data []byte) (err error) {
+	var polys []rlwe.PolyQP
+	if polys, err = unmarshalPolyQPSlice(rlwe.KindCKGCRP, data); err != nil {
+		return err
+	}
+	if len(polys) != 1 {
+		return errors.New("CKGCRP: invalid number of polynomials")
+	}
+	*crp = CKGCRP(polys[0])
+	return nil
+}
+
+// MarshalBinary encodes the target RKGCRP on a slice of bytes.
+func (crp RKGCRP) MarshalBinary() (data []byte, err error) {
+	return marshalPolyQPSlice(rlwe.KindRKGCRP, crp)
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target RKGCRP.
+func (crp *RKGCRP) UnmarshalBinary(data []byte) (err error) {
+	*crp, err = unmarshalPolyQPSlice(rlwe.KindRKGCRP, data)
+	return err
+}
+
+// MarshalBinary encodes the target RTGCRP on a slice of bytes.
+func (crp RTGCRP) MarshalBinary() (data []byte, err error) {
+	return marshalPolyQPSlice(rlwe.KindRTGCRP, crp)
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target RTGCRP.
+func (crp *RTGCRP) UnmarshalBinary(data []byte) (err error) {
+	*crp, err = unmarshalPolyQPSlice(rlwe.KindRTGCRP, data)
+	return err
+}
+
+// MarshalBinary encodes the target CKSCRP on a slice of bytes.
+func (crp CKSCRP) MarshalBinary() (data []byte, err error) {
+	poly := ring.Poly(crp)
+	data = make([]byte, rlwe.EnvelopeHeaderLen+poly.GetDataLen(true))
+	if _, err = poly.WriteTo(data[rlwe.EnvelopeHeaderLen:]); err != nil {
+		return nil, err
+	}
+	rlwe.NewEnvelope(rlwe.KindCKSCRP, &poly, 0).WriteHeader(data)
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target CKSCRP.
+func (crp *CKSCRP) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = openEnvelope(data, rlwe.KindCKSCRP); err != nil {
+		return err
+	}
+
+	poly := new(ring.Poly)
+	if err = poly.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	if err = env.CheckPoly(poly, 0); err != nil {
+		return err
+	}
+
+	*crp = CKSCRP(*poly)
+	return nil
+}
+
+// marshalPolyQPSlice encodes polys in an rlwe.Envelope of the given kind, whose LogN and Level are the ones of the first polynomial.
+func marshalPolyQPSlice(kind rlwe.ObjectKind, polys []rlwe.PolyQP) (data []byte, err error) {
+
+	dataLen := rlwe.EnvelopeHeaderLen + 4
+	for i := range polys {
+		dataLen += polys[i].GetDataLen(true)
+	}
+
+	data = make([]byte, dataLen)
+	binary.BigEndian.PutUint32(data[rlwe.EnvelopeHeaderLen:], uint32(len(polys)))
+
+	ptr := rlwe.EnvelopeHeaderLen + 4
+	var inc int
+	for i := range polys {
+		if inc, err = polys[i].WriteTo(data[ptr:]); err != nil {
+			return nil, err
+		}
+		ptr += inc
+	}
+
+	rlwe.NewEnvelope(kind, envelopePolyQP(polys), 0).WriteHeader(data)
+
+	return data, nil
+}
+
+// unmarshalPolyQPSlice decodes a slice of bytes generated by marshalPolyQPSlice with the given kind.
+func unmarshalPolyQPSlice(kind rlwe.ObjectKind, data []byte) (polys []rlwe.PolyQP, err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = openEnvelope(data, kind); err != nil {
+		return nil, err
+	}
+
+	if len(data) < 4 {
+		return nil, errors.New("too small bytearray")
+	}
+
+	// Each PolyQP is encoded on at least 2 bytes, which bounds the count before the allocation.
+	nbPolys := binary.BigEndian.Uint32(data)
+	if uint64(nbPolys) > uint64(len(data)-4)/2 {
+		return nil, errors.New("too small bytearray")
+	}
+
+	polys = make([]rlwe.PolyQP, nbPolys)
+
+	ptr := 4
+	var inc int
+	for i := range polys {
+		if len(data) < ptr+2 {
+			return nil, errors.New("too small bytearray")
+		}
+		if inc, err = polys[i].DecodePolyNew(data[ptr:]); err != nil {
+			return nil, err
+		}
+		ptr += inc
+	}
+
+	if ptr != len(data) {
+		return nil, errors.New("remaining unparsed data")
+	}
+
+	return polys, env.CheckPoly(envelopePolyQP(polys), 0)
+}
+
+// envelopePolyQP returns the polynomial defining the LogN and Level of the Envelope of polys, that is
+// the first polynomial modulo Q, or nil if polys is empty.
+func envelopePolyQP(polys []rlwe.PolyQP) *ring.Poly {
+	if len(polys) == 0 {
+		return nil
+	}
+	return polys[0].Q
+}
+
+// openEnvelope is rlwe.OpenEnvelope for the objects of this file, which have no legacy encoding and
+// are therefore rejected if they are not encoded in an Envelope.
+func openEnvelope(data []byte, kind rlwe.ObjectKind) (env rlwe.Envelope, payload []byte, err error) {
+
+	if env, payload, err = rlwe.OpenEnvelope(data, kind); err != nil {
+		return
+	}
+
+	if env.IsLegacy() {
+		return rlwe.Envelope{}, nil, fmt.Errorf("invalid envelope: %s has no legacy encoding", kind)
+	}
+
+	return
+}
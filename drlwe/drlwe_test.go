@@ -1,6 +1,8 @@
 package drlwe
 
 import (
+	"encoding"
+	"encoding/binary"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -86,6 +88,9 @@ func TestDRLWE(t *testing.T) {
 			testPublicKeySwitching,
 			testRelinKeyGen,
 			testRotKeyGen,
+			testKeyGenPow2Base,
+			testCRSDescriptor,
+			testThreshold,
 			testMarshalling,
 		} {
 			testSet(textCtx, t)
@@ -354,10 +359,6 @@ func testRelinKeyGen(testCtx testContext, t *testing.T) {
 func testRotKeyGen(testCtx testContext, t *testing.T) {
 
 	params := testCtx.params
-	ringQ := params.RingQ()
-	ringP := params.RingP()
-	ringQP := params.RingQP()
-	levelQ, levelP := params.QCount()-1, params.PCount()-1
 
 	t.Run(testString(params, "RotKeyGen"), func(t *testing.T) {
 
@@ -396,48 +397,367 @@ func testRotKeyGen(testCtx testContext, t *testing.T) {
 		rotKeySet := rlwe.NewRotationKeySet(params, []uint64{galEl})
 		rtg[0].GenRotationKey(shares[0], crp, rotKeySet.Keys[galEl])
 
-		skIn := testCtx.skIdeal.CopyNew()
-		skOut := testCtx.skIdeal.CopyNew()
-		galElInv := ring.ModExp(galEl, uint64(2*params.N()-1), uint64(2*params.N()))
-		ringQ.PermuteNTT(testCtx.skIdeal.Value.Q, galElInv, skOut.Value.Q)
-		ringP.PermuteNTT(testCtx.skIdeal.Value.P, galElInv, skOut.Value.P)
+		verifyRotationKey(t, params, testCtx.skIdeal, galEl, rotKeySet.Keys[galEl])
+	})
 
-		swk := rotKeySet.Keys[galEl]
+	t.Run(testString(params, "RotKeyGen/Batched"), func(t *testing.T) {
 
-		// Decrypts
-		// [-asIn + w*P*sOut + e, a] + [asIn]
-		for j := range swk.Value {
-			ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, swk.Value[j][1], skOut.Value, swk.Value[j][0])
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
 
+		rtg := make([]*RTGProtocol, nbParties)
+		for i := range rtg {
+			switch i {
+			case 0:
+				rtg[i] = NewRTGProtocol(params)
+			case 1:
+				// The shares of the Galois elements of this party are generated in parallel
+				rtg[i] = NewRTGProtocol(params.WithParallelism(3))
+			default:
+				rtg[i] = rtg[0].ShallowCopy()
+			}
 		}
 
-		// Sums all basis together (equivalent to multiplying with CRT decomposition of 1)
-		// sum([1]_w * [w*P*sOut + e]) = P*sOut + sum(e)
-		for j := range swk.Value {
-			if j > 0 {
-				ringQP.AddLvl(levelQ, levelP, swk.Value[0][0], swk.Value[j][0], swk.Value[0][0])
+		galEls := []uint64{
+			params.GaloisElementForColumnRotationBy(5),
+			params.GaloisElementForRowRotation(),
+			params.GaloisElementForColumnRotationBy(1),
+			params.GaloisElementForColumnRotationBy(-3),
+		}
+
+		// Each party samples the CRP from its own copy of the CRS and with a different
+		// ordering of the Galois elements, and must obtain the same CRP.
+		crp := make([]RTGMultiCRP, nbParties)
+		for i := range crp {
+			crs, _ := utils.NewKeyedPRNG([]byte{'b', 'a', 't', 'c', 'h'})
+			k := i % len(galEls)
+			galElsPerm := append(append([]uint64{}, galEls[k:]...), galEls[:k]...)
+			crp[i] = rtg[i].SampleMultiCRP(galElsPerm, crs)
+		}
+
+		for i := 1; i < nbParties; i++ {
+			for _, galEl := range galEls {
+				for j := range crp[0][galEl] {
+					require.True(t, crp[0][galEl][j].Equals(crp[i][galEl][j]))
+				}
 			}
 		}
 
-		// sOut * P
-		ringQ.MulScalarBigint(skIn.Value.Q, ringP.ModulusBigint, skIn.Value.Q)
+		shares := make([]*RTGMultiShare, nbParties)
+		for i := range shares {
+			shares[i] = rtg[i].AllocateMultiShare(galEls)
+			rtg[i].GenMultiShare(testCtx.skShares[i], crp[i], shares[i])
+		}
 
-		// P*s^i + sum(e) - P*s^i = sum(e)
-		ringQ.Sub(swk.Value[0][0].Q, skIn.Value.Q, swk.Value[0][0].Q)
+		for i := 1; i < nbParties; i++ {
+			rtg[0].AggregateMultiShare(shares[0], shares[i], shares[0])
+		}
 
-		// Checks that the error is below the bound
-		// Worst error bound is N * floor(6*sigma) * #Keys
-		ringQP.InvNTTLvl(levelQ, levelP, swk.Value[0][0], swk.Value[0][0])
-		ringQP.InvMFormLvl(levelQ, levelP, swk.Value[0][0], swk.Value[0][0])
+		rotKeySet := new(rlwe.RotationKeySet)
+		rtg[0].GenRotationKeySet(shares[0], crp[0], rotKeySet)
 
-		// Worst bound of inner sum
-		// N*#Keys*(N * #Parties * floor(sigma*6) + #Parties * floor(sigma*6) + N * #Parties  +  #Parties * floor(6*sigma))
-		log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
-		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringQ.Modulus)-1, ringQ, swk.Value[0][0].Q))
-		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringP.Modulus)-1, ringP, swk.Value[0][0].P))
+		require.Equal(t, len(galEls), len(rotKeySet.Keys))
+		for _, galEl := range galEls {
+			verifyRotationKey(t, params, testCtx.skIdeal, galEl, rotKeySet.Keys[galEl])
+		}
+
+		// Mismatching Galois elements
+		shareOther := rtg[0].AllocateMultiShare(galEls[:2])
+		require.Panics(t, func() { rtg[0].AggregateMultiShare(shares[0], shareOther, shares[0]) })
+		require.Panics(t, func() { rtg[0].AggregateMultiShare(shareOther, shares[0], shares[0]) })
+		shareOther = rtg[0].AllocateMultiShare(append([]uint64{params.GaloisElementForColumnRotationBy(7)}, galEls[1:]...))
+		require.Panics(t, func() { rtg[0].AggregateMultiShare(shares[0], shareOther, shares[0]) })
+		require.Panics(t, func() {
+			rtg[0].GenRotationKeySet(shares[0], rtg[0].SampleMultiCRP(galEls[:2], testCtx.crs), new(rlwe.RotationKeySet))
+		})
 	})
 }
 
+func testKeyGenPow2Base(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	// Small enough for the error of the key-switching without P to stay below the
+	// smallest modulus of the test parameters, as required by log2OfInnerSum.
+	pow2Base := 8
+
+	if params.PCount() == 0 {
+		t.Skip("method is unsuported when params.PCount() == 0")
+	}
+
+	// Power-of-two decomposition with one modulus in P, and without P.
+	for _, P := range [][]uint64{params.P()[:1], {}} {
+
+		paramsPow2, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{
+			LogN:     params.LogN(),
+			Q:        params.Q(),
+			P:        P,
+			H:        params.HammingWeight(),
+			Sigma:    params.Sigma(),
+			RingType: params.RingType(),
+			Pow2Base: pow2Base,
+		})
+		require.NoError(t, err)
+
+		testCtx := newTestContext(paramsPow2)
+		ringQ := paramsPow2.RingQ()
+		ringQP := paramsPow2.RingQP()
+		levelQ, levelP := paramsPow2.QCount()-1, paramsPow2.PCount()-1
+
+		// Without P, the error of the key-switching grows with the base of the decomposition
+		log2Bound := 11 + paramsPow2.LogN()
+		if paramsPow2.PCount() == 0 {
+			log2Bound = pow2Base + 2*paramsPow2.LogN() + 10
+		}
+
+		t.Run(testString(paramsPow2, fmt.Sprintf("RelinKeyGen/Pow2Base=%d/", pow2Base)), func(t *testing.T) {
+
+			rkg := NewRKGProtocol(paramsPow2)
+
+			ephSk := make([]*rlwe.SecretKey, nbParties)
+			share1 := make([]*RKGShare, nbParties)
+			share2 := make([]*RKGShare, nbParties)
+			for i := range ephSk {
+				ephSk[i], share1[i], share2[i] = rkg.AllocateShare()
+			}
+
+			crp := rkg.SampleCRP(testCtx.crs)
+			for i := range ephSk {
+				rkg.GenShareRoundOne(testCtx.skShares[i], crp, ephSk[i], share1[i])
+			}
+			for i := 1; i < nbParties; i++ {
+				rkg.AggregateShare(share1[0], share1[i], share1[0])
+			}
+			for i := range ephSk {
+				rkg.GenShareRoundTwo(ephSk[i], testCtx.skShares[i], share1[0], share2[i])
+			}
+			for i := 1; i < nbParties; i++ {
+				rkg.AggregateShare(share2[0], share2[i], share2[0])
+			}
+
+			rlk := rlwe.NewRelinKey(paramsPow2, 1)
+			rkg.GenRelinearizationKey(share1[0], share2[0], rlk)
+			require.Equal(t, paramsPow2.Beta()*paramsPow2.DecompPw2(), len(rlk.Keys[0].Value))
+
+			skIn := testCtx.skIdeal.CopyNew()
+			ringQP.MulCoeffsMontgomeryLvl(levelQ, levelP, skIn.Value, skIn.Value, skIn.Value)
+
+			verifySwitchingKeyPow2Base(t, paramsPow2, testCtx.uniformSampler, skIn, testCtx.skIdeal, rlk.Keys[0], log2Bound)
+		})
+
+		t.Run(testString(paramsPow2, fmt.Sprintf("RotKeyGen/Pow2Base=%d/", pow2Base)), func(t *testing.T) {
+
+			rtg := NewRTGProtocol(paramsPow2)
+
+			shares := make([]*RTGShare, nbParties)
+			for i := range shares {
+				shares[i] = rtg.AllocateShare()
+			}
+
+			galEl := paramsPow2.GaloisElementForColumnRotationBy(1)
+
+			crp := rtg.SampleCRP(testCtx.crs)
+			for i := range shares {
+				rtg.GenShare(testCtx.skShares[i], galEl, crp, shares[i])
+			}
+			for i := 1; i < nbParties; i++ {
+				rtg.AggregateShare(shares[0], shares[i], shares[0])
+			}
+
+			rotKeySet := rlwe.NewRotationKeySet(paramsPow2, []uint64{galEl})
+			rtg.GenRotationKey(shares[0], crp, rotKeySet.Keys[galEl])
+
+			skOut := testCtx.skIdeal.CopyNew()
+			galElInv := ring.ModExp(galEl, uint64(2*paramsPow2.N()-1), uint64(2*paramsPow2.N()))
+			ringQ.PermuteNTT(testCtx.skIdeal.Value.Q, galElInv, skOut.Value.Q)
+
+			verifySwitchingKeyPow2Base(t, paramsPow2, testCtx.uniformSampler, testCtx.skIdeal, skOut, rotKeySet.Keys[galEl], log2Bound)
+		})
+	}
+}
+
+// verifySwitchingKeyPow2Base checks that key-switching a random polynomial c with swk yields [d0, d1]
+// such that d0 + d1*skOut - c*skIn has a norm below the given bound.
+func verifySwitchingKeyPow2Base(t *testing.T, params rlwe.Parameters, uniformSampler *ring.UniformSampler, skIn, skOut *rlwe.SecretKey, swk *rlwe.SwitchingKey, log2Bound int) {
+
+	ringQ := params.RingQ()
+	levelQ := params.MaxLevel()
+
+	ks := rlwe.NewKeySwitcher(params)
+
+	c := ringQ.NewPoly()
+	uniformSampler.Read(c)
+	c.IsNTT = true
+
+	d0, d1 := ringQ.NewPoly(), ringQ.NewPoly()
+	ks.SwitchKeysInPlace(levelQ, c, swk, d0, d1)
+
+	// d0 + d1*skOut - c*skIn
+	ringQ.MulCoeffsMontgomeryAndAdd(d1, skOut.Value.Q, d0)
+	ringQ.MulCoeffsMontgomeryAndSub(c, skIn.Value.Q, d0)
+	ringQ.InvNTT(d0, d0)
+
+	require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(levelQ, ringQ, d0))
+}
+
+// verifyRotationKey checks that swk is a valid rotation key for the Galois element galEl under the
+// ideal secret key skIdeal, i.e. that its error is below the worst case bound.
+func verifyRotationKey(t *testing.T, params rlwe.Parameters, skIdeal *rlwe.SecretKey, galEl uint64, swk *rlwe.SwitchingKey) {
+
+	ringQ := params.RingQ()
+	ringP := params.RingP()
+	ringQP := params.RingQP()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	skIn := skIdeal.CopyNew()
+	skOut := skIdeal.CopyNew()
+	galElInv := ring.ModExp(galEl, uint64(2*params.N()-1), uint64(2*params.N()))
+	ringQ.PermuteNTT(skIdeal.Value.Q, galElInv, skOut.Value.Q)
+	ringP.PermuteNTT(skIdeal.Value.P, galElInv, skOut.Value.P)
+
+	// Decrypts
+	// [-asIn + w*P*sOut + e, a] + [asIn]
+	for j := range swk.Value {
+		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, swk.Value[j][1], skOut.Value, swk.Value[j][0])
+
+	}
+
+	// Sums all basis together (equivalent to multiplying with CRT decomposition of 1)
+	// sum([1]_w * [w*P*sOut + e]) = P*sOut + sum(e)
+	for j := range swk.Value {
+		if j > 0 {
+			ringQP.AddLvl(levelQ, levelP, swk.Value[0][0], swk.Value[j][0], swk.Value[0][0])
+		}
+	}
+
+	// sOut * P
+	ringQ.MulScalarBigint(skIn.Value.Q, ringP.ModulusBigint, skIn.Value.Q)
+
+	// P*s^i + sum(e) - P*s^i = sum(e)
+	ringQ.Sub(swk.Value[0][0].Q, skIn.Value.Q, swk.Value[0][0].Q)
+
+	// Checks that the error is below the bound
+	// Worst error bound is N * floor(6*sigma) * #Keys
+	ringQP.InvNTTLvl(levelQ, levelP, swk.Value[0][0], swk.Value[0][0])
+	ringQP.InvMFormLvl(levelQ, levelP, swk.Value[0][0], swk.Value[0][0])
+
+	// Worst bound of inner sum
+	// N*#Keys*(N * #Parties * floor(sigma*6) + #Parties * floor(sigma*6) + N * #Parties  +  #Parties * floor(6*sigma))
+	log2Bound := bits.Len64(3 * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+	require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringQ.Modulus)-1, ringQ, swk.Value[0][0].Q))
+	require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(len(ringP.Modulus)-1, ringP, swk.Value[0][0].P))
+}
+
+func testCRSDescriptor(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+
+	t.Run(testString(params, "CRSDescriptor"), func(t *testing.T) {
+
+		root, err := NewCRSDescriptor()
+		require.NoError(t, err)
+
+		ckg := NewCKGProtocol(params)
+
+		// Two parties deriving the CRS with the same label obtain the same CRP
+		crs0, err := root.Derive("CKG").NewCRS(params)
+		require.NoError(t, err)
+		crs1, err := root.Derive("CKG").NewCRS(params)
+		require.NoError(t, err)
+
+		crp0 := rlwe.PolyQP(ckg.SampleCRP(crs0))
+		crp1 := rlwe.PolyQP(ckg.SampleCRP(crs1))
+		require.True(t, crp0.Equals(crp1))
+
+		// Distinct labels are domain-separated
+		crsOther, err := root.Derive("RKG").NewCRS(params)
+		require.NoError(t, err)
+		crpOther := rlwe.PolyQP(ckg.SampleCRP(crsOther))
+		require.False(t, crp0.Equals(crpOther))
+
+		// A descriptor captured at the current clock resumes the CRS where it stands
+		resumed := root.Derive("CKG")
+		resumed.Clock = crs0.GetClock()
+
+		data, err := resumed.MarshalBinary()
+		require.NoError(t, err)
+		require.Len(t, data, rlwe.EnvelopeHeaderLen+CRSSeedSize+8)
+
+		received := new(CRSDescriptor)
+		require.NoError(t, received.UnmarshalBinary(data))
+		require.Equal(t, resumed, *received)
+
+		// The descriptor has no encoding without Envelope
+		require.Error(t, new(CRSDescriptor).UnmarshalBinary(data[rlwe.EnvelopeHeaderLen:]))
+
+		crsResumed, err := received.NewCRS(params)
+		require.NoError(t, err)
+
+		crpNext := rlwe.PolyQP(ckg.SampleCRP(crs0))
+		crpResumed := rlwe.PolyQP(ckg.SampleCRP(crsResumed))
+		require.True(t, crpNext.Equals(crpResumed))
+	})
+}
+
+func testThreshold(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQP := params.RingQP()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	for _, threshold := range []int{1, nbParties - 1, nbParties} {
+
+		t.Run(testString(params, fmt.Sprintf("Threshold/t=%d", threshold)), func(t *testing.T) {
+
+			points := make([]ShamirPublicPoint, nbParties)
+			for i := range points {
+				points[i] = ShamirPublicPoint(i + 1)
+			}
+
+			thr := NewThresholdizer(params)
+
+			_, err := thr.GenShamirPolynomial(0, testCtx.skShares[0])
+			require.Error(t, err)
+
+			// Each party secret-shares its secret-key share among all the parties
+			tsks := make([]*ShamirSecretShare, nbParties)
+			for i := range tsks {
+				tsks[i] = thr.AllocateThresholdSecretShare()
+			}
+
+			share := thr.AllocateThresholdSecretShare()
+			for _, sk := range testCtx.skShares {
+				poly, err := thr.GenShamirPolynomial(threshold, sk)
+				require.NoError(t, err)
+				for j, point := range points {
+					thr.GenShamirSecretShare(point, poly, share)
+					thr.AggregateShares(tsks[j], share, tsks[j])
+				}
+			}
+
+			// Any set of threshold parties recovers additive shares of the collective secret-key
+			cmb := NewCombiner(params, threshold)
+			for offset := 0; offset < nbParties; offset++ {
+
+				actives := make([]ShamirPublicPoint, threshold)
+				for i := range actives {
+					actives[i] = points[(offset+i)%nbParties]
+				}
+
+				skRec := rlwe.NewSecretKey(params)
+				skAdd := rlwe.NewSecretKey(params)
+				for i := range actives {
+					cmb.GenAdditiveShare(actives, actives[i], tsks[(offset+i)%nbParties], skAdd)
+					ringQP.AddLvl(levelQ, levelP, skRec.Value, skAdd.Value, skRec.Value)
+				}
+
+				require.True(t, skRec.Value.Equals(testCtx.skIdeal.Value))
+			}
+		})
+	}
+}
+
 func testMarshalling(testCtx testContext, t *testing.T) {
 
 	params := testCtx.params
@@ -558,6 +878,162 @@ func testMarshalling(testCtx testContext, t *testing.T) {
 		}
 	})
 
+	t.Run(testString(params, "Marshalling/RTGMulti"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		galEls := []uint64{testCtx.params.GaloisElementForColumnRotationBy(64), testCtx.params.GaloisElementForRowRotation()}
+
+		rtg := NewRTGProtocol(testCtx.params)
+		rtgShare := rtg.AllocateMultiShare(galEls)
+
+		crp := rtg.SampleMultiCRP(galEls, testCtx.crs)
+
+		rtg.GenMultiShare(testCtx.skShares[0], crp, rtgShare)
+
+		data, err := rtgShare.MarshalBinary()
+		require.NoError(t, err)
+
+		resRTGShare := new(RTGMultiShare)
+		err = resRTGShare.UnmarshalBinary(data)
+		require.NoError(t, err)
+
+		require.Equal(t, len(resRTGShare.Value), len(rtgShare.Value))
+
+		for galEl, share := range rtgShare.Value {
+			require.Contains(t, resRTGShare.Value, galEl)
+			for i, val := range share.Value {
+				require.True(t, resRTGShare.Value[galEl].Value[i].Equals(val))
+			}
+		}
+	})
+
+	t.Run(testString(params, "Marshalling/CRP"), func(t *testing.T) {
+
+		ckgCRP := NewCKGProtocol(params).SampleCRP(testCtx.crs)
+		data, err := ckgCRP.MarshalBinary()
+		require.NoError(t, err)
+		resCKGCRP := new(CKGCRP)
+		require.NoError(t, resCKGCRP.UnmarshalBinary(data))
+		ckgPoly := rlwe.PolyQP(ckgCRP)
+		require.True(t, ckgPoly.Equals(rlwe.PolyQP(*resCKGCRP)))
+
+		cksCRP := NewCKSProtocol(params, 3.2).SampleCRP(params.MaxLevel(), testCtx.crs)
+		data, err = cksCRP.MarshalBinary()
+		require.NoError(t, err)
+		resCKSCRP := new(CKSCRP)
+		require.NoError(t, resCKSCRP.UnmarshalBinary(data))
+		cksPoly, resCKSPoly := ring.Poly(cksCRP), ring.Poly(*resCKSCRP)
+		require.True(t, cksPoly.Equals(&resCKSPoly))
+
+		if params.PCount() == 0 {
+			return
+		}
+
+		rkgCRP := NewRKGProtocol(params).SampleCRP(testCtx.crs)
+		data, err = rkgCRP.MarshalBinary()
+		require.NoError(t, err)
+		resRKGCRP := new(RKGCRP)
+		require.NoError(t, resRKGCRP.UnmarshalBinary(data))
+		require.Len(t, *resRKGCRP, len(rkgCRP))
+		for i := range rkgCRP {
+			require.True(t, rkgCRP[i].Equals((*resRKGCRP)[i]))
+		}
+
+		// Trailing bytes are rejected
+		env, payload, err := rlwe.DecodeEnvelope(data)
+		require.NoError(t, err)
+		require.NoError(t, resRKGCRP.UnmarshalBinary(env.Seal(payload)))
+		require.Error(t, resRKGCRP.UnmarshalBinary(env.Seal(append(append([]byte{}, payload...), 0))))
+
+		// A count of polynomials larger than the data is rejected before the allocation
+		binary.BigEndian.PutUint32(data[rlwe.EnvelopeHeaderLen:], 1<<31)
+		require.Error(t, resRKGCRP.UnmarshalBinary(data))
+
+		rtgCRP := NewRTGProtocol(params).SampleCRP(testCtx.crs)
+		data, err = rtgCRP.MarshalBinary()
+		require.NoError(t, err)
+		resRTGCRP := new(RTGCRP)
+		require.NoError(t, resRTGCRP.UnmarshalBinary(data))
+		require.Len(t, *resRTGCRP, len(rtgCRP))
+		for i := range rtgCRP {
+			require.True(t, rtgCRP[i].Equals((*resRTGCRP)[i]))
+		}
+
+		// The CRPs are encoded in an Envelope recording their kind
+		require.Error(t, resRKGCRP.UnmarshalBinary(data))
+		require.Error(t, resRTGCRP.UnmarshalBinary(data[rlwe.EnvelopeHeaderLen:]))
+	})
+
+	t.Run(testString(params, "Marshalling/Threshold"), func(t *testing.T) {
+
+		thr := NewThresholdizer(params)
+		poly, err := thr.GenShamirPolynomial(nbParties, testCtx.skShares[0])
+		require.NoError(t, err)
+
+		share := thr.AllocateThresholdSecretShare()
+		thr.GenShamirSecretShare(ShamirPublicPoint(1), poly, share)
+
+		data, err := share.MarshalBinary()
+		require.NoError(t, err)
+
+		resShare := new(ShamirSecretShare)
+		require.NoError(t, resShare.UnmarshalBinary(data))
+		require.True(t, share.Equals(resShare.PolyQP))
+	})
+
+	t.Run(testString(params, "Marshalling/OtherParameters"), func(t *testing.T) {
+
+		paramsOther, err := rlwe.NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+
+		shares := map[string]interface {
+			MarshalBinary() ([]byte, error)
+		}{
+			"CKG":       NewCKGProtocol(params).AllocateShare(),
+			"CKS":       NewCKSProtocol(params, params.Sigma()).AllocateShare(params.MaxLevel()),
+			"PCKS":      NewPCKSProtocol(params, params.Sigma()).AllocateShare(params.MaxLevel()),
+			"Threshold": NewThresholdizer(params).AllocateThresholdSecretShare(),
+		}
+
+		newShares := map[string]func() encoding.BinaryUnmarshaler{
+			"CKG":       func() encoding.BinaryUnmarshaler { return new(CKGShare) },
+			"CKS":       func() encoding.BinaryUnmarshaler { return new(CKSShare) },
+			"PCKS":      func() encoding.BinaryUnmarshaler { return new(PCKSShare) },
+			"Threshold": func() encoding.BinaryUnmarshaler { return new(ShamirSecretShare) },
+			"RKG":       func() encoding.BinaryUnmarshaler { return new(RKGShare) },
+			"RTG":       func() encoding.BinaryUnmarshaler { return new(RTGShare) },
+			"RTGMulti":  func() encoding.BinaryUnmarshaler { return new(RTGMultiShare) },
+		}
+
+		if params.PCount() != 0 {
+			_, shares["RKG"], _ = NewRKGProtocol(params).AllocateShare()
+			shares["RTG"] = NewRTGProtocol(params).AllocateShare()
+			shares["RTGMulti"] = NewRTGProtocol(params).AllocateMultiShare([]uint64{params.GaloisElementForRowRotation()})
+		}
+
+		// The shares record the fingerprint and the ring type of their parameters, which are checked on decoding
+		for name, share := range shares {
+			data, err := share.MarshalBinary()
+			require.NoError(t, err, name)
+
+			env, _, err := rlwe.DecodeEnvelope(data)
+			require.NoError(t, err, name)
+			require.Equal(t, params.Fingerprint(), env.ParametersHash, name)
+			require.Equal(t, params.RingType(), env.RingType, name)
+
+			require.Error(t, paramsOther.UnmarshalObject(data, newShares[name]()), name)
+
+			resShare := newShares[name]()
+			require.NoError(t, params.UnmarshalObject(data, resShare), name)
+			resData, err := resShare.(encoding.BinaryMarshaler).MarshalBinary()
+			require.NoError(t, err, name)
+			require.Equal(t, data, resData, name)
+		}
+	})
+
 	t.Run(testString(params, "Marshalling/RTG"), func(t *testing.T) {
 
 		if params.PCount() == 0 {
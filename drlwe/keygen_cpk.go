@@ -18,7 +18,7 @@ type CollectivePublicKeyGenerator interface {
 // CKGProtocol is the structure storing the parameters and and precomputations for the collective key generation protocol.
 type CKGProtocol struct {
 	params           rlwe.Parameters
-	gaussianSamplerQ *ring.GaussianSampler
+	gaussianSamplerQ ring.ErrorSampler
 }
 
 // ShallowCopy creates a shallow copy of CKGProtocol in which all the read-only data-structures are
@@ -30,11 +30,12 @@ func (ckg *CKGProtocol) ShallowCopy() *CKGProtocol {
 		panic(err)
 	}
 
-	return &CKGProtocol{ckg.params, ring.NewGaussianSampler(prng, ckg.params.RingQ(), ckg.params.Sigma(), int(6*ckg.params.Sigma()))}
+	return &CKGProtocol{ckg.params, rlwe.NewErrorSampler(ckg.params, prng)}
 }
 
 // CKGShare is a struct storing the CKG protocol's share.
 type CKGShare struct {
+	ShareMetadata
 	Value rlwe.PolyQP
 }
 
@@ -43,17 +44,30 @@ type CKGCRP rlwe.PolyQP
 
 // MarshalBinary encodes the target element on a slice of bytes.
 func (share *CKGShare) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, share.Value.GetDataLen(true))
-	if _, err = share.Value.WriteTo(data); err != nil {
+	data = make([]byte, rlwe.EnvelopeHeaderLen+share.Value.GetDataLen(true))
+	if _, err = share.Value.WriteTo(data[rlwe.EnvelopeHeaderLen:]); err != nil {
 		return nil, err
 	}
+	share.Envelope(rlwe.KindCKGShare, share.Value.Q).WriteHeader(data)
 	return
 }
 
 // UnmarshalBinary decodes a slice of bytes on the target element.
+// It also accepts the legacy encoding without Envelope.
 func (share *CKGShare) UnmarshalBinary(data []byte) (err error) {
-	_, err = share.Value.DecodePolyNew(data)
-	return err
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindCKGShare); err != nil {
+		return err
+	}
+
+	if _, err = share.Value.DecodePolyNew(data); err != nil {
+		return err
+	}
+
+	share.ReadEnvelope(env)
+
+	return env.CheckPoly(share.Value.Q, 0)
 }
 
 // NewCKGProtocol creates a new CKGProtocol instance
@@ -65,13 +79,13 @@ func NewCKGProtocol(params rlwe.Parameters) *CKGProtocol {
 	if err != nil {
 		panic(err)
 	}
-	ckg.gaussianSamplerQ = ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma()))
+	ckg.gaussianSamplerQ = rlwe.NewErrorSampler(params, prng)
 	return ckg
 }
 
 // AllocateShare allocates the share of the CKG protocol.
 func (ckg *CKGProtocol) AllocateShare() *CKGShare {
-	return &CKGShare{ckg.params.RingQP().NewPoly()}
+	return &CKGShare{ShareMetadata: NewShareMetadata(ckg.params), Value: ckg.params.RingQP().NewPoly()}
 }
 
 // SampleCRP samples a common random polynomial to be used in the CKG protocol from the provided
@@ -22,7 +22,7 @@ type RelinearizationKeyGenerator interface {
 type RKGProtocol struct {
 	params           rlwe.Parameters
 	pBigInt          *big.Int
-	gaussianSamplerQ *ring.GaussianSampler
+	gaussianSamplerQ ring.ErrorSampler
 	ternarySamplerQ  *ring.TernarySampler // sampling in Montgomerry form
 
 	tmpPoly1 rlwe.PolyQP
@@ -44,7 +44,7 @@ func (ekg *RKGProtocol) ShallowCopy() *RKGProtocol {
 	return &RKGProtocol{
 		params:           ekg.params,
 		pBigInt:          ekg.pBigInt,
-		gaussianSamplerQ: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
+		gaussianSamplerQ: rlwe.NewErrorSampler(params, prng),
 		ternarySamplerQ:  ring.NewTernarySamplerWithHammingWeight(prng, params.RingQ(), params.HammingWeight(), false),
 		tmpPoly1:         params.RingQP().NewPoly(),
 		tmpPoly2:         params.RingQP().NewPoly(),
@@ -53,6 +53,7 @@ func (ekg *RKGProtocol) ShallowCopy() *RKGProtocol {
 
 // RKGShare is a share in the RKG protocol.
 type RKGShare struct {
+	ShareMetadata
 	Value [][2]rlwe.PolyQP
 }
 
@@ -71,7 +72,7 @@ func NewRKGProtocol(params rlwe.Parameters) *RKGProtocol {
 	}
 
 	rkg.pBigInt = params.PBigInt()
-	rkg.gaussianSamplerQ = ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma()))
+	rkg.gaussianSamplerQ = rlwe.NewErrorSampler(params, prng)
 	rkg.ternarySamplerQ = ring.NewTernarySamplerWithHammingWeight(prng, params.RingQ(), params.HammingWeight(), false)
 	rkg.tmpPoly1 = params.RingQP().NewPoly()
 	rkg.tmpPoly2 = params.RingQP().NewPoly()
@@ -81,10 +82,11 @@ func NewRKGProtocol(params rlwe.Parameters) *RKGProtocol {
 // AllocateShare allocates the share of the EKG protocol.
 func (ekg *RKGProtocol) AllocateShare() (ephSk *rlwe.SecretKey, r1 *RKGShare, r2 *RKGShare) {
 	ephSk = rlwe.NewSecretKey(ekg.params)
-	r1, r2 = new(RKGShare), new(RKGShare)
-	r1.Value = make([][2]rlwe.PolyQP, ekg.params.Beta())
-	r2.Value = make([][2]rlwe.PolyQP, ekg.params.Beta())
-	for i := 0; i < ekg.params.Beta(); i++ {
+	r1, r2 = &RKGShare{ShareMetadata: NewShareMetadata(ekg.params)}, &RKGShare{ShareMetadata: NewShareMetadata(ekg.params)}
+	decompSize := ekg.params.Beta() * ekg.params.DecompPw2()
+	r1.Value = make([][2]rlwe.PolyQP, decompSize)
+	r2.Value = make([][2]rlwe.PolyQP, decompSize)
+	for i := 0; i < decompSize; i++ {
 		r1.Value[i][0] = ekg.params.RingQP().NewPoly()
 		r1.Value[i][1] = ekg.params.RingQP().NewPoly()
 		r2.Value[i][0] = ekg.params.RingQP().NewPoly()
@@ -96,7 +98,7 @@ func (ekg *RKGProtocol) AllocateShare() (ephSk *rlwe.SecretKey, r1 *RKGShare, r2
 // SampleCRP samples a common random polynomial to be used in the RKG protocol from the provided
 // common reference string.
 func (ekg *RKGProtocol) SampleCRP(crs CRS) RKGCRP {
-	crp := make([]rlwe.PolyQP, ekg.params.Beta())
+	crp := make([]rlwe.PolyQP, ekg.params.Beta()*ekg.params.DecompPw2())
 	us := rlwe.NewUniformSamplerQP(ekg.params, crs)
 	for i := range crp {
 		crp[i] = ekg.params.RingQP().NewPoly()
@@ -126,40 +128,55 @@ func (ekg *RKGProtocol) GenShareRoundOne(sk *rlwe.SecretKey, crp RKGCRP, ephSkOu
 	ringQP.NTTLvl(levelQ, levelP, ephSkOut.Value, ephSkOut.Value)
 	ringQP.MFormLvl(levelQ, levelP, ephSkOut.Value, ephSkOut.Value)
 
+	// Without P, each element of the RNS decomposition basis is a single modulus of Q
+	alpha := utils.MaxInt(ekg.params.PCount(), 1)
+	decompPw2 := ekg.params.DecompPw2()
+
 	for i := 0; i < ekg.params.Beta(); i++ {
-		// h = e
-		ekg.gaussianSamplerQ.Read(shareOut.Value[i][0].Q)
-		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i][0].Q, levelP, nil, shareOut.Value[i][0].P)
-		ringQP.NTTLvl(levelQ, levelP, shareOut.Value[i][0], shareOut.Value[i][0])
-
-		// h = sk*CrtBaseDecompQi + e
-		for j := 0; j < ekg.params.PCount(); j++ {
-			index := i*ekg.params.PCount() + j
-
-			// Handles the case where nb pj does not divides nb qi
-			if index >= ekg.params.QCount() {
-				break
-			}
 
-			qi := ringQ.Modulus[index]
-			skP := ekg.tmpPoly1.Q.Coeffs[index]
-			h := shareOut.Value[i][0].Q.Coeffs[index]
+		for j := 0; j < decompPw2; j++ {
+
+			share := shareOut.Value[i*decompPw2+j]
+
+			// h = e
+			ekg.gaussianSamplerQ.Read(share[0].Q)
+			ringQP.ExtendBasisSmallNormAndCenter(share[0].Q, levelP, nil, share[0].P)
+			ringQP.NTTLvl(levelQ, levelP, share[0], share[0])
+
+			// h = sk*CrtBaseDecompQi*2^{w*j} + e
+			for k := 0; k < alpha; k++ {
+				index := i*alpha + k
+
+				// Handles the case where nb pj does not divides nb qi
+				if index >= ekg.params.QCount() {
+					break
+				}
 
-			for w := 0; w < ringQ.N; w++ {
-				h[w] = ring.CRed(h[w]+skP[w], qi)
+				qi := ringQ.Modulus[index]
+				skP := ekg.tmpPoly1.Q.Coeffs[index]
+				h := share[0].Q.Coeffs[index]
+
+				for w := 0; w < ringQ.N; w++ {
+					h[w] = ring.CRed(h[w]+skP[w], qi)
+				}
+
+				// sk * 2^{w*(j+1)} mod qi for the next power-of-two digit
+				if j < decompPw2-1 {
+					ring.MulScalarMontgomeryVec(skP, skP, ring.MForm(1<<ekg.params.Pow2Base(), qi, ringQ.BredParams[index]), qi, ringQ.MredParams[index])
+				}
 			}
-		}
 
-		// h = sk*CrtBaseDecompQi + -u*a + e
-		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, ephSkOut.Value, crp[i], shareOut.Value[i][0])
+			// h = sk*CrtBaseDecompQi*2^{w*j} + -u*a + e
+			ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, ephSkOut.Value, crp[i*decompPw2+j], share[0])
 
-		// Second Element
-		// e_2i
-		ekg.gaussianSamplerQ.Read(shareOut.Value[i][1].Q)
-		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i][1].Q, levelP, nil, shareOut.Value[i][1].P)
-		ringQP.NTTLvl(levelQ, levelP, shareOut.Value[i][1], shareOut.Value[i][1])
-		// s*a + e_2i
-		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, sk.Value, crp[i], shareOut.Value[i][1])
+			// Second Element
+			// e_2i
+			ekg.gaussianSamplerQ.Read(share[1].Q)
+			ringQP.ExtendBasisSmallNormAndCenter(share[1].Q, levelP, nil, share[1].P)
+			ringQP.NTTLvl(levelQ, levelP, share[1], share[1])
+			// s*a + e_2i
+			ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, sk.Value, crp[i*decompPw2+j], share[1])
+		}
 	}
 }
 
@@ -181,7 +198,7 @@ func (ekg *RKGProtocol) GenShareRoundTwo(ephSk, sk *rlwe.SecretKey, round1 *RKGS
 
 	// Each sample is of the form [-u*a_i + s*w_i + e_i]
 	// So for each element of the base decomposition w_i:
-	for i := 0; i < ekg.params.Beta(); i++ {
+	for i := range shareOut.Value {
 
 		// Computes [(sum samples)*sk + e_1i, sk*a + e_2i]
 
@@ -207,7 +224,7 @@ func (ekg *RKGProtocol) GenShareRoundTwo(ephSk, sk *rlwe.SecretKey, round1 *RKGS
 // AggregateShare combines two RKG shares into a single one.
 func (ekg *RKGProtocol) AggregateShare(share1, share2, shareOut *RKGShare) {
 	ringQP, levelQ, levelP := ekg.params.RingQP(), ekg.params.QCount()-1, ekg.params.PCount()-1
-	for i := 0; i < ekg.params.Beta(); i++ {
+	for i := range shareOut.Value {
 		ringQP.AddLvl(levelQ, levelP, share1.Value[i][0], share2.Value[i][0], shareOut.Value[i][0])
 		ringQP.AddLvl(levelQ, levelP, share1.Value[i][1], share2.Value[i][1], shareOut.Value[i][1])
 	}
@@ -216,7 +233,7 @@ func (ekg *RKGProtocol) AggregateShare(share1, share2, shareOut *RKGShare) {
 // GenRelinearizationKey computes the generated RLK from the public shares and write the result in evalKeyOut.
 func (ekg *RKGProtocol) GenRelinearizationKey(round1 *RKGShare, round2 *RKGShare, evalKeyOut *rlwe.RelinearizationKey) {
 	ringQP, levelQ, levelP := ekg.params.RingQP(), ekg.params.QCount()-1, ekg.params.PCount()-1
-	for i := 0; i < ekg.params.Beta(); i++ {
+	for i := range round2.Value {
 		ringQP.AddLvl(levelQ, levelP, round2.Value[i][0], round2.Value[i][1], evalKeyOut.Keys[0].Value[i][0])
 		evalKeyOut.Keys[0].Value[i][1].Copy(round1.Value[i][1])
 		ringQP.MFormLvl(levelQ, levelP, evalKeyOut.Keys[0].Value[i][0], evalKeyOut.Keys[0].Value[i][0])
@@ -227,15 +244,15 @@ func (ekg *RKGProtocol) GenRelinearizationKey(round1 *RKGShare, round2 *RKGShare
 // MarshalBinary encodes the target element on a slice of bytes.
 func (share *RKGShare) MarshalBinary() ([]byte, error) {
 	//we have modulus * bitLog * Len of 1 ring rings
-	data := make([]byte, 1+2*share.Value[0][0].GetDataLen(true)*len(share.Value))
+	data := make([]byte, rlwe.EnvelopeHeaderLen+1+2*share.Value[0][0].GetDataLen(true)*len(share.Value))
 	if len(share.Value) > 0xFF {
 		return []byte{}, errors.New("RKGShare : uint8 overflow on length")
 	}
-	data[0] = uint8(len(share.Value))
+	data[rlwe.EnvelopeHeaderLen] = uint8(len(share.Value))
 
 	//write all of our rings in the data
 	//write all the polys
-	ptr := 1
+	ptr := rlwe.EnvelopeHeaderLen + 1
 	var inc int
 	var err error
 	for _, elem := range share.Value {
@@ -251,12 +268,25 @@ func (share *RKGShare) MarshalBinary() ([]byte, error) {
 		ptr += inc
 	}
 
+	share.Envelope(rlwe.KindRKGShare, share.Value[0][0].Q).WriteHeader(data)
+
 	return data, nil
 
 }
 
 // UnmarshalBinary decodes a slice of bytes on the target element.
+// It also accepts the legacy encoding without Envelope.
 func (share *RKGShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindRKGShare); err != nil {
+		return err
+	}
+
+	if len(data) < 1 || data[0] == 0 {
+		return errors.New("RKGShare: invalid encoding")
+	}
+
 	share.Value = make([][2]rlwe.PolyQP, data[0])
 	ptr := 1
 	var inc int
@@ -272,5 +302,11 @@ func (share *RKGShare) UnmarshalBinary(data []byte) (err error) {
 		ptr += inc
 	}
 
-	return nil
+	if ptr != len(data) {
+		return errors.New("RKGShare: remaining unparsed data")
+	}
+
+	share.ReadEnvelope(env)
+
+	return env.CheckPoly(share.Value[0][0].Q, 0)
 }
@@ -1,7 +1,9 @@
 package drlwe
 
 import (
+	"encoding/binary"
 	"errors"
+	"sort"
 
 	"github.com/tuneinsight/lattigo/v3/ring"
 	"github.com/tuneinsight/lattigo/v3/rlwe"
@@ -18,18 +20,31 @@ type RotationKeyGenerator interface {
 
 // RTGShare is represent a Party's share in the RTG protocol.
 type RTGShare struct {
+	ShareMetadata
 	Value []rlwe.PolyQP
 }
 
 // RTGCRP is a type for common reference polynomials in the RTG protocol.
 type RTGCRP []rlwe.PolyQP
 
+// RTGMultiShare represents a Party's share in the batched RTG protocol, which generates
+// the rotation keys of several Galois elements at once. It stores one RTGShare per Galois
+// element.
+type RTGMultiShare struct {
+	ShareMetadata
+	Value map[uint64]*RTGShare
+}
+
+// RTGMultiCRP is a type for the common reference polynomials in the batched RTG protocol.
+// It stores one RTGCRP per Galois element.
+type RTGMultiCRP map[uint64]RTGCRP
+
 // RTGProtocol is the structure storing the parameters for the collective rotation-keys generation.
 type RTGProtocol struct {
 	params           rlwe.Parameters
 	tmpPoly0         rlwe.PolyQP
 	tmpPoly1         rlwe.PolyQP
-	gaussianSamplerQ *ring.GaussianSampler
+	gaussianSamplerQ ring.ErrorSampler
 }
 
 // ShallowCopy creates a shallow copy of RTGProtocol in which all the read-only data-structures are
@@ -47,7 +62,7 @@ func (rtg *RTGProtocol) ShallowCopy() *RTGProtocol {
 		params:           rtg.params,
 		tmpPoly0:         params.RingQP().NewPoly(),
 		tmpPoly1:         params.RingQP().NewPoly(),
-		gaussianSamplerQ: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
+		gaussianSamplerQ: rlwe.NewErrorSampler(params, prng),
 	}
 }
 
@@ -60,7 +75,7 @@ func NewRTGProtocol(params rlwe.Parameters) *RTGProtocol {
 	if err != nil {
 		panic(err)
 	}
-	rtg.gaussianSamplerQ = ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma()))
+	rtg.gaussianSamplerQ = rlwe.NewErrorSampler(params, prng)
 	rtg.tmpPoly0 = params.RingQP().NewPoly()
 	rtg.tmpPoly1 = params.RingQP().NewPoly()
 	return rtg
@@ -68,8 +83,8 @@ func NewRTGProtocol(params rlwe.Parameters) *RTGProtocol {
 
 // AllocateShare allocates a party's share in the RTG protocol.
 func (rtg *RTGProtocol) AllocateShare() (rtgShare *RTGShare) {
-	rtgShare = new(RTGShare)
-	rtgShare.Value = make([]rlwe.PolyQP, rtg.params.Beta())
+	rtgShare = &RTGShare{ShareMetadata: NewShareMetadata(rtg.params)}
+	rtgShare.Value = make([]rlwe.PolyQP, rtg.params.Beta()*rtg.params.DecompPw2())
 	for i := range rtgShare.Value {
 		rtgShare.Value[i] = rtg.params.RingQP().NewPoly()
 	}
@@ -79,7 +94,7 @@ func (rtg *RTGProtocol) AllocateShare() (rtgShare *RTGShare) {
 // SampleCRP samples a common random polynomial to be used in the RTG protocol from the provided
 // common reference string.
 func (rtg *RTGProtocol) SampleCRP(crs CRS) RTGCRP {
-	crp := make([]rlwe.PolyQP, rtg.params.Beta())
+	crp := make([]rlwe.PolyQP, rtg.params.Beta()*rtg.params.DecompPw2())
 	us := rlwe.NewUniformSamplerQP(rtg.params, crs)
 	for i := range crp {
 		crp[i] = rtg.params.RingQP().NewPoly()
@@ -100,71 +115,277 @@ func (rtg *RTGProtocol) GenShare(sk *rlwe.SecretKey, galEl uint64, crp RTGCRP, s
 	galElInv := ring.ModExp(galEl, ringQ.NthRoot-1, ringQ.NthRoot)
 
 	ringQ.PermuteNTT(sk.Value.Q, galElInv, rtg.tmpPoly1.Q)
-	ringP.PermuteNTT(sk.Value.P, galElInv, rtg.tmpPoly1.P)
 
-	ringQ.MulScalarBigint(sk.Value.Q, ringP.ModulusBigint, rtg.tmpPoly0.Q)
+	if ringP != nil {
+		ringP.PermuteNTT(sk.Value.P, galElInv, rtg.tmpPoly1.P)
+		ringQ.MulScalarBigint(sk.Value.Q, ringP.ModulusBigint, rtg.tmpPoly0.Q)
+	} else {
+		rtg.tmpPoly0.Q.Copy(sk.Value.Q)
+	}
+
+	// Without P, each element of the RNS decomposition basis is a single modulus of Q
+	alpha := utils.MaxInt(rtg.params.PCount(), 1)
+	decompPw2 := rtg.params.DecompPw2()
 
 	var index int
 
 	for i := 0; i < rtg.params.Beta(); i++ {
 
-		// e
-		rtg.gaussianSamplerQ.Read(shareOut.Value[i].Q)
-		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i].Q, levelP, nil, shareOut.Value[i].P)
-		ringQP.NTTLazyLvl(levelQ, levelP, shareOut.Value[i], shareOut.Value[i])
-		ringQP.MFormLvl(levelQ, levelP, shareOut.Value[i], shareOut.Value[i])
+		for j := 0; j < decompPw2; j++ {
 
-		// a is the CRP
+			share := shareOut.Value[i*decompPw2+j]
 
-		// e + sk_in * (qiBarre*qiStar) * 2^w
-		// (qiBarre*qiStar)%qi = 1, else 0
-		for j := 0; j < rtg.params.PCount(); j++ {
+			// e
+			rtg.gaussianSamplerQ.Read(share.Q)
+			ringQP.ExtendBasisSmallNormAndCenter(share.Q, levelP, nil, share.P)
+			ringQP.NTTLazyLvl(levelQ, levelP, share, share)
+			ringQP.MFormLvl(levelQ, levelP, share, share)
 
-			index = i*rtg.params.PCount() + j
+			// a is the CRP
 
-			// Handles the case where nb pj does not divides nb qi
-			if index >= rtg.params.QCount() {
-				break
-			}
+			// e + sk_in * (qiBarre*qiStar) * 2^w
+			// (qiBarre*qiStar)%qi = 1, else 0
+			for k := 0; k < alpha; k++ {
 
-			qi := ringQ.Modulus[index]
-			tmp0 := rtg.tmpPoly0.Q.Coeffs[index]
-			tmp1 := shareOut.Value[i].Q.Coeffs[index]
+				index = i*alpha + k
 
-			for w := 0; w < ringQ.N; w++ {
-				tmp1[w] = ring.CRed(tmp1[w]+tmp0[w], qi)
+				// Handles the case where nb pj does not divides nb qi
+				if index >= rtg.params.QCount() {
+					break
+				}
+
+				qi := ringQ.Modulus[index]
+				tmp0 := rtg.tmpPoly0.Q.Coeffs[index]
+				tmp1 := share.Q.Coeffs[index]
+
+				for w := 0; w < ringQ.N; w++ {
+					tmp1[w] = ring.CRed(tmp1[w]+tmp0[w], qi)
+				}
+
+				// sk_in * 2^{w*(j+1)} mod qi for the next power-of-two digit
+				if j < decompPw2-1 {
+					ring.MulScalarMontgomeryVec(tmp0, tmp0, ring.MForm(1<<rtg.params.Pow2Base(), qi, ringQ.BredParams[index]), qi, ringQ.MredParams[index])
+				}
 			}
-		}
 
-		// sk_in * (qiBarre*qiStar) * 2^w - a*sk + e
-		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, crp[i], rtg.tmpPoly1, shareOut.Value[i])
+			// sk_in * (qiBarre*qiStar) * 2^w - a*sk + e
+			ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, crp[i*decompPw2+j], rtg.tmpPoly1, share)
+		}
 	}
 }
 
 // AggregateShare aggregates two share in the Rotation Key Generation protocol.
 func (rtg *RTGProtocol) AggregateShare(share1, share2, shareOut *RTGShare) {
 	ringQP, levelQ, levelP := rtg.params.RingQP(), rtg.params.QCount()-1, rtg.params.PCount()-1
-	for i := 0; i < rtg.params.Beta(); i++ {
+	for i := range shareOut.Value {
 		ringQP.AddLvl(levelQ, levelP, share1.Value[i], share2.Value[i], shareOut.Value[i])
 	}
 }
 
 // GenRotationKey finalizes the RTG protocol and populates the input RotationKey with the computed collective SwitchingKey.
 func (rtg *RTGProtocol) GenRotationKey(share *RTGShare, crp RTGCRP, rotKey *rlwe.SwitchingKey) {
-	for i := 0; i < rtg.params.Beta(); i++ {
+	for i := range share.Value {
 		rotKey.Value[i][0].CopyValues(share.Value[i])
 		rotKey.Value[i][1].CopyValues(crp[i])
 	}
 }
 
+// AllocateMultiShare allocates a party's share in the batched RTG protocol for the given Galois elements.
+func (rtg *RTGProtocol) AllocateMultiShare(galEls []uint64) (share *RTGMultiShare) {
+	share = &RTGMultiShare{ShareMetadata: NewShareMetadata(rtg.params), Value: make(map[uint64]*RTGShare, len(galEls))}
+	for _, galEl := range galEls {
+		share.Value[galEl] = rtg.AllocateShare()
+	}
+	return
+}
+
+// SampleMultiCRP samples the common random polynomials to be used in the batched RTG protocol
+// for the given Galois elements from the provided common reference string. The CRPs are sampled
+// in increasing order of Galois element, so that all the parties obtain the same CRPs from the
+// same CRS regardless of the order of galEls.
+func (rtg *RTGProtocol) SampleMultiCRP(galEls []uint64, crs CRS) RTGMultiCRP {
+	crp := make(RTGMultiCRP, len(galEls))
+	for _, galEl := range sortedGaloisElements(galEls) {
+		if _, exists := crp[galEl]; !exists {
+			crp[galEl] = rtg.SampleCRP(crs)
+		}
+	}
+	return crp
+}
+
+// GenMultiShare generates a party's share in the batched RTG protocol for all the Galois elements of crp.
+// The Galois elements are split across at most params.Parallelism() workers with utils.ParallelFor, each
+// worker but the calling one generating its shares with its own shallow copy of the receiver.
+func (rtg *RTGProtocol) GenMultiShare(sk *rlwe.SecretKey, crp RTGMultiCRP, shareOut *RTGMultiShare) {
+
+	galEls := make([]uint64, 0, len(crp))
+	for galEl := range crp {
+		if _, inShare := shareOut.Value[galEl]; !inShare {
+			panic("cannot GenMultiShare: shareOut is not allocated for all the Galois elements of crp")
+		}
+		galEls = append(galEls, galEl)
+	}
+
+	workers := utils.MaxInt(1, utils.MinInt(rtg.params.Parallelism(), len(galEls)))
+
+	utils.ParallelFor(workers, workers, func(w int) {
+		worker := rtg
+		if w > 0 {
+			worker = rtg.ShallowCopy()
+		}
+		for i := w; i < len(galEls); i += workers {
+			worker.GenShare(sk, galEls[i], crp[galEls[i]], shareOut.Value[galEls[i]])
+		}
+	})
+}
+
+// AggregateMultiShare aggregates two shares in the batched RTG protocol. The three shares must be
+// for the same Galois elements.
+func (rtg *RTGProtocol) AggregateMultiShare(share1, share2, shareOut *RTGMultiShare) {
+	if len(share1.Value) != len(shareOut.Value) || len(share2.Value) != len(shareOut.Value) {
+		panic("cannot AggregateMultiShare: the shares are not for the same Galois elements")
+	}
+	for galEl, out := range shareOut.Value {
+		in1, inShare1 := share1.Value[galEl]
+		in2, inShare2 := share2.Value[galEl]
+		if !inShare1 || !inShare2 {
+			panic("cannot AggregateMultiShare: the shares are not for the same Galois elements")
+		}
+		rtg.AggregateShare(in1, in2, out)
+	}
+}
+
+// GenRotationKeySet finalizes the batched RTG protocol and populates the input RotationKeySet with the
+// computed collective SwitchingKeys. The switching keys are allocated in rotKeys if not already present.
+// crp must contain the CRPs of all the Galois elements of share.
+func (rtg *RTGProtocol) GenRotationKeySet(share *RTGMultiShare, crp RTGMultiCRP, rotKeys *rlwe.RotationKeySet) {
+	for galEl := range share.Value {
+		if _, inCRP := crp[galEl]; !inCRP {
+			panic("cannot GenRotationKeySet: crp does not contain all the Galois elements of share")
+		}
+	}
+	if rotKeys.Keys == nil {
+		rotKeys.Keys = make(map[uint64]*rlwe.SwitchingKey, len(share.Value))
+	}
+	for galEl, galShare := range share.Value {
+		swk, inSet := rotKeys.Keys[galEl]
+		if !inSet {
+			swk = rlwe.NewSwitchingKey(rtg.params, rtg.params.QCount()-1, rtg.params.PCount()-1)
+			rotKeys.Keys[galEl] = swk
+		}
+		rtg.GenRotationKey(galShare, crp[galEl], swk)
+	}
+}
+
+func sortedGaloisElements(galEls []uint64) (sorted []uint64) {
+	sorted = make([]uint64, len(galEls))
+	copy(sorted, galEls)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return
+}
+
+// MarshalBinary encodes the target element on a slice of bytes. The shares are
+// written in increasing order of Galois element.
+func (share *RTGMultiShare) MarshalBinary() (data []byte, err error) {
+
+	galEls := make([]uint64, 0, len(share.Value))
+	for galEl := range share.Value {
+		galEls = append(galEls, galEl)
+	}
+	galEls = sortedGaloisElements(galEls)
+
+	data = make([]byte, rlwe.EnvelopeHeaderLen+4)
+	binary.BigEndian.PutUint32(data[rlwe.EnvelopeHeaderLen:], uint32(len(galEls)))
+
+	for _, galEl := range galEls {
+
+		var shareData []byte
+		if shareData, err = share.Value[galEl].MarshalBinary(); err != nil {
+			return nil, err
+		}
+
+		header := make([]byte, 12)
+		binary.BigEndian.PutUint64(header, galEl)
+		binary.BigEndian.PutUint32(header[8:], uint32(len(shareData)))
+
+		data = append(data, header...)
+		data = append(data, shareData...)
+	}
+
+	share.Envelope(rlwe.KindRTGMultiShare, share.envelopePoly()).WriteHeader(data)
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+// It also accepts the legacy encoding without Envelope.
+func (share *RTGMultiShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindRTGMultiShare); err != nil {
+		return err
+	}
+
+	if len(data) < 4 {
+		return errors.New("RTGMultiShare: too small bytearray")
+	}
+
+	nbShares := int(binary.BigEndian.Uint32(data))
+	share.Value = make(map[uint64]*RTGShare, nbShares)
+
+	ptr := 4
+	for i := 0; i < nbShares; i++ {
+
+		if len(data) < ptr+12 {
+			return errors.New("RTGMultiShare: too small bytearray")
+		}
+
+		galEl := binary.BigEndian.Uint64(data[ptr:])
+		shareLen := int(binary.BigEndian.Uint32(data[ptr+8:]))
+		ptr += 12
+
+		if len(data) < ptr+shareLen {
+			return errors.New("RTGMultiShare: too small bytearray")
+		}
+
+		galShare := new(RTGShare)
+		if err = galShare.UnmarshalBinary(data[ptr : ptr+shareLen]); err != nil {
+			return err
+		}
+		share.Value[galEl] = galShare
+		ptr += shareLen
+	}
+
+	if ptr != len(data) {
+		return errors.New("RTGMultiShare: remaining unparsed data")
+	}
+
+	share.ReadEnvelope(env)
+
+	return env.CheckPoly(share.envelopePoly(), 0)
+}
+
+// envelopePoly returns the polynomial defining the LogN and Level of the Envelope of the target RTGMultiShare,
+// which is the one of the share of smallest Galois element.
+func (share *RTGMultiShare) envelopePoly() *ring.Poly {
+	var minGalEl uint64
+	var pol *ring.Poly
+	for galEl, galShare := range share.Value {
+		if (pol == nil || galEl < minGalEl) && len(galShare.Value) > 0 {
+			minGalEl, pol = galEl, galShare.Value[0].Q
+		}
+	}
+	return pol
+}
+
 // MarshalBinary encode the target element on a slice of byte.
 func (share *RTGShare) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, 1+share.Value[0].GetDataLen(true)*len(share.Value))
+	data = make([]byte, rlwe.EnvelopeHeaderLen+1+share.Value[0].GetDataLen(true)*len(share.Value))
 	if len(share.Value) > 0xFF {
 		return []byte{}, errors.New("RKGShare : uint8 overflow on length")
 	}
-	data[0] = uint8(len(share.Value))
-	ptr := 1
+	data[rlwe.EnvelopeHeaderLen] = uint8(len(share.Value))
+	ptr := rlwe.EnvelopeHeaderLen + 1
 	var inc int
 	for _, val := range share.Value {
 		if inc, err = val.WriteTo(data[ptr:]); err != nil {
@@ -173,11 +394,24 @@ func (share *RTGShare) MarshalBinary() (data []byte, err error) {
 		ptr += inc
 	}
 
+	share.Envelope(rlwe.KindRTGShare, share.Value[0].Q).WriteHeader(data)
+
 	return data, nil
 }
 
 // UnmarshalBinary decodes a slice of bytes on the target element.
+// It also accepts the legacy encoding without Envelope.
 func (share *RTGShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindRTGShare); err != nil {
+		return err
+	}
+
+	if len(data) < 1 || data[0] == 0 {
+		return errors.New("RTGShare: invalid encoding")
+	}
+
 	share.Value = make([]rlwe.PolyQP, data[0])
 	ptr := 1
 	var inc int
@@ -188,5 +422,11 @@ func (share *RTGShare) UnmarshalBinary(data []byte) (err error) {
 		ptr += inc
 	}
 
-	return nil
+	if ptr != len(data) {
+		return errors.New("RTGShare: remaining unparsed data")
+	}
+
+	share.ReadEnvelope(env)
+
+	return env.CheckPoly(share.Value[0].Q, 0)
 }
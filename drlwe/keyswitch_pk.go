@@ -16,6 +16,7 @@ type PublicKeySwitchingProtocol interface {
 
 // PCKSShare represents a party's share in the PCKS protocol.
 type PCKSShare struct {
+	ShareMetadata
 	Value [2]*ring.Poly
 }
 
@@ -85,7 +86,7 @@ func NewPCKSProtocol(params rlwe.Parameters, sigmaSmudging float64) (pcks *PCKSP
 
 // AllocateShare allocates the shares of the PCKS protocol.
 func (pcks *PCKSProtocol) AllocateShare(levelQ int) (s *PCKSShare) {
-	return &PCKSShare{[2]*ring.Poly{pcks.params.RingQ().NewPolyLvl(levelQ), pcks.params.RingQ().NewPolyLvl(levelQ)}}
+	return &PCKSShare{ShareMetadata: NewShareMetadata(pcks.params), Value: [2]*ring.Poly{pcks.params.RingQ().NewPolyLvl(levelQ), pcks.params.RingQ().NewPolyLvl(levelQ)}}
 }
 
 // GenShare is the first part of the unique round of the PCKSProtocol protocol. Each party computes the following :
@@ -191,8 +192,9 @@ func (pcks *PCKSProtocol) KeySwitch(ctIn *rlwe.Ciphertext, combined *PCKSShare,
 
 // MarshalBinary encodes a PCKS share on a slice of bytes.
 func (share *PCKSShare) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, share.Value[0].GetDataLen(true)+share.Value[1].GetDataLen(true))
-	var inc, pt int
+	data = make([]byte, rlwe.EnvelopeHeaderLen+share.Value[0].GetDataLen(true)+share.Value[1].GetDataLen(true))
+	var inc int
+	pt := rlwe.EnvelopeHeaderLen
 	if inc, err = share.Value[0].WriteTo(data[pt:]); err != nil {
 		return nil, err
 	}
@@ -201,11 +203,19 @@ func (share *PCKSShare) MarshalBinary() (data []byte, err error) {
 	if _, err = share.Value[1].WriteTo(data[pt:]); err != nil {
 		return nil, err
 	}
+	share.Envelope(rlwe.KindPCKSShare, share.Value[0]).WriteHeader(data)
 	return
 }
 
 // UnmarshalBinary decodes marshaled PCKS share on the target PCKS share.
+// It also accepts the legacy encoding without Envelope.
 func (share *PCKSShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindPCKSShare); err != nil {
+		return err
+	}
+
 	var pt, inc int
 	share.Value[0] = new(ring.Poly)
 	if inc, err = share.Value[0].DecodePolyNew(data[pt:]); err != nil {
@@ -217,5 +227,8 @@ func (share *PCKSShare) UnmarshalBinary(data []byte) (err error) {
 	if _, err = share.Value[1].DecodePolyNew(data[pt:]); err != nil {
 		return
 	}
-	return
+
+	share.ReadEnvelope(env)
+
+	return env.CheckPoly(share.Value[0], 0)
 }
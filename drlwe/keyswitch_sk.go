@@ -46,6 +46,7 @@ func (cks *CKSProtocol) ShallowCopy() *CKSProtocol {
 
 // CKSShare is a type for the CKS protocol shares.
 type CKSShare struct {
+	ShareMetadata
 	Value *ring.Poly
 }
 
@@ -54,13 +55,31 @@ type CKSCRP ring.Poly
 
 // MarshalBinary encodes a CKS share on a slice of bytes.
 func (ckss *CKSShare) MarshalBinary() (data []byte, err error) {
-	return ckss.Value.MarshalBinary()
+	data = make([]byte, rlwe.EnvelopeHeaderLen+ckss.Value.GetDataLen(true))
+	if _, err = ckss.Value.WriteTo(data[rlwe.EnvelopeHeaderLen:]); err != nil {
+		return nil, err
+	}
+	ckss.Envelope(rlwe.KindCKSShare, ckss.Value).WriteHeader(data)
+	return
 }
 
 // UnmarshalBinary decodes marshaled CKS share on the target CKS share.
+// It also accepts the legacy encoding without Envelope.
 func (ckss *CKSShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindCKSShare); err != nil {
+		return err
+	}
+
 	ckss.Value = new(ring.Poly)
-	return ckss.Value.UnmarshalBinary(data)
+	if err = ckss.Value.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	ckss.ReadEnvelope(env)
+
+	return env.CheckPoly(ckss.Value, 0)
 }
 
 // NewCKSProtocol creates a new CKSProtocol that will be used to perform a collective key-switching on a ciphertext encrypted under a collective public-key, whose
@@ -86,7 +105,7 @@ func NewCKSProtocol(params rlwe.Parameters, sigmaSmudging float64) *CKSProtocol
 
 // AllocateShare allocates the shares of the CKSProtocol
 func (cks *CKSProtocol) AllocateShare(level int) *CKSShare {
-	return &CKSShare{cks.params.RingQ().NewPolyLvl(level)}
+	return &CKSShare{ShareMetadata: NewShareMetadata(cks.params), Value: cks.params.RingQ().NewPolyLvl(level)}
 }
 
 // SampleCRP samples a common random polynomial to be used in the CKS protocol from the provided
@@ -0,0 +1,37 @@
+package drlwe
+
+import (
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+// ShareMetadata records the parameters with which a share was generated. It is set by the AllocateShare methods
+// of the protocols and written in the Envelope of the encoded share, so that rlwe.Parameters.CheckEnvelope and
+// rlwe.Parameters.UnmarshalObject reject the shares of other parameters. The zero value denotes unknown parameters
+// (e.g., for the shares decoded from legacy encodings).
+type ShareMetadata struct {
+	ParametersFingerprint uint64    // Fingerprint of the parameters of the share, 0 if unknown
+	RingType              ring.Type // ring type of the parameters of the share, ignored if ParametersFingerprint is 0
+}
+
+// NewShareMetadata returns the ShareMetadata of the shares generated with the parameters params.
+func NewShareMetadata(params rlwe.Parameters) ShareMetadata {
+	return ShareMetadata{ParametersFingerprint: params.Fingerprint(), RingType: params.RingType()}
+}
+
+// Envelope returns the Envelope of an encoded share of the given kind whose LogN and Level are the ones of pol.
+func (m ShareMetadata) Envelope(kind rlwe.ObjectKind, pol *ring.Poly) (env rlwe.Envelope) {
+	env = rlwe.NewEnvelope(kind, pol, 0)
+	if m.ParametersFingerprint != 0 {
+		env.ParametersHash, env.RingType = m.ParametersFingerprint, m.RingType
+	}
+	return
+}
+
+// ReadEnvelope sets the target ShareMetadata from the Envelope of an encoded share.
+func (m *ShareMetadata) ReadEnvelope(env rlwe.Envelope) {
+	*m = ShareMetadata{}
+	if env.ParametersHash != 0 && env.RingType != rlwe.UnspecifiedRingType {
+		m.ParametersFingerprint, m.RingType = env.ParametersHash, env.RingType
+	}
+}
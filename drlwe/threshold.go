@@ -0,0 +1,196 @@
+package drlwe
+
+import (
+	"errors"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// ShamirPublicPoint is a type for Shamir public point associated with a party identity within
+// the t-out-of-N-threshold scheme. Public points must be non-zero and distinct among the parties.
+type ShamirPublicPoint uint64
+
+// ShamirPolynomial represents a polynomial with rlwe.PolyQP coefficients. It is used by a party to
+// secret-share its secret-key (the constant coefficient) in the t-out-of-N-threshold scheme.
+type ShamirPolynomial struct {
+	Coeffs []rlwe.PolyQP
+}
+
+// ShamirSecretShare represents a t-out-of-N-threshold secret-share of a secret-key.
+type ShamirSecretShare struct {
+	ShareMetadata
+	rlwe.PolyQP
+}
+
+// Thresholdizer is the structure storing the parameters and state for the generation of the
+// Shamir secret-shares of a party's secret-key in the t-out-of-N-threshold scheme.
+type Thresholdizer struct {
+	params   rlwe.Parameters
+	ringQP   *rlwe.RingQP
+	usampler rlwe.UniformSamplerQP
+}
+
+// NewThresholdizer creates a new Thresholdizer instance.
+func NewThresholdizer(params rlwe.Parameters) *Thresholdizer {
+	thr := new(Thresholdizer)
+	thr.params = params
+	thr.ringQP = params.RingQP()
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	thr.usampler = rlwe.NewUniformSamplerQP(params, prng)
+	return thr
+}
+
+// ShallowCopy creates a shallow copy of Thresholdizer in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// Thresholdizer can be used concurrently.
+func (thr *Thresholdizer) ShallowCopy() *Thresholdizer {
+	return NewThresholdizer(thr.params)
+}
+
+// GenShamirPolynomial generates a new secret ShamirPolynomial of degree threshold-1 whose constant
+// coefficient is the provided secret-key, to be used in the t-out-of-N-threshold scheme with t = threshold.
+func (thr *Thresholdizer) GenShamirPolynomial(threshold int, secret *rlwe.SecretKey) (*ShamirPolynomial, error) {
+	if threshold < 1 {
+		return nil, errors.New("threshold should be >= 1")
+	}
+	gen := &ShamirPolynomial{Coeffs: make([]rlwe.PolyQP, threshold)}
+	gen.Coeffs[0] = secret.Value.CopyNew()
+	for i := 1; i < threshold; i++ {
+		gen.Coeffs[i] = thr.ringQP.NewPoly()
+		thr.usampler.Read(&gen.Coeffs[i])
+	}
+	return gen, nil
+}
+
+// AllocateThresholdSecretShare allocates a ShamirSecretShare struct.
+func (thr *Thresholdizer) AllocateThresholdSecretShare() *ShamirSecretShare {
+	return &ShamirSecretShare{ShareMetadata: NewShareMetadata(thr.params), PolyQP: thr.ringQP.NewPoly()}
+}
+
+// GenShamirSecretShare generates a secret share for the given recipient, identified by its ShamirPublicPoint,
+// by evaluating the secret ShamirPolynomial at the recipient's point.
+func (thr *Thresholdizer) GenShamirSecretShare(recipient ShamirPublicPoint, secretPoly *ShamirPolynomial, shareOut *ShamirSecretShare) {
+
+	if recipient == 0 {
+		panic("cannot GenShamirSecretShare: the public point 0 would reveal the secret")
+	}
+
+	ringQ, ringP := thr.ringQP.RingQ, thr.ringQP.RingP
+	levelQ, levelP := thr.params.QCount()-1, thr.params.PCount()-1
+
+	// Horner evaluation of the secret polynomial at the recipient's point
+	thr.ringQP.CopyValuesLvl(levelQ, levelP, secretPoly.Coeffs[len(secretPoly.Coeffs)-1], shareOut.PolyQP)
+	for i := len(secretPoly.Coeffs) - 2; i >= 0; i-- {
+		ringQ.MulScalarLvl(levelQ, shareOut.Q, uint64(recipient), shareOut.Q)
+		if ringP != nil {
+			ringP.MulScalarLvl(levelP, shareOut.P, uint64(recipient), shareOut.P)
+		}
+		thr.ringQP.AddLvl(levelQ, levelP, shareOut.PolyQP, secretPoly.Coeffs[i], shareOut.PolyQP)
+	}
+}
+
+// AggregateShares aggregates two ShamirSecretShare and stores the result in outShare. A party obtains its
+// threshold share of the collective secret-key by aggregating the shares it received from all the parties.
+func (thr *Thresholdizer) AggregateShares(share1, share2, outShare *ShamirSecretShare) {
+	thr.ringQP.AddLvl(thr.params.QCount()-1, thr.params.PCount()-1, share1.PolyQP, share2.PolyQP, outShare.PolyQP)
+}
+
+// Combiner is the structure storing the parameters and state for the conversion of a party's
+// ShamirSecretShare into an additive share of the collective secret-key, given the set of
+// the active parties.
+type Combiner struct {
+	params    rlwe.Parameters
+	ringQP    *rlwe.RingQP
+	threshold int
+}
+
+// NewCombiner creates a new Combiner instance for the t-out-of-N-threshold scheme with t = threshold.
+func NewCombiner(params rlwe.Parameters, threshold int) *Combiner {
+	return &Combiner{params: params, ringQP: params.RingQP(), threshold: threshold}
+}
+
+// GenAdditiveShare generates, from the ShamirSecretShare of the party of public point ownPoint, its additive share of the
+// collective secret-key with respect to the set of active parties. The sum of the additive shares of all the active
+// parties is equal to the collective secret-key. The set of active parties must contain ownPoint and at least
+// threshold points.
+func (cmb *Combiner) GenAdditiveShare(activePoints []ShamirPublicPoint, ownPoint ShamirPublicPoint, ownShare *ShamirSecretShare, skOut *rlwe.SecretKey) {
+
+	if len(activePoints) < cmb.threshold {
+		panic("cannot GenAdditiveShare: not enough active parties to reach the threshold")
+	}
+
+	var isActive bool
+	for _, point := range activePoints {
+		isActive = isActive || point == ownPoint
+	}
+
+	if !isActive {
+		panic("cannot GenAdditiveShare: ownPoint is not in the set of active parties")
+	}
+
+	lagrangeMul(cmb.ringQP.RingQ, cmb.params.QCount()-1, activePoints, ownPoint, ownShare.Q, skOut.Value.Q)
+	if cmb.ringQP.RingP != nil {
+		lagrangeMul(cmb.ringQP.RingP, cmb.params.PCount()-1, activePoints, ownPoint, ownShare.P, skOut.Value.P)
+	}
+}
+
+// lagrangeMul multiplies p1 by the Lagrange coefficient prod_{j != own} x_j / (x_j - x_own) of the
+// point own with respect to the given points and writes the result on p2.
+func lagrangeMul(r *ring.Ring, level int, points []ShamirPublicPoint, own ShamirPublicPoint, p1, p2 *ring.Poly) {
+	for i := 0; i < level+1; i++ {
+
+		qi := r.Modulus[i]
+		bredParams := r.BredParams[i]
+
+		num, den := uint64(1), uint64(1)
+		for _, point := range points {
+			if point != own {
+				xj := ring.BRedAdd(uint64(point), qi, bredParams)
+				xOwn := ring.BRedAdd(uint64(own), qi, bredParams)
+				num = ring.BRed(num, xj, qi, bredParams)
+				den = ring.BRed(den, ring.CRed(xj+qi-xOwn, qi), qi, bredParams)
+			}
+		}
+
+		if den == 0 {
+			panic("cannot GenAdditiveShare: public points must be distinct modulo all the moduli")
+		}
+
+		lambda := ring.BRed(num, ring.ModExp(den, qi-2, qi), qi, bredParams)
+
+		ring.MulScalarMontgomeryVec(p1.Coeffs[i][:r.N], p2.Coeffs[i][:r.N], ring.MForm(lambda, qi, bredParams), qi, r.MredParams[i])
+	}
+}
+
+// MarshalBinary encodes the target element on a slice of bytes.
+func (share *ShamirSecretShare) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, rlwe.EnvelopeHeaderLen+share.GetDataLen(true))
+	if _, err = share.WriteTo(data[rlwe.EnvelopeHeaderLen:]); err != nil {
+		return nil, err
+	}
+	share.Envelope(rlwe.KindShamirSecretShare, share.Q).WriteHeader(data)
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes on the target element.
+// It also accepts the legacy encoding without Envelope.
+func (share *ShamirSecretShare) UnmarshalBinary(data []byte) (err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, rlwe.KindShamirSecretShare); err != nil {
+		return err
+	}
+
+	if _, err = share.DecodePolyNew(data); err != nil {
+		return err
+	}
+
+	share.ReadEnvelope(env)
+
+	return env.CheckPoly(share.Q, 0)
+}
@@ -66,13 +66,19 @@ func obliviousRiding() {
 
 	riderSk, riderPk := kgen.GenKeyPair()
 
-	decryptor := bfv.NewDecryptor(params, riderSk)
+	decryptor, err := bfv.NewDecryptor(params, riderSk)
+	if err != nil {
+		panic(err)
+	}
 
 	encryptorRiderPk := bfv.NewEncryptor(params, riderPk)
 
 	encryptorRiderSk := bfv.NewEncryptor(params, riderSk)
 
-	evaluator := bfv.NewEvaluator(params, rlwe.EvaluationKey{})
+	evaluator, err := bfv.NewEvaluator(params, rlwe.EvaluationKey{})
+	if err != nil {
+		panic(err)
+	}
 
 	fmt.Println("============================================")
 	fmt.Println("Homomorphic computations on batched integers")
@@ -74,7 +74,10 @@ func main() {
 	kgenRLWE := ckks.NewKeyGenerator(paramsRLWE)
 	skRLWE := kgenRLWE.GenSecretKey()
 	encryptor := ckks.NewEncryptor(paramsRLWE, skRLWE)
-	decryptor := ckks.NewDecryptor(paramsRLWE, skRLWE)
+	decryptor, err := ckks.NewDecryptor(paramsRLWE, skRLWE)
+	if err != nil {
+		panic(err)
+	}
 
 	fmt.Printf("Gen SlotsToCoeffs Matrices... ")
 	start = time.Now()
@@ -102,7 +105,10 @@ func main() {
 
 	fmt.Printf("Done (%s)\n", time.Since(start))
 
-	eval := ckksAdvanced.NewEvaluator(paramsRLWE, rlwe.EvaluationKey{Rlk: rlk, Rtks: rotKey})
+	eval, err := ckksAdvanced.NewEvaluator(paramsRLWE, rlwe.EvaluationKey{Rlk: rlk, Rtks: rotKey})
+	if err != nil {
+		panic(err)
+	}
 
 	// LWE Parameters
 	kgenLWE := ckks.NewKeyGenerator(paramsLWE)
@@ -242,7 +248,10 @@ func main() {
 	linTransf := ckks.GenLinearTransformBSGS(encoder, AMatDiag, paramsRLWE.MaxLevel(), 1.0, 16.0, paramsLWE.LogN())
 	fmt.Printf("Done (%s)\n", time.Since(start))
 
-	evalRepack := ckks.NewEvaluator(paramsRLWE, rlwe.EvaluationKey{Rlk: rlk, Rtks: rotKeyRepack})
+	evalRepack, err := ckks.NewEvaluator(paramsRLWE, rlwe.EvaluationKey{Rlk: rlk, Rtks: rotKeyRepack})
+	if err != nil {
+		panic(err)
+	}
 
 	fmt.Printf("Homomorphic Partial Decryption : pt = A x sk + encode(LWE) + I(X)*Q... ")
 	start = time.Now()
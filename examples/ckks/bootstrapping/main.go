@@ -45,7 +45,9 @@ func main() {
 	sk, pk = kgen.GenKeyPair()
 
 	encoder = ckks.NewEncoder(params)
-	decryptor = ckks.NewDecryptor(params, sk)
+	if decryptor, err = ckks.NewDecryptor(params, sk); err != nil {
+		panic(err)
+	}
 	encryptor = ckks.NewEncryptor(params, pk)
 
 	fmt.Println()
@@ -44,11 +44,17 @@ func example() {
 
 	encryptor := ckks.NewEncryptor(params, sk)
 
-	decryptor := ckks.NewDecryptor(params, sk)
+	decryptor, err := ckks.NewDecryptor(params, sk)
+	if err != nil {
+		panic(err)
+	}
 
 	encoder := ckks.NewEncoder(params)
 
-	evaluator := ckks.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk})
+	evaluator, err := ckks.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk})
+	if err != nil {
+		panic(err)
+	}
 
 	fmt.Printf("Done in %s \n", time.Since(start))
 
@@ -38,10 +38,16 @@ func chebyshevinterpolation() {
 	encryptor := ckks.NewEncryptor(params, pk)
 
 	// Decryptor
-	decryptor := ckks.NewDecryptor(params, sk)
+	decryptor, err := ckks.NewDecryptor(params, sk)
+	if err != nil {
+		panic(err)
+	}
 
 	// Evaluator
-	evaluator := ckks.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk})
+	evaluator, err := ckks.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk})
+	if err != nil {
+		panic(err)
+	}
 
 	// Values to encrypt
 	values := make([]float64, params.Slots())
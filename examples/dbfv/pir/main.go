@@ -177,7 +177,10 @@ func main() {
 	l.Println("> Result:")
 
 	// Decryption by the external party
-	decryptor := bfv.NewDecryptor(params, P[0].sk)
+	decryptor, err := bfv.NewDecryptor(params, P[0].sk)
+	if err != nil {
+		panic(err)
+	}
 	ptres := bfv.NewPlaintext(params)
 	elapsedDecParty := runTimed(func() {
 		decryptor.Decrypt(encOut, ptres)
@@ -389,7 +392,10 @@ func requestphase(params bfv.Parameters, queryIndex, NGoRoutine int, encQuery *b
 		encPartial[i] = bfv.NewCiphertext(params, 2)
 	}
 
-	evaluator := bfv.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: rtk})
+	evaluator, err := bfv.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: rtk})
+	if err != nil {
+		panic(err)
+	}
 
 	// Split the task among the Go routines
 	tasks := make(chan *maskTask)
@@ -130,7 +130,10 @@ func main() {
 
 	// Decrypt the result with the target secret key
 	l.Println("> Result:")
-	decryptor := bfv.NewDecryptor(params, tsk)
+	decryptor, err := bfv.NewDecryptor(params, tsk)
+	if err != nil {
+		panic(err)
+	}
 	ptres := bfv.NewPlaintext(params)
 	elapsedDecParty := runTimed(func() {
 		decryptor.Decrypt(encOut, ptres)
@@ -195,7 +198,10 @@ func evalPhase(params bfv.Parameters, NGoRoutine int, encInputs []*bfv.Ciphertex
 	}
 	encRes = encLvls[len(encLvls)-1][0]
 
-	evaluator := bfv.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: nil})
+	evaluator, err := bfv.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk, Rtks: nil})
+	if err != nil {
+		panic(err)
+	}
 	// Split the task among the Go routines
 	tasks := make(chan *multTask)
 	workers := &sync.WaitGroup{}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/ckks/bootstrapping"
+	"github.com/tuneinsight/lattigo/v3/dckks"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+var flagSecure = flag.Bool("secure", false, "run the example with the secure logN=16 parameters (memory intensive).")
+
+func main() {
+
+	flag.Parse()
+
+	nParties := 3
+
+	// Bootstrapping parameters
+	// By default, the ring degree is reduced to 2^13 so that the example runs quickly;
+	// these parameters are insecure and only meant for demonstration.
+	ckksParams := bootstrapping.DefaultCKKSParameters[0]
+	btpParams := bootstrapping.DefaultParameters[0]
+
+	if !*flagSecure {
+		ckksParams.LogN = 13
+		ckksParams.LogSlots = 12
+	}
+
+	params, err := ckks.NewParametersFromLiteral(ckksParams)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println()
+	fmt.Printf("CKKS parameters: logN = %d, logSlots = %d, h = %d, logQP = %d, levels = %d, scale= 2^%f, sigma = %f \n", params.LogN(), params.LogSlots(), params.HammingWeight(), params.LogQP(), params.QCount(), math.Log2(params.DefaultScale()), params.Sigma())
+
+	// The bootstrapping circuit assumes a secret of Hamming weight params.HammingWeight(). Since the collective
+	// secret is the sum of the secret shares, each party samples a share of Hamming weight params.HammingWeight()/nParties.
+	hw := dckks.BootstrappingSecretShareHammingWeight(params, nParties)
+	fmt.Printf("Parties: %d, secret share Hamming weight: %d\n", nParties, hw)
+
+	kgen := ckks.NewKeyGenerator(params)
+	skShares := make([]*rlwe.SecretKey, nParties)
+	for i := range skShares {
+		skShares[i] = kgen.GenSecretKeyWithHammingWeight(hw)
+	}
+
+	// The common reference string, known to all the parties
+	crs, err := utils.NewKeyedPRNG([]byte{'l', 'a', 't', 't', 'i', 'g', 'o'})
+	if err != nil {
+		panic(err)
+	}
+
+	// Collective public key generation
+	fmt.Println()
+	fmt.Println("Generating the collective public key...")
+	ckg := dckks.NewCKGProtocol(params)
+	ckgCRP := ckg.SampleCRP(crs)
+	ckgShare, ckgTmp := ckg.AllocateShare(), ckg.AllocateShare()
+	for i, sk := range skShares {
+		if i == 0 {
+			ckg.GenShare(sk, ckgCRP, ckgShare)
+		} else {
+			ckg.GenShare(sk, ckgCRP, ckgTmp)
+			ckg.AggregateShare(ckgShare, ckgTmp, ckgShare)
+		}
+	}
+	pk := ckks.NewPublicKey(params)
+	ckg.GenPublicKey(ckgShare, ckgCRP, pk)
+	fmt.Println("Done")
+
+	// Collective bootstrapping keys generation
+	fmt.Println()
+	fmt.Println("Generating the collective bootstrapping keys...")
+	btpkg := dckks.NewBootstrappingKeyGenProtocol(params, btpParams)
+	btpCRP := btpkg.SampleCRP(crs)
+
+	ephSks := make([]*rlwe.SecretKey, nParties)
+	ephSk, round1, round2 := btpkg.AllocateShare()
+	_, round1Tmp, round2Tmp := btpkg.AllocateShare()
+
+	for i, sk := range skShares {
+		ephSks[i] = ephSk.CopyNew()
+		if i == 0 {
+			btpkg.GenShareRoundOne(sk, btpCRP, ephSks[i], round1)
+		} else {
+			btpkg.GenShareRoundOne(sk, btpCRP, ephSks[i], round1Tmp)
+			btpkg.AggregateShareRoundOne(round1, round1Tmp, round1)
+		}
+	}
+
+	for i, sk := range skShares {
+		if i == 0 {
+			btpkg.GenShareRoundTwo(ephSks[i], sk, round1, round2)
+		} else {
+			btpkg.GenShareRoundTwo(ephSks[i], sk, round1, round2Tmp)
+			btpkg.AggregateShareRoundTwo(round2, round2Tmp, round2)
+		}
+	}
+
+	btp, err := bootstrapping.NewBootstrapper(params, btpParams, btpkg.GenBootstrappingKey(round1, round2, btpCRP))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("Done")
+
+	// For the sake of the example, the decryption is done with the ideal secret-key, i.e. the
+	// sum of the secret shares. See the dckks.CKSProtocol for a collective decryption.
+	skIdeal := ckks.NewSecretKey(params)
+	for _, sk := range skShares {
+		params.RingQP().AddLvl(params.QCount()-1, params.PCount()-1, skIdeal.Value, sk.Value, skIdeal.Value)
+	}
+
+	encoder := ckks.NewEncoder(params)
+	encryptor := ckks.NewEncryptor(params, pk)
+	decryptor, err := ckks.NewDecryptor(params, skIdeal)
+	if err != nil {
+		panic(err)
+	}
+
+	valuesWant := make([]complex128, params.Slots())
+	for i := range valuesWant {
+		valuesWant[i] = utils.RandComplex128(-1, 1)
+	}
+
+	ciphertext := encryptor.EncryptNew(encoder.EncodeNew(valuesWant, params.MaxLevel(), params.DefaultScale(), params.LogSlots()))
+
+	fmt.Println()
+	fmt.Println("Bootstrapping...")
+	ciphertext = btp.Bootstrapp(ciphertext)
+	fmt.Println("Done")
+
+	valuesTest := encoder.Decode(decryptor.DecryptNew(ciphertext), params.LogSlots())
+
+	fmt.Println()
+	fmt.Printf("Level: %d (logQ = %d)\n", ciphertext.Level(), params.LogQLvl(ciphertext.Level()))
+	fmt.Printf("ValuesTest: %6.10f %6.10f %6.10f %6.10f...\n", valuesTest[0], valuesTest[1], valuesTest[2], valuesTest[3])
+	fmt.Printf("ValuesWant: %6.10f %6.10f %6.10f %6.10f...\n", valuesWant[0], valuesWant[1], valuesWant[2], valuesWant[3])
+	fmt.Println(ckks.GetPrecisionStats(params, encoder, nil, valuesWant, valuesTest, params.LogSlots(), 0).String())
+}
@@ -44,7 +44,10 @@ func main() {
 
 	sk := kgen.GenSecretKey()
 	encryptor := rlwe.NewEncryptor(params, sk)
-	decryptor := rlwe.NewDecryptor(params, sk)
+	decryptor, err := rlwe.NewDecryptor(params, sk)
+	if err != nil {
+		panic(err)
+	}
 
 	// Rotation Keys
 	rotations := []int{}
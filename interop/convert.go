@@ -0,0 +1,472 @@
+package interop
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/drlwe"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+// NewPoly returns the message of the polynomial pol.
+func NewPoly(pol *ring.Poly) *Poly {
+
+	N := pol.Degree()
+
+	m := &Poly{
+		Moduli:  uint32(len(pol.Coeffs)),
+		IsNTT:   pol.IsNTT,
+		IsMForm: pol.IsMForm,
+		Coeffs:  make([]byte, 8*N*len(pol.Coeffs)),
+	}
+
+	for N > 1 {
+		m.LogN++
+		N >>= 1
+	}
+
+	ptr := 0
+	for _, coeffs := range pol.Coeffs {
+		for _, c := range coeffs {
+			binary.BigEndian.PutUint64(m.Coeffs[ptr:], c)
+			ptr += 8
+		}
+	}
+
+	return m
+}
+
+// ToLattigo returns the polynomial of the message.
+// It returns an error if the message is not a valid polynomial.
+func (m *Poly) ToLattigo() (*ring.Poly, error) {
+
+	if m == nil {
+		return nil, errors.New("invalid Poly: missing polynomial")
+	}
+
+	if m.LogN > rlwe.MaxLogN {
+		return nil, fmt.Errorf("invalid Poly: logN (%d) is larger than %d", m.LogN, rlwe.MaxLogN)
+	}
+
+	if m.Moduli == 0 || m.Moduli > rlwe.MaxModuliCount {
+		return nil, fmt.Errorf("invalid Poly: number of moduli (%d) must be between 1 and %d", m.Moduli, rlwe.MaxModuliCount)
+	}
+
+	N := 1 << m.LogN
+
+	if len(m.Coeffs) != 8*N*int(m.Moduli) {
+		return nil, fmt.Errorf("invalid Poly: coeffs has %d bytes but %d are expected", len(m.Coeffs), 8*N*int(m.Moduli))
+	}
+
+	pol := ring.NewPoly(N, int(m.Moduli))
+	pol.IsNTT = m.IsNTT
+	pol.IsMForm = m.IsMForm
+
+	ptr := 0
+	for _, coeffs := range pol.Coeffs {
+		for j := range coeffs {
+			coeffs[j] = binary.BigEndian.Uint64(m.Coeffs[ptr:])
+			ptr += 8
+		}
+	}
+
+	return pol, nil
+}
+
+// NewPolyQP returns the message of the polynomial pol.
+func NewPolyQP(pol rlwe.PolyQP) *PolyQP {
+	m := &PolyQP{Q: NewPoly(pol.Q)}
+	if pol.P != nil {
+		m.P = NewPoly(pol.P)
+	}
+	return m
+}
+
+// ToLattigo returns the polynomial of the message.
+// It returns an error if the message is not a valid polynomial.
+func (m *PolyQP) ToLattigo() (pol rlwe.PolyQP, err error) {
+
+	if m == nil {
+		return pol, errors.New("invalid PolyQP: missing polynomial")
+	}
+
+	if pol.Q, err = m.Q.ToLattigo(); err != nil {
+		return rlwe.PolyQP{}, err
+	}
+
+	if m.P != nil {
+		if pol.P, err = m.P.ToLattigo(); err != nil {
+			return rlwe.PolyQP{}, err
+		}
+
+		if pol.P.Degree() != pol.Q.Degree() {
+			return rlwe.PolyQP{}, errors.New("invalid PolyQP: q and p have different degrees")
+		}
+	}
+
+	return
+}
+
+// NewPolyQPPair returns the message of the pair of polynomials pair.
+func NewPolyQPPair(pair [2]rlwe.PolyQP) *PolyQPPair {
+	return &PolyQPPair{C0: NewPolyQP(pair[0]), C1: NewPolyQP(pair[1])}
+}
+
+// ToLattigo returns the pair of polynomials of the message.
+// It returns an error if the message is not a valid pair of polynomials.
+func (m *PolyQPPair) ToLattigo() (pair [2]rlwe.PolyQP, err error) {
+
+	if m == nil {
+		return pair, errors.New("invalid PolyQPPair: missing pair")
+	}
+
+	if pair[0], err = m.C0.ToLattigo(); err != nil {
+		return
+	}
+
+	pair[1], err = m.C1.ToLattigo()
+
+	return
+}
+
+// NewCiphertext returns the message of the ciphertext ct.
+func NewCiphertext(ct *rlwe.Ciphertext) *Ciphertext {
+	m := &Ciphertext{Value: make([]*Poly, len(ct.Value)), ParametersFingerprint: ct.ParametersFingerprint}
+	for i, pol := range ct.Value {
+		m.Value[i] = NewPoly(pol)
+	}
+	return m
+}
+
+// ToLattigo returns the ciphertext of the message.
+// It returns an error if the message is not a valid ciphertext.
+func (m *Ciphertext) ToLattigo() (ct *rlwe.Ciphertext, err error) {
+
+	if m == nil || len(m.Value) == 0 {
+		return nil, errors.New("invalid Ciphertext: missing value")
+	}
+
+	ct = &rlwe.Ciphertext{Value: make([]*ring.Poly, len(m.Value)), ParametersFingerprint: m.ParametersFingerprint}
+	for i, pol := range m.Value {
+		if ct.Value[i], err = pol.ToLattigo(); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// NewCKKSCiphertext returns the message of the CKKS ciphertext ct.
+func NewCKKSCiphertext(ct *ckks.Ciphertext) *CKKSCiphertext {
+	return &CKKSCiphertext{Ciphertext: NewCiphertext(ct.Ciphertext), Scale: ct.Scale}
+}
+
+// ToLattigo returns the CKKS ciphertext of the message.
+// It returns an error if the message is not a valid CKKS ciphertext.
+func (m *CKKSCiphertext) ToLattigo() (ct *ckks.Ciphertext, err error) {
+
+	if m == nil {
+		return nil, errors.New("invalid CKKSCiphertext: missing ciphertext")
+	}
+
+	ct = &ckks.Ciphertext{Scale: m.Scale}
+	if ct.Ciphertext, err = m.Ciphertext.ToLattigo(); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// NewSecretKey returns the message of the secret key sk.
+func NewSecretKey(sk *rlwe.SecretKey) *SecretKey {
+	return &SecretKey{Value: NewPolyQP(sk.Value), ParametersFingerprint: sk.ParametersFingerprint}
+}
+
+// ToLattigo returns the secret key of the message.
+// It returns an error if the message is not a valid secret key.
+func (m *SecretKey) ToLattigo() (sk *rlwe.SecretKey, err error) {
+
+	if m == nil {
+		return nil, errors.New("invalid SecretKey: missing key")
+	}
+
+	sk = &rlwe.SecretKey{ParametersFingerprint: m.ParametersFingerprint}
+	if sk.Value, err = m.Value.ToLattigo(); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// NewPublicKey returns the message of the public key pk.
+func NewPublicKey(pk *rlwe.PublicKey) *PublicKey {
+	return &PublicKey{Value: NewPolyQPPair(pk.Value), ParametersFingerprint: pk.ParametersFingerprint}
+}
+
+// ToLattigo returns the public key of the message.
+// It returns an error if the message is not a valid public key.
+func (m *PublicKey) ToLattigo() (pk *rlwe.PublicKey, err error) {
+
+	if m == nil {
+		return nil, errors.New("invalid PublicKey: missing key")
+	}
+
+	pk = &rlwe.PublicKey{ParametersFingerprint: m.ParametersFingerprint}
+	if pk.Value, err = m.Value.ToLattigo(); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// NewSwitchingKey returns the message of the switching key swk.
+func NewSwitchingKey(swk *rlwe.SwitchingKey) *SwitchingKey {
+	m := &SwitchingKey{Value: make([]*PolyQPPair, len(swk.Value)), ParametersFingerprint: swk.ParametersFingerprint}
+	for i := range swk.Value {
+		m.Value[i] = NewPolyQPPair(swk.Value[i])
+	}
+	return m
+}
+
+// ToLattigo returns the switching key of the message.
+// It returns an error if the message is not a valid switching key.
+func (m *SwitchingKey) ToLattigo() (swk *rlwe.SwitchingKey, err error) {
+
+	if m == nil || len(m.Value) == 0 {
+		return nil, errors.New("invalid SwitchingKey: missing value")
+	}
+
+	swk = &rlwe.SwitchingKey{Value: make([][2]rlwe.PolyQP, len(m.Value)), ParametersFingerprint: m.ParametersFingerprint}
+	for i, pair := range m.Value {
+		if swk.Value[i], err = pair.ToLattigo(); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// NewRelinearizationKey returns the message of the relinearization key rlk.
+func NewRelinearizationKey(rlk *rlwe.RelinearizationKey) *RelinearizationKey {
+	m := &RelinearizationKey{Keys: make([]*SwitchingKey, len(rlk.Keys))}
+	for i, swk := range rlk.Keys {
+		m.Keys[i] = NewSwitchingKey(swk)
+	}
+	return m
+}
+
+// ToLattigo returns the relinearization key of the message.
+// It returns an error if the message is not a valid relinearization key.
+func (m *RelinearizationKey) ToLattigo() (rlk *rlwe.RelinearizationKey, err error) {
+
+	if m == nil || len(m.Keys) == 0 {
+		return nil, errors.New("invalid RelinearizationKey: missing keys")
+	}
+
+	rlk = &rlwe.RelinearizationKey{Keys: make([]*rlwe.SwitchingKey, len(m.Keys))}
+	for i, swk := range m.Keys {
+		if rlk.Keys[i], err = swk.ToLattigo(); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// NewRotationKeySet returns the message of the rotation key set rtks.
+func NewRotationKeySet(rtks *rlwe.RotationKeySet) *RotationKeySet {
+	m := &RotationKeySet{Keys: make(map[uint64]*SwitchingKey, len(rtks.Keys))}
+	for galEl, swk := range rtks.Keys {
+		m.Keys[galEl] = NewSwitchingKey(swk)
+	}
+	return m
+}
+
+// ToLattigo returns the rotation key set of the message.
+// It returns an error if the message is not a valid rotation key set.
+func (m *RotationKeySet) ToLattigo() (rtks *rlwe.RotationKeySet, err error) {
+
+	if m == nil {
+		return nil, errors.New("invalid RotationKeySet: missing key set")
+	}
+
+	rtks = &rlwe.RotationKeySet{Keys: make(map[uint64]*rlwe.SwitchingKey, len(m.Keys))}
+	for galEl, swk := range m.Keys {
+		if rtks.Keys[galEl], err = swk.ToLattigo(); err != nil {
+			return nil, fmt.Errorf("invalid RotationKeySet: key %d: %w", galEl, err)
+		}
+	}
+
+	return
+}
+
+// NewCKGShare returns the message of the share share.
+func NewCKGShare(share *drlwe.CKGShare) *CKGShare {
+	return &CKGShare{Value: NewPolyQP(share.Value)}
+}
+
+// ToLattigo returns the share of the message.
+// It returns an error if the message is not a valid share.
+func (m *CKGShare) ToLattigo() (share *drlwe.CKGShare, err error) {
+
+	if m == nil {
+		return nil, errors.New("invalid CKGShare: missing share")
+	}
+
+	share = new(drlwe.CKGShare)
+	if share.Value, err = m.Value.ToLattigo(); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// NewRKGShare returns the message of the share share.
+func NewRKGShare(share *drlwe.RKGShare) *RKGShare {
+	m := &RKGShare{Value: make([]*PolyQPPair, len(share.Value))}
+	for i := range share.Value {
+		m.Value[i] = NewPolyQPPair(share.Value[i])
+	}
+	return m
+}
+
+// ToLattigo returns the share of the message.
+// It returns an error if the message is not a valid share.
+func (m *RKGShare) ToLattigo() (share *drlwe.RKGShare, err error) {
+
+	if m == nil || len(m.Value) == 0 {
+		return nil, errors.New("invalid RKGShare: missing value")
+	}
+
+	share = &drlwe.RKGShare{Value: make([][2]rlwe.PolyQP, len(m.Value))}
+	for i, pair := range m.Value {
+		if share.Value[i], err = pair.ToLattigo(); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// NewRTGShare returns the message of the share share.
+func NewRTGShare(share *drlwe.RTGShare) *RTGShare {
+	m := &RTGShare{Value: make([]*PolyQP, len(share.Value))}
+	for i := range share.Value {
+		m.Value[i] = NewPolyQP(share.Value[i])
+	}
+	return m
+}
+
+// ToLattigo returns the share of the message.
+// It returns an error if the message is not a valid share.
+func (m *RTGShare) ToLattigo() (share *drlwe.RTGShare, err error) {
+
+	if m == nil || len(m.Value) == 0 {
+		return nil, errors.New("invalid RTGShare: missing value")
+	}
+
+	share = &drlwe.RTGShare{Value: make([]rlwe.PolyQP, len(m.Value))}
+	for i, pol := range m.Value {
+		if share.Value[i], err = pol.ToLattigo(); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// NewRTGMultiShare returns the message of the share share.
+func NewRTGMultiShare(share *drlwe.RTGMultiShare) *RTGMultiShare {
+	m := &RTGMultiShare{Value: make(map[uint64]*RTGShare, len(share.Value))}
+	for galEl, s := range share.Value {
+		m.Value[galEl] = NewRTGShare(s)
+	}
+	return m
+}
+
+// ToLattigo returns the share of the message.
+// It returns an error if the message is not a valid share.
+func (m *RTGMultiShare) ToLattigo() (share *drlwe.RTGMultiShare, err error) {
+
+	if m == nil {
+		return nil, errors.New("invalid RTGMultiShare: missing share")
+	}
+
+	share = &drlwe.RTGMultiShare{Value: make(map[uint64]*drlwe.RTGShare, len(m.Value))}
+	for galEl, s := range m.Value {
+		if share.Value[galEl], err = s.ToLattigo(); err != nil {
+			return nil, fmt.Errorf("invalid RTGMultiShare: share %d: %w", galEl, err)
+		}
+	}
+
+	return
+}
+
+// NewCKSShare returns the message of the share share.
+func NewCKSShare(share *drlwe.CKSShare) *CKSShare {
+	return &CKSShare{Value: NewPoly(share.Value)}
+}
+
+// ToLattigo returns the share of the message.
+// It returns an error if the message is not a valid share.
+func (m *CKSShare) ToLattigo() (share *drlwe.CKSShare, err error) {
+
+	if m == nil {
+		return nil, errors.New("invalid CKSShare: missing share")
+	}
+
+	share = new(drlwe.CKSShare)
+	if share.Value, err = m.Value.ToLattigo(); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// NewPCKSShare returns the message of the share share.
+func NewPCKSShare(share *drlwe.PCKSShare) *PCKSShare {
+	return &PCKSShare{C0: NewPoly(share.Value[0]), C1: NewPoly(share.Value[1])}
+}
+
+// ToLattigo returns the share of the message.
+// It returns an error if the message is not a valid share.
+func (m *PCKSShare) ToLattigo() (share *drlwe.PCKSShare, err error) {
+
+	if m == nil {
+		return nil, errors.New("invalid PCKSShare: missing share")
+	}
+
+	share = new(drlwe.PCKSShare)
+	if share.Value[0], err = m.C0.ToLattigo(); err != nil {
+		return nil, err
+	}
+	if share.Value[1], err = m.C1.ToLattigo(); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// NewShamirSecretShare returns the message of the share share.
+func NewShamirSecretShare(share *drlwe.ShamirSecretShare) *ShamirSecretShare {
+	return &ShamirSecretShare{Value: NewPolyQP(share.PolyQP)}
+}
+
+// ToLattigo returns the share of the message.
+// It returns an error if the message is not a valid share.
+func (m *ShamirSecretShare) ToLattigo() (share *drlwe.ShamirSecretShare, err error) {
+
+	if m == nil {
+		return nil, errors.New("invalid ShamirSecretShare: missing share")
+	}
+
+	share = new(drlwe.ShamirSecretShare)
+	if share.PolyQP, err = m.Value.ToLattigo(); err != nil {
+		return nil, err
+	}
+
+	return
+}
@@ -0,0 +1,329 @@
+package interop
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/drlwe"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// roundTrip checks that the message m is unchanged by its binary and JSON encodings, decoding them on
+// the messages returned by alloc, and returns the decoded messages.
+func roundTrip(t *testing.T, m Message, alloc func() Message) (fromBinary, fromJSON Message) {
+
+	data, err := Marshal(m)
+	require.NoError(t, err)
+	fromBinary = alloc()
+	require.NoError(t, Unmarshal(data, fromBinary))
+	require.Equal(t, m, fromBinary)
+
+	data, err = json.Marshal(m)
+	require.NoError(t, err)
+	fromJSON = alloc()
+	require.NoError(t, json.Unmarshal(data, fromJSON))
+	require.Equal(t, m, fromJSON)
+
+	return
+}
+
+func TestInterop(t *testing.T) {
+
+	params, err := ckks.NewParametersFromLiteral(ckks.PN12QP109)
+	if err != nil {
+		panic(err)
+	}
+
+	kgen := ckks.NewKeyGenerator(params)
+	sk, pk := kgen.GenKeyPair()
+
+	prng, err := utils.NewKeyedPRNG([]byte{'i', 'n', 't', 'e', 'r', 'o', 'p'})
+	if err != nil {
+		panic(err)
+	}
+
+	t.Run("Poly/Golden", func(t *testing.T) {
+
+		pol := ring.NewPoly(2, 1)
+		pol.Coeffs[0][0], pol.Coeffs[0][1] = 1, 2
+		pol.IsNTT = true
+
+		data, err := Marshal(NewPoly(pol))
+		require.NoError(t, err)
+		require.Equal(t, append([]byte{0x08, 0x01, 0x10, 0x01, 0x18, 0x01, 0x2a, 0x10}, []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}...), data)
+
+		data, err = json.Marshal(NewPoly(pol))
+		require.NoError(t, err)
+		require.Equal(t, `{"logN":1,"moduli":1,"isNtt":true,"coeffs":"AAAAAAAAAAEAAAAAAAAAAg=="}`, string(data))
+	})
+
+	// The golden vectors are generated by testdata/gen with the reference protobuf implementation
+	t.Run("Golden", func(t *testing.T) {
+
+		alloc := map[string]func() Message{
+			"Poly":               func() Message { return new(Poly) },
+			"PolyQP":             func() Message { return new(PolyQP) },
+			"PolyQPPair":         func() Message { return new(PolyQPPair) },
+			"Ciphertext":         func() Message { return new(Ciphertext) },
+			"CKKSCiphertext":     func() Message { return new(CKKSCiphertext) },
+			"SecretKey":          func() Message { return new(SecretKey) },
+			"PublicKey":          func() Message { return new(PublicKey) },
+			"SwitchingKey":       func() Message { return new(SwitchingKey) },
+			"RelinearizationKey": func() Message { return new(RelinearizationKey) },
+			"RotationKeySet":     func() Message { return new(RotationKeySet) },
+			"CKGShare":           func() Message { return new(CKGShare) },
+			"RKGShare":           func() Message { return new(RKGShare) },
+			"RTGShare":           func() Message { return new(RTGShare) },
+			"RTGMultiShare":      func() Message { return new(RTGMultiShare) },
+			"CKSShare":           func() Message { return new(CKSShare) },
+			"PCKSShare":          func() Message { return new(PCKSShare) },
+			"ShamirSecretShare":  func() Message { return new(ShamirSecretShare) },
+		}
+
+		data, err := ioutil.ReadFile("testdata/golden.json")
+		require.NoError(t, err)
+
+		var goldens []struct {
+			Message string          `json:"message"`
+			Binary  string          `json:"binary"`
+			JSON    json.RawMessage `json:"json"`
+		}
+		require.NoError(t, json.Unmarshal(data, &goldens))
+
+		covered := map[string]bool{}
+		coveredFields := map[string]bool{}
+
+		for i, golden := range goldens {
+
+			covered[golden.Message] = true
+
+			bin, err := hex.DecodeString(golden.Binary)
+			require.NoError(t, err)
+
+			// Binary encoding
+			fromBinary := alloc[golden.Message]()
+			require.NoError(t, Unmarshal(bin, fromBinary), i)
+			data, err := Marshal(fromBinary)
+			require.NoError(t, err)
+			require.Equal(t, bin, append([]byte{}, data...), i)
+			markFields(reflect.ValueOf(fromBinary), coveredFields)
+
+			// Proto3 JSON encoding
+			fromJSON := alloc[golden.Message]()
+			require.NoError(t, json.Unmarshal(golden.JSON, fromJSON), i)
+			require.Equal(t, fromBinary, fromJSON, i)
+			data, err = json.Marshal(fromBinary)
+			require.NoError(t, err)
+			require.JSONEq(t, string(golden.JSON), string(data), i)
+		}
+
+		require.Equal(t, len(alloc), len(covered))
+
+		// Each field of each message is set in at least one golden vector
+		for _, m := range alloc {
+			typ := reflect.TypeOf(m()).Elem()
+			for i := 0; i < typ.NumField(); i++ {
+				require.True(t, coveredFields[typ.Name()+"."+typ.Field(i).Name], "field %s.%s is not covered by the golden vectors", typ.Name(), typ.Field(i).Name)
+			}
+		}
+	})
+
+	t.Run("SecretKey", func(t *testing.T) {
+		_, m := roundTrip(t, NewSecretKey(sk), func() Message { return new(SecretKey) })
+		skNew, err := m.(*SecretKey).ToLattigo()
+		require.NoError(t, err)
+		require.True(t, sk.Value.Equals(skNew.Value))
+		require.Equal(t, params.Parameters.Fingerprint(), skNew.ParametersFingerprint)
+	})
+
+	t.Run("PublicKey", func(t *testing.T) {
+		m, _ := roundTrip(t, NewPublicKey(pk), func() Message { return new(PublicKey) })
+		pkNew, err := m.(*PublicKey).ToLattigo()
+		require.NoError(t, err)
+		require.True(t, pk.Equals(pkNew))
+		require.Equal(t, pk.ParametersFingerprint, pkNew.ParametersFingerprint)
+	})
+
+	t.Run("RelinearizationKey", func(t *testing.T) {
+		rlk := kgen.GenRelinearizationKey(sk, 2)
+		m, _ := roundTrip(t, NewRelinearizationKey(rlk), func() Message { return new(RelinearizationKey) })
+		rlkNew, err := m.(*RelinearizationKey).ToLattigo()
+		require.NoError(t, err)
+		require.True(t, rlk.Equals(rlkNew))
+	})
+
+	t.Run("RotationKeySet", func(t *testing.T) {
+		rtks := kgen.GenRotationKeysForRotations([]int{1, 2, 5}, true, sk)
+		_, m := roundTrip(t, NewRotationKeySet(rtks), func() Message { return new(RotationKeySet) })
+		rtksNew, err := m.(*RotationKeySet).ToLattigo()
+		require.NoError(t, err)
+		require.True(t, rtks.Equals(rtksNew))
+
+		// The encoding is deterministic regardless of the iteration order of the map
+		data0, _ := Marshal(NewRotationKeySet(rtks))
+		data1, _ := Marshal(NewRotationKeySet(rtksNew))
+		require.Equal(t, data0, data1)
+	})
+
+	t.Run("CKKSCiphertext", func(t *testing.T) {
+		ct := ckks.NewCiphertextRandom(prng, params, 1, params.MaxLevel()-1, params.DefaultScale())
+		m, _ := roundTrip(t, NewCKKSCiphertext(ct), func() Message { return new(CKKSCiphertext) })
+		ctNew, err := m.(*CKKSCiphertext).ToLattigo()
+		require.NoError(t, err)
+		require.Equal(t, ct.Scale, ctNew.Scale)
+		require.Equal(t, params.Parameters.Fingerprint(), ctNew.ParametersFingerprint)
+		require.Equal(t, ct.Degree(), ctNew.Degree())
+		require.Equal(t, ct.Level(), ctNew.Level())
+		for i := range ct.Value {
+			require.True(t, ct.Value[i].Equals(ctNew.Value[i]))
+		}
+	})
+
+	t.Run("Shares", func(t *testing.T) {
+
+		ckg := drlwe.NewCKGProtocol(params.Parameters)
+		ckgShare := ckg.AllocateShare()
+		ckg.GenShare(sk, ckg.SampleCRP(prng), ckgShare)
+		m, _ := roundTrip(t, NewCKGShare(ckgShare), func() Message { return new(CKGShare) })
+		ckgShareNew, err := m.(*CKGShare).ToLattigo()
+		require.NoError(t, err)
+		// The messages of the shares do not carry their drlwe.ShareMetadata
+		require.Equal(t, drlwe.ShareMetadata{}, ckgShareNew.ShareMetadata)
+		require.Equal(t, ckgShare.Value, ckgShareNew.Value)
+
+		rkg := drlwe.NewRKGProtocol(params.Parameters)
+		ephSk, rkgShare, _ := rkg.AllocateShare()
+		rkg.GenShareRoundOne(sk, rkg.SampleCRP(prng), ephSk, rkgShare)
+		m, _ = roundTrip(t, NewRKGShare(rkgShare), func() Message { return new(RKGShare) })
+		rkgShareNew, err := m.(*RKGShare).ToLattigo()
+		require.NoError(t, err)
+		require.Equal(t, rkgShare.Value, rkgShareNew.Value)
+
+		rtg := drlwe.NewRTGProtocol(params.Parameters)
+		galEls := []uint64{params.GaloisElementForColumnRotationBy(1), params.GaloisElementForRowRotation()}
+		rtgShare := rtg.AllocateMultiShare(galEls)
+		rtg.GenMultiShare(sk, rtg.SampleMultiCRP(galEls, prng), rtgShare)
+		_, m = roundTrip(t, NewRTGMultiShare(rtgShare), func() Message { return new(RTGMultiShare) })
+		rtgShareNew, err := m.(*RTGMultiShare).ToLattigo()
+		require.NoError(t, err)
+		require.Len(t, rtgShareNew.Value, len(rtgShare.Value))
+		for galEl := range rtgShare.Value {
+			require.Equal(t, rtgShare.Value[galEl].Value, rtgShareNew.Value[galEl].Value)
+		}
+
+		ct := ckks.NewCiphertextRandom(prng, params, 1, params.MaxLevel(), params.DefaultScale())
+
+		cks := drlwe.NewCKSProtocol(params.Parameters, rlwe.DefaultSigma)
+		cksShare := cks.AllocateShare(ct.Level())
+		cks.GenShare(sk, rlwe.NewSecretKey(params.Parameters), ct.Value[1], cksShare)
+		m, _ = roundTrip(t, NewCKSShare(cksShare), func() Message { return new(CKSShare) })
+		cksShareNew, err := m.(*CKSShare).ToLattigo()
+		require.NoError(t, err)
+		require.Equal(t, cksShare.Value, cksShareNew.Value)
+
+		pcks := drlwe.NewPCKSProtocol(params.Parameters, rlwe.DefaultSigma)
+		pcksShare := pcks.AllocateShare(ct.Level())
+		pcks.GenShare(sk, pk, ct.Value[1], pcksShare)
+		m, _ = roundTrip(t, NewPCKSShare(pcksShare), func() Message { return new(PCKSShare) })
+		pcksShareNew, err := m.(*PCKSShare).ToLattigo()
+		require.NoError(t, err)
+		require.Equal(t, pcksShare.Value, pcksShareNew.Value)
+
+		thr := drlwe.NewThresholdizer(params.Parameters)
+		shamirPoly, err := thr.GenShamirPolynomial(2, sk)
+		require.NoError(t, err)
+		shamirShare := thr.AllocateThresholdSecretShare()
+		thr.GenShamirSecretShare(1, shamirPoly, shamirShare)
+		m, _ = roundTrip(t, NewShamirSecretShare(shamirShare), func() Message { return new(ShamirSecretShare) })
+		shamirShareNew, err := m.(*ShamirSecretShare).ToLattigo()
+		require.NoError(t, err)
+		require.Equal(t, shamirShare.PolyQP, shamirShareNew.PolyQP)
+	})
+
+	t.Run("UnknownFields", func(t *testing.T) {
+
+		data, err := Marshal(NewPublicKey(pk))
+		require.NoError(t, err)
+
+		// Appends the fields 15 (varint), 16 (bytes), 17 (fixed64) and 18 (fixed32)
+		data = append(data, 0x78, 0x2a)
+		data = append(data, 0x82, 0x01, 0x03, 'a', 'b', 'c')
+		data = append(data, 0x89, 0x01, 1, 2, 3, 4, 5, 6, 7, 8)
+		data = append(data, 0x95, 0x01, 1, 2, 3, 4)
+
+		m := new(PublicKey)
+		require.NoError(t, Unmarshal(data, m))
+		require.Equal(t, NewPublicKey(pk), m)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+
+		data, err := Marshal(NewCKKSCiphertext(ckks.NewCiphertextRandom(prng, params, 1, params.MaxLevel(), params.DefaultScale())))
+		require.NoError(t, err)
+
+		// Truncated encodings
+		for _, cut := range []int{1, 2, 10, len(data) / 2, len(data) - 1} {
+			require.Error(t, Unmarshal(data[:cut], new(CKKSCiphertext)), cut)
+		}
+
+		// Invalid wire types and field numbers
+		require.Error(t, Unmarshal([]byte{0x0b}, new(CKKSCiphertext)))
+		require.Error(t, Unmarshal([]byte{0x0a, 0x00, 0x12, 0x00}, new(CKKSCiphertext)))
+		require.Error(t, Unmarshal([]byte{0x00, 0x00}, new(CKKSCiphertext)))
+		require.Error(t, Unmarshal([]byte{0x80}, new(CKKSCiphertext)))
+
+		// Well-formed messages that are not valid Lattigo objects
+		for _, m := range []*Poly{
+			nil,
+			{LogN: 1, Moduli: 0},
+			{LogN: rlwe.MaxLogN + 1, Moduli: 1},
+			{LogN: 1, Moduli: 1, Coeffs: make([]byte, 15)},
+		} {
+			_, err := m.ToLattigo()
+			require.Error(t, err)
+		}
+
+		_, err = new(Ciphertext).ToLattigo()
+		require.Error(t, err)
+
+		_, err = (&PublicKey{Value: &PolyQPPair{C0: NewPolyQP(pk.Value[0])}}).ToLattigo()
+		require.Error(t, err)
+
+		_, err = (&PolyQP{Q: NewPoly(pk.Value[0].Q), P: NewPoly(ring.NewPoly(params.N()/2, 1))}).ToLattigo()
+		require.Error(t, err)
+	})
+}
+
+// markFields records in covered the fields "Message.Field" that are set in the message v or in its sub-messages.
+func markFields(v reflect.Value, covered map[string]bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			markFields(v.Elem(), covered)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Ptr {
+			for i := 0; i < v.Len(); i++ {
+				markFields(v.Index(i), covered)
+			}
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			markFields(v.MapIndex(k), covered)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !reflect.DeepEqual(v.Field(i).Interface(), reflect.Zero(v.Field(i).Type()).Interface()) {
+				covered[v.Type().Name()+"."+v.Type().Field(i).Name] = true
+			}
+			markFields(v.Field(i), covered)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package interop
+
+// Poly is the message of a polynomial in RNS representation. See lattigo.proto for the layout of Coeffs.
+type Poly struct {
+	LogN    uint32 `json:"logN,omitempty"`
+	Moduli  uint32 `json:"moduli,omitempty"`
+	IsNTT   bool   `json:"isNtt,omitempty"`
+	IsMForm bool   `json:"isMform,omitempty"`
+	Coeffs  []byte `json:"coeffs,omitempty"`
+}
+
+func (m *Poly) encode(e *encoder) {
+	e.uint(1, uint64(m.LogN))
+	e.uint(2, uint64(m.Moduli))
+	e.bool(3, m.IsNTT)
+	e.bool(4, m.IsMForm)
+	e.bytes(5, m.Coeffs)
+}
+
+func (m *Poly) decodeField(f field) (err error) {
+	switch f.num {
+	case 1:
+		m.LogN, err = f.uint32()
+	case 2:
+		m.Moduli, err = f.uint32()
+	case 3:
+		m.IsNTT, err = f.bool()
+	case 4:
+		m.IsMForm, err = f.bool()
+	case 5:
+		m.Coeffs, err = f.bytes()
+	}
+	return
+}
+
+// PolyQP is the message of a polynomial modulo QP.
+type PolyQP struct {
+	Q *Poly `json:"q,omitempty"`
+	P *Poly `json:"p,omitempty"`
+}
+
+func (m *PolyQP) encode(e *encoder) {
+	if m.Q != nil {
+		e.message(1, m.Q)
+	}
+	if m.P != nil {
+		e.message(2, m.P)
+	}
+}
+
+func (m *PolyQP) decodeField(f field) error {
+	switch f.num {
+	case 1:
+		m.Q = new(Poly)
+		return f.message(m.Q)
+	case 2:
+		m.P = new(Poly)
+		return f.message(m.P)
+	}
+	return nil
+}
+
+// PolyQPPair is the message of a pair of polynomials modulo QP.
+type PolyQPPair struct {
+	C0 *PolyQP `json:"c0,omitempty"`
+	C1 *PolyQP `json:"c1,omitempty"`
+}
+
+func (m *PolyQPPair) encode(e *encoder) {
+	if m.C0 != nil {
+		e.message(1, m.C0)
+	}
+	if m.C1 != nil {
+		e.message(2, m.C1)
+	}
+}
+
+func (m *PolyQPPair) decodeField(f field) error {
+	switch f.num {
+	case 1:
+		m.C0 = new(PolyQP)
+		return f.message(m.C0)
+	case 2:
+		m.C1 = new(PolyQP)
+		return f.message(m.C1)
+	}
+	return nil
+}
+
+// Ciphertext is the message of a generic RLWE ciphertext.
+type Ciphertext struct {
+	Value                 []*Poly `json:"value,omitempty"`
+	ParametersFingerprint uint64  `json:"parametersFingerprint,omitempty,string"`
+}
+
+func (m *Ciphertext) encode(e *encoder) {
+	for _, pol := range m.Value {
+		e.message(1, pol)
+	}
+	e.uint(2, m.ParametersFingerprint)
+}
+
+func (m *Ciphertext) decodeField(f field) (err error) {
+	switch f.num {
+	case 1:
+		pol := new(Poly)
+		m.Value = append(m.Value, pol)
+		err = f.message(pol)
+	case 2:
+		m.ParametersFingerprint, err = f.uint64()
+	}
+	return
+}
+
+// CKKSCiphertext is the message of a CKKS ciphertext.
+type CKKSCiphertext struct {
+	Ciphertext *Ciphertext `json:"ciphertext,omitempty"`
+	Scale      float64     `json:"scale,omitempty"`
+}
+
+func (m *CKKSCiphertext) encode(e *encoder) {
+	if m.Ciphertext != nil {
+		e.message(1, m.Ciphertext)
+	}
+	e.double(2, m.Scale)
+}
+
+func (m *CKKSCiphertext) decodeField(f field) (err error) {
+	switch f.num {
+	case 1:
+		m.Ciphertext = new(Ciphertext)
+		err = f.message(m.Ciphertext)
+	case 2:
+		m.Scale, err = f.double()
+	}
+	return
+}
+
+// SecretKey is the message of an RLWE secret key.
+type SecretKey struct {
+	Value                 *PolyQP `json:"value,omitempty"`
+	ParametersFingerprint uint64  `json:"parametersFingerprint,omitempty,string"`
+}
+
+func (m *SecretKey) encode(e *encoder) {
+	if m.Value != nil {
+		e.message(1, m.Value)
+	}
+	e.uint(2, m.ParametersFingerprint)
+}
+
+func (m *SecretKey) decodeField(f field) (err error) {
+	switch f.num {
+	case 1:
+		m.Value = new(PolyQP)
+		err = f.message(m.Value)
+	case 2:
+		m.ParametersFingerprint, err = f.uint64()
+	}
+	return
+}
+
+// PublicKey is the message of an RLWE public key.
+type PublicKey struct {
+	Value                 *PolyQPPair `json:"value,omitempty"`
+	ParametersFingerprint uint64      `json:"parametersFingerprint,omitempty,string"`
+}
+
+func (m *PublicKey) encode(e *encoder) {
+	if m.Value != nil {
+		e.message(1, m.Value)
+	}
+	e.uint(2, m.ParametersFingerprint)
+}
+
+func (m *PublicKey) decodeField(f field) (err error) {
+	switch f.num {
+	case 1:
+		m.Value = new(PolyQPPair)
+		err = f.message(m.Value)
+	case 2:
+		m.ParametersFingerprint, err = f.uint64()
+	}
+	return
+}
+
+// SwitchingKey is the message of an RLWE key-switching key.
+type SwitchingKey struct {
+	Value                 []*PolyQPPair `json:"value,omitempty"`
+	ParametersFingerprint uint64        `json:"parametersFingerprint,omitempty,string"`
+}
+
+func (m *SwitchingKey) encode(e *encoder) {
+	for _, pair := range m.Value {
+		e.message(1, pair)
+	}
+	e.uint(2, m.ParametersFingerprint)
+}
+
+func (m *SwitchingKey) decodeField(f field) (err error) {
+	switch f.num {
+	case 1:
+		pair := new(PolyQPPair)
+		m.Value = append(m.Value, pair)
+		err = f.message(pair)
+	case 2:
+		m.ParametersFingerprint, err = f.uint64()
+	}
+	return
+}
+
+// RelinearizationKey is the message of an RLWE relinearization key.
+type RelinearizationKey struct {
+	Keys []*SwitchingKey `json:"keys,omitempty"`
+}
+
+func (m *RelinearizationKey) encode(e *encoder) {
+	for _, swk := range m.Keys {
+		e.message(1, swk)
+	}
+}
+
+func (m *RelinearizationKey) decodeField(f field) error {
+	if f.num == 1 {
+		swk := new(SwitchingKey)
+		m.Keys = append(m.Keys, swk)
+		return f.message(swk)
+	}
+	return nil
+}
+
+// RotationKeySet is the message of a set of RLWE rotation keys indexed by their Galois element.
+type RotationKeySet struct {
+	Keys map[uint64]*SwitchingKey `json:"keys,omitempty"`
+}
+
+func (m *RotationKeySet) encode(e *encoder) {
+	keys := make([]uint64, 0, len(m.Keys))
+	for galEl := range m.Keys {
+		keys = append(keys, galEl)
+	}
+	e.mapEntries(1, keys, func(galEl uint64) Message { return m.Keys[galEl] })
+}
+
+func (m *RotationKeySet) decodeField(f field) (err error) {
+	if f.num == 1 {
+		entry := &mapEntry{value: new(SwitchingKey)}
+		if err = f.message(entry); err != nil {
+			return err
+		}
+		if m.Keys == nil {
+			m.Keys = make(map[uint64]*SwitchingKey)
+		}
+		m.Keys[entry.key] = entry.value.(*SwitchingKey)
+	}
+	return nil
+}
+
+// CKGShare is the message of a share of the collective public key generation protocol.
+type CKGShare struct {
+	Value *PolyQP `json:"value,omitempty"`
+}
+
+func (m *CKGShare) encode(e *encoder) {
+	if m.Value != nil {
+		e.message(1, m.Value)
+	}
+}
+
+func (m *CKGShare) decodeField(f field) error {
+	if f.num == 1 {
+		m.Value = new(PolyQP)
+		return f.message(m.Value)
+	}
+	return nil
+}
+
+// RKGShare is the message of a share of the collective relinearization key generation protocol.
+type RKGShare struct {
+	Value []*PolyQPPair `json:"value,omitempty"`
+}
+
+func (m *RKGShare) encode(e *encoder) {
+	for _, pair := range m.Value {
+		e.message(1, pair)
+	}
+}
+
+func (m *RKGShare) decodeField(f field) error {
+	if f.num == 1 {
+		pair := new(PolyQPPair)
+		m.Value = append(m.Value, pair)
+		return f.message(pair)
+	}
+	return nil
+}
+
+// RTGShare is the message of a share of the collective rotation key generation protocol.
+type RTGShare struct {
+	Value []*PolyQP `json:"value,omitempty"`
+}
+
+func (m *RTGShare) encode(e *encoder) {
+	for _, pol := range m.Value {
+		e.message(1, pol)
+	}
+}
+
+func (m *RTGShare) decodeField(f field) error {
+	if f.num == 1 {
+		pol := new(PolyQP)
+		m.Value = append(m.Value, pol)
+		return f.message(pol)
+	}
+	return nil
+}
+
+// RTGMultiShare is the message of a set of shares of the collective rotation key generation protocol
+// indexed by their Galois element.
+type RTGMultiShare struct {
+	Value map[uint64]*RTGShare `json:"value,omitempty"`
+}
+
+func (m *RTGMultiShare) encode(e *encoder) {
+	keys := make([]uint64, 0, len(m.Value))
+	for galEl := range m.Value {
+		keys = append(keys, galEl)
+	}
+	e.mapEntries(1, keys, func(galEl uint64) Message { return m.Value[galEl] })
+}
+
+func (m *RTGMultiShare) decodeField(f field) (err error) {
+	if f.num == 1 {
+		entry := &mapEntry{value: new(RTGShare)}
+		if err = f.message(entry); err != nil {
+			return err
+		}
+		if m.Value == nil {
+			m.Value = make(map[uint64]*RTGShare)
+		}
+		m.Value[entry.key] = entry.value.(*RTGShare)
+	}
+	return nil
+}
+
+// CKSShare is the message of a share of the collective key-switching protocol.
+type CKSShare struct {
+	Value *Poly `json:"value,omitempty"`
+}
+
+func (m *CKSShare) encode(e *encoder) {
+	if m.Value != nil {
+		e.message(1, m.Value)
+	}
+}
+
+func (m *CKSShare) decodeField(f field) error {
+	if f.num == 1 {
+		m.Value = new(Poly)
+		return f.message(m.Value)
+	}
+	return nil
+}
+
+// PCKSShare is the message of a share of the collective public key-switching protocol.
+type PCKSShare struct {
+	C0 *Poly `json:"c0,omitempty"`
+	C1 *Poly `json:"c1,omitempty"`
+}
+
+func (m *PCKSShare) encode(e *encoder) {
+	if m.C0 != nil {
+		e.message(1, m.C0)
+	}
+	if m.C1 != nil {
+		e.message(2, m.C1)
+	}
+}
+
+func (m *PCKSShare) decodeField(f field) error {
+	switch f.num {
+	case 1:
+		m.C0 = new(Poly)
+		return f.message(m.C0)
+	case 2:
+		m.C1 = new(Poly)
+		return f.message(m.C1)
+	}
+	return nil
+}
+
+// ShamirSecretShare is the message of a share of a threshold secret key.
+type ShamirSecretShare struct {
+	Value *PolyQP `json:"value,omitempty"`
+}
+
+func (m *ShamirSecretShare) encode(e *encoder) {
+	if m.Value != nil {
+		e.message(1, m.Value)
+	}
+}
+
+func (m *ShamirSecretShare) decodeField(f field) error {
+	if f.num == 1 {
+		m.Value = new(PolyQP)
+		return f.message(m.Value)
+	}
+	return nil
+}
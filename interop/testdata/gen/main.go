@@ -0,0 +1,154 @@
+// Command gen generates the golden vectors of the interop package (../golden.json) with the reference
+// protobuf implementation: lattigo.proto is compiled with github.com/bufbuild/protocompile, and the test
+// vectors below are encoded with the binary and proto3 JSON encodings of google.golang.org/protobuf.
+//
+// It is a separate module, so that the interop package does not depend on protobuf. Run it from this
+// directory with
+//
+//	go run . > ../golden.json
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// A test vector is given by the name of its message and its value in proto3 JSON.
+var vectors = []struct {
+	message string
+	value   string
+}{
+	{"Poly", `{"logN": 1, "moduli": 2, "isNtt": true, "isMform": true, "coeffs": "HyYtNDtCSVBXXmVsc3qBiI+WnaSrsrnAx87V3OPq8fg="}`},
+	{"Poly", `{"logN": 1, "moduli": 1, "coeffs": "PkVMU1phaG92fYSLkpmgpw=="}`},
+	{"Poly", `{}`},
+	{"PolyQP", `{"q": {"logN": 1, "moduli": 1, "isNtt": true, "coeffs": "XWRrcnmAh46VnKOqsbi/xg=="}, "p": {"logN": 1, "moduli": 1, "isNtt": true, "coeffs": "fIOKkZifpq20u8LJ0Nfe5Q=="}}`},
+	{"PolyQP", `{"q": {"moduli": 1, "coeffs": "m6KpsLe+xcw="}}`},
+	{"PolyQPPair", `{"c0": {"q": {"moduli": 1, "coeffs": "m6KpsLe+xcw="}}, "c1": {"q": {}, "p": {"moduli": 1, "isMform": true, "coeffs": "m6KpsLe+xcw="}}}`},
+	{"Ciphertext", `{"value": [{"logN": 1, "moduli": 1, "coeffs": "PkVMU1phaG92fYSLkpmgpw=="}, {"logN": 1, "moduli": 1, "coeffs": "XWRrcnmAh46VnKOqsbi/xg=="}], "parametersFingerprint": "18446744073709551615"}`},
+	{"Ciphertext", `{"value": [{}], "parametersFingerprint": "9007199254740993"}`},
+	{"CKKSCiphertext", `{"ciphertext": {"value": [{"moduli": 1, "coeffs": "m6KpsLe+xcw="}], "parametersFingerprint": "1"}, "scale": 1649267441664}`},
+	{"CKKSCiphertext", `{"ciphertext": {}, "scale": 1.2089258196146292e+24}`},
+	{"CKKSCiphertext", `{"scale": 0.1}`},
+	{"SecretKey", `{"value": {"q": {"moduli": 1, "isNtt": true, "isMform": true, "coeffs": "m6KpsLe+xcw="}}, "parametersFingerprint": "12345678901234567890"}`},
+	{"PublicKey", `{"value": {"c0": {"q": {"moduli": 1, "coeffs": "m6KpsLe+xcw="}}, "c1": {"q": {"moduli": 1, "coeffs": "m6KpsLe+xcw="}}}, "parametersFingerprint": "4294967296"}`},
+	{"SwitchingKey", `{"value": [{"c0": {"q": {"moduli": 1}}}, {"c1": {"p": {"moduli": 1}}}], "parametersFingerprint": "18446744073709551615"}`},
+	{"RelinearizationKey", `{"keys": [{"value": [{"c0": {"q": {"moduli": 1}}}]}, {"parametersFingerprint": "7"}]}`},
+	{"RotationKeySet", `{"keys": {"25": {"parametersFingerprint": "2"}, "5": {"value": [{"c0": {"q": {"moduli": 1, "coeffs": "m6KpsLe+xcw="}}}]}, "18446744073709551615": {}, "0": {"parametersFingerprint": "1"}}}`},
+	{"RotationKeySet", `{}`},
+	{"CKGShare", `{"value": {"q": {"moduli": 1, "coeffs": "m6KpsLe+xcw="}, "p": {"moduli": 1}}}`},
+	{"RKGShare", `{"value": [{"c0": {"q": {"moduli": 1}}, "c1": {"q": {"moduli": 1, "coeffs": "m6KpsLe+xcw="}}}, {}]}`},
+	{"RTGShare", `{"value": [{"q": {"moduli": 1, "coeffs": "m6KpsLe+xcw="}}, {"p": {"logN": 3}}]}`},
+	{"RTGMultiShare", `{"value": {"5": {"value": [{"q": {"moduli": 1}}]}, "2047": {}, "0": {"value": [{}]}, "9007199254740993": {"value": [{"p": {"moduli": 1}}]}}}`},
+	{"CKSShare", `{"value": {"logN": 1, "moduli": 1, "isNtt": true, "coeffs": "PkVMU1phaG92fYSLkpmgpw=="}}`},
+	{"PCKSShare", `{"c0": {"moduli": 1, "coeffs": "m6KpsLe+xcw="}, "c1": {"logN": 1}}`},
+	{"PCKSShare", `{"c1": {}}`},
+	{"ShamirSecretShare", `{"value": {"q": {"moduli": 1, "isMform": true, "coeffs": "m6KpsLe+xcw="}, "p": {"moduli": 1, "isMform": true, "coeffs": "m6KpsLe+xcw="}}}`},
+}
+
+// golden is a golden vector: the deterministic protobuf binary encoding and the proto3 JSON encoding of a message.
+type golden struct {
+	Message string          `json:"message"`
+	Binary  string          `json:"binary"`
+	JSON    json.RawMessage `json:"json"`
+}
+
+func main() {
+
+	compiler := protocompile.Compiler{Resolver: &protocompile.SourceResolver{ImportPaths: []string{"../.."}}}
+	files, err := compiler.Compile(context.Background(), "lattigo.proto")
+	if err != nil {
+		panic(err)
+	}
+
+	messages := files[0].Messages()
+
+	goldens := make([]golden, len(vectors))
+
+	// The full names of the fields set in at least one vector
+	covered := map[protoreflect.FullName]bool{}
+
+	for i, v := range vectors {
+
+		desc := messages.ByName(protoreflect.Name(v.message))
+		if desc == nil {
+			panic(fmt.Errorf("unknown message %s", v.message))
+		}
+
+		m := dynamicpb.NewMessage(desc)
+		if err = protojson.Unmarshal([]byte(v.value), m); err != nil {
+			panic(err)
+		}
+
+		markFields(m, covered)
+
+		bin, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+		if err != nil {
+			panic(err)
+		}
+
+		js, err := protojson.Marshal(m)
+		if err != nil {
+			panic(err)
+		}
+
+		// protojson randomizes its whitespaces
+		var buf bytes.Buffer
+		if err = json.Compact(&buf, js); err != nil {
+			panic(err)
+		}
+
+		goldens[i] = golden{Message: v.message, Binary: hex.EncodeToString(bin), JSON: buf.Bytes()}
+	}
+
+	// Each field of each message of lattigo.proto must be set in at least one vector
+	for i := 0; i < messages.Len(); i++ {
+		fields := messages.Get(i).Fields()
+		for j := 0; j < fields.Len(); j++ {
+			if !covered[fields.Get(j).FullName()] {
+				panic(fmt.Errorf("field %s is not covered by the vectors", fields.Get(j).FullName()))
+			}
+		}
+	}
+
+	out, err := json.MarshalIndent(goldens, "", "\t")
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Fprintln(os.Stdout, string(out))
+}
+
+// markFields records in covered the fields that are set in the message m or in its sub-messages.
+func markFields(m protoreflect.Message, covered map[protoreflect.FullName]bool) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		covered[fd.FullName()] = true
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					markFields(mv.Message(), covered)
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				for i := 0; i < v.List().Len(); i++ {
+					markFields(v.List().Get(i).Message(), covered)
+				}
+			}
+		case fd.Message() != nil:
+			markFields(v.Message(), covered)
+		}
+		return true
+	})
+}
@@ -0,0 +1,244 @@
+// Package interop implements an interchange schema for the keys, ciphertexts and multiparty shares of Lattigo,
+// for the exchange of these objects with other languages and implementations. The schema is specified by the
+// Protocol Buffers file lattigo.proto of this package: the types of this package mirror its messages, Marshal
+// and Unmarshal implement their protobuf binary encoding, and the encoding/json package produces their proto3
+// JSON mapping. The New* functions and the ToLattigo methods convert between the messages and the Lattigo types.
+// The codec is written by hand rather than generated with protoc-gen-go, so that the module does not depend on
+// google.golang.org/protobuf, which requires a more recent Go version than this module. Both encodings are
+// tested against golden vectors generated with the reference protobuf implementation (see testdata/gen), which
+// set every field of every message of lattigo.proto.
+//
+// The messages do not carry the parameters of the scheme, which are exchanged with Parameters.MarshalJSON, and
+// the messages of the shares do not carry their drlwe.ShareMetadata, which is zero after ToLattigo.
+package interop
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// The wire types of the protobuf binary encoding.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// Message is the interface implemented by the messages of the schema.
+type Message interface {
+	encode(e *encoder)
+	decodeField(f field) error
+}
+
+// Marshal returns the protobuf binary encoding of the message m.
+func Marshal(m Message) ([]byte, error) {
+	e := new(encoder)
+	m.encode(e)
+	return e.buf, nil
+}
+
+// Unmarshal decodes the protobuf binary encoding data on the message m. The unknown fields are skipped.
+// It returns an error if data is malformed.
+func Unmarshal(data []byte, m Message) error {
+	return decodeMessage(data, m)
+}
+
+// encoder writes the protobuf binary encoding of a message. As in proto3, the scalar fields with
+// a default value are omitted.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) varint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func (e *encoder) tag(num, wireType int) {
+	e.varint(uint64(num)<<3 | uint64(wireType))
+}
+
+func (e *encoder) uint(num int, v uint64) {
+	if v != 0 {
+		e.tag(num, wireVarint)
+		e.varint(v)
+	}
+}
+
+func (e *encoder) bool(num int, v bool) {
+	if v {
+		e.uint(num, 1)
+	}
+}
+
+func (e *encoder) double(num int, v float64) {
+	if bits := math.Float64bits(v); bits != 0 {
+		e.tag(num, wireFixed64)
+		var tmp [8]byte
+		binary.LittleEndian.PutUint64(tmp[:], bits)
+		e.buf = append(e.buf, tmp[:]...)
+	}
+}
+
+func (e *encoder) bytes(num int, v []byte) {
+	if len(v) != 0 {
+		e.tag(num, wireBytes)
+		e.varint(uint64(len(v)))
+		e.buf = append(e.buf, v...)
+	}
+}
+
+// message writes the sub-message m, which is written even if it is empty.
+func (e *encoder) message(num int, m Message) {
+	sub := new(encoder)
+	m.encode(sub)
+	e.tag(num, wireBytes)
+	e.varint(uint64(len(sub.buf)))
+	e.buf = append(e.buf, sub.buf...)
+}
+
+// mapEntries writes the entries of a map<uint64, Message> field in increasing order of key.
+func (e *encoder) mapEntries(num int, keys []uint64, value func(key uint64) Message) {
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, key := range keys {
+		e.message(num, &mapEntry{key: key, value: value(key)})
+	}
+}
+
+// field is a decoded field of a message.
+type field struct {
+	num      int
+	wireType int
+	u        uint64 // value of the varint and fixed fields
+	b        []byte // value of the length-delimited fields
+}
+
+func (f field) typeError() error {
+	return fmt.Errorf("invalid wire type %d for field %d", f.wireType, f.num)
+}
+
+func (f field) uint32() (uint32, error) {
+	if f.wireType != wireVarint {
+		return 0, f.typeError()
+	}
+	return uint32(f.u), nil
+}
+
+func (f field) uint64() (uint64, error) {
+	if f.wireType != wireVarint {
+		return 0, f.typeError()
+	}
+	return f.u, nil
+}
+
+func (f field) bool() (bool, error) {
+	if f.wireType != wireVarint {
+		return false, f.typeError()
+	}
+	return f.u != 0, nil
+}
+
+func (f field) double() (float64, error) {
+	if f.wireType != wireFixed64 {
+		return 0, f.typeError()
+	}
+	return math.Float64frombits(f.u), nil
+}
+
+func (f field) bytes() ([]byte, error) {
+	if f.wireType != wireBytes {
+		return nil, f.typeError()
+	}
+	return append([]byte{}, f.b...), nil
+}
+
+// message decodes the field on the sub-message m.
+func (f field) message(m Message) error {
+	if f.wireType != wireBytes {
+		return f.typeError()
+	}
+	return decodeMessage(f.b, m)
+}
+
+// decodeMessage decodes the fields of data on the message m.
+func decodeMessage(data []byte, m Message) error {
+
+	for len(data) > 0 {
+
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("invalid protobuf encoding: malformed tag")
+		}
+		data = data[n:]
+
+		f := field{num: int(tag >> 3), wireType: int(tag & 7)}
+
+		if f.num <= 0 || tag>>3 > math.MaxInt32 {
+			return errors.New("invalid protobuf encoding: invalid field number")
+		}
+
+		switch f.wireType {
+		case wireVarint:
+			if f.u, n = binary.Uvarint(data); n <= 0 {
+				return errors.New("invalid protobuf encoding: malformed varint")
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errors.New("invalid protobuf encoding: truncated fixed64")
+			}
+			f.u, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errors.New("invalid protobuf encoding: truncated fixed32")
+			}
+			f.u, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case wireBytes:
+			var length uint64
+			if length, n = binary.Uvarint(data); n <= 0 {
+				return errors.New("invalid protobuf encoding: malformed length")
+			}
+			data = data[n:]
+			if length > uint64(len(data)) {
+				return errors.New("invalid protobuf encoding: truncated length-delimited field")
+			}
+			f.b, data = data[:length], data[length:]
+		default:
+			return fmt.Errorf("invalid protobuf encoding: unsupported wire type %d", f.wireType)
+		}
+
+		if err := m.decodeField(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapEntry is an entry of a map<uint64, Message> field.
+type mapEntry struct {
+	key   uint64
+	value Message
+}
+
+// encode writes the key and the value of the entry, which are written even if they have a default value
+// as in the reference implementation.
+func (m *mapEntry) encode(e *encoder) {
+	e.tag(1, wireVarint)
+	e.varint(m.key)
+	e.message(2, m.value)
+}
+
+func (m *mapEntry) decodeField(f field) (err error) {
+	switch f.num {
+	case 1:
+		m.key, err = f.uint64()
+	case 2:
+		err = f.message(m.value)
+	}
+	return
+}
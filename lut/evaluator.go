@@ -0,0 +1,204 @@
+package lut
+
+import (
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// Evaluator is a struct that evaluates lookup tables on LWE ciphertexts of parameters paramsLWE with the blind
+// rotation over the parameters paramsBR. It stores a memory pool used to store intermediate computations.
+type Evaluator struct {
+	*rlwe.KeySwitcher
+	paramsBR  rlwe.Parameters
+	paramsLWE rlwe.Parameters
+
+	acc    *rlwe.Ciphertext
+	accTmp *rlwe.Ciphertext
+}
+
+// NewEvaluator creates a new Evaluator for the blind rotation over paramsBR of the LWE ciphertexts of parameters paramsLWE.
+// The ring type of paramsBR must be ring.Standard, and paramsBR must have a non-empty P or a non-zero Pow2Base.
+func NewEvaluator(paramsBR, paramsLWE rlwe.Parameters) *Evaluator {
+
+	if paramsBR.RingType() != ring.Standard {
+		panic("cannot NewEvaluator: the ring type of paramsBR must be ring.Standard")
+	}
+
+	return &Evaluator{
+		KeySwitcher: rlwe.NewKeySwitcher(paramsBR),
+		paramsBR:    paramsBR,
+		paramsLWE:   paramsLWE,
+		acc:         rlwe.NewCiphertext(paramsBR, 1, paramsBR.MaxLevel()),
+		accTmp:      rlwe.NewCiphertext(paramsBR, 1, paramsBR.MaxLevel()),
+	}
+}
+
+// ShallowCopy creates a shallow copy of the Evaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// Evaluators can be used concurrently.
+func (eval *Evaluator) ShallowCopy() *Evaluator {
+	return &Evaluator{
+		KeySwitcher: eval.KeySwitcher.ShallowCopy(),
+		paramsBR:    eval.paramsBR,
+		paramsLWE:   eval.paramsLWE,
+		acc:         rlwe.NewCiphertext(eval.paramsBR, 1, eval.paramsBR.MaxLevel()),
+		accTmp:      rlwe.NewCiphertext(eval.paramsBR, 1, eval.paramsBR.MaxLevel()),
+	}
+}
+
+// EvaluateNew evaluates the lookup table, given by its test polynomial lut (see InitLUT), on the LWE ciphertext ct
+// and returns the result in a new RLWE ciphertext of paramsBR, in the coefficient domain (see Evaluate).
+func (eval *Evaluator) EvaluateNew(ct *LWECiphertext, lut *ring.Poly, brk *BlindRotationKey) (ctOut *rlwe.Ciphertext) {
+	ctOut = rlwe.NewCiphertext(eval.paramsBR, 1, brk.SkPos[0].LevelQ())
+	eval.Evaluate(ct, lut, brk, ctOut)
+	return
+}
+
+// Evaluate evaluates the lookup table, given by its test polynomial lut (see InitLUT), on the LWE ciphertext ct
+// with the blind-rotation key brk and returns the result on ctOut, in the domain of ctOut. The constant coefficient
+// of ctOut is an encryption, under the RLWE secret of brk, of the lookup table evaluated on the phase of ct, and can
+// be extracted as an LWE ciphertext with ExtractLWE.
+func (eval *Evaluator) Evaluate(ct *LWECiphertext, lut *ring.Poly, brk *BlindRotationKey, ctOut *rlwe.Ciphertext) {
+
+	if len(ct.A) != len(brk.SkPos) {
+		panic("cannot Evaluate: the dimension of the LWE ciphertext does not match the blind-rotation key")
+	}
+
+	ringQ := eval.paramsBR.RingQ()
+
+	levelQ := utils.MinInt(ctOut.Level(), brk.SkPos[0].LevelQ())
+
+	N := ringQ.N
+	twoN := uint64(2 * N)
+
+	acc := eval.acc
+	acc.Value[0].Coeffs = acc.Value[0].Coeffs[:levelQ+1]
+	acc.Value[1].Coeffs = acc.Value[1].Coeffs[:levelQ+1]
+
+	// acc = (X^{-(b + N/2)} * lut, 0), where b is the modulus switch of the LWE ciphertext to 2N,
+	// so that the constant coefficient of X^{-(phase + N/2)} * lut is lut[phase + N/2].
+	mulByMonomialLvl(ringQ, levelQ, lut, int((twoN-(eval.modSwitch(ct.B)+uint64(N/2))%twoN)%twoN), acc.Value[0])
+	acc.Value[1].Zero()
+
+	// acc = acc * X^{-a[i] * s[i]} = acc + (X^{-a[i]} - 1) * acc * s+[i] + (X^{a[i]} - 1) * acc * s-[i]
+	for i, ai := range ct.A {
+
+		a := eval.modSwitch(ai)
+
+		if a == 0 {
+			continue
+		}
+
+		eval.ExternalProduct(acc, brk.SkPos[i], eval.accTmp)
+		mulByMonomialMinusOneAndAddLvl(ringQ, levelQ, eval.accTmp, int(twoN-a), acc)
+
+		eval.ExternalProduct(acc, brk.SkNeg[i], eval.accTmp)
+		mulByMonomialMinusOneAndAddLvl(ringQ, levelQ, eval.accTmp, int(a), acc)
+	}
+
+	if ctOut.Value[0].IsNTT {
+		ringQ.NTTLvl(levelQ, acc.Value[0], ctOut.Value[0])
+		ringQ.NTTLvl(levelQ, acc.Value[1], ctOut.Value[1])
+		ctOut.Value[1].IsNTT = true
+	} else {
+		ring.CopyValuesLvl(levelQ, acc.Value[0], ctOut.Value[0])
+		ring.CopyValuesLvl(levelQ, acc.Value[1], ctOut.Value[1])
+		ctOut.Value[1].IsNTT = false
+	}
+
+	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:levelQ+1]
+	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:levelQ+1]
+}
+
+// EvaluateBatch evaluates the lookup table, given by its test polynomial lut (see InitLUT), on each of the LWE
+// ciphertexts cts and returns the results in new RLWE ciphertexts of paramsBR, in the coefficient domain.
+// The ciphertexts are split across paramsBR.Parallelism() shallow copies of the Evaluator.
+func (eval *Evaluator) EvaluateBatch(cts []*LWECiphertext, lut *ring.Poly, brk *BlindRotationKey) (ctsOut []*rlwe.Ciphertext) {
+	return eval.EvaluateBatchLvl(brk.SkPos[0].LevelQ(), cts, lut, brk)
+}
+
+// EvaluateBatchLvl is as EvaluateBatch, but the blind rotations are carried out, and the results returned, at the level
+// min(level, level of brk), which reduces their cost when the results are only needed at a lower level.
+func (eval *Evaluator) EvaluateBatchLvl(level int, cts []*LWECiphertext, lut *ring.Poly, brk *BlindRotationKey) (ctsOut []*rlwe.Ciphertext) {
+
+	level = utils.MinInt(level, brk.SkPos[0].LevelQ())
+
+	workers := utils.MaxInt(1, utils.MinInt(eval.paramsBR.Parallelism(), len(cts)))
+
+	evals := make([]*Evaluator, workers)
+	evals[0] = eval
+	for w := 1; w < workers; w++ {
+		evals[w] = eval.ShallowCopy()
+	}
+
+	ctsOut = make([]*rlwe.Ciphertext, len(cts))
+
+	utils.ParallelFor(workers, workers, func(w int) {
+		for i := w; i < len(cts); i += workers {
+			ctsOut[i] = rlwe.NewCiphertext(eval.paramsBR, 1, level)
+			evals[w].Evaluate(cts[i], lut, brk, ctsOut[i])
+		}
+	})
+
+	return
+}
+
+// modSwitch returns round(x * 2N / Q0) mod 2N, where Q0 is the modulus of the LWE ciphertexts.
+func (eval *Evaluator) modSwitch(x uint64) uint64 {
+	q := eval.paramsLWE.RingQ().Modulus[0]
+	twoN := uint64(eval.paramsBR.N() << 1)
+	hi, lo := bits.Mul64(x, twoN)
+	lo, carry := bits.Add64(lo, q>>1, 0)
+	quo, _ := bits.Div64(hi+carry, lo, q)
+	return quo % twoN
+}
+
+// mulByMonomialLvl sets pOut to p * X^k for k in [0, 2N).
+func mulByMonomialLvl(ringQ *ring.Ring, level int, p *ring.Poly, k int, pOut *ring.Poly) {
+
+	N := ringQ.N
+
+	for i := 0; i < level+1; i++ {
+
+		q := ringQ.Modulus[i]
+		pi, pOuti := p.Coeffs[i], pOut.Coeffs[i]
+
+		for j := 0; j < N; j++ {
+			// The coefficient j of p * X^k is p[j-k], negated if j-k mod 2N is in [N, 2N)
+			if t := (j - k + 2*N) % (2 * N); t < N {
+				pOuti[j] = pi[t]
+			} else if pi[t-N] != 0 {
+				pOuti[j] = q - pi[t-N]
+			} else {
+				pOuti[j] = 0
+			}
+		}
+	}
+}
+
+// mulByMonomialMinusOneAndAddLvl adds ct * (X^k - 1) to ctOut for k in [0, 2N). ct and ctOut must be distinct.
+func mulByMonomialMinusOneAndAddLvl(ringQ *ring.Ring, level int, ct *rlwe.Ciphertext, k int, ctOut *rlwe.Ciphertext) {
+
+	N := ringQ.N
+
+	for u := range ct.Value {
+		for i := 0; i < level+1; i++ {
+
+			q := ringQ.Modulus[i]
+			pi, pOuti := ct.Value[u].Coeffs[i], ctOut.Value[u].Coeffs[i]
+
+			for j := 0; j < N; j++ {
+				x := pOuti[j] + q - pi[j]
+				if t := (j - k + 2*N) % (2 * N); t < N {
+					x += pi[t]
+				} else {
+					x += q - pi[t-N]
+				}
+				pOuti[j] = ring.BRedAdd(x, q, ringQ.BredParams[i])
+			}
+		}
+	}
+}
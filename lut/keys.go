@@ -0,0 +1,57 @@
+package lut
+
+import (
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+// BlindRotationKey is a type for the blind-rotation keys. For each coefficient s[i] of the ternary LWE secret,
+// it stores an RGSW encryption of 1 if s[i] = 1 and of 0 otherwise in SkPos[i], and an RGSW encryption of 1
+// if s[i] = -1 and of 0 otherwise in SkNeg[i], under the RLWE secret of the blind rotation.
+type BlindRotationKey struct {
+	SkPos []*rlwe.RGSWCiphertext
+	SkNeg []*rlwe.RGSWCiphertext
+}
+
+// GenBlindRotationKey generates the BlindRotationKey of the LWE secret skLWE, of parameters paramsLWE,
+// under the RLWE secret skBR, of parameters paramsBR. The coefficients of skLWE must be in {-1, 0, 1}.
+func GenBlindRotationKey(paramsBR rlwe.Parameters, skBR *rlwe.SecretKey, paramsLWE rlwe.Parameters, skLWE *rlwe.SecretKey) (brk *BlindRotationKey) {
+
+	levelQ, levelP := paramsBR.QCount()-1, paramsBR.PCount()-1
+
+	encryptor := rlwe.NewRGSWEncryptor(paramsBR, skBR)
+
+	one := rlwe.NewPlaintext(paramsBR, levelQ)
+	for i := range one.Value.Coeffs {
+		one.Value.Coeffs[i][0] = 1
+	}
+
+	s := secretKeyCoefficients(paramsLWE, skLWE)
+
+	brk = &BlindRotationKey{
+		SkPos: make([]*rlwe.RGSWCiphertext, len(s)),
+		SkNeg: make([]*rlwe.RGSWCiphertext, len(s)),
+	}
+
+	for i, si := range s {
+
+		var ptPos, ptNeg *rlwe.Plaintext
+
+		switch si {
+		case 1:
+			ptPos = one
+		case -1:
+			ptNeg = one
+		case 0:
+		default:
+			panic("cannot GenBlindRotationKey: skLWE must be ternary")
+		}
+
+		brk.SkPos[i] = rlwe.NewRGSWCiphertext(paramsBR, levelQ, levelP)
+		encryptor.Encrypt(ptPos, brk.SkPos[i])
+
+		brk.SkNeg[i] = rlwe.NewRGSWCiphertext(paramsBR, levelQ, levelP)
+		encryptor.Encrypt(ptNeg, brk.SkNeg[i])
+	}
+
+	return
+}
@@ -0,0 +1,34 @@
+// Package lut implements the evaluation of lookup tables on LWE ciphertexts with the blind rotation of
+// the FHEW/TFHE schemes (a.k.a. programmable or functional bootstrapping): the LWE ciphertexts are
+// switched to the modulus 2N, and a test polynomial encoding the lookup table is homomorphically rotated
+// by their phase with RGSW encryptions of their secret key, which outputs RLWE ciphertexts with a fresh noise.
+package lut
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+)
+
+// InitLUT returns the test polynomial, in the coefficient domain, of the function g for the blind rotation
+// over ringQ. The blind rotation of an LWE ciphertext of phase x * Q0 with this test polynomial outputs an
+// RLWE ciphertext whose constant coefficient encrypts round(g(x) * scale), for x in [-1/4, 1/4).
+//
+// The phase x is rounded to a multiple of 1/(2N) by the modulus switching, with an error that grows with
+// the Hamming weight of the LWE secret, hence g should be constant around the encoded messages.
+// Outside of [-1/4, 1/4), the blind rotation outputs -g(x - 1/2) for x >= 1/4 and -g(x + 1/2) for x < -1/4.
+func InitLUT(g func(x float64) float64, scale float64, ringQ *ring.Ring) (lut *ring.Poly) {
+
+	N := ringQ.N
+
+	coeffs := make([]*big.Int, N)
+	for j := range coeffs {
+		coeffs[j], _ = new(big.Float).SetFloat64(math.Round(g(float64(j-N/2)/float64(2*N)) * scale)).Int(nil)
+	}
+
+	lut = ringQ.NewPoly()
+	ringQ.SetCoefficientsBigint(coeffs, lut)
+
+	return
+}
@@ -0,0 +1,133 @@
+package lut
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+func testString(params rlwe.Parameters, opname string) string {
+	return fmt.Sprintf("%s/logN=%d/logQ=%d/logP=%d/#Qi=%d/#Pi=%d",
+		opname,
+		params.LogN(),
+		params.LogQ(),
+		params.LogP(),
+		params.QCount(),
+		params.PCount())
+}
+
+// TestParamsLWE is the set of parameters of the test LWE ciphertexts.
+var TestParamsLWE = rlwe.ParametersLiteral{LogN: 9, Q: []uint64{0x3001}, P: []uint64{}, H: 64}
+
+// TestParamsBR is the set of parameters of the test blind rotation.
+var TestParamsBR = rlwe.ParametersLiteral{LogN: 10, LogQ: []int{50}, LogP: []int{50}}
+
+func TestLUT(t *testing.T) {
+
+	paramsLWE, err := rlwe.NewParametersFromLiteral(TestParamsLWE)
+	require.NoError(t, err)
+
+	paramsBR, err := rlwe.NewParametersFromLiteral(TestParamsBR)
+	require.NoError(t, err)
+
+	skLWE := rlwe.NewKeyGenerator(paramsLWE).GenSecretKey()
+	skBR := rlwe.NewKeyGenerator(paramsBR).GenSecretKey()
+
+	// Encodes the messages m in [-T/2, T/2) as round((m + 1/2) * Q0 / (2T)), hence in the middle of the
+	// intervals [m/(2T), (m+1)/(2T)) that partition the phases [-1/4, 1/4)
+	T := 8
+	q := paramsLWE.RingQ().Modulus[0]
+
+	cts := make([]*LWECiphertext, paramsLWE.N())
+	values := make([]int, paramsLWE.N())
+
+	pt := rlwe.NewPlaintext(paramsLWE, 0)
+	for j := range values {
+		values[j] = j%T - T/2
+		pt.Value.Coeffs[0][j] = uint64(math.Round(float64(int(q)*(2*values[j]+1))/float64(4*T))+float64(q)) % q
+	}
+
+	ct := rlwe.NewCiphertextNTT(paramsLWE, 1, 0)
+	rlwe.NewEncryptor(paramsLWE, skLWE).Encrypt(pt, ct)
+
+	for j := range cts {
+		cts[j] = ExtractLWE(paramsLWE, ct, j)
+	}
+
+	t.Run(testString(paramsLWE, "ExtractLWE"), func(t *testing.T) {
+		for j := range cts {
+			phase := DecryptLWE(paramsLWE, cts[j], skLWE)
+			diff := int64(phase) - int64(pt.Value.Coeffs[0][j])
+			if diff > int64(q>>1) {
+				diff -= int64(q)
+			} else if diff < -int64(q>>1) {
+				diff += int64(q)
+			}
+			require.Less(t, math.Abs(float64(diff)), 6*paramsLWE.Sigma())
+		}
+	})
+
+	brk := GenBlindRotationKey(paramsBR, skBR, paramsLWE, skLWE)
+
+	eval := NewEvaluator(paramsBR, paramsLWE)
+	decryptor, err := rlwe.NewDecryptor(paramsBR, skBR)
+	require.NoError(t, err)
+
+	table := []int{3, -2, 0, 1, -4, 2, -1, 0}
+	g := func(x float64) float64 {
+		return float64(table[int(math.Floor(x*float64(2*T)))+T/2])
+	}
+
+	scale := math.Exp2(40)
+	lut := InitLUT(g, scale, paramsBR.RingQ())
+
+	verify := func(t *testing.T, ctOut *rlwe.Ciphertext, want int) {
+		ptOut := rlwe.NewPlaintext(paramsBR, ctOut.Level())
+		decryptor.Decrypt(ctOut, ptOut)
+		if ptOut.Value.IsNTT {
+			paramsBR.RingQ().InvNTTLvl(ptOut.Level(), ptOut.Value, ptOut.Value)
+		}
+		coeffs := make([]*big.Int, paramsBR.N())
+		for i := range coeffs {
+			coeffs[i] = new(big.Int)
+		}
+		paramsBR.RingQ().PolyToBigintCenteredLvl(ptOut.Level(), ptOut.Value, 1, coeffs)
+		have, _ := new(big.Float).Quo(new(big.Float).SetInt(coeffs[0]), big.NewFloat(scale)).Float64()
+		require.Equal(t, want, int(math.Round(have)))
+	}
+
+	t.Run(testString(paramsBR, "Evaluate"), func(t *testing.T) {
+		for j := 0; j < 2*T; j++ {
+			verify(t, eval.EvaluateNew(cts[j], lut, brk), table[values[j]+T/2])
+		}
+	})
+
+	t.Run(testString(paramsBR, "EvaluateNTT"), func(t *testing.T) {
+		ctOut := rlwe.NewCiphertextNTT(paramsBR, 1, paramsBR.MaxLevel())
+		eval.Evaluate(cts[1], lut, brk, ctOut)
+		verify(t, ctOut, table[values[1]+T/2])
+	})
+
+	t.Run(testString(paramsBR, "EvaluateBatch"), func(t *testing.T) {
+		paramsBR := paramsBR.WithParallelism(runtime.NumCPU())
+		eval := NewEvaluator(paramsBR, paramsLWE)
+		ctsOut := eval.EvaluateBatch(cts[:2*T], lut, brk)
+		for j := range ctsOut {
+			verify(t, ctsOut[j], table[values[j]+T/2])
+		}
+	})
+
+	t.Run(testString(paramsBR, "EvaluateBatchLvl"), func(t *testing.T) {
+		ctsOut := eval.EvaluateBatchLvl(0, cts[:2], lut, brk)
+		for j := range ctsOut {
+			require.Equal(t, 0, ctsOut[j].Level())
+			verify(t, ctsOut[j], table[values[j]+T/2])
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package lut
+
+import (
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+// LWECiphertext is a type for LWE ciphertexts modulo the first modulus Q0 of a set of rlwe.Parameters.
+// Its phase is B + <A, s> mod Q0, where s is the vector of the coefficients of an rlwe.SecretKey.
+type LWECiphertext struct {
+	B uint64
+	A []uint64
+}
+
+// ExtractLWE extracts the idx-th coefficient of the RLWE ciphertext ct, at level 0, as an LWE ciphertext
+// under the coefficients of the secret key of ct. The ciphertext can be in the NTT domain.
+func ExtractLWE(params rlwe.Parameters, ct *rlwe.Ciphertext, idx int) *LWECiphertext {
+
+	if params.RingType() != ring.Standard {
+		panic("cannot ExtractLWE: the ring type must be ring.Standard")
+	}
+
+	ringQ := params.RingQ()
+	N := ringQ.N
+	q := ringQ.Modulus[0]
+
+	b, a := ct.Value[0].Coeffs[0], ct.Value[1].Coeffs[0]
+
+	if ct.Value[0].IsNTT {
+		tmp := ringQ.NewPolyLvl(0)
+		ringQ.InvNTTLvl(0, ct.Value[0], tmp)
+		b = tmp.Coeffs[0]
+		tmp = ringQ.NewPolyLvl(0)
+		ringQ.InvNTTLvl(0, ct.Value[1], tmp)
+		a = tmp.Coeffs[0]
+	}
+
+	lwe := &LWECiphertext{B: b[idx], A: make([]uint64, N)}
+
+	// The idx-th coefficient of a * s is sum_{j<=idx} a[idx-j] * s[j] - sum_{j>idx} a[N+idx-j] * s[j]
+	for j := 0; j <= idx; j++ {
+		lwe.A[j] = a[idx-j]
+	}
+
+	for j := idx + 1; j < N; j++ {
+		if c := a[N+idx-j]; c != 0 {
+			lwe.A[j] = q - c
+		}
+	}
+
+	return lwe
+}
+
+// DecryptLWE returns the phase B + <A, s> mod Q0 of the LWE ciphertext ct, where s is the vector of the
+// coefficients of the secret key sk.
+func DecryptLWE(params rlwe.Parameters, ct *LWECiphertext, sk *rlwe.SecretKey) uint64 {
+
+	ringQ := params.RingQ()
+	q := ringQ.Modulus[0]
+
+	phase := ct.B
+	for j, sj := range secretKeyCoefficients(params, sk) {
+		if sj < 0 {
+			sj += int64(q)
+		}
+		phase = ring.CRed(phase+ring.BRed(ct.A[j], uint64(sj), q, ringQ.BredParams[0]), q)
+	}
+
+	return phase
+}
+
+// secretKeyCoefficients returns the centered coefficients of the secret key sk modulo Q0.
+func secretKeyCoefficients(params rlwe.Parameters, sk *rlwe.SecretKey) (s []int64) {
+
+	ringQ := params.RingQ()
+	q := ringQ.Modulus[0]
+
+	tmp := ringQ.NewPolyLvl(0)
+	ringQ.InvNTTLvl(0, sk.Value.Q, tmp)
+	ringQ.InvMFormLvl(0, tmp, tmp)
+
+	s = make([]int64, ringQ.N)
+	for j, c := range tmp.Coeffs[0] {
+		if c > q>>1 {
+			s[j] = -int64(q - c)
+		} else {
+			s[j] = int64(c)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,141 @@
+package ole
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+// MarshalBinary encodes the target FirstMessage on a slice of bytes.
+func (msg *FirstMessage) MarshalBinary() (data []byte, err error) {
+	return marshalPolys(rlwe.KindOLEFirstMessage, msg.Value)
+}
+
+// UnmarshalBinary decodes a slice of bytes generated by MarshalBinary on the target FirstMessage.
+func (msg *FirstMessage) UnmarshalBinary(data []byte) (err error) {
+	msg.Value, err = unmarshalPolys(rlwe.KindOLEFirstMessage, data)
+	return
+}
+
+// MarshalBinary encodes the target SecondMessage on a slice of bytes.
+func (msg *SecondMessage) MarshalBinary() (data []byte, err error) {
+	return marshalPolys(rlwe.KindOLESecondMessage, msg.Value)
+}
+
+// UnmarshalBinary decodes a slice of bytes generated by MarshalBinary on the target SecondMessage.
+func (msg *SecondMessage) UnmarshalBinary(data []byte) (err error) {
+	msg.Value, err = unmarshalPolys(rlwe.KindOLESecondMessage, data)
+	return
+}
+
+// MarshalBinary encodes the target Setup on a slice of bytes.
+func (setup *Setup) MarshalBinary() (data []byte, err error) {
+	return marshalPolys(rlwe.KindOLESetup, []*ring.Poly{setup.Sk, setup.Sigma})
+}
+
+// UnmarshalBinary decodes a slice of bytes generated by MarshalBinary on the target Setup.
+func (setup *Setup) UnmarshalBinary(data []byte) (err error) {
+
+	var polys []*ring.Poly
+	if polys, err = unmarshalPolys(rlwe.KindOLESetup, data); err != nil {
+		return err
+	}
+
+	if len(polys) != 2 {
+		return errors.New("Setup: invalid number of polynomials")
+	}
+
+	setup.Sk, setup.Sigma = polys[0], polys[1]
+
+	return nil
+}
+
+// marshalPolys encodes, in an rlwe.Envelope of the given kind, the number of polynomials on 4 bytes followed, for
+// each polynomial, by the length of its encoding on 4 bytes and its encoding. The Envelope does not record the
+// parameters, and its LogN and Level are the ones of the first polynomial.
+func marshalPolys(kind rlwe.ObjectKind, polys []*ring.Poly) (data []byte, err error) {
+
+	data = make([]byte, rlwe.EnvelopeHeaderLen+4)
+	binary.BigEndian.PutUint32(data[rlwe.EnvelopeHeaderLen:], uint32(len(polys)))
+
+	for _, pol := range polys {
+
+		var polData []byte
+		if polData, err = pol.MarshalBinary(); err != nil {
+			return nil, err
+		}
+
+		header := make([]byte, 4)
+		binary.BigEndian.PutUint32(header, uint32(len(polData)))
+
+		data = append(data, header...)
+		data = append(data, polData...)
+	}
+
+	rlwe.NewEnvelope(kind, envelopePoly(polys), 0).WriteHeader(data)
+
+	return data, nil
+}
+
+// unmarshalPolys decodes a slice of bytes generated by marshalPolys with the given kind.
+func unmarshalPolys(kind rlwe.ObjectKind, data []byte) (polys []*ring.Poly, err error) {
+
+	var env rlwe.Envelope
+	if env, data, err = rlwe.OpenEnvelope(data, kind); err != nil {
+		return nil, err
+	}
+
+	if env.IsLegacy() {
+		return nil, fmt.Errorf("invalid envelope: %s has no legacy encoding", kind)
+	}
+
+	if len(data) < 4 {
+		return nil, errors.New("too small bytearray")
+	}
+
+	// Each polynomial is encoded on at least 8 bytes, which bounds the count before the allocation.
+	nbPolys := binary.BigEndian.Uint32(data)
+	if uint64(nbPolys) > uint64(len(data)-4)/8 {
+		return nil, errors.New("too small bytearray")
+	}
+
+	polys = make([]*ring.Poly, nbPolys)
+
+	ptr := 4
+	for i := range polys {
+
+		if len(data) < ptr+4 {
+			return nil, errors.New("too small bytearray")
+		}
+
+		polLen := int(binary.BigEndian.Uint32(data[ptr:]))
+		ptr += 4
+
+		if polLen < 4 || len(data) < ptr+polLen {
+			return nil, errors.New("too small bytearray")
+		}
+
+		polys[i] = new(ring.Poly)
+		if err = polys[i].UnmarshalBinary(data[ptr : ptr+polLen]); err != nil {
+			return nil, err
+		}
+		ptr += polLen
+	}
+
+	if ptr != len(data) {
+		return nil, errors.New("remaining unparsed data")
+	}
+
+	return polys, env.CheckPoly(envelopePoly(polys), 0)
+}
+
+// envelopePoly returns the first polynomial of polys, or nil if polys is empty.
+func envelopePoly(polys []*ring.Poly) *ring.Poly {
+	if len(polys) == 0 {
+		return nil
+	}
+	return polys[0]
+}
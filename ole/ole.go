@@ -0,0 +1,268 @@
+package ole
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// CRP is a struct storing the public uniformly random polynomials of a batch of vOLEs.
+type CRP struct {
+	A      []*ring.Poly // uniform polynomials mod Q, in the NTT domain
+	APrime []*ring.Poly // uniform polynomials mod P, in the NTT and Montgomery domains
+}
+
+// SampleCRP samples from the common reference string crs the public polynomials of a batch of n vOLEs.
+func SampleCRP(params Parameters, n int, crs utils.PRNG) (crp *CRP) {
+
+	ringQ := params.RingQ()
+	uniformSampler := ring.NewUniformSampler(crs, ringQ)
+
+	crp = &CRP{A: make([]*ring.Poly, n), APrime: make([]*ring.Poly, n)}
+	for i := 0; i < n; i++ {
+		crp.A[i] = uniformSampler.ReadNew()
+		crp.APrime[i] = uniformSampler.ReadLvlNew(params.PLevel())
+		ringQ.MFormLvl(params.PLevel(), crp.APrime[i], crp.APrime[i])
+	}
+
+	return
+}
+
+// Len returns the number of vOLEs of the batch.
+func (crp *CRP) Len() int {
+	return len(crp.A)
+}
+
+// Setup is a struct storing the secret setup of a party: its secret key sk and its additive share sigma of the
+// product of the secret keys of the Receiver and the Sender, both mod Q in the NTT and Montgomery domains.
+type Setup struct {
+	Sk    *ring.Poly
+	Sigma *ring.Poly
+}
+
+// GenSetup generates the secret setups of the Receiver and the Sender, such that sigmaR + sigmaS = skR * skS mod Q.
+// It implements the setup functionality of the protocol as a trusted dealer.
+func GenSetup(params Parameters) (receiver, sender *Setup) {
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	ringQ := params.RingQ()
+	ternarySampler := ring.NewTernarySampler(prng, ringQ, 1.0/3.0, true)
+
+	receiver = &Setup{Sk: ternarySampler.ReadNew(), Sigma: ringQ.NewPoly()}
+	sender = &Setup{Sk: ternarySampler.ReadNew(), Sigma: ring.NewUniformSampler(prng, ringQ).ReadNew()}
+	ringQ.NTT(receiver.Sk, receiver.Sk)
+	ringQ.NTT(sender.Sk, sender.Sk)
+
+	// sigmaR = skR * skS - sigmaS
+	ringQ.MulCoeffsMontgomery(receiver.Sk, sender.Sk, receiver.Sigma)
+	ringQ.Sub(receiver.Sigma, sender.Sigma, receiver.Sigma)
+
+	return
+}
+
+// FirstMessage is a struct storing the message c = (Q/P) * u + a * skR + eR mod Q, in the NTT domain, of each vOLE of
+// a batch, sent by the Receiver to the Sender.
+type FirstMessage struct {
+	Value []*ring.Poly
+}
+
+// SecondMessage is a struct storing the message d = (P/M) * v + a' * skS + eS mod P, in the NTT domain, of each vOLE of
+// a batch, sent by the Sender to the Receiver.
+type SecondMessage struct {
+	Value []*ring.Poly
+}
+
+// Receiver is the party of the vOLE protocol with the input u, which obtains the output beta.
+type Receiver struct {
+	params       Parameters
+	setup        *Setup
+	errorSampler ring.ErrorSampler
+	pool         *ring.Poly
+
+	u   []*ring.Poly // NTT(MForm(u)) mod P
+	rho []*ring.Poly // NTT(-(a * sigmaR) * (P/Q)) mod P
+}
+
+// NewReceiver creates a new Receiver with the secret setup setup.
+func NewReceiver(params Parameters, setup *Setup) *Receiver {
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	return &Receiver{
+		params:       params,
+		setup:        setup,
+		errorSampler: params.ErrorDistribution().NewSampler(prng, params.RingQ(), params.Sigma()),
+		pool:         params.RingQ().NewPoly(),
+	}
+}
+
+// GenFirstMessage generates the first message of a batch of vOLEs with the inputs u, which are polynomials mod M in
+// the coefficient domain, and the public polynomials crp. It panics if the number of inputs is not crp.Len().
+func (r *Receiver) GenFirstMessage(u []*ring.Poly, crp *CRP) (msg *FirstMessage) {
+
+	if len(u) != crp.Len() {
+		panic(fmt.Errorf("cannot GenFirstMessage: the number of inputs (%d) must be equal to the number of vOLEs of the CRP (%d)", len(u), crp.Len()))
+	}
+
+	params := r.params
+	ringQ := params.RingQ()
+	qLevel, pLevel := params.QLevel(), params.PLevel()
+
+	msg = &FirstMessage{Value: make([]*ring.Poly, len(u))}
+	r.u = make([]*ring.Poly, len(u))
+	r.rho = make([]*ring.Poly, len(u))
+
+	tmp := ringQ.NewPoly()
+
+	for i := range u {
+
+		// tmp = NTT(u) mod Q
+		liftLvl(params, u[i], qLevel, tmp)
+		ringQ.NTT(tmp, tmp)
+
+		r.u[i] = ringQ.NewPolyLvl(pLevel)
+		ringQ.MFormLvl(pLevel, tmp, r.u[i])
+
+		// c = NTT(u * (Q/P) + e + a * skR)
+		c := ringQ.NewPoly()
+		r.errorSampler.ReadAndAddLvl(qLevel, c)
+		ringQ.NTT(c, c)
+		ringQ.MulScalarBigint(tmp, params.qDivP, tmp)
+		ringQ.Add(c, tmp, c)
+		ringQ.MulCoeffsMontgomeryAndAdd(crp.A[i], r.setup.Sk, c)
+		msg.Value[i] = c
+
+		// rhoR = NTT(-(a * sigmaR) * (P/Q))
+		rho := ringQ.NewPoly()
+		ringQ.MulCoeffsMontgomery(crp.A[i], r.setup.Sigma, rho)
+		ringQ.DivRoundByLastModulusManyNTTLvl(qLevel, qLevel-pLevel, rho, r.pool, rho)
+		rho.Coeffs = rho.Coeffs[:pLevel+1]
+		ringQ.NegLvl(pLevel, rho, rho)
+		r.rho[i] = rho
+	}
+
+	return
+}
+
+// Finalize returns the outputs beta = (u * d - a' * rhoR) * (M/P) mod M, in the coefficient domain, of the batch of
+// vOLEs from the second message of the Sender and the public polynomials crp. It panics if GenFirstMessage was not
+// called first or if the message does not match the batch.
+func (r *Receiver) Finalize(msg *SecondMessage, crp *CRP) (beta []*ring.Poly) {
+
+	if len(r.u) == 0 || len(msg.Value) != len(r.u) || crp.Len() != len(r.u) {
+		panic(fmt.Errorf("cannot Finalize: the second message and the CRP must match the batch of the first message"))
+	}
+
+	params := r.params
+	ringQ := params.RingQ()
+	pLevel, mLevel := params.PLevel(), params.MLevel()
+
+	beta = make([]*ring.Poly, len(r.u))
+	for i := range beta {
+		beta[i] = ringQ.NewPolyLvl(pLevel)
+		ringQ.MulCoeffsMontgomeryLvl(pLevel, r.u[i], msg.Value[i], beta[i])
+		ringQ.MulCoeffsMontgomeryAndSubLvl(pLevel, crp.APrime[i], r.rho[i], beta[i])
+		ringQ.InvNTTLvl(pLevel, beta[i], beta[i])
+		ringQ.DivRoundByLastModulusManyLvl(pLevel, pLevel-mLevel, beta[i], r.pool, beta[i])
+		beta[i].Coeffs = beta[i].Coeffs[:mLevel+1]
+	}
+
+	r.u, r.rho = nil, nil
+
+	return
+}
+
+// Sender is the party of the vOLE protocol with the input v, which obtains the output alpha.
+type Sender struct {
+	params       Parameters
+	setup        *Setup
+	sk           *ring.Poly // NTT(skS) mod P
+	errorSampler ring.ErrorSampler
+	pool         *ring.Poly
+}
+
+// NewSender creates a new Sender with the secret setup setup.
+func NewSender(params Parameters, setup *Setup) *Sender {
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	ringQ := params.RingQ()
+
+	sk := ringQ.NewPolyLvl(params.PLevel())
+	ringQ.InvMFormLvl(params.PLevel(), setup.Sk, sk)
+
+	return &Sender{
+		params:       params,
+		setup:        setup,
+		sk:           sk,
+		errorSampler: params.ErrorDistribution().NewSampler(prng, ringQ, params.Sigma()),
+		pool:         ringQ.NewPoly(),
+	}
+}
+
+// GenSecondMessage generates, from the first message of the Receiver, the second message of a batch of vOLEs with the
+// inputs v, which are polynomials mod M in the coefficient domain, and the public polynomials crp. It also returns the
+// outputs alpha = -(a' * rhoS) * (M/P) mod M, in the coefficient domain, where rhoS = (skS * c - a * sigmaS) * (P/Q).
+// It panics if the number of inputs or the first message do not match crp.Len().
+func (s *Sender) GenSecondMessage(first *FirstMessage, v []*ring.Poly, crp *CRP) (msg *SecondMessage, alpha []*ring.Poly) {
+
+	if len(v) != crp.Len() || len(first.Value) != crp.Len() {
+		panic(fmt.Errorf("cannot GenSecondMessage: the number of inputs (%d) and of polynomials of the first message (%d) must be equal to the number of vOLEs of the CRP (%d)", len(v), len(first.Value), crp.Len()))
+	}
+
+	params := s.params
+	ringQ := params.RingQ()
+	qLevel, pLevel, mLevel := params.QLevel(), params.PLevel(), params.MLevel()
+
+	msg = &SecondMessage{Value: make([]*ring.Poly, len(v))}
+	alpha = make([]*ring.Poly, len(v))
+
+	rho := ringQ.NewPoly()
+
+	for i := range v {
+
+		// rhoS = NTT(skS * c - a * sigmaS) * (P/Q)
+		ringQ.MulCoeffsMontgomery(s.setup.Sk, first.Value[i], rho)
+		ringQ.MulCoeffsMontgomeryAndSub(crp.A[i], s.setup.Sigma, rho)
+		ringQ.DivRoundByLastModulusManyNTTLvl(qLevel, qLevel-pLevel, rho, s.pool, rho)
+
+		// d = NTT(v * (P/M) + e + a' * skS)
+		d := ringQ.NewPolyLvl(pLevel)
+		liftLvl(params, v[i], pLevel, d)
+		ringQ.MulScalarBigintLvl(pLevel, d, params.pDivM, d)
+		s.errorSampler.ReadAndAddLvl(pLevel, d)
+		ringQ.NTTLvl(pLevel, d, d)
+		ringQ.MulCoeffsMontgomeryAndAddLvl(pLevel, crp.APrime[i], s.sk, d)
+		msg.Value[i] = d
+
+		// alpha = -(a' * rhoS) * (M/P)
+		alpha[i] = ringQ.NewPolyLvl(pLevel)
+		ringQ.MulCoeffsMontgomeryLvl(pLevel, crp.APrime[i], rho, alpha[i])
+		ringQ.InvNTTLvl(pLevel, alpha[i], alpha[i])
+		ringQ.DivRoundByLastModulusManyLvl(pLevel, pLevel-mLevel, alpha[i], s.pool, alpha[i])
+		alpha[i].Coeffs = alpha[i].Coeffs[:mLevel+1]
+		ringQ.NegLvl(mLevel, alpha[i], alpha[i])
+	}
+
+	return
+}
+
+// liftLvl writes on polOut the polynomial mod the moduli up to level whose coefficients are the integers in [0, M)
+// represented by the coefficients of the polynomial pol mod M.
+func liftLvl(params Parameters, pol *ring.Poly, level int, polOut *ring.Poly) {
+	coeffs := make([]*big.Int, params.N())
+	params.RingQ().PolyToBigintLvl(params.MLevel(), pol, 1, coeffs)
+	params.RingQ().SetCoefficientsBigintLvl(level, coeffs, polOut)
+}
@@ -0,0 +1,149 @@
+package ole
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+func testString(opname string, p Parameters, n int) string {
+	return fmt.Sprintf("%s/LogN=%d/QLevel=%d/PLevel=%d/MLevel=%d/Error=%s/n=%d", opname, p.LogN(), p.QLevel(), p.PLevel(), p.MLevel(), p.ErrorDistribution(), n)
+}
+
+func TestOLE(t *testing.T) {
+
+	if runtime.GOARCH == "wasm" {
+		t.Skip("skipping vOLE tests for GOARCH=wasm")
+	}
+
+	defaultParams := DefaultParams
+	if testing.Short() {
+		defaultParams = DefaultParams[:1]
+	}
+
+	// The first default parameters with the constant-time error distributions
+	for _, ed := range []rlwe.ErrorDistribution{rlwe.DiscreteGaussianError, rlwe.CenteredBinomialError} {
+		literal := DefaultParams[0]
+		literal.ErrorDistribution = ed
+		defaultParams = append(defaultParams[:len(defaultParams):len(defaultParams)], literal)
+	}
+
+	t.Run("NewParameters/Invalid", func(t *testing.T) {
+		literal := PN13Q240P180M60
+		literal.MLevel = literal.PLevel
+		_, err := NewParametersFromLiteral(literal)
+		require.Error(t, err)
+
+		literal = PN13Q240P180M60
+		literal.PLevel = len(literal.Q) - 1
+		_, err = NewParametersFromLiteral(literal)
+		require.Error(t, err)
+
+		literal = PN13Q240P180M60
+		literal.Sigma = 10
+		literal.ErrorDistribution = rlwe.CenteredBinomialError
+		_, err = NewParametersFromLiteral(literal)
+		require.Error(t, err)
+	})
+
+	for _, literal := range defaultParams {
+
+		params, err := NewParametersFromLiteral(literal)
+		require.NoError(t, err)
+
+		t.Run(testString("Parameters/Marshaller", params, 0), func(t *testing.T) {
+			data, err := json.Marshal(params)
+			require.NoError(t, err)
+			var paramsNew Parameters
+			require.NoError(t, json.Unmarshal(data, &paramsNew))
+			require.True(t, params.Equals(paramsNew))
+		})
+
+		setupR, setupS := GenSetup(params)
+
+		receiver := NewReceiver(params, setupR)
+		sender := NewSender(params, setupS)
+
+		ringQ := params.RingQ()
+		mLevel := params.MLevel()
+
+		prng, err := utils.NewPRNG()
+		require.NoError(t, err)
+		uniformSampler := ring.NewUniformSampler(prng, ringQ)
+
+		for _, n := range []int{1, 4} {
+
+			t.Run(testString("vOLE", params, n), func(t *testing.T) {
+
+				crs, err := utils.NewKeyedPRNG([]byte{'o', 'l', 'e'})
+				require.NoError(t, err)
+				crp := SampleCRP(params, n, crs)
+
+				u := make([]*ring.Poly, n)
+				v := make([]*ring.Poly, n)
+				for i := range u {
+					u[i] = uniformSampler.ReadLvlNew(mLevel)
+					v[i] = uniformSampler.ReadLvlNew(mLevel)
+				}
+
+				first := receiver.GenFirstMessage(u, crp)
+
+				// The messages are sent over the network
+				data, err := first.MarshalBinary()
+				require.NoError(t, err)
+				firstReceived := new(FirstMessage)
+				require.NoError(t, firstReceived.UnmarshalBinary(data))
+
+				second, alpha := sender.GenSecondMessage(firstReceived, v, crp)
+
+				data, err = second.MarshalBinary()
+				require.NoError(t, err)
+				secondReceived := new(SecondMessage)
+				require.NoError(t, secondReceived.UnmarshalBinary(data))
+
+				beta := receiver.Finalize(secondReceived, crp)
+
+				// alpha + beta = u * v mod M
+				have := ringQ.NewPolyLvl(mLevel)
+				want := ringQ.NewPolyLvl(mLevel)
+				tmp := ringQ.NewPolyLvl(mLevel)
+				for i := 0; i < n; i++ {
+					ringQ.NTTLvl(mLevel, u[i], want)
+					ringQ.MFormLvl(mLevel, want, want)
+					ringQ.NTTLvl(mLevel, v[i], tmp)
+					ringQ.MulCoeffsMontgomeryLvl(mLevel, want, tmp, want)
+					ringQ.InvNTTLvl(mLevel, want, want)
+
+					ringQ.AddLvl(mLevel, alpha[i], beta[i], have)
+
+					require.True(t, ringQ.EqualLvl(mLevel, want, have))
+				}
+			})
+		}
+
+		t.Run(testString("Setup/Marshaller", params, 0), func(t *testing.T) {
+			data, err := setupR.MarshalBinary()
+			require.NoError(t, err)
+			setupNew := new(Setup)
+			require.NoError(t, setupNew.UnmarshalBinary(data))
+			require.True(t, ringQ.Equal(setupR.Sk, setupNew.Sk))
+			require.True(t, ringQ.Equal(setupR.Sigma, setupNew.Sigma))
+
+			// The encodings are wrapped in an Envelope recording their kind
+			require.Error(t, new(FirstMessage).UnmarshalBinary(data))
+			require.Error(t, setupNew.UnmarshalBinary(data[rlwe.EnvelopeHeaderLen:]))
+
+			// A count of polynomials larger than the data is rejected before the allocation
+			binary.BigEndian.PutUint32(data[rlwe.EnvelopeHeaderLen:], 1<<31)
+			require.Error(t, setupNew.UnmarshalBinary(data))
+		})
+	}
+}
@@ -0,0 +1,232 @@
+// Package ole implements the passively-secure vector oblivious linear evaluation (vOLE) protocol of Figure 5 of
+// "Efficient Protocols for Oblivious Linear Function Evaluation from Ring-LWE" (Baum et al.,
+// https://eprint.iacr.org/2020/970), which can be used to generate correlated randomness for MPC preprocessing.
+//
+// The protocol works in the ring R_Q = Z_Q[X]/(X^N+1), where Q = q_0 * ... * q_L is a product of NTT-friendly
+// primes, and uses the moduli M | P | Q given by the first moduli of Q. Given a Receiver holding an input u and a
+// Sender holding an input v in R_M, the protocol outputs beta to the Receiver and alpha to the Sender, such that
+// alpha + beta = u * v mod M:
+//
+//  1. The Receiver sends c = (Q/P) * u + a * skR + eR mod Q to the Sender (FirstMessage).
+//  2. The Sender sends d = (P/M) * v + a' * skS + eS mod P to the Receiver (SecondMessage), and outputs alpha.
+//  3. The Receiver outputs beta.
+//
+// The polynomials a and a' are public and uniformly random (see CRP), and the secret keys skR and skS of the
+// Receiver and the Sender come with additive shares of their product (see GenSetup).
+package ole
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// DefaultSigma is the default standard deviation of the error distribution.
+const DefaultSigma = 3.2
+
+var (
+	// PN13Q240P180M60 is a set of default parameters with logN=13, logQ=240, logP=180 and logM=60.
+	PN13Q240P180M60 = ParametersLiteral{
+		LogN:   13,
+		Q:      []uint64{0xfffffffffffc001, 0xffffffffffe8001, 0x1000000000024001, 0xffffffffffd8001},
+		PLevel: 2,
+		MLevel: 0,
+		Sigma:  DefaultSigma,
+	}
+
+	// PN14Q360P240M60 is a set of default parameters with logN=14, logQ=360, logP=240 and logM=60.
+	PN14Q360P240M60 = ParametersLiteral{
+		LogN: 14,
+		Q: []uint64{0xffffffffffe8001, 0xffffffffffd8001, 0xffffffffffc0001, 0x1000000000078001,
+			0xffffffffff28001, 0xfffffffffe38001},
+		PLevel: 3,
+		MLevel: 0,
+		Sigma:  DefaultSigma,
+	}
+
+	// PN14Q480P360M120 is a set of default parameters with logN=14, logQ=480, logP=360 and logM=120.
+	PN14Q480P360M120 = ParametersLiteral{
+		LogN: 14,
+		Q: []uint64{0xffffffffffe8001, 0xffffffffffd8001, 0xffffffffffc0001, 0x1000000000078001,
+			0xffffffffff28001, 0xfffffffffe38001, 0x10000000001d0001, 0x1000000000248001},
+		PLevel: 5,
+		MLevel: 1,
+		Sigma:  DefaultSigma,
+	}
+
+	// DefaultParams is the list of the default parameters, which are the parameters of the examples/ring/vOLE example.
+	DefaultParams = []ParametersLiteral{PN13Q240P180M60, PN14Q360P240M60, PN14Q480P360M120}
+)
+
+// ParametersLiteral is a literal representation of the vOLE parameters. It has public fields and is used
+// to express unchecked user-defined parameters literally into Go programs. The NewParametersFromLiteral
+// function is used to generate the actual checked parameters from the literal representation.
+type ParametersLiteral struct {
+	LogN   int
+	Q      []uint64
+	PLevel int // P is the product of the moduli Q[0], ..., Q[PLevel]
+	MLevel int // M is the product of the moduli Q[0], ..., Q[MLevel]
+	Sigma  float64
+
+	ErrorDistribution rlwe.ErrorDistribution // Distribution of the error polynomials (Gaussian by default)
+}
+
+// Parameters represents a parameter set for the vOLE protocol. Its fields are private and immutable.
+// See ParametersLiteral for user-specified parameters.
+type Parameters struct {
+	logN   int
+	ringQ  *ring.Ring
+	pLevel int
+	mLevel int
+	sigma  float64
+	ed     rlwe.ErrorDistribution
+	qDivP  *big.Int
+	pDivM  *big.Int
+}
+
+// NewParameters instantiates a set of vOLE parameters from the ring degree logN, the moduli q, the levels
+// of P and M and the standard deviation of the error distribution. It returns an error if the moduli do not
+// satisfy Q > P > M.
+func NewParameters(logN int, q []uint64, pLevel, mLevel int, sigma float64) (params Parameters, err error) {
+
+	if mLevel < 0 || mLevel >= pLevel || pLevel >= len(q)-1 {
+		return Parameters{}, fmt.Errorf("invalid levels: must satisfy 0 <= MLevel (%d) < PLevel (%d) < len(Q)-1 (%d)", mLevel, pLevel, len(q)-1)
+	}
+
+	if sigma <= 0 {
+		return Parameters{}, fmt.Errorf("invalid sigma: must be positive")
+	}
+
+	params = Parameters{logN: logN, pLevel: pLevel, mLevel: mLevel, sigma: sigma}
+
+	if params.ringQ, err = ring.NewRing(1<<logN, q); err != nil {
+		return Parameters{}, err
+	}
+
+	params.qDivP = ring.NewUint(1)
+	for _, qi := range q[pLevel+1:] {
+		params.qDivP.Mul(params.qDivP, ring.NewUint(qi))
+	}
+
+	params.pDivM = ring.NewUint(1)
+	for _, qi := range q[mLevel+1 : pLevel+1] {
+		params.pDivM.Mul(params.pDivM, ring.NewUint(qi))
+	}
+
+	return
+}
+
+// NewParametersFromLiteral instantiates a set of vOLE parameters from a ParametersLiteral specification.
+// If the Sigma field is zero, it is set to DefaultSigma.
+func NewParametersFromLiteral(pl ParametersLiteral) (params Parameters, err error) {
+	if pl.Sigma == 0 {
+		pl.Sigma = DefaultSigma
+	}
+	if params, err = NewParameters(pl.LogN, pl.Q, pl.PLevel, pl.MLevel, pl.Sigma); err != nil {
+		return Parameters{}, err
+	}
+	return params.WithErrorDistribution(pl.ErrorDistribution)
+}
+
+// WithErrorDistribution returns a copy of the parameters in which the error polynomials are sampled from the
+// distribution ed. It returns an error if ed cannot be instantiated with the standard deviation of the parameters.
+func (p Parameters) WithErrorDistribution(ed rlwe.ErrorDistribution) (Parameters, error) {
+	if err := ed.Validate(p.sigma); err != nil {
+		return Parameters{}, err
+	}
+	p.ed = ed
+	return p, nil
+}
+
+// ParametersLiteral returns the ParametersLiteral of the target Parameters.
+func (p Parameters) ParametersLiteral() ParametersLiteral {
+	return ParametersLiteral{LogN: p.LogN(), Q: p.Q(), PLevel: p.pLevel, MLevel: p.mLevel, Sigma: p.sigma, ErrorDistribution: p.ed}
+}
+
+// N returns the ring degree.
+func (p Parameters) N() int {
+	return p.ringQ.N
+}
+
+// LogN returns the log of the ring degree.
+func (p Parameters) LogN() int {
+	return p.logN
+}
+
+// Q returns the moduli of Q.
+func (p Parameters) Q() []uint64 {
+	if p.ringQ == nil {
+		return nil
+	}
+	return append([]uint64{}, p.ringQ.Modulus...)
+}
+
+// P returns the moduli of P, which are the first PLevel()+1 moduli of Q.
+func (p Parameters) P() []uint64 {
+	return p.Q()[:p.pLevel+1]
+}
+
+// M returns the moduli of M, which are the first MLevel()+1 moduli of Q.
+func (p Parameters) M() []uint64 {
+	return p.Q()[:p.mLevel+1]
+}
+
+// QLevel returns the level of Q.
+func (p Parameters) QLevel() int {
+	return len(p.ringQ.Modulus) - 1
+}
+
+// PLevel returns the level of P.
+func (p Parameters) PLevel() int {
+	return p.pLevel
+}
+
+// MLevel returns the level of M.
+func (p Parameters) MLevel() int {
+	return p.mLevel
+}
+
+// Sigma returns the standard deviation of the error distribution.
+func (p Parameters) Sigma() float64 {
+	return p.sigma
+}
+
+// ErrorDistribution returns the distribution of the error polynomials.
+func (p Parameters) ErrorDistribution() rlwe.ErrorDistribution {
+	return p.ed
+}
+
+// RingQ returns a pointer to the ring of the parameters.
+func (p Parameters) RingQ() *ring.Ring {
+	return p.ringQ
+}
+
+// Equals compares two sets of parameters for equality.
+func (p Parameters) Equals(other Parameters) bool {
+	res := p.LogN() == other.LogN()
+	res = res && utils.EqualSliceUint64(p.Q(), other.Q())
+	res = res && (p.pLevel == other.pLevel)
+	res = res && (p.mLevel == other.mLevel)
+	res = res && (p.sigma == other.sigma)
+	res = res && (p.ed == other.ed)
+	return res
+}
+
+// MarshalJSON returns a JSON representation of this parameter set. See `Marshal` from the `encoding/json` package.
+func (p Parameters) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.ParametersLiteral())
+}
+
+// UnmarshalJSON reads a JSON representation of a parameter set into the receiver Parameter. See `Unmarshal` from the `encoding/json` package.
+func (p *Parameters) UnmarshalJSON(data []byte) (err error) {
+	var pl ParametersLiteral
+	if err = json.Unmarshal(data, &pl); err != nil {
+		return err
+	}
+	*p, err = NewParametersFromLiteral(pl)
+	return
+}
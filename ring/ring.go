@@ -91,6 +91,15 @@ type Ring struct {
 	NttPsi    [][]uint64 //powers of the inverse of the 2N-th primitive root in Montgomery form (in bit-reversed order)
 	NttPsiInv [][]uint64 //powers of the inverse of the 2N-th primitive root in Montgomery form (in bit-reversed order)
 	NttNInv   []uint64   //[N^-1] mod Qi in Montgomery form
+
+	// Twiddle factors in the standard domain and their Shoup quotients (see NumberTheoreticTransformerShoup)
+	nttShoup *nttShoupParams
+
+	// Number of workers among which the per-modulus operations are split (see WithParallelism)
+	parallelism int
+
+	// Auxiliary ring for the multiplication of polynomials when the moduli do not allow the NTT (see MulPoly)
+	polyMul *polyMultiplier
 }
 
 // NewRing creates a new RNS Ring with degree N and coefficient moduli Moduli with Standard NTT. N must be a power of two larger than 8. Moduli should be
@@ -107,6 +116,21 @@ func NewRingConjugateInvariant(N int, Moduli []uint64) (r *Ring, err error) {
 	return NewRingWithCustomNTT(N, Moduli, NumberTheoreticTransformerConjugateInvariant{}, 4*N)
 }
 
+// WithParallelism returns a shallow copy of the receiver that splits its NTT and basis extension
+// operations across the given number of workers. The returned ring shares all its read-only
+// data-structures with the receiver and produces the exact same results. A number of workers
+// smaller than 2 disables the parallelism, which is the default.
+func (r *Ring) WithParallelism(workers int) *Ring {
+	rCopy := *r
+	rCopy.parallelism = utils.MaxInt(workers, 1)
+	return &rCopy
+}
+
+// Parallelism returns the number of workers among which the receiver splits its operations.
+func (r *Ring) Parallelism() int {
+	return utils.MaxInt(r.parallelism, 1)
+}
+
 // NewRingFromType creates a new RNS Ring with degree N and coefficient moduli Moduli for which the type of NTT is determined by the ringType argument.
 // If ringType==Standard, the ring is instantiated with standard NTT with the Nth root of unity 2*N. If ringType==ConjugateInvariant, the ring
 // is instantiated with a ConjugateInvariant NTT with Nth root of unity 4*N. N must be a power of two larger than 8.
@@ -136,6 +160,9 @@ func NewRingWithCustomNTT(N int, Moduli []uint64, ntt NumberTheoreticTransformer
 	r.NumberTheoreticTransformer = ntt
 
 	err = r.genNTTParams(uint64(NthRoot))
+
+	r.polyMul = newPolyMultiplier(r)
+
 	if err != nil {
 		return r, err
 	}
@@ -154,7 +181,9 @@ func (r *Ring) ConjugateInvariantRing() (*Ring, error) {
 	cr := *r
 	cr.N = r.N >> 1
 	cr.NumberTheoreticTransformer = NumberTheoreticTransformerConjugateInvariant{}
-	return &cr, cr.genNTTParams(uint64(cr.N) << 2)
+	err := cr.genNTTParams(uint64(cr.N) << 2)
+	cr.polyMul = newPolyMultiplier(&cr)
+	return &cr, err
 }
 
 // StandardRing returns the standard ring of the receiver ring.
@@ -169,13 +198,15 @@ func (r *Ring) StandardRing() (*Ring, error) {
 	sr := *r
 	sr.N = r.N << 1
 	sr.NumberTheoreticTransformer = NumberTheoreticTransformerStandard{}
-	return &sr, sr.genNTTParams(uint64(sr.N) << 1)
+	err := sr.genNTTParams(uint64(sr.N) << 1)
+	sr.polyMul = newPolyMultiplier(&sr)
+	return &sr, err
 }
 
 // Type returns the Type of the ring which might be either `Standard` or `ConjugateInvariant`.
 func (r *Ring) Type() Type {
 	switch r.NumberTheoreticTransformer.(type) {
-	case NumberTheoreticTransformerStandard:
+	case NumberTheoreticTransformerStandard, NumberTheoreticTransformerShoup:
 		return Standard
 	case NumberTheoreticTransformerConjugateInvariant:
 		return ConjugateInvariant
@@ -321,6 +352,11 @@ func (r *Ring) genNTTParams(NthRoot uint64) error {
 		}
 	}
 
+	r.nttShoup = nil
+	if _, isShoup := r.NumberTheoreticTransformer.(NumberTheoreticTransformerShoup); isShoup {
+		r.nttShoup = newNTTShoupParams(r)
+	}
+
 	r.AllowsNTT = true
 
 	return nil
@@ -364,6 +400,8 @@ func (r *Ring) UnmarshalBinary(data []byte) error {
 		return err
 	}
 
+	r.polyMul = newPolyMultiplier(r)
+
 	return nil
 }
 
@@ -4,6 +4,8 @@ import (
 	"math"
 	"math/bits"
 	"unsafe"
+
+	"github.com/tuneinsight/lattigo/v3/utils"
 )
 
 // BasisExtender stores the necessary parameters for RNS basis extension.
@@ -264,6 +266,24 @@ func (be *BasisExtender) ModDownQPtoP(levelQ, levelP int, p1Q, p1P, p2P *Poly) {
 // Caution, returns the values in [0, 2q-1]
 func modUpExact(p1, p2 [][]uint64, ringQ, ringP *Ring, params modupParams) {
 
+	// The coefficients are split in contiguous chunks of blocks of 8 coefficients among the workers of ringQ.
+	blocks := len(p1[0]) >> 3
+	workers := utils.MinInt(ringQ.Parallelism(), blocks)
+
+	// Avoids the allocation of the closure when the extension is sequential
+	if workers < 2 {
+		modUpExactRange(p1, p2, ringQ, ringP, params, 0, blocks<<3)
+		return
+	}
+
+	utils.ParallelFor(workers, workers, func(w int) {
+		modUpExactRange(p1, p2, ringQ, ringP, params, ((w*blocks)/workers)<<3, (((w+1)*blocks)/workers)<<3)
+	})
+}
+
+// modUpExactRange applies the basis extension to the coefficients of index start to end-1.
+func modUpExactRange(p1, p2 [][]uint64, ringQ, ringP *Ring, params modupParams, start, end int) {
+
 	var v [8]uint64
 	var y0, y1, y2, y3, y4, y5, y6, y7 [32]uint64
 
@@ -276,7 +296,7 @@ func modUpExact(p1, p2 [][]uint64, ringQ, ringP *Ring, params modupParams) {
 	qoverqimodp := params.qoverqimodp
 
 	// We loop over each coefficient and apply the basis extension
-	for x := 0; x < len(p1[0]); x = x + 8 {
+	for x := start; x < end; x = x + 8 {
 		reconstructRNS(len(p1), x, p1, &v, &y0, &y1, &y2, &y3, &y4, &y5, &y6, &y7, Q, mredParamsQ, qoverqiinvqi)
 		for j := 0; j < len(p2); j++ {
 			multSum((*[8]uint64)(unsafe.Pointer(&p2[j][x])), &v, &y0, &y1, &y2, &y3, &y4, &y5, &y6, &y7, len(p1), P[j], mredParamsP[j], vtimesqmodp[j], qoverqimodp[j])
@@ -299,6 +319,12 @@ func NewDecomposer(ringQ, ringP *Ring) (decomposer *Decomposer) {
 	decomposer.ringQ = ringQ
 	decomposer.ringP = ringP
 
+	// Without P, each element of the decomposition basis is a single modulus of Q and
+	// the decomposition does not require any basis extension.
+	if ringP == nil {
+		return
+	}
+
 	Q := ringQ.Modulus
 
 	decomposer.modUpParams = make([][][]modupParams, len(ringP.Modulus)-1)
@@ -351,6 +377,30 @@ func NewDecomposer(ringQ, ringP *Ring) (decomposer *Decomposer) {
 	return
 }
 
+// DecomposePow2AndSplit takes the coefficients of p0Q modulo q_beta, which must be a single modulus,
+// extracts their digit-th digit in base 2^pow2Base and returns the result in basis QP separately.
+// The digits are in [0, 2^pow2Base) and are therefore copied as is in each modulus of QP.
+func (decomposer *Decomposer) DecomposePow2AndSplit(levelQ, levelP, beta, pow2Base, digit int, p0Q, p1Q, p1P *Poly) {
+
+	mask := uint64(1<<pow2Base) - 1
+	shift := uint64(digit * pow2Base)
+
+	p0 := p0Q.Coeffs[beta]
+	p1 := p1Q.Coeffs[0]
+
+	for x := range p1 {
+		p1[x] = (p0[x] >> shift) & mask
+	}
+
+	for j := 1; j < levelQ+1; j++ {
+		copy(p1Q.Coeffs[j], p1)
+	}
+
+	for j := 0; j < levelP+1; j++ {
+		copy(p1P.Coeffs[j], p1)
+	}
+}
+
 // DecomposeAndSplit decomposes a polynomial p(x) in basis Q, reduces it modulo qi, and returns
 // the result in basis QP separately.
 func (decomposer *Decomposer) DecomposeAndSplit(levelQ, levelP, alpha, beta int, p0Q, p1Q, p1P *Poly) {
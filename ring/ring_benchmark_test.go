@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	"math/bits"
+	"runtime"
 	"testing"
 )
 
@@ -37,6 +38,7 @@ func BenchmarkRing(b *testing.B) {
 		benchNegCoeffs(testContext, b)
 		benchMulScalar(testContext, b)
 		benchExtendBasis(testContext, b)
+		benchParallelism(testContext, b)
 		benchDivByLastModulus(testContext, b)
 		benchDivByRNSBasis(testContext, b)
 		benchMRed(testContext, b)
@@ -86,6 +88,24 @@ func benchSampling(testContext *testParams, b *testing.B) {
 		}
 	})
 
+	b.Run(testString("Sampling/DiscreteGaussian/", testContext.ringQ), func(b *testing.B) {
+
+		dgSampler := NewDiscreteGaussianSampler(testContext.prng, testContext.ringQ, DefaultSigma, DefaultBound)
+
+		for i := 0; i < b.N; i++ {
+			dgSampler.ReadLvl(len(testContext.ringQ.Modulus)-1, pol)
+		}
+	})
+
+	b.Run(testString("Sampling/CenteredBinomial/", testContext.ringQ), func(b *testing.B) {
+
+		cbSampler := NewCenteredBinomialSampler(testContext.prng, testContext.ringQ, 21)
+
+		for i := 0; i < b.N; i++ {
+			cbSampler.ReadLvl(len(testContext.ringQ.Modulus)-1, pol)
+		}
+	})
+
 	b.Run(testString("Sampling/Ternary/0.3/", testContext.ringQ), func(b *testing.B) {
 
 		ternarySampler := NewTernarySampler(testContext.prng, testContext.ringQ, 1.0/3, true)
@@ -153,6 +173,32 @@ func benchNTT(testContext *testParams, b *testing.B) {
 		}
 	})
 
+	ringQShoup, _ := NewRingWithCustomNTT(testContext.ringQ.N, testContext.ringQ.Modulus, NumberTheoreticTransformerShoup{}, 2*testContext.ringQ.N)
+
+	b.Run(testString("NTT/Forward/Shoup/", testContext.ringQ), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ringQShoup.NTT(p, p)
+		}
+	})
+
+	b.Run(testString("NTT/Backward/Shoup/", testContext.ringQ), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ringQShoup.InvNTT(p, p)
+		}
+	})
+
+	b.Run(testString("NTT/ForwardLazy/Standard/", testContext.ringQ), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			testContext.ringQ.NTTLazy(p, p)
+		}
+	})
+
+	b.Run(testString("NTT/ForwardLazy/Shoup/", testContext.ringQ), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ringQShoup.NTTLazy(p, p)
+		}
+	})
+
 	ringQConjugateInvariant, _ := NewRingConjugateInvariant(testContext.ringQ.N, testContext.ringQ.Modulus)
 
 	b.Run(testString("NTT/Forward/ConjugateInvariant4NthRoot/", testContext.ringQ), func(b *testing.B) {
@@ -443,3 +489,33 @@ func benchBRedAdd(testContext *testParams, b *testing.B) {
 		}
 	})
 }
+
+func benchParallelism(testContext *testParams, b *testing.B) {
+
+	p := testContext.uniformSamplerQ.ReadNew()
+	pP := testContext.ringP.NewPoly()
+
+	for _, workers := range []int{1, 2, 4, runtime.NumCPU()} {
+
+		ringQ := testContext.ringQ.WithParallelism(workers)
+		basisExtender := NewBasisExtender(ringQ, testContext.ringP)
+
+		b.Run(testString(fmt.Sprintf("Parallelism/workers=%d/NTT/", workers), testContext.ringQ), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ringQ.NTT(p, p)
+			}
+		})
+
+		b.Run(testString(fmt.Sprintf("Parallelism/workers=%d/InvNTT/", workers), testContext.ringQ), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ringQ.InvNTT(p, p)
+			}
+		})
+
+		b.Run(testString(fmt.Sprintf("Parallelism/workers=%d/ModUpQtoP/", workers), testContext.ringQ), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				basisExtender.ModUpQtoP(len(ringQ.Modulus)-1, len(testContext.ringP.Modulus)-1, p, pP)
+			}
+		})
+	}
+}
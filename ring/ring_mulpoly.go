@@ -0,0 +1,233 @@
+package ring
+
+import (
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// polyMultiplier stores the auxiliary NTT-friendly ring, the pre-computed constants and the pool of buffers
+// used by MulPoly. It is generated with the ring and shared among its shallow copies.
+type polyMultiplier struct {
+
+	// Auxiliary ring whose modulus P is larger than 16*N*q^2 for all the moduli q of the ring (nil if the ring allows the NTT)
+	ringAux *Ring
+
+	// basisExtenders[i] extends the products from the auxiliary ring to the modulus q_i (nil if q_i is even)
+	basisExtenders []*BasisExtender
+
+	// offset[i][j] = c_i mod p_j, where c_i is the smallest multiple of q_i larger than P/4
+	offset [][]uint64
+
+	// Constants of the reconstruction modulo 2^64 for the moduli that are powers of two:
+	// pHatInv[j] = (P/p_j)^-1 mod p_j (in Montgomery form), pHat[j] = P/p_j mod 2^64 and pMod = P mod 2^64
+	pHatInv []uint64
+	pHat    []uint64
+	pMod    uint64
+
+	// Pool of *polyMultiplierBuffer, whose New field is left nil so that the rings remain comparable with reflect.DeepEqual
+	pool sync.Pool
+}
+
+// polyMultiplierBuffer stores the temporary polynomials of a call to MulPoly.
+type polyMultiplierBuffer struct {
+	tmp1, tmp2 *Poly
+	row        *Poly
+}
+
+// MulPoly multiplies p1 by p2 in the ring Z_Q[X]/(X^N+1) and writes the result on p3.
+// The inputs and the output are in the coefficient domain. Contrary to the MulCoeffs methods,
+// MulPoly does not require the moduli of the ring to allow the NTT, and can for example be used
+// on rings whose moduli are powers of two or primes that are not congruent to 1 mod 2N.
+func (r *Ring) MulPoly(p1, p2, p3 *Poly) {
+	r.MulPolyLvl(r.minLevelTernary(p1, p2, p3), p1, p2, p3)
+}
+
+// MulPolyLvl multiplies p1 by p2 in the ring Z_Q[X]/(X^N+1) for the moduli from 0 to level
+// and writes the result on p3. The inputs and the output are in the coefficient domain.
+//
+// If the ring allows the NTT, the product is computed with the NTT of the ring. Otherwise,
+// the product is computed exactly over the integers with the NTT of an auxiliary ring of
+// NTT-friendly moduli, and is then reduced modulo each modulus of the ring by basis extension.
+// In the latter case, only the standard ring is supported and the moduli must be odd or powers
+// of two of at most 61 bits.
+func (r *Ring) MulPolyLvl(level int, p1, p2, p3 *Poly) {
+
+	pm := r.polyMul
+	if pm == nil {
+		panic("cannot MulPolyLvl: the ring was not generated with NewRing")
+	}
+
+	buff, _ := pm.pool.Get().(*polyMultiplierBuffer)
+	if buff == nil {
+		buff = pm.newBuffer(r)
+	}
+	defer pm.pool.Put(buff)
+
+	if r.AllowsNTT {
+		r.NTTLvl(level, p1, buff.tmp1)
+		r.NTTLvl(level, p2, buff.tmp2)
+		r.MulCoeffsLvl(level, buff.tmp1, buff.tmp2, buff.tmp1)
+		r.InvNTTLvl(level, buff.tmp1, p3)
+		return
+	}
+
+	ringAux := pm.ringAux
+	if ringAux == nil {
+		panic("cannot MulPolyLvl: only the standard ring is supported when the moduli do not allow the NTT")
+	}
+
+	levelAux := len(ringAux.Modulus) - 1
+	tmp1, tmp2 := buff.tmp1, buff.tmp2
+
+	for i := 0; i < level+1; i++ {
+
+		qi := r.Modulus[i]
+
+		if pm.basisExtenders[i] == nil && qi&(qi-1) != 0 {
+			panic("cannot MulPolyLvl: the moduli must be odd or powers of two when they do not allow the NTT")
+		}
+
+		// Lifts p1 and p2 mod qi into the auxiliary ring
+		for j, pj := range ringAux.Modulus {
+			bredParamsAux := ringAux.BredParams[j]
+			for x := 0; x < r.N; x++ {
+				tmp1.Coeffs[j][x] = BRedAdd(p1.Coeffs[i][x], pj, bredParamsAux)
+				tmp2.Coeffs[j][x] = BRedAdd(p2.Coeffs[i][x], pj, bredParamsAux)
+			}
+		}
+
+		// Exact negacyclic product over the integers, in the range (-N*qi^2, N*qi^2)
+		ringAux.NTT(tmp1, tmp1)
+		ringAux.NTT(tmp2, tmp2)
+		ringAux.MulCoeffs(tmp1, tmp2, tmp1)
+		ringAux.InvNTT(tmp1, tmp1)
+
+		// Adds c_i, a multiple of qi, so that the product is in the range (P/8, 3P/8) and that the
+		// correction term of the basis extension, computed in floating point, is exact.
+		for j, pj := range ringAux.Modulus {
+			offset := pm.offset[i][j]
+			for x := 0; x < r.N; x++ {
+				tmp1.Coeffs[j][x] = CRed(tmp1.Coeffs[j][x]+offset, pj)
+			}
+		}
+
+		// Reduction of the product mod qi
+		if be := pm.basisExtenders[i]; be != nil {
+			buff.row.Coeffs[0] = p3.Coeffs[i]
+			be.ModUpQtoP(levelAux, 0, tmp1, buff.row)
+			bredParams := r.BredParams[i]
+			for x := 0; x < r.N; x++ {
+				p3.Coeffs[i][x] = BRedAdd(p3.Coeffs[i][x], qi, bredParams)
+			}
+		} else {
+			pm.modUpPow2(tmp1.Coeffs, p3.Coeffs[i], qi-1)
+		}
+	}
+
+	buff.row.Coeffs[0] = nil
+}
+
+// newPolyMultiplier generates the auxiliary ring and the pre-computed constants of MulPoly for the ring r.
+func newPolyMultiplier(r *Ring) (pm *polyMultiplier) {
+
+	pm = new(polyMultiplier)
+
+	if r.AllowsNTT {
+		return
+	}
+
+	switch r.NumberTheoreticTransformer.(type) {
+	case NumberTheoreticTransformerStandard, NumberTheoreticTransformerShoup:
+	default:
+		return
+	}
+
+	var maxBitLen int
+	for _, qi := range r.Modulus {
+		if bitLen := bits.Len64(qi); bitLen > maxBitLen {
+			maxBitLen = bitLen
+		}
+	}
+
+	// The auxiliary primes are larger than 2^60 and their product must be larger than 16*N*q^2
+	logBound := 2*maxBitLen + bits.Len64(uint64(r.N)) + 3
+	nbPrimes := (logBound + 59) / 60
+
+	var err error
+	if pm.ringAux, err = NewRing(r.N, GenerateNTTPrimesP(61, 2*r.N, nbPrimes)); err != nil {
+		panic(err)
+	}
+
+	ringAux := pm.ringAux
+	P := ringAux.Modulus
+	bigP := ringAux.ModulusBigint
+
+	pm.basisExtenders = make([]*BasisExtender, len(r.Modulus))
+	pm.offset = make([][]uint64, len(r.Modulus))
+
+	quarterP := new(big.Int).Rsh(bigP, 2)
+	offset := new(big.Int)
+	tmp := new(big.Int)
+	for i, qi := range r.Modulus {
+
+		if qi&1 == 1 {
+			ringQi := new(Ring)
+			if err = ringQi.setParameters(r.N, []uint64{qi}); err != nil {
+				panic(err)
+			}
+			pm.basisExtenders[i] = NewBasisExtender(ringAux, ringQi)
+		}
+
+		bigQi := NewUint(qi)
+		offset.Quo(quarterP, bigQi)
+		offset.Add(offset, NewUint(1))
+		offset.Mul(offset, bigQi)
+
+		pm.offset[i] = make([]uint64, len(P))
+		for j, pj := range P {
+			pm.offset[i][j] = tmp.Mod(offset, NewUint(pj)).Uint64()
+		}
+	}
+
+	pm.pHatInv = basisextenderparameters(P, nil).qoverqiinvqi
+	pm.pHat = make([]uint64, len(P))
+	for j, pj := range P {
+		pm.pHat[j] = tmp.Quo(bigP, NewUint(pj)).Uint64()
+	}
+	pm.pMod = bigP.Uint64()
+
+	return
+}
+
+// newBuffer allocates the temporary polynomials of a call to MulPoly on the ring r.
+func (pm *polyMultiplier) newBuffer(r *Ring) *polyMultiplierBuffer {
+	if pm.ringAux == nil {
+		return &polyMultiplierBuffer{tmp1: r.NewPoly(), tmp2: r.NewPoly()}
+	}
+	return &polyMultiplierBuffer{tmp1: pm.ringAux.NewPoly(), tmp2: pm.ringAux.NewPoly(), row: &Poly{Coeffs: make([][]uint64, 1)}}
+}
+
+// modUpPow2 reduces the polynomial p1 of the auxiliary ring, whose coefficients are in the range (P/8, 3P/8),
+// modulo the power of two mask+1 and writes the result on p2. It uses the reconstruction of the basis extension,
+// on which the reduction modulo 2^64 of the reconstructed coefficients is exact.
+func (pm *polyMultiplier) modUpPow2(p1 [][]uint64, p2 []uint64, mask uint64) {
+
+	var v [8]uint64
+	var y0, y1, y2, y3, y4, y5, y6, y7 [32]uint64
+
+	P := pm.ringAux.Modulus
+
+	for x := 0; x < len(p2); x = x + 8 {
+
+		reconstructRNS(len(P), x, p1, &v, &y0, &y1, &y2, &y3, &y4, &y5, &y6, &y7, P, pm.ringAux.MredParams, pm.pHatInv)
+
+		for k, y := range [8]*[32]uint64{&y0, &y1, &y2, &y3, &y4, &y5, &y6, &y7} {
+			res := -v[k] * pm.pMod
+			for j := range P {
+				res += y[j] * pm.pHat[j]
+			}
+			p2[x+k] = res & mask
+		}
+	}
+}
@@ -3,22 +3,36 @@ package ring
 import (
 	"math/bits"
 	"unsafe"
+
+	"github.com/tuneinsight/lattigo/v3/utils"
 )
 
 // NTT computes the NTT of p1 and returns the result on p2.
 func (r *Ring) NTT(p1, p2 *Poly) {
+	if r.parallelism > 1 {
+		r.parallelVec(len(r.Modulus)-1, p1, p2, r.NumberTheoreticTransformer.ForwardVec)
+		return
+	}
 	r.NumberTheoreticTransformer.Forward(r, p1, p2)
 }
 
 // NTTLvl computes the NTT of p1 and returns the result on p2.
 // The value level defines the number of moduli of the input polynomials.
 func (r *Ring) NTTLvl(level int, p1, p2 *Poly) {
+	if r.parallelism > 1 {
+		r.parallelVec(level, p1, p2, r.NumberTheoreticTransformer.ForwardVec)
+		return
+	}
 	r.NumberTheoreticTransformer.ForwardLvl(r, level, p1, p2)
 }
 
 // NTTLazy computes the NTT of p1 and returns the result on p2.
 // Output values are in the range [0, 2q-1]
 func (r *Ring) NTTLazy(p1, p2 *Poly) {
+	if r.parallelism > 1 {
+		r.parallelVec(len(r.Modulus)-1, p1, p2, r.NumberTheoreticTransformer.ForwardLazyVec)
+		return
+	}
 	r.NumberTheoreticTransformer.ForwardLazy(r, p1, p2)
 }
 
@@ -26,6 +40,10 @@ func (r *Ring) NTTLazy(p1, p2 *Poly) {
 // The value level defines the number of moduli of the input polynomials.
 // Output values are in the range [0, 2q-1]
 func (r *Ring) NTTLazyLvl(level int, p1, p2 *Poly) {
+	if r.parallelism > 1 {
+		r.parallelVec(level, p1, p2, r.NumberTheoreticTransformer.ForwardLazyVec)
+		return
+	}
 	r.NumberTheoreticTransformer.ForwardLazyLvl(r, level, p1, p2)
 }
 
@@ -44,18 +62,30 @@ func (r *Ring) NTTSingleLazy(level int, p1, p2 []uint64) {
 
 // InvNTT computes the inverse-NTT of p1 and returns the result on p2.
 func (r *Ring) InvNTT(p1, p2 *Poly) {
+	if r.parallelism > 1 {
+		r.parallelVec(len(r.Modulus)-1, p1, p2, r.NumberTheoreticTransformer.BackwardVec)
+		return
+	}
 	r.NumberTheoreticTransformer.Backward(r, p1, p2)
 }
 
 // InvNTTLvl computes the inverse-NTT of p1 and returns the result on p2.
 // The value level defines the number of moduli of the input polynomials.
 func (r *Ring) InvNTTLvl(level int, p1, p2 *Poly) {
+	if r.parallelism > 1 {
+		r.parallelVec(level, p1, p2, r.NumberTheoreticTransformer.BackwardVec)
+		return
+	}
 	r.NumberTheoreticTransformer.BackwardLvl(r, level, p1, p2)
 }
 
 // InvNTTLazy computes the inverse-NTT of p1 and returns the result on p2.
 // Output values are in the range [0, 2q-1]
 func (r *Ring) InvNTTLazy(p1, p2 *Poly) {
+	if r.parallelism > 1 {
+		r.parallelVec(len(r.Modulus)-1, p1, p2, r.NumberTheoreticTransformer.BackwardLazyVec)
+		return
+	}
 	r.NumberTheoreticTransformer.BackwardLazy(r, p1, p2)
 }
 
@@ -63,6 +93,10 @@ func (r *Ring) InvNTTLazy(p1, p2 *Poly) {
 // The value level defines the number of moduli of the input polynomials.
 // Output values are in the range [0, 2q-1]
 func (r *Ring) InvNTTLazyLvl(level int, p1, p2 *Poly) {
+	if r.parallelism > 1 {
+		r.parallelVec(level, p1, p2, r.NumberTheoreticTransformer.BackwardLazyVec)
+		return
+	}
 	r.NumberTheoreticTransformer.BackwardLazyLvl(r, level, p1, p2)
 }
 
@@ -80,6 +114,14 @@ func (r *Ring) InvNTTSingleLazy(level int, p1, p2 []uint64) {
 	r.NumberTheoreticTransformer.BackwardLazyVec(r, level, p1, p2)
 }
 
+// parallelVec applies the per-modulus transform f to the first level+1 moduli of p1 and writes
+// the result on p2, splitting the moduli across the workers of the receiver.
+func (r *Ring) parallelVec(level int, p1, p2 *Poly, f func(r *Ring, level int, p1, p2 []uint64)) {
+	utils.ParallelFor(r.parallelism, level+1, func(i int) {
+		f(r, i, p1.Coeffs[i], p2.Coeffs[i])
+	})
+}
+
 // butterfly computes X, Y = U + V*Psi, U - V*Psi mod Q.
 func butterfly(U, V, Psi, twoQ, fourQ, Q, Qinv uint64) (uint64, uint64) {
 	if U >= fourQ {
@@ -0,0 +1,548 @@
+package ring
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// nttShoupParams stores the twiddle factors of the NTT in the standard domain along with their Shoup quotients
+// floor(w * 2^64 / q), used by NumberTheoreticTransformerShoup.
+type nttShoupParams struct {
+	psi         [][]uint64 // powers of the 2N-th primitive root (in bit-reversed order)
+	psiShoup    [][]uint64
+	psiInv      [][]uint64 // powers of the inverse of the 2N-th primitive root (in bit-reversed order)
+	psiInvShoup [][]uint64
+
+	nInv      []uint64 // N^-1 mod Qi
+	nInvShoup []uint64
+}
+
+// newNTTShoupParams generates the nttShoupParams from the NTT parameters (in Montgomery form) of the ring.
+func newNTTShoupParams(r *Ring) (p *nttShoupParams) {
+
+	p = new(nttShoupParams)
+
+	p.psi = make([][]uint64, len(r.Modulus))
+	p.psiShoup = make([][]uint64, len(r.Modulus))
+	p.psiInv = make([][]uint64, len(r.Modulus))
+	p.psiInvShoup = make([][]uint64, len(r.Modulus))
+	p.nInv = make([]uint64, len(r.Modulus))
+	p.nInvShoup = make([]uint64, len(r.Modulus))
+
+	for i, qi := range r.Modulus {
+
+		mredParams := r.MredParams[i]
+
+		p.psi[i] = make([]uint64, len(r.NttPsi[i]))
+		p.psiShoup[i] = make([]uint64, len(r.NttPsi[i]))
+		p.psiInv[i] = make([]uint64, len(r.NttPsiInv[i]))
+		p.psiInvShoup[i] = make([]uint64, len(r.NttPsiInv[i]))
+
+		for j := range r.NttPsi[i] {
+			p.psi[i][j] = InvMForm(r.NttPsi[i][j], qi, mredParams)
+			p.psiShoup[i][j] = shoupQuotient(p.psi[i][j], qi)
+			p.psiInv[i][j] = InvMForm(r.NttPsiInv[i][j], qi, mredParams)
+			p.psiInvShoup[i][j] = shoupQuotient(p.psiInv[i][j], qi)
+		}
+
+		p.nInv[i] = InvMForm(r.NttNInv[i], qi, mredParams)
+		p.nInvShoup[i] = shoupQuotient(p.nInv[i], qi)
+	}
+
+	return
+}
+
+// shoupQuotient returns floor(w * 2^64 / q) for w < q.
+func shoupQuotient(w, q uint64) (wShoup uint64) {
+	wShoup, _ = bits.Div64(w, 0, q)
+	return
+}
+
+// mulShoupLazy returns x * w mod q in the range [0, 2q-1] for any x, given w < q and wShoup = floor(w * 2^64 / q).
+func mulShoupLazy(x, w, wShoup, q uint64) uint64 {
+	hi, _ := bits.Mul64(x, wShoup)
+	return x*w - hi*q
+}
+
+// cRedLazy returns x - m if x >= m and x otherwise, for x < 2m < 2^63, without branching.
+func cRedLazy(x, m uint64) uint64 {
+	x -= m
+	return x + (m & uint64(int64(x)>>63))
+}
+
+// NumberTheoreticTransformerShoup computes the standard nega-cyclic NTT in the ring Z[X]/(X^N+1) with Harvey's butterflies,
+// which multiply by the twiddle factors using Shoup's precomputed quotients instead of the Montgomery reduction. The results are identical to the ones of NumberTheoreticTransformerStandard.
+// The moduli of the ring must be smaller than 2^61.
+//
+// The transformer is not used by default since it doubles the memory used by the twiddle factors of the ring.
+// It can be enabled with NewRingWithCustomNTT(N, Moduli, NumberTheoreticTransformerShoup{}, 2*N).
+type NumberTheoreticTransformerShoup struct {
+}
+
+// Forward writes the forward NTT in Z[X]/(X^N+1) of p1 on p2.
+func (rntt NumberTheoreticTransformerShoup) Forward(r *Ring, p1, p2 *Poly) {
+	rntt.ForwardLvl(r, len(r.Modulus)-1, p1, p2)
+}
+
+// ForwardLvl writes the forward NTT in Z[X]/(X^N+1) of p1 on p2.
+// Only computes the NTT for the first level+1 moduli.
+func (rntt NumberTheoreticTransformerShoup) ForwardLvl(r *Ring, level int, p1, p2 *Poly) {
+	for x := 0; x < level+1; x++ {
+		rntt.ForwardVec(r, x, p1.Coeffs[x], p2.Coeffs[x])
+	}
+}
+
+// ForwardLazy writes the forward NTT in Z[X]/(X^N+1) of p1 on p2.
+// Returns values in the range [0, 2q-1].
+func (rntt NumberTheoreticTransformerShoup) ForwardLazy(r *Ring, p1, p2 *Poly) {
+	rntt.ForwardLazyLvl(r, len(r.Modulus)-1, p1, p2)
+}
+
+// ForwardLazyLvl writes the forward NTT in Z[X]/(X^N+1) of p1 on p2.
+// Only computes the NTT for the first level+1 moduli and returns values in the range [0, 2q-1].
+func (rntt NumberTheoreticTransformerShoup) ForwardLazyLvl(r *Ring, level int, p1, p2 *Poly) {
+	for x := 0; x < level+1; x++ {
+		rntt.ForwardLazyVec(r, x, p1.Coeffs[x], p2.Coeffs[x])
+	}
+}
+
+// Backward writes the backward NTT in Z[X]/(X^N+1) of p1 on p2.
+func (rntt NumberTheoreticTransformerShoup) Backward(r *Ring, p1, p2 *Poly) {
+	rntt.BackwardLvl(r, len(r.Modulus)-1, p1, p2)
+}
+
+// BackwardLvl writes the backward NTT in Z[X]/(X^N+1) of p1 on p2.
+// Only computes the NTT for the first level+1 moduli.
+func (rntt NumberTheoreticTransformerShoup) BackwardLvl(r *Ring, level int, p1, p2 *Poly) {
+	for x := 0; x < level+1; x++ {
+		rntt.BackwardVec(r, x, p1.Coeffs[x], p2.Coeffs[x])
+	}
+}
+
+// BackwardLazy writes the backward NTT in Z[X]/(X^N+1) of p1 on p2.
+// Returns values in the range [0, 2q-1].
+func (rntt NumberTheoreticTransformerShoup) BackwardLazy(r *Ring, p1, p2 *Poly) {
+	rntt.BackwardLazyLvl(r, len(r.Modulus)-1, p1, p2)
+}
+
+// BackwardLazyLvl writes the backward NTT in Z[X]/(X^N+1) of p1 on p2.
+// Only computes the NTT for the first level+1 moduli and returns values in the range [0, 2q-1].
+func (rntt NumberTheoreticTransformerShoup) BackwardLazyLvl(r *Ring, level int, p1, p2 *Poly) {
+	for x := 0; x < level+1; x++ {
+		rntt.BackwardLazyVec(r, x, p1.Coeffs[x], p2.Coeffs[x])
+	}
+}
+
+// ForwardVec writes the forward NTT in Z[X]/(X^N+1) of the i-th level of p1 on the i-th level of p2.
+func (rntt NumberTheoreticTransformerShoup) ForwardVec(r *Ring, level int, p1, p2 []uint64) {
+	sp := r.nttShoup
+	NTTShoup(p1, p2, r.N, sp.psi[level], sp.psiShoup[level], r.Modulus[level])
+}
+
+// ForwardLazyVec writes the forward NTT in Z[X]/(X^N+1) of the i-th level of p1 on the i-th level of p2.
+// Returns values in the range [0, 2q-1].
+func (rntt NumberTheoreticTransformerShoup) ForwardLazyVec(r *Ring, level int, p1, p2 []uint64) {
+	sp := r.nttShoup
+	NTTShoupLazy(p1, p2, r.N, sp.psi[level], sp.psiShoup[level], r.Modulus[level])
+}
+
+// BackwardVec writes the backward NTT in Z[X]/(X^N+1) of the i-th level of p1 on the i-th level of p2.
+func (rntt NumberTheoreticTransformerShoup) BackwardVec(r *Ring, level int, p1, p2 []uint64) {
+	sp := r.nttShoup
+	InvNTTShoup(p1, p2, r.N, sp.psiInv[level], sp.psiInvShoup[level], sp.nInv[level], sp.nInvShoup[level], r.Modulus[level])
+}
+
+// BackwardLazyVec writes the backward NTT in Z[X]/(X^N+1) of the i-th level of p1 on the i-th level of p2.
+// Returns values in the range [0, 2q-1].
+func (rntt NumberTheoreticTransformerShoup) BackwardLazyVec(r *Ring, level int, p1, p2 []uint64) {
+	sp := r.nttShoup
+	InvNTTShoupLazy(p1, p2, r.N, sp.psiInv[level], sp.psiInvShoup[level], sp.nInv[level], sp.nInvShoup[level], r.Modulus[level])
+}
+
+// butterflyShoup computes X, Y = U + V*Psi, U - V*Psi mod Q.
+func butterflyShoup(U, V, Psi, PsiShoup, twoQ, fourQ, Q uint64) (uint64, uint64) {
+	if U >= fourQ {
+		U -= fourQ
+	}
+	V = mulShoupLazy(V, Psi, PsiShoup, Q)
+	return U + V, U + twoQ - V
+}
+
+// invbutterflyShoup computes X, Y = U + V, (U - V) * Psi mod Q.
+func invbutterflyShoup(U, V, Psi, PsiShoup, twoQ, fourQ, Q uint64) (X, Y uint64) {
+	X = U + V
+	if X >= twoQ {
+		X -= twoQ
+	}
+	Y = mulShoupLazy(U+fourQ-V, Psi, PsiShoup, Q)
+	return
+}
+
+// NTTShoup computes the NTT on the input coefficients using Harvey's butterflies with the twiddle factors nttPsi (in the
+// standard domain and in bit-reversed order) and their Shoup quotients nttPsiShoup.
+func NTTShoup(coeffsIn, coeffsOut []uint64, N int, nttPsi, nttPsiShoup []uint64, Q uint64) {
+	NTTShoupLazy(coeffsIn, coeffsOut, N, nttPsi, nttPsiShoup, Q)
+	for i := range coeffsOut[:N] {
+		coeffsOut[i] = cRedLazy(coeffsOut[i], Q)
+	}
+}
+
+// NTTShoupLazy computes the NTT on the input coefficients using Harvey's butterflies with the twiddle factors nttPsi (in the
+// standard domain and in bit-reversed order) and their Shoup quotients nttPsiShoup, with output values in the range [0, 2q-1].
+func NTTShoupLazy(coeffsIn, coeffsOut []uint64, N int, nttPsi, nttPsiShoup []uint64, Q uint64) {
+	var j1, j2, t int
+	var F, FShoup, V uint64
+
+	fourQ := 4 * Q
+	twoQ := 2 * Q
+
+	// Copy the result of the first round of butterflies on p2 with approximate reduction
+	t = N >> 1
+	F, FShoup = nttPsi[1], nttPsiShoup[1]
+
+	for jx, jy := 0, t; jx <= t-1; jx, jy = jx+8, jy+8 {
+
+		xin := (*[8]uint64)(unsafe.Pointer(&coeffsIn[jx]))
+		yin := (*[8]uint64)(unsafe.Pointer(&coeffsIn[jy]))
+
+		xout := (*[8]uint64)(unsafe.Pointer(&coeffsOut[jx]))
+		yout := (*[8]uint64)(unsafe.Pointer(&coeffsOut[jy]))
+
+		V = mulShoupLazy(yin[0], F, FShoup, Q)
+		xout[0], yout[0] = xin[0]+V, xin[0]+twoQ-V
+
+		V = mulShoupLazy(yin[1], F, FShoup, Q)
+		xout[1], yout[1] = xin[1]+V, xin[1]+twoQ-V
+
+		V = mulShoupLazy(yin[2], F, FShoup, Q)
+		xout[2], yout[2] = xin[2]+V, xin[2]+twoQ-V
+
+		V = mulShoupLazy(yin[3], F, FShoup, Q)
+		xout[3], yout[3] = xin[3]+V, xin[3]+twoQ-V
+
+		V = mulShoupLazy(yin[4], F, FShoup, Q)
+		xout[4], yout[4] = xin[4]+V, xin[4]+twoQ-V
+
+		V = mulShoupLazy(yin[5], F, FShoup, Q)
+		xout[5], yout[5] = xin[5]+V, xin[5]+twoQ-V
+
+		V = mulShoupLazy(yin[6], F, FShoup, Q)
+		xout[6], yout[6] = xin[6]+V, xin[6]+twoQ-V
+
+		V = mulShoupLazy(yin[7], F, FShoup, Q)
+		xout[7], yout[7] = xin[7]+V, xin[7]+twoQ-V
+	}
+
+	// Continue the rest of the second to the n-1 butterflies on p2 with approximate reduction
+	var reduce bool
+
+	for m := 2; m < N; m <<= 1 {
+
+		reduce = (bits.Len64(uint64(m))&1 == 1)
+
+		t >>= 1
+
+		if t >= 8 {
+
+			for i := 0; i < m; i++ {
+
+				j1 = (i * t) << 1
+
+				j2 = j1 + t - 1
+
+				F, FShoup = nttPsi[m+i], nttPsiShoup[m+i]
+
+				if reduce {
+
+					for jx, jy := j1, j1+t; jx <= j2; jx, jy = jx+8, jy+8 {
+
+						x := (*[8]uint64)(unsafe.Pointer(&coeffsOut[jx]))
+						y := (*[8]uint64)(unsafe.Pointer(&coeffsOut[jy]))
+
+						x[0], y[0] = butterflyShoup(x[0], y[0], F, FShoup, twoQ, fourQ, Q)
+						x[1], y[1] = butterflyShoup(x[1], y[1], F, FShoup, twoQ, fourQ, Q)
+						x[2], y[2] = butterflyShoup(x[2], y[2], F, FShoup, twoQ, fourQ, Q)
+						x[3], y[3] = butterflyShoup(x[3], y[3], F, FShoup, twoQ, fourQ, Q)
+						x[4], y[4] = butterflyShoup(x[4], y[4], F, FShoup, twoQ, fourQ, Q)
+						x[5], y[5] = butterflyShoup(x[5], y[5], F, FShoup, twoQ, fourQ, Q)
+						x[6], y[6] = butterflyShoup(x[6], y[6], F, FShoup, twoQ, fourQ, Q)
+						x[7], y[7] = butterflyShoup(x[7], y[7], F, FShoup, twoQ, fourQ, Q)
+					}
+
+				} else {
+
+					for jx, jy := j1, j1+t; jx <= j2; jx, jy = jx+8, jy+8 {
+
+						x := (*[8]uint64)(unsafe.Pointer(&coeffsOut[jx]))
+						y := (*[8]uint64)(unsafe.Pointer(&coeffsOut[jy]))
+
+						V = mulShoupLazy(y[0], F, FShoup, Q)
+						x[0], y[0] = x[0]+V, x[0]+twoQ-V
+
+						V = mulShoupLazy(y[1], F, FShoup, Q)
+						x[1], y[1] = x[1]+V, x[1]+twoQ-V
+
+						V = mulShoupLazy(y[2], F, FShoup, Q)
+						x[2], y[2] = x[2]+V, x[2]+twoQ-V
+
+						V = mulShoupLazy(y[3], F, FShoup, Q)
+						x[3], y[3] = x[3]+V, x[3]+twoQ-V
+
+						V = mulShoupLazy(y[4], F, FShoup, Q)
+						x[4], y[4] = x[4]+V, x[4]+twoQ-V
+
+						V = mulShoupLazy(y[5], F, FShoup, Q)
+						x[5], y[5] = x[5]+V, x[5]+twoQ-V
+
+						V = mulShoupLazy(y[6], F, FShoup, Q)
+						x[6], y[6] = x[6]+V, x[6]+twoQ-V
+
+						V = mulShoupLazy(y[7], F, FShoup, Q)
+						x[7], y[7] = x[7]+V, x[7]+twoQ-V
+					}
+				}
+			}
+
+		} else if t == 4 {
+
+			if reduce {
+
+				for i, j1 := m, 0; i < 2*m; i, j1 = i+2, j1+4*t {
+
+					psi := (*[2]uint64)(unsafe.Pointer(&nttPsi[i]))
+					psiShoup := (*[2]uint64)(unsafe.Pointer(&nttPsiShoup[i]))
+					x := (*[16]uint64)(unsafe.Pointer(&coeffsOut[j1]))
+
+					x[0], x[4] = butterflyShoup(x[0], x[4], psi[0], psiShoup[0], twoQ, fourQ, Q)
+					x[1], x[5] = butterflyShoup(x[1], x[5], psi[0], psiShoup[0], twoQ, fourQ, Q)
+					x[2], x[6] = butterflyShoup(x[2], x[6], psi[0], psiShoup[0], twoQ, fourQ, Q)
+					x[3], x[7] = butterflyShoup(x[3], x[7], psi[0], psiShoup[0], twoQ, fourQ, Q)
+					x[8], x[12] = butterflyShoup(x[8], x[12], psi[1], psiShoup[1], twoQ, fourQ, Q)
+					x[9], x[13] = butterflyShoup(x[9], x[13], psi[1], psiShoup[1], twoQ, fourQ, Q)
+					x[10], x[14] = butterflyShoup(x[10], x[14], psi[1], psiShoup[1], twoQ, fourQ, Q)
+					x[11], x[15] = butterflyShoup(x[11], x[15], psi[1], psiShoup[1], twoQ, fourQ, Q)
+
+				}
+			} else {
+
+				for i, j1 := m, 0; i < 2*m; i, j1 = i+2, j1+4*t {
+
+					psi := (*[2]uint64)(unsafe.Pointer(&nttPsi[i]))
+					psiShoup := (*[2]uint64)(unsafe.Pointer(&nttPsiShoup[i]))
+					x := (*[16]uint64)(unsafe.Pointer(&coeffsOut[j1]))
+
+					V = mulShoupLazy(x[4], psi[0], psiShoup[0], Q)
+					x[0], x[4] = x[0]+V, x[0]+twoQ-V
+
+					V = mulShoupLazy(x[5], psi[0], psiShoup[0], Q)
+					x[1], x[5] = x[1]+V, x[1]+twoQ-V
+
+					V = mulShoupLazy(x[6], psi[0], psiShoup[0], Q)
+					x[2], x[6] = x[2]+V, x[2]+twoQ-V
+
+					V = mulShoupLazy(x[7], psi[0], psiShoup[0], Q)
+					x[3], x[7] = x[3]+V, x[3]+twoQ-V
+
+					V = mulShoupLazy(x[12], psi[1], psiShoup[1], Q)
+					x[8], x[12] = x[8]+V, x[8]+twoQ-V
+
+					V = mulShoupLazy(x[13], psi[1], psiShoup[1], Q)
+					x[9], x[13] = x[9]+V, x[9]+twoQ-V
+
+					V = mulShoupLazy(x[14], psi[1], psiShoup[1], Q)
+					x[10], x[14] = x[10]+V, x[10]+twoQ-V
+
+					V = mulShoupLazy(x[15], psi[1], psiShoup[1], Q)
+					x[11], x[15] = x[11]+V, x[11]+twoQ-V
+
+				}
+
+			}
+
+		} else if t == 2 {
+
+			if reduce {
+
+				for i, j1 := m, 0; i < 2*m; i, j1 = i+4, j1+8*t {
+
+					psi := (*[4]uint64)(unsafe.Pointer(&nttPsi[i]))
+					psiShoup := (*[4]uint64)(unsafe.Pointer(&nttPsiShoup[i]))
+					x := (*[16]uint64)(unsafe.Pointer(&coeffsOut[j1]))
+
+					x[0], x[2] = butterflyShoup(x[0], x[2], psi[0], psiShoup[0], twoQ, fourQ, Q)
+					x[1], x[3] = butterflyShoup(x[1], x[3], psi[0], psiShoup[0], twoQ, fourQ, Q)
+					x[4], x[6] = butterflyShoup(x[4], x[6], psi[1], psiShoup[1], twoQ, fourQ, Q)
+					x[5], x[7] = butterflyShoup(x[5], x[7], psi[1], psiShoup[1], twoQ, fourQ, Q)
+					x[8], x[10] = butterflyShoup(x[8], x[10], psi[2], psiShoup[2], twoQ, fourQ, Q)
+					x[9], x[11] = butterflyShoup(x[9], x[11], psi[2], psiShoup[2], twoQ, fourQ, Q)
+					x[12], x[14] = butterflyShoup(x[12], x[14], psi[3], psiShoup[3], twoQ, fourQ, Q)
+					x[13], x[15] = butterflyShoup(x[13], x[15], psi[3], psiShoup[3], twoQ, fourQ, Q)
+				}
+			} else {
+
+				for i, j1 := m, 0; i < 2*m; i, j1 = i+4, j1+8*t {
+
+					psi := (*[4]uint64)(unsafe.Pointer(&nttPsi[i]))
+					psiShoup := (*[4]uint64)(unsafe.Pointer(&nttPsiShoup[i]))
+					x := (*[16]uint64)(unsafe.Pointer(&coeffsOut[j1]))
+
+					V = mulShoupLazy(x[2], psi[0], psiShoup[0], Q)
+					x[0], x[2] = x[0]+V, x[0]+twoQ-V
+
+					V = mulShoupLazy(x[3], psi[0], psiShoup[0], Q)
+					x[1], x[3] = x[1]+V, x[1]+twoQ-V
+
+					V = mulShoupLazy(x[6], psi[1], psiShoup[1], Q)
+					x[4], x[6] = x[4]+V, x[4]+twoQ-V
+
+					V = mulShoupLazy(x[7], psi[1], psiShoup[1], Q)
+					x[5], x[7] = x[5]+V, x[5]+twoQ-V
+
+					V = mulShoupLazy(x[10], psi[2], psiShoup[2], Q)
+					x[8], x[10] = x[8]+V, x[8]+twoQ-V
+
+					V = mulShoupLazy(x[11], psi[2], psiShoup[2], Q)
+					x[9], x[11] = x[9]+V, x[9]+twoQ-V
+
+					V = mulShoupLazy(x[14], psi[3], psiShoup[3], Q)
+					x[12], x[14] = x[12]+V, x[12]+twoQ-V
+
+					V = mulShoupLazy(x[15], psi[3], psiShoup[3], Q)
+					x[13], x[15] = x[13]+V, x[13]+twoQ-V
+				}
+			}
+
+		} else {
+
+			for i, j1 := m, 0; i < 2*m; i, j1 = i+8, j1+16 {
+
+				psi := (*[8]uint64)(unsafe.Pointer(&nttPsi[i]))
+				psiShoup := (*[8]uint64)(unsafe.Pointer(&nttPsiShoup[i]))
+				x := (*[16]uint64)(unsafe.Pointer(&coeffsOut[j1]))
+
+				x[0], x[1] = butterflyShoup(x[0], x[1], psi[0], psiShoup[0], twoQ, fourQ, Q)
+				x[2], x[3] = butterflyShoup(x[2], x[3], psi[1], psiShoup[1], twoQ, fourQ, Q)
+				x[4], x[5] = butterflyShoup(x[4], x[5], psi[2], psiShoup[2], twoQ, fourQ, Q)
+				x[6], x[7] = butterflyShoup(x[6], x[7], psi[3], psiShoup[3], twoQ, fourQ, Q)
+				x[8], x[9] = butterflyShoup(x[8], x[9], psi[4], psiShoup[4], twoQ, fourQ, Q)
+				x[10], x[11] = butterflyShoup(x[10], x[11], psi[5], psiShoup[5], twoQ, fourQ, Q)
+				x[12], x[13] = butterflyShoup(x[12], x[13], psi[6], psiShoup[6], twoQ, fourQ, Q)
+				x[14], x[15] = butterflyShoup(x[14], x[15], psi[7], psiShoup[7], twoQ, fourQ, Q)
+			}
+
+		}
+	}
+}
+
+// InvNTTShoup computes the InvNTT on the input coefficients using Harvey's butterflies with the twiddle factors nttPsiInv (in the
+// standard domain and in bit-reversed order) and their Shoup quotients nttPsiInvShoup, and nInv = N^-1 mod Q with its Shoup quotient nInvShoup.
+func InvNTTShoup(coeffsIn, coeffsOut []uint64, N int, nttPsiInv, nttPsiInvShoup []uint64, nInv, nInvShoup, Q uint64) {
+	InvNTTShoupLazy(coeffsIn, coeffsOut, N, nttPsiInv, nttPsiInvShoup, nInv, nInvShoup, Q)
+	for i := range coeffsOut[:N] {
+		coeffsOut[i] = cRedLazy(coeffsOut[i], Q)
+	}
+}
+
+// InvNTTShoupLazy computes the InvNTT on the input coefficients using Harvey's butterflies (see InvNTTShoup) with output values in the range [0, 2q-1].
+func InvNTTShoupLazy(coeffsIn, coeffsOut []uint64, N int, nttPsiInv, nttPsiInvShoup []uint64, nInv, nInvShoup, Q uint64) {
+	invNTTShoupCore(coeffsIn, coeffsOut, N, nttPsiInv, nttPsiInvShoup, Q)
+	for i := range coeffsOut[:N] {
+		coeffsOut[i] = mulShoupLazy(coeffsOut[i], nInv, nInvShoup, Q)
+	}
+}
+
+func invNTTShoupCore(coeffsIn, coeffsOut []uint64, N int, nttPsiInv, nttPsiInvShoup []uint64, Q uint64) {
+	var h, t int
+	var F, FShoup uint64
+
+	// Copy the result of the first round of butterflies on p2 with approximate reduction
+	t = 1
+	h = N >> 1
+	twoQ := Q << 1
+	fourQ := Q << 2
+
+	for i, j := h, 0; i < 2*h; i, j = i+8, j+16 {
+
+		psi := (*[8]uint64)(unsafe.Pointer(&nttPsiInv[i]))
+		psiShoup := (*[8]uint64)(unsafe.Pointer(&nttPsiInvShoup[i]))
+		xin := (*[16]uint64)(unsafe.Pointer(&coeffsIn[j]))
+		xout := (*[16]uint64)(unsafe.Pointer(&coeffsOut[j]))
+
+		xout[0], xout[1] = invbutterflyShoup(xin[0], xin[1], psi[0], psiShoup[0], twoQ, fourQ, Q)
+		xout[2], xout[3] = invbutterflyShoup(xin[2], xin[3], psi[1], psiShoup[1], twoQ, fourQ, Q)
+		xout[4], xout[5] = invbutterflyShoup(xin[4], xin[5], psi[2], psiShoup[2], twoQ, fourQ, Q)
+		xout[6], xout[7] = invbutterflyShoup(xin[6], xin[7], psi[3], psiShoup[3], twoQ, fourQ, Q)
+		xout[8], xout[9] = invbutterflyShoup(xin[8], xin[9], psi[4], psiShoup[4], twoQ, fourQ, Q)
+		xout[10], xout[11] = invbutterflyShoup(xin[10], xin[11], psi[5], psiShoup[5], twoQ, fourQ, Q)
+		xout[12], xout[13] = invbutterflyShoup(xin[12], xin[13], psi[6], psiShoup[6], twoQ, fourQ, Q)
+		xout[14], xout[15] = invbutterflyShoup(xin[14], xin[15], psi[7], psiShoup[7], twoQ, fourQ, Q)
+	}
+
+	// Continue the rest of the second to the n-1 butterflies on p2 with approximate reduction
+	t <<= 1
+	for m := N >> 1; m > 1; m >>= 1 {
+
+		h = m >> 1
+
+		if t >= 8 {
+
+			for i, j1, j2 := 0, 0, t-1; i < h; i, j1, j2 = i+1, j1+2*t, j2+2*t {
+
+				F, FShoup = nttPsiInv[h+i], nttPsiInvShoup[h+i]
+
+				for jx, jy := j1, j1+t; jx <= j2; jx, jy = jx+8, jy+8 {
+
+					x := (*[8]uint64)(unsafe.Pointer(&coeffsOut[jx]))
+					y := (*[8]uint64)(unsafe.Pointer(&coeffsOut[jy]))
+
+					x[0], y[0] = invbutterflyShoup(x[0], y[0], F, FShoup, twoQ, fourQ, Q)
+					x[1], y[1] = invbutterflyShoup(x[1], y[1], F, FShoup, twoQ, fourQ, Q)
+					x[2], y[2] = invbutterflyShoup(x[2], y[2], F, FShoup, twoQ, fourQ, Q)
+					x[3], y[3] = invbutterflyShoup(x[3], y[3], F, FShoup, twoQ, fourQ, Q)
+					x[4], y[4] = invbutterflyShoup(x[4], y[4], F, FShoup, twoQ, fourQ, Q)
+					x[5], y[5] = invbutterflyShoup(x[5], y[5], F, FShoup, twoQ, fourQ, Q)
+					x[6], y[6] = invbutterflyShoup(x[6], y[6], F, FShoup, twoQ, fourQ, Q)
+					x[7], y[7] = invbutterflyShoup(x[7], y[7], F, FShoup, twoQ, fourQ, Q)
+				}
+			}
+
+		} else if t == 4 {
+
+			for i, j1 := h, 0; i < 2*h; i, j1 = i+2, j1+4*t {
+
+				psi := (*[2]uint64)(unsafe.Pointer(&nttPsiInv[i]))
+				psiShoup := (*[2]uint64)(unsafe.Pointer(&nttPsiInvShoup[i]))
+				x := (*[16]uint64)(unsafe.Pointer(&coeffsOut[j1]))
+
+				x[0], x[4] = invbutterflyShoup(x[0], x[4], psi[0], psiShoup[0], twoQ, fourQ, Q)
+				x[1], x[5] = invbutterflyShoup(x[1], x[5], psi[0], psiShoup[0], twoQ, fourQ, Q)
+				x[2], x[6] = invbutterflyShoup(x[2], x[6], psi[0], psiShoup[0], twoQ, fourQ, Q)
+				x[3], x[7] = invbutterflyShoup(x[3], x[7], psi[0], psiShoup[0], twoQ, fourQ, Q)
+				x[8], x[12] = invbutterflyShoup(x[8], x[12], psi[1], psiShoup[1], twoQ, fourQ, Q)
+				x[9], x[13] = invbutterflyShoup(x[9], x[13], psi[1], psiShoup[1], twoQ, fourQ, Q)
+				x[10], x[14] = invbutterflyShoup(x[10], x[14], psi[1], psiShoup[1], twoQ, fourQ, Q)
+				x[11], x[15] = invbutterflyShoup(x[11], x[15], psi[1], psiShoup[1], twoQ, fourQ, Q)
+			}
+
+		} else {
+
+			for i, j1 := h, 0; i < 2*h; i, j1 = i+4, j1+8*t {
+
+				psi := (*[4]uint64)(unsafe.Pointer(&nttPsiInv[i]))
+				psiShoup := (*[4]uint64)(unsafe.Pointer(&nttPsiInvShoup[i]))
+				x := (*[16]uint64)(unsafe.Pointer(&coeffsOut[j1]))
+
+				x[0], x[2] = invbutterflyShoup(x[0], x[2], psi[0], psiShoup[0], twoQ, fourQ, Q)
+				x[1], x[3] = invbutterflyShoup(x[1], x[3], psi[0], psiShoup[0], twoQ, fourQ, Q)
+				x[4], x[6] = invbutterflyShoup(x[4], x[6], psi[1], psiShoup[1], twoQ, fourQ, Q)
+				x[5], x[7] = invbutterflyShoup(x[5], x[7], psi[1], psiShoup[1], twoQ, fourQ, Q)
+				x[8], x[10] = invbutterflyShoup(x[8], x[10], psi[2], psiShoup[2], twoQ, fourQ, Q)
+				x[9], x[11] = invbutterflyShoup(x[9], x[11], psi[2], psiShoup[2], twoQ, fourQ, Q)
+				x[12], x[14] = invbutterflyShoup(x[12], x[14], psi[3], psiShoup[3], twoQ, fourQ, Q)
+				x[13], x[15] = invbutterflyShoup(x[13], x[15], psi[3], psiShoup[3], twoQ, fourQ, Q)
+			}
+		}
+
+		t <<= 1
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/tuneinsight/lattigo/v3/utils"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -116,5 +118,68 @@ func TestNTT(t *testing.T) {
 
 			assert.True(t, ringQ.Equal(tv.poly, x), "invNTT should reverse NTT")
 		})
+
+		ringQShoup, _ := NewRingWithCustomNTT(tv.N, tv.Qis, NumberTheoreticTransformerShoup{}, 2*tv.N)
+
+		t.Run(fmt.Sprintf("Shoup/N=%d/limbs=%d", ringQShoup.N, len(ringQShoup.Modulus)), func(t *testing.T) {
+			x := ringQShoup.NewPoly()
+			ringQShoup.NTT(tv.poly, x)
+
+			assert.True(t, ringQShoup.Equal(x, tv.polyNTT), "transformed poly and polyNTT should match")
+
+			ringQShoup.InvNTT(x, x)
+
+			assert.True(t, ringQShoup.Equal(tv.poly, x), "invNTT should reverse NTT")
+		})
+	}
+}
+
+func TestNTTShoup(t *testing.T) {
+
+	prng, _ := utils.NewKeyedPRNG([]byte{'n', 't', 't'})
+
+	for _, defaultParam := range DefaultParams[:4] {
+
+		ringQ, _ := NewRing(1<<defaultParam.logN, defaultParam.qi)
+		ringQShoup, _ := NewRingWithCustomNTT(1<<defaultParam.logN, defaultParam.qi, NumberTheoreticTransformerShoup{}, 2<<defaultParam.logN)
+		level := len(ringQ.Modulus) - 1
+
+		t.Run(fmt.Sprintf("N=%d/limbs=%d", ringQ.N, len(ringQ.Modulus)), func(t *testing.T) {
+
+			assert.Equal(t, Standard, ringQShoup.Type())
+
+			// Lazy inputs in [0, 2q-1]
+			p := NewUniformSampler(prng, ringQ).ReadNew()
+			for i, qi := range ringQ.Modulus {
+				for j := 0; j < ringQ.N; j += 2 {
+					p.Coeffs[i][j] += qi
+				}
+			}
+
+			want, have := ringQ.NewPoly(), ringQ.NewPoly()
+
+			ringQ.NTT(p, want)
+			ringQShoup.NTT(p, have)
+			assert.True(t, ringQ.Equal(want, have))
+
+			ringQ.InvNTT(p, want)
+			ringQShoup.InvNTT(p, have)
+			assert.True(t, ringQ.Equal(want, have))
+
+			ringQShoup.NTTLazyLvl(level, p, have)
+			ringQ.NTTLvl(level, p, want)
+			ringQ.ReduceLvl(level, have, have)
+			assert.True(t, ringQ.Equal(want, have))
+
+			ringQShoup.InvNTTLazyLvl(level, p, have)
+			for i, qi := range ringQ.Modulus {
+				for j := 0; j < ringQ.N; j++ {
+					assert.Less(t, have.Coeffs[i][j], 2*qi)
+				}
+			}
+			ringQ.InvNTTLvl(level, p, want)
+			ringQ.ReduceLvl(level, have, have)
+			assert.True(t, ringQ.Equal(want, have))
+		})
 	}
 }
@@ -310,20 +310,12 @@ func (pol *Poly) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary decodes a slice of byte on the target polynomial.
 func (pol *Poly) UnmarshalBinary(data []byte) (err error) {
 
-	N := int(1 << data[0])
-	numberModulies := int(data[1])
-
-	if data[2] == 1 {
-		pol.IsNTT = true
-	}
-
-	if data[3] == 1 {
-		pol.IsMForm = true
+	N, numberModulies, err := decodePolyHeader(data, 8)
+	if err != nil {
+		return err
 	}
 
-	pointer := 4
-
-	if ((len(data) - pointer) >> 3) != N*numberModulies {
+	if len(data)-4 != (N*numberModulies)<<3 {
 		return errors.New("invalid polynomial encoding")
 	}
 
@@ -334,12 +326,35 @@ func (pol *Poly) UnmarshalBinary(data []byte) (err error) {
 	return nil
 }
 
+// decodePolyHeader decodes the ring degree and the number of moduli of the polynomial encoded on data,
+// with coefficients encoded on coeffSize bytes. It returns an error if data is too small to store the polynomial.
+func decodePolyHeader(data []byte, coeffSize int) (N, numberModuli int, err error) {
+
+	if len(data) < 4 {
+		return 0, 0, errors.New("invalid polynomial encoding: too small bytearray")
+	}
+
+	if data[0] > 30 {
+		return 0, 0, errors.New("invalid polynomial encoding: invalid ring degree")
+	}
+
+	N, numberModuli = 1<<data[0], int(data[1])
+
+	if uint64(len(data)-4) < uint64(N)*uint64(numberModuli)*uint64(coeffSize) {
+		return 0, 0, errors.New("invalid polynomial encoding: too small bytearray")
+	}
+
+	return
+}
+
 // DecodePolyNew decodes a slice of bytes in the target polynomial returns the number of bytes
 // decoded.
 func (pol *Poly) DecodePolyNew(data []byte) (pointer int, err error) {
 
-	N := int(1 << data[0])
-	numberModulies := int(data[1])
+	N, numberModulies, err := decodePolyHeader(data, 8)
+	if err != nil {
+		return 0, err
+	}
 
 	if data[2] == 1 {
 		pol.IsNTT = true
@@ -351,7 +366,7 @@ func (pol *Poly) DecodePolyNew(data []byte) (pointer int, err error) {
 
 	pointer = 4
 
-	if pol.Coeffs == nil {
+	if len(pol.Coeffs) != numberModulies {
 		pol.Coeffs = make([][]uint64, numberModulies)
 	}
 
@@ -366,8 +381,10 @@ func (pol *Poly) DecodePolyNew(data []byte) (pointer int, err error) {
 // decoded.
 func (pol *Poly) DecodePolyNew32(data []byte) (pointer int, err error) {
 
-	N := int(1 << data[0])
-	numberModulies := int(data[1])
+	N, numberModulies, err := decodePolyHeader(data, 4)
+	if err != nil {
+		return 0, err
+	}
 
 	if data[2] == 1 {
 		pol.IsNTT = true
@@ -379,7 +396,7 @@ func (pol *Poly) DecodePolyNew32(data []byte) (pointer int, err error) {
 
 	pointer = 4
 
-	if pol.Coeffs == nil {
+	if len(pol.Coeffs) != numberModulies {
 		pol.Coeffs = make([][]uint64, numberModulies)
 	}
 
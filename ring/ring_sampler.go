@@ -17,3 +17,37 @@ type baseSampler struct {
 type Sampler interface {
 	Read(pOut *Poly)
 }
+
+// ErrorSampler is an interface for the samplers of error polynomials.
+// It is implemented by GaussianSampler, DiscreteGaussianSampler and CenteredBinomialSampler.
+type ErrorSampler interface {
+	Sampler
+	ReadLvl(level int, pol *Poly)
+	ReadNew() (pol *Poly)
+	ReadLvlNew(level int) (pol *Poly)
+	ReadAndAddLvl(level int, pol *Poly)
+}
+
+// writeSignedLvl writes the small signed integer value on the coefficient x of pol
+// for the moduli 0 to level, without branching on value.
+func writeSignedLvl(level int, pol *Poly, moduli []uint64, x int, value int64) {
+	mask := uint64(value >> 63)
+	for j, qj := range moduli[:level+1] {
+		pol.Coeffs[j][x] = uint64(value) + (qj & mask)
+	}
+}
+
+// addSignedLvl adds the small signed integer value on the coefficient x of pol
+// for the moduli 0 to level, without branching on value or on the coefficients of pol.
+// The moduli must be smaller than 2^63.
+func addSignedLvl(level int, pol *Poly, moduli []uint64, x int, value int64) {
+	mask := uint64(value >> 63)
+	for j, qj := range moduli[:level+1] {
+		// c in [0, 2q)
+		c := pol.Coeffs[j][x] + uint64(value) + (qj & mask)
+		// c in [0, q)
+		c -= qj
+		c += qj & uint64(int64(c)>>63)
+		pol.Coeffs[j][x] = c
+	}
+}
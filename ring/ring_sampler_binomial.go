@@ -0,0 +1,83 @@
+package ring
+
+import (
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// CenteredBinomialSampler keeps the state of a constant-time centered binomial polynomial sampler.
+// Each coefficient is sampled as the difference of the Hamming weights of two uniform eta-bit strings,
+// which gives a distribution on [-eta, eta] of mean zero and variance eta/2.
+type CenteredBinomialSampler struct {
+	baseSampler
+	eta           int
+	mask          uint64
+	randomBufferN []byte
+}
+
+// NewCenteredBinomialSampler creates a new instance of CenteredBinomialSampler from a PRNG, a ring definition and the
+// distribution parameter eta, which must be between 1 and 64.
+func NewCenteredBinomialSampler(prng utils.PRNG, baseRing *Ring, eta int) *CenteredBinomialSampler {
+
+	if eta < 1 || eta > 64 {
+		panic("cannot NewCenteredBinomialSampler: eta must be between 1 and 64")
+	}
+
+	cbSampler := new(CenteredBinomialSampler)
+	cbSampler.prng = prng
+	cbSampler.baseRing = baseRing
+	cbSampler.eta = eta
+	cbSampler.mask = 0xffffffffffffffff >> (64 - eta)
+	cbSampler.randomBufferN = make([]byte, 16*baseRing.N)
+	return cbSampler
+}
+
+// Eta returns the parameter eta of the distribution.
+func (cbSampler *CenteredBinomialSampler) Eta() int {
+	return cbSampler.eta
+}
+
+// Read samples a centered binomial polynomial on "pol" at the maximum level in the default ring.
+func (cbSampler *CenteredBinomialSampler) Read(pol *Poly) {
+	cbSampler.ReadLvl(len(cbSampler.baseRing.Modulus)-1, pol)
+}
+
+// ReadLvl samples a centered binomial polynomial at the provided level in the default ring.
+func (cbSampler *CenteredBinomialSampler) ReadLvl(level int, pol *Poly) {
+	cbSampler.read(level, pol, writeSignedLvl)
+}
+
+// ReadNew samples a new centered binomial polynomial at the maximum level in the default ring.
+func (cbSampler *CenteredBinomialSampler) ReadNew() (pol *Poly) {
+	pol = cbSampler.baseRing.NewPoly()
+	cbSampler.Read(pol)
+	return pol
+}
+
+// ReadLvlNew samples a new centered binomial polynomial at the provided level in the default ring.
+func (cbSampler *CenteredBinomialSampler) ReadLvlNew(level int) (pol *Poly) {
+	pol = cbSampler.baseRing.NewPolyLvl(level)
+	cbSampler.ReadLvl(level, pol)
+	return pol
+}
+
+// ReadAndAddLvl samples a centered binomial polynomial at the given level in the default ring and adds it on "pol".
+func (cbSampler *CenteredBinomialSampler) ReadAndAddLvl(level int, pol *Poly) {
+	cbSampler.read(level, pol, addSignedLvl)
+}
+
+func (cbSampler *CenteredBinomialSampler) read(level int, pol *Poly, write func(level int, pol *Poly, moduli []uint64, x int, value int64)) {
+
+	cbSampler.prng.Clock(cbSampler.randomBufferN)
+
+	moduli := cbSampler.baseRing.Modulus
+	mask := cbSampler.mask
+
+	for x := 0; x < cbSampler.baseRing.N; x++ {
+		a := binary.LittleEndian.Uint64(cbSampler.randomBufferN[x<<4:]) & mask
+		b := binary.LittleEndian.Uint64(cbSampler.randomBufferN[(x<<4)+8:]) & mask
+		write(level, pol, moduli, x, int64(bits.OnesCount64(a))-int64(bits.OnesCount64(b)))
+	}
+}
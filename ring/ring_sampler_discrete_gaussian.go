@@ -0,0 +1,174 @@
+package ring
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// DiscreteGaussianSampler keeps the state of a constant-time discrete Gaussian polynomial sampler.
+// The samples are drawn by inversion of a cumulative distribution table (CDT) with 63 bits of precision:
+// the whole table is scanned for each coefficient, so that the running time and the memory access pattern
+// of the sampler do not depend on the sampled values.
+type DiscreteGaussianSampler struct {
+	baseSampler
+	sigma         float64
+	bound         int
+	cdt           []uint64
+	randomBufferN []byte
+}
+
+// NewDiscreteGaussianSampler creates a new instance of DiscreteGaussianSampler from a PRNG, a ring definition and the
+// distribution parameters. Sigma is the desired standard deviation and bound is the maximum coefficient norm in absolute value.
+func NewDiscreteGaussianSampler(prng utils.PRNG, baseRing *Ring, sigma float64, bound int) *DiscreteGaussianSampler {
+
+	if sigma <= 0 || bound < 1 {
+		panic("cannot NewDiscreteGaussianSampler: sigma must be positive and bound must be at least 1")
+	}
+
+	dgSampler := new(DiscreteGaussianSampler)
+	dgSampler.prng = prng
+	dgSampler.baseRing = baseRing
+	dgSampler.sigma = sigma
+	dgSampler.bound = bound
+	dgSampler.cdt = DiscreteGaussianCDT(sigma, bound)
+	dgSampler.randomBufferN = make([]byte, 8*baseRing.N)
+	return dgSampler
+}
+
+// DiscreteGaussianCDT returns the cumulative distribution table of the absolute value of the discrete Gaussian
+// distribution of standard deviation sigma truncated to [-bound, bound], scaled by 2^63.
+// The k-th entry of the table is round(2^63 * P(|x| <= k)) for k = 0 to bound-1.
+func DiscreteGaussianCDT(sigma float64, bound int) (cdt []uint64) {
+
+	// 2 * sigma^2
+	twoSigma2 := new(big.Float).SetPrec(128).SetFloat64(sigma)
+	twoSigma2.Mul(twoSigma2, twoSigma2)
+	twoSigma2.Mul(twoSigma2, big.NewFloat(2))
+
+	rho := make([]*big.Float, bound+1)
+	sum := new(big.Float).SetPrec(128)
+	for k := 0; k <= bound; k++ {
+		// exp(-k^2 / (2 * sigma^2)), computed with 128 bits of precision
+		x := new(big.Float).SetPrec(128).SetInt64(-int64(k) * int64(k))
+		rho[k] = bigExp(x.Quo(x, twoSigma2))
+		sum.Add(sum, rho[k])
+		if k != 0 {
+			sum.Add(sum, rho[k])
+		}
+	}
+
+	scale := new(big.Float).SetPrec(128).SetMantExp(big.NewFloat(1), 63)
+
+	cdt = make([]uint64, bound)
+	acc := new(big.Float).SetPrec(128)
+	tmp := new(big.Float).SetPrec(128)
+	for k := 0; k < bound; k++ {
+		acc.Add(acc, rho[k])
+		if k != 0 {
+			acc.Add(acc, rho[k])
+		}
+		tmp.Quo(acc, sum)
+		tmp.Mul(tmp, scale)
+		tmp.Add(tmp, big.NewFloat(0.5))
+		cdt[k], _ = tmp.Uint64()
+	}
+
+	return
+}
+
+// bigExp returns exp(x) with the precision of x, computed with its Taylor series after a reduction of the
+// argument to [0, 1/2].
+func bigExp(x *big.Float) (y *big.Float) {
+
+	prec := x.Prec()
+	workPrec := prec + 64
+
+	z := new(big.Float).SetPrec(workPrec).Abs(x)
+
+	// exp(|x|) = exp(|x|/2^r)^(2^r)
+	var r int
+	if z.Sign() != 0 {
+		if exp := z.MantExp(nil); exp > -1 {
+			r = exp + 1
+			z.SetMantExp(z, -r)
+		}
+	}
+
+	y = new(big.Float).SetPrec(workPrec).SetInt64(1)
+	term := new(big.Float).SetPrec(workPrec).SetInt64(1)
+	for k := int64(1); ; k++ {
+		term.Mul(term, z)
+		term.Quo(term, new(big.Float).SetInt64(k))
+		if term.Sign() == 0 || term.MantExp(nil) < y.MantExp(nil)-int(workPrec) {
+			break
+		}
+		y.Add(y, term)
+	}
+
+	for i := 0; i < r; i++ {
+		y.Mul(y, y)
+	}
+
+	if x.Sign() < 0 {
+		y.Quo(new(big.Float).SetPrec(workPrec).SetInt64(1), y)
+	}
+
+	return y.SetPrec(prec)
+}
+
+// Read samples a discrete Gaussian polynomial on "pol" at the maximum level in the default ring, standard deviation and bound.
+func (dgSampler *DiscreteGaussianSampler) Read(pol *Poly) {
+	dgSampler.ReadLvl(len(dgSampler.baseRing.Modulus)-1, pol)
+}
+
+// ReadLvl samples a discrete Gaussian polynomial at the provided level, in the default ring, standard deviation and bound.
+func (dgSampler *DiscreteGaussianSampler) ReadLvl(level int, pol *Poly) {
+	dgSampler.read(level, pol, writeSignedLvl)
+}
+
+// ReadNew samples a new discrete Gaussian polynomial at the maximum level in the default ring, standard deviation and bound.
+func (dgSampler *DiscreteGaussianSampler) ReadNew() (pol *Poly) {
+	pol = dgSampler.baseRing.NewPoly()
+	dgSampler.Read(pol)
+	return pol
+}
+
+// ReadLvlNew samples a new discrete Gaussian polynomial at the provided level, in the default ring, standard deviation and bound.
+func (dgSampler *DiscreteGaussianSampler) ReadLvlNew(level int) (pol *Poly) {
+	pol = dgSampler.baseRing.NewPolyLvl(level)
+	dgSampler.ReadLvl(level, pol)
+	return pol
+}
+
+// ReadAndAddLvl samples a discrete Gaussian polynomial at the given level for the receiver's default standard deviation and bound and adds it on "pol".
+func (dgSampler *DiscreteGaussianSampler) ReadAndAddLvl(level int, pol *Poly) {
+	dgSampler.read(level, pol, addSignedLvl)
+}
+
+func (dgSampler *DiscreteGaussianSampler) read(level int, pol *Poly, write func(level int, pol *Poly, moduli []uint64, x int, value int64)) {
+
+	dgSampler.prng.Clock(dgSampler.randomBufferN)
+
+	moduli := dgSampler.baseRing.Modulus
+
+	for x := 0; x < dgSampler.baseRing.N; x++ {
+
+		randomInt := binary.LittleEndian.Uint64(dgSampler.randomBufferN[x<<3 : (x+1)<<3])
+
+		// 63 bits for the magnitude and 1 bit for the sign
+		sign := randomInt >> 63
+		randomInt &= 0x7fffffffffffffff
+
+		// |x| = #{k : cdt[k] <= randomInt}
+		var magnitude uint64
+		for _, c := range dgSampler.cdt {
+			_, borrow := bits.Sub64(randomInt, c, 0)
+			magnitude += borrow ^ 1
+		}
+
+		write(level, pol, moduli, x, int64(magnitude)*(1-2*int64(sign)))
+	}
+}
@@ -3,7 +3,9 @@ package ring
 import (
 	"flag"
 	"fmt"
+	"math"
 	"math/big"
+	"sync"
 	"testing"
 
 	"github.com/tuneinsight/lattigo/v3/utils"
@@ -60,6 +62,7 @@ func TestRing(t *testing.T) {
 	}
 
 	testNewRing(t)
+	testMulPoly(t)
 	for _, defaultParam := range defaultParams[:] {
 
 		var testContext *testParams
@@ -75,6 +78,8 @@ func TestRing(t *testing.T) {
 		testMarshalBinary(testContext, t)
 		testUniformSampler(testContext, t)
 		testGaussianSampler(testContext, t)
+		testDiscreteGaussianSampler(testContext, t)
+		testCenteredBinomialSampler(testContext, t)
 		testTernarySampler(testContext, t)
 		testGaloisShift(testContext, t)
 		testModularReduction(testContext, t)
@@ -83,6 +88,7 @@ func TestRing(t *testing.T) {
 		testExtendBasis(testContext, t)
 		testScaling(testContext, t)
 		testMultByMonomial(testContext, t)
+		testParallelism(testContext, t)
 	}
 }
 
@@ -360,6 +366,173 @@ func testGaussianSampler(testContext *testParams, t *testing.T) {
 	})
 }
 
+func testDiscreteGaussianSampler(testContext *testParams, t *testing.T) {
+
+	t.Run(testString("DiscreteGaussianSampler/", testContext.ringQ), func(t *testing.T) {
+
+		prng, err := utils.NewKeyedPRNG([]byte{'d', 'g'})
+		require.NoError(t, err)
+
+		sampler := NewDiscreteGaussianSampler(prng, testContext.ringQ, DefaultSigma, DefaultBound)
+
+		pmf := make([]float64, 2*DefaultBound+1)
+		var sum float64
+		for k := -DefaultBound; k <= DefaultBound; k++ {
+			pmf[k+DefaultBound] = math.Exp(-float64(k*k) / (2 * DefaultSigma * DefaultSigma))
+			sum += pmf[k+DefaultBound]
+		}
+		for k := range pmf {
+			pmf[k] /= sum
+		}
+
+		testErrorSamplerDistribution(testContext.ringQ, sampler, pmf, t)
+	})
+
+	t.Run(testString("DiscreteGaussianCDT/", testContext.ringQ), func(t *testing.T) {
+		// round(2^63 * P(|x| <= k)) for sigma = 3.2 and bound = 19, computed with 80 decimal digits of precision
+		cdt := []uint64{
+			1149872836518706909, 3340023669317320545, 5231742859181312745, 6713673040852152462,
+			7766573333558595358, 8445050410543775022, 8841576294031517488, 9051758687454227147,
+			9152802460441190450, 9196859083481262409, 9214281214904026339, 9220529772758650826,
+			9222562348483741346, 9223162004373033245, 9223322456656299390, 9223361395058736926,
+			9223369965413244325, 9223371676247247625, 9223371985993021523,
+		}
+		require.Equal(t, cdt, DiscreteGaussianCDT(DefaultSigma, DefaultBound))
+	})
+}
+
+func testCenteredBinomialSampler(testContext *testParams, t *testing.T) {
+
+	for _, eta := range []int{2, 21, 64} {
+
+		t.Run(testString(fmt.Sprintf("CenteredBinomialSampler/eta=%d/", eta), testContext.ringQ), func(t *testing.T) {
+
+			prng, err := utils.NewKeyedPRNG([]byte{'c', 'b', 'd'})
+			require.NoError(t, err)
+
+			sampler := NewCenteredBinomialSampler(prng, testContext.ringQ, eta)
+
+			// P(x = k) = binomial(2*eta, eta+k) / 2^(2*eta)
+			pmf := make([]float64, 2*eta+1)
+			for k := -eta; k <= eta; k++ {
+				lgCoeff, _ := math.Lgamma(float64(2*eta + 1))
+				lgA, _ := math.Lgamma(float64(eta + k + 1))
+				lgB, _ := math.Lgamma(float64(eta - k + 1))
+				pmf[k+eta] = math.Exp(lgCoeff - lgA - lgB - float64(2*eta)*math.Ln2)
+			}
+
+			testErrorSamplerDistribution(testContext.ringQ, sampler, pmf, t)
+		})
+	}
+}
+
+// testErrorSamplerDistribution checks that the samples of the sampler are consistent across the moduli of the ring,
+// that ReadAndAddLvl is consistent with ReadLvl, and that the samples follow the probability mass function pmf on
+// [-bound, bound] (with len(pmf) = 2*bound+1) with a chi-square goodness of fit test and by comparing the first two moments.
+func testErrorSamplerDistribution(ringQ *Ring, sampler ErrorSampler, pmf []float64, t *testing.T) {
+
+	bound := (len(pmf) - 1) / 2
+	level := len(ringQ.Modulus) - 1
+
+	// Target of 2^17 samples
+	nbPolys := utils.MaxInt(1, (1<<17)/ringQ.N)
+	nbSamples := float64(nbPolys * ringQ.N)
+
+	counts := make([]float64, len(pmf))
+
+	var mean, variance float64
+
+	for i := 0; i < nbPolys; i++ {
+
+		pol := sampler.ReadNew()
+
+		for x := 0; x < ringQ.N; x++ {
+
+			var value int64
+			if c := pol.Coeffs[0][x]; c > ringQ.Modulus[0]>>1 {
+				value = -int64(ringQ.Modulus[0] - c)
+			} else {
+				value = int64(c)
+			}
+
+			require.True(t, -int64(bound) <= value && value <= int64(bound))
+
+			for j := 1; j < level+1; j++ {
+				expected := uint64(value)
+				if value < 0 {
+					expected += ringQ.Modulus[j]
+				}
+				require.Equal(t, expected, pol.Coeffs[j][x])
+			}
+
+			counts[value+int64(bound)]++
+			mean += float64(value)
+			variance += float64(value * value)
+		}
+	}
+
+	mean /= nbSamples
+	variance = variance/nbSamples - mean*mean
+
+	var expMean, expVariance float64
+	for k, p := range pmf {
+		expMean += float64(k-bound) * p
+		expVariance += float64((k-bound)*(k-bound)) * p
+	}
+	expVariance -= expMean * expMean
+
+	// Moments, with a margin of 6 standard deviations of the estimators
+	require.Less(t, math.Abs(mean-expMean), 6*math.Sqrt(expVariance/nbSamples))
+	require.Less(t, math.Abs(variance-expVariance), 6*expVariance*math.Sqrt(2/nbSamples)+1e-9)
+
+	// Chi-square goodness of fit, merging the tails whose expected count is smaller than 5
+	var chi2, obsTail, expTail float64
+	var df int
+	for k, p := range pmf {
+		obsTail += counts[k]
+		expTail += p * nbSamples
+		if expTail >= 5 {
+			chi2 += (obsTail - expTail) * (obsTail - expTail) / expTail
+			obsTail, expTail = 0, 0
+			df++
+		}
+	}
+	if expTail > 0 {
+		chi2 += (obsTail - expTail) * (obsTail - expTail) / expTail
+		df++
+	}
+	df--
+
+	// Loose upper bound on the quantile 1-1e-9 of the chi-square distribution with df degrees of freedom
+	require.Less(t, chi2, float64(df)+10*math.Sqrt(2*float64(df))+20)
+
+	// ReadAndAddLvl must add the same samples as ReadLvl for the same PRNG state
+	prngA, _ := utils.NewKeyedPRNG([]byte{'a', 'd', 'd'})
+	prngB, _ := utils.NewKeyedPRNG([]byte{'a', 'd', 'd'})
+
+	var samplerA, samplerB ErrorSampler
+	switch s := sampler.(type) {
+	case *DiscreteGaussianSampler:
+		samplerA = NewDiscreteGaussianSampler(prngA, ringQ, s.sigma, s.bound)
+		samplerB = NewDiscreteGaussianSampler(prngB, ringQ, s.sigma, s.bound)
+	case *CenteredBinomialSampler:
+		samplerA = NewCenteredBinomialSampler(prngA, ringQ, s.eta)
+		samplerB = NewCenteredBinomialSampler(prngB, ringQ, s.eta)
+	}
+
+	prngU, _ := utils.NewKeyedPRNG([]byte{'u'})
+	uniform := NewUniformSampler(prngU, ringQ).ReadNew()
+	e := samplerA.ReadLvlNew(level)
+
+	have := uniform.CopyNew()
+	samplerB.ReadAndAddLvl(level, have)
+
+	want := ringQ.NewPoly()
+	ringQ.AddLvl(level, uniform, e, want)
+
+	require.True(t, ringQ.EqualLvl(level, have, want))
+}
+
 func testTernarySampler(testContext *testParams, t *testing.T) {
 
 	for _, p := range []float64{.5, 1. / 3., 128. / 65536.} {
@@ -772,3 +945,149 @@ func testMultByMonomial(testContext *testParams, t *testing.T) {
 		require.Equal(t, p3Want.Coeffs[0][:testContext.ringQ.N], p3Test.Coeffs[0][:testContext.ringQ.N])
 	})
 }
+
+func testParallelism(testContext *testParams, t *testing.T) {
+
+	ringQ := testContext.ringQ
+	ringQPar := ringQ.WithParallelism(4)
+
+	require.Equal(t, 1, ringQ.Parallelism())
+	require.Equal(t, 4, ringQPar.Parallelism())
+
+	t.Run(testString("Parallelism/NTT/", ringQ), func(t *testing.T) {
+
+		level := len(ringQ.Modulus) - 2
+
+		p := testContext.uniformSamplerQ.ReadNew()
+		pWant := ringQ.NewPoly()
+		pHave := ringQ.NewPoly()
+
+		ringQ.NTT(p, pWant)
+		ringQPar.NTT(p, pHave)
+		require.True(t, ringQ.Equal(pWant, pHave))
+
+		ringQ.InvNTT(p, pWant)
+		ringQPar.InvNTT(p, pHave)
+		require.True(t, ringQ.Equal(pWant, pHave))
+
+		ringQ.NTTLazyLvl(level, p, pWant)
+		ringQPar.NTTLazyLvl(level, p, pHave)
+		require.True(t, ringQ.EqualLvl(level, pWant, pHave))
+
+		ringQ.InvNTTLazyLvl(level, p, pWant)
+		ringQPar.InvNTTLazyLvl(level, p, pHave)
+		require.True(t, ringQ.EqualLvl(level, pWant, pHave))
+	})
+
+	t.Run(testString("Parallelism/ModUp/", ringQ), func(t *testing.T) {
+
+		levelQ := len(ringQ.Modulus) - 1
+		levelP := len(testContext.ringP.Modulus) - 1
+
+		p := testContext.uniformSamplerQ.ReadNew()
+		pWant := testContext.ringP.NewPoly()
+		pHave := testContext.ringP.NewPoly()
+
+		NewBasisExtender(ringQ, testContext.ringP).ModUpQtoP(levelQ, levelP, p, pWant)
+		NewBasisExtender(ringQPar, testContext.ringP).ModUpQtoP(levelQ, levelP, p, pHave)
+
+		require.True(t, testContext.ringP.Equal(pWant, pHave))
+	})
+}
+
+func testMulPoly(t *testing.T) {
+
+	// Negacyclic schoolbook multiplication
+	mulPolyNaive := func(r *Ring, level int, p1, p2, p3 *Poly) {
+		for i := 0; i < level+1; i++ {
+			qi, bredParams := r.Modulus[i], r.BredParams[i]
+			for k := 0; k < r.N; k++ {
+				p3.Coeffs[i][k] = 0
+			}
+			for j := 0; j < r.N; j++ {
+				for k := 0; k < r.N; k++ {
+					prod := BRed(p1.Coeffs[i][j], p2.Coeffs[i][k], qi, bredParams)
+					if j+k < r.N {
+						p3.Coeffs[i][j+k] = CRed(p3.Coeffs[i][j+k]+prod, qi)
+					} else {
+						p3.Coeffs[i][j+k-r.N] = CRed(p3.Coeffs[i][j+k-r.N]+qi-prod, qi)
+					}
+				}
+			}
+		}
+	}
+
+	N := 64
+
+	for _, tc := range []struct {
+		name   string
+		moduli []uint64
+	}{
+		{"PowersOfTwo", []uint64{1 << 16, 1 << 40, 1 << 60}},
+		{"NonNTTPrimes", []uint64{0x1fffffffffffffff, 0x3ffffffffffe5, 65539}},
+		{"NTTPrimes", GenerateNTTPrimes(55, 2*N, 3)},
+	} {
+
+		moduli := tc.moduli
+		r, _ := NewRing(N, moduli)
+
+		t.Run(fmt.Sprintf("MulPoly/%s/N=%d/limbs=%d/AllowsNTT=%t", tc.name, N, len(moduli), r.AllowsNTT), func(t *testing.T) {
+
+			prng, _ := utils.NewPRNG()
+
+			p1 := r.NewPoly()
+			p2 := r.NewPoly()
+			for i, qi := range r.Modulus {
+				for j := 0; j < N; j++ {
+					p1.Coeffs[i][j] = RandUniform(prng, qi, r.Mask[i])
+					p2.Coeffs[i][j] = RandUniform(prng, qi, r.Mask[i])
+				}
+			}
+
+			pWant := r.NewPoly()
+			pHave := r.NewPoly()
+
+			mulPolyNaive(r, len(r.Modulus)-1, p1, p2, pWant)
+			r.MulPoly(p1, p2, pHave)
+			require.True(t, r.Equal(pWant, pHave))
+
+			level := len(r.Modulus) - 2
+			mulPolyNaive(r, level, p1, p2, pWant)
+			r.MulPolyLvl(level, p1, p2, p1)
+			require.True(t, r.EqualLvl(level, pWant, p1))
+		})
+
+		t.Run(fmt.Sprintf("MulPoly/Concurrent/%s/N=%d/limbs=%d/AllowsNTT=%t", tc.name, N, len(moduli), r.AllowsNTT), func(t *testing.T) {
+
+			prng, _ := utils.NewPRNG()
+
+			p1 := r.NewPoly()
+			p2 := r.NewPoly()
+			for i, qi := range r.Modulus {
+				for j := 0; j < N; j++ {
+					p1.Coeffs[i][j] = RandUniform(prng, qi, r.Mask[i])
+					p2.Coeffs[i][j] = RandUniform(prng, qi, r.Mask[i])
+				}
+			}
+
+			pWant := r.NewPoly()
+			mulPolyNaive(r, len(r.Modulus)-1, p1, p2, pWant)
+
+			pHave := make([]*Poly, 4)
+			var wg sync.WaitGroup
+			for w := range pHave {
+				pHave[w] = r.NewPoly()
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					r.MulPoly(p1, p2, pHave[w])
+				}(w)
+			}
+			wg.Wait()
+
+			for w := range pHave {
+				require.True(t, r.Equal(pWant, pHave[w]))
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package rlwe
 
 import (
+	"fmt"
+
 	"github.com/tuneinsight/lattigo/v3/ring"
 	"github.com/tuneinsight/lattigo/v3/utils"
 )
@@ -14,23 +16,26 @@ type Decryptor interface {
 
 // decryptor is a structure used to decrypt ciphertext. It stores the secret-key.
 type decryptor struct {
-	ringQ *ring.Ring
-	pool  *ring.Poly
-	sk    *SecretKey
+	params Parameters
+	ringQ  *ring.Ring
+	pool   *ring.Poly
+	sk     *SecretKey
 }
 
 // NewDecryptor instantiates a new generic RLWE Decryptor.
-func NewDecryptor(params Parameters, sk *SecretKey) Decryptor {
+// It returns an error if the secret key cannot be used with the parameters (see Parameters.CheckSecretKey).
+func NewDecryptor(params Parameters, sk *SecretKey) (Decryptor, error) {
 
-	if sk.Value.Q.Degree() != params.N() {
-		panic("secret_key is invalid for the provided parameters")
+	if err := params.CheckSecretKey(sk); err != nil {
+		return nil, err
 	}
 
 	return &decryptor{
-		ringQ: params.RingQ(),
-		pool:  params.RingQ().NewPoly(),
-		sk:    sk,
-	}
+		params: params,
+		ringQ:  params.RingQ(),
+		pool:   params.RingQ().NewPoly(),
+		sk:     sk,
+	}, nil
 }
 
 // Decrypt decrypts the ciphertext and write the result in ptOut.
@@ -80,19 +85,27 @@ func (d *decryptor) Decrypt(ciphertext *Ciphertext, plaintext *Plaintext) {
 // Decryptor can be used concurrently.
 func (d *decryptor) ShallowCopy() Decryptor {
 	return &decryptor{
-		ringQ: d.ringQ,
-		pool:  d.ringQ.NewPoly(),
-		sk:    d.sk,
+		params: d.params,
+		ringQ:  d.ringQ,
+		pool:   d.ringQ.NewPoly(),
+		sk:     d.sk,
 	}
 }
 
 // WithKey creates a shallow copy of Decryptor with a new decryption key, in which all the
 // read-only data-structures are shared with the receiver and the temporary buffers
 // are reallocated. The receiver and the returned Decryptor can be used concurrently.
+// The method panics if the secret key cannot be used with the parameters (see Parameters.CheckSecretKey).
 func (d *decryptor) WithKey(sk *SecretKey) Decryptor {
+
+	if err := d.params.CheckSecretKey(sk); err != nil {
+		panic(fmt.Errorf("cannot WithKey: %w", err))
+	}
+
 	return &decryptor{
-		ringQ: d.ringQ,
-		pool:  d.ringQ.NewPoly(),
-		sk:    sk,
+		params: d.params,
+		ringQ:  d.ringQ,
+		pool:   d.ringQ.NewPoly(),
+		sk:     sk,
 	}
 }
@@ -0,0 +1,105 @@
+package rlwe
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// ErrorDistribution is the type of distribution from which the error polynomials are sampled.
+type ErrorDistribution int
+
+// GaussianError, DiscreteGaussianError and CenteredBinomialError are the supported error distributions.
+const (
+	GaussianError         = ErrorDistribution(0) // Truncated Gaussian of standard deviation Sigma sampled by rejection (Default)
+	DiscreteGaussianError = ErrorDistribution(1) // Discrete Gaussian of standard deviation Sigma sampled in constant time
+	CenteredBinomialError = ErrorDistribution(2) // Centered binomial of parameter eta=round(2*Sigma^2) sampled in constant time
+)
+
+// String returns the string representation of the ErrorDistribution.
+func (ed ErrorDistribution) String() string {
+	switch ed {
+	case GaussianError:
+		return "Gaussian"
+	case DiscreteGaussianError:
+		return "DiscreteGaussian"
+	case CenteredBinomialError:
+		return "CenteredBinomial"
+	default:
+		return "Invalid"
+	}
+}
+
+// UnmarshalJSON reads a JSON byte slice into the receiver ErrorDistribution.
+func (ed *ErrorDistribution) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	switch s {
+	default:
+		return fmt.Errorf("invalid error distribution: %s", s)
+	case "Gaussian":
+		*ed = GaussianError
+	case "DiscreteGaussian":
+		*ed = DiscreteGaussianError
+	case "CenteredBinomial":
+		*ed = CenteredBinomialError
+	}
+
+	return nil
+}
+
+// MarshalJSON marshals the receiver ErrorDistribution into a JSON []byte.
+func (ed ErrorDistribution) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ed.String())
+}
+
+// CenteredBinomialEta returns the parameter eta of the centered binomial distribution whose variance
+// eta/2 is the closest to sigma^2.
+func CenteredBinomialEta(sigma float64) int {
+	return int(math.Round(2 * sigma * sigma))
+}
+
+// checkErrorDistribution checks that the error distribution ed can be instantiated with the standard deviation sigma.
+func checkErrorDistribution(ed ErrorDistribution, sigma float64) error {
+	switch ed {
+	case GaussianError:
+	case DiscreteGaussianError:
+		if sigma <= 0 {
+			return fmt.Errorf("invalid error distribution: DiscreteGaussian requires sigma > 0")
+		}
+	case CenteredBinomialError:
+		if eta := CenteredBinomialEta(sigma); eta < 1 || eta > 64 {
+			return fmt.Errorf("invalid error distribution: CenteredBinomial requires 1 <= round(2*sigma^2) <= 64 but is %d", eta)
+		}
+	default:
+		return fmt.Errorf("invalid error distribution: %d", ed)
+	}
+	return nil
+}
+
+// Validate returns an error if the error distribution cannot be instantiated with the standard deviation sigma.
+func (ed ErrorDistribution) Validate(sigma float64) error {
+	return checkErrorDistribution(ed, sigma)
+}
+
+// NewSampler returns a new sampler in the ring baseRing for the error distribution of standard deviation sigma.
+func (ed ErrorDistribution) NewSampler(prng utils.PRNG, baseRing *ring.Ring, sigma float64) ring.ErrorSampler {
+	switch ed {
+	case DiscreteGaussianError:
+		return ring.NewDiscreteGaussianSampler(prng, baseRing, sigma, int(6*sigma))
+	case CenteredBinomialError:
+		return ring.NewCenteredBinomialSampler(prng, baseRing, CenteredBinomialEta(sigma))
+	default:
+		return ring.NewGaussianSampler(prng, baseRing, sigma, int(6*sigma))
+	}
+}
+
+// NewErrorSampler returns a new sampler in the ring Q for the error distribution of the target parameters.
+func NewErrorSampler(params Parameters, prng utils.PRNG) ring.ErrorSampler {
+	return params.ErrorDistribution().NewSampler(prng, params.RingQ(), params.Sigma())
+}
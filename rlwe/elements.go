@@ -15,6 +15,9 @@ type Plaintext struct {
 // Ciphertext is a generic type for RLWE ciphertext.
 type Ciphertext struct {
 	Value []*ring.Poly
+
+	// ParametersFingerprint is the Fingerprint of the parameters of the ciphertext, or 0 if it is unknown.
+	ParametersFingerprint uint64
 }
 
 // AdditiveShare is a type for storing additively shared values in Z_Q[X] (RNS domain)
@@ -79,7 +82,7 @@ func (pt *Plaintext) Copy(other *Plaintext) {
 
 // NewCiphertext returns a new Element with zero values.
 func NewCiphertext(params Parameters, degree, level int) *Ciphertext {
-	el := new(Ciphertext)
+	el := &Ciphertext{ParametersFingerprint: params.Fingerprint()}
 	el.Value = make([]*ring.Poly, degree+1)
 	for i := 0; i < degree+1; i++ {
 		el.Value[i] = ring.NewPoly(params.N(), level+1)
@@ -89,7 +92,7 @@ func NewCiphertext(params Parameters, degree, level int) *Ciphertext {
 
 // NewCiphertextNTT returns a new Element with zero values and the NTT flags set.
 func NewCiphertextNTT(params Parameters, degree, level int) *Ciphertext {
-	el := new(Ciphertext)
+	el := &Ciphertext{ParametersFingerprint: params.Fingerprint()}
 	el.Value = make([]*ring.Poly, degree+1)
 	for i := 0; i < degree+1; i++ {
 		el.Value[i] = ring.NewPoly(params.N(), level+1)
@@ -193,7 +196,7 @@ func SwitchCiphertextRingDegree(ctIn *Ciphertext, ctOut *Ciphertext) {
 // CopyNew creates a new element as a copy of the target element.
 func (el *Ciphertext) CopyNew() *Ciphertext {
 
-	ctxCopy := new(Ciphertext)
+	ctxCopy := &Ciphertext{ParametersFingerprint: el.ParametersFingerprint}
 
 	ctxCopy.Value = make([]*ring.Poly, el.Degree()+1)
 	for i := range el.Value {
@@ -210,6 +213,7 @@ func (el *Ciphertext) Copy(ctxCopy *Ciphertext) {
 		for i := range ctxCopy.Value {
 			el.Value[i].Copy(ctxCopy.Value[i])
 		}
+		el.ParametersFingerprint = ctxCopy.ParametersFingerprint
 	}
 }
 
@@ -62,7 +62,7 @@ func newEncryptorBase(params Parameters) *encryptorBase {
 }
 
 type encryptorSamplers struct {
-	gaussianSampler *ring.GaussianSampler
+	gaussianSampler ring.ErrorSampler
 	ternarySampler  *ring.TernarySampler
 	uniformSampler  *ring.UniformSampler
 }
@@ -74,7 +74,7 @@ func newEncryptorSamplers(params Parameters) *encryptorSamplers {
 	}
 
 	return &encryptorSamplers{
-		gaussianSampler: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
+		gaussianSampler: NewErrorSampler(params, prng),
 		ternarySampler:  ring.NewTernarySamplerWithHammingWeight(prng, params.ringQ, params.h, false),
 		uniformSampler:  ring.NewUniformSampler(prng, params.RingQ()),
 	}
@@ -108,6 +108,7 @@ func newEncryptorBuffers(params Parameters) *encryptorBuffers {
 // then the encryption of zero is sampled in QP before being rescaled by P; otherwise, it is directly
 // sampled in Q.
 func (enc *pkEncryptor) Encrypt(pt *Plaintext, ct *Ciphertext) {
+	ct.ParametersFingerprint = enc.params.Fingerprint()
 	enc.uniformSampler.ReadLvl(utils.MinInt(pt.Level(), ct.Level()), ct.Value[1])
 
 	if enc.basisextender != nil {
@@ -125,6 +126,7 @@ func (enc *pkEncryptor) EncryptFromCRP(pt *Plaintext, crp *ring.Poly, ct *Cipher
 // Encrypt encrypts the input plaintext and write the result on ct.
 func (enc *skEncryptor) Encrypt(pt *Plaintext, ct *Ciphertext) {
 
+	ct.ParametersFingerprint = enc.params.Fingerprint()
 	enc.uniformSampler.ReadLvl(utils.MinInt(pt.Level(), ct.Level()), ct.Value[1])
 
 	enc.encrypt(pt, ct)
@@ -133,6 +135,7 @@ func (enc *skEncryptor) Encrypt(pt *Plaintext, ct *Ciphertext) {
 // EncryptFromCRP encrypts the input plaintext and writes the result on ct.
 // The encryption algorithm depends on the implementor.
 func (enc *skEncryptor) EncryptFromCRP(pt *Plaintext, crp *ring.Poly, ct *Ciphertext) {
+	ct.ParametersFingerprint = enc.params.Fingerprint()
 	ring.CopyValues(crp, ct.Value[1])
 
 	enc.encrypt(pt, ct)
@@ -0,0 +1,240 @@
+package rlwe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+)
+
+// EnvelopeMagic is the magic number prefixing the binary encoding of the objects.
+const EnvelopeMagic = "LTGO"
+
+// EnvelopeVersion is the current version of the binary format.
+const EnvelopeVersion = 1
+
+// EnvelopeHeaderLen is the length in bytes of the header of an Envelope.
+const EnvelopeHeaderLen = 26
+
+// UnspecifiedRingType is the ring type of the envelopes of objects that do not record their ring type.
+const UnspecifiedRingType = ring.Type(0xFF)
+
+// ObjectKind is a type tag for the objects encoded in an Envelope.
+type ObjectKind uint8
+
+// The kinds of objects that can be encoded in an Envelope. The values are part of the binary format
+// and must not be modified: new kinds must be appended at the end of the list.
+const (
+	KindParameters ObjectKind = iota + 1
+	KindBFVParameters
+	KindCKKSParameters
+	KindCiphertext
+	KindCKKSCiphertext
+	KindSecretKey
+	KindPublicKey
+	KindSwitchingKey
+	KindRelinearizationKey
+	KindRotationKeySet
+	KindRGSWCiphertext
+	KindCKGShare
+	KindRKGShare
+	KindRTGShare
+	KindRTGMultiShare
+	KindCKSShare
+	KindPCKSShare
+	KindShamirSecretShare
+	KindBFVMaskedTransformShare
+	KindBFVPublicKeyE2SShare
+	KindCKKSMaskedTransformShare
+	KindCKKSPublicKeyE2SShare
+	KindCKKSBootstrappingKeyGenShare
+	KindCKGCRP
+	KindRKGCRP
+	KindRTGCRP
+	KindCKSCRP
+	KindCRSDescriptor
+	KindOLEFirstMessage
+	KindOLESecondMessage
+	KindOLESetup
+	kindEnd
+)
+
+var objectKindNames = [...]string{
+	"Invalid",
+	"Parameters",
+	"BFVParameters",
+	"CKKSParameters",
+	"Ciphertext",
+	"CKKSCiphertext",
+	"SecretKey",
+	"PublicKey",
+	"SwitchingKey",
+	"RelinearizationKey",
+	"RotationKeySet",
+	"RGSWCiphertext",
+	"CKGShare",
+	"RKGShare",
+	"RTGShare",
+	"RTGMultiShare",
+	"CKSShare",
+	"PCKSShare",
+	"ShamirSecretShare",
+	"BFVMaskedTransformShare",
+	"BFVPublicKeyE2SShare",
+	"CKKSMaskedTransformShare",
+	"CKKSPublicKeyE2SShare",
+	"CKKSBootstrappingKeyGenShare",
+	"CKGCRP",
+	"RKGCRP",
+	"RTGCRP",
+	"CKSCRP",
+	"CRSDescriptor",
+	"OLEFirstMessage",
+	"OLESecondMessage",
+	"OLESetup",
+}
+
+// String returns the string representation of the ObjectKind.
+func (kind ObjectKind) String() string {
+	if kind == 0 || kind >= kindEnd {
+		return objectKindNames[0]
+	}
+	return objectKindNames[kind]
+}
+
+// Envelope is the self-describing header prefixing the binary encoding of the objects. It is encoded on
+// EnvelopeHeaderLen bytes as follows:
+//
+//	4 bytes : EnvelopeMagic
+//	1 byte  : Version
+//	1 byte  : Kind
+//	8 bytes : ParametersHash
+//	1 byte  : RingType
+//	1 byte  : LogN
+//	1 byte  : Level
+//	1 byte  : Degree
+//	8 bytes : length of the payload
+//
+// The payload, i.e., the encoding of the object without the envelope, follows the header.
+// Encodings that do not start with EnvelopeMagic are legacy encodings, which are decoded with Version 0.
+type Envelope struct {
+	Version        uint8
+	Kind           ObjectKind
+	ParametersHash uint64    // Fingerprint of the parameters of the object, 0 if unspecified
+	RingType       ring.Type // ring type of the object, UnspecifiedRingType if unspecified
+	LogN           int       // log2 of the ring degree of the object
+	Level          int       // level of the object
+	Degree         int       // degree of the object if it is a ciphertext, 0 otherwise
+}
+
+// NewEnvelope returns a new Envelope of the given kind and degree, whose LogN and Level are the ones of
+// the polynomial pol. The LogN and Level are zero if pol is nil.
+func NewEnvelope(kind ObjectKind, pol *ring.Poly, degree int) (env Envelope) {
+	env = Envelope{Version: EnvelopeVersion, Kind: kind, RingType: UnspecifiedRingType, Degree: degree}
+	if pol != nil && len(pol.Coeffs) > 0 {
+		env.LogN = bits.Len64(uint64(pol.Degree())) - 1
+		env.Level = pol.Level()
+	}
+	return
+}
+
+// IsLegacy returns true if the Envelope was decoded from a legacy encoding, in which case its fields carry
+// no information.
+func (env Envelope) IsLegacy() bool {
+	return env.Version == 0
+}
+
+// Seal returns the encoding of the Envelope followed by the payload.
+func (env Envelope) Seal(payload []byte) (data []byte) {
+	data = make([]byte, EnvelopeHeaderLen+len(payload))
+	copy(data[EnvelopeHeaderLen:], payload)
+	env.WriteHeader(data)
+	return
+}
+
+// WriteHeader writes the header of the Envelope on the first EnvelopeHeaderLen bytes of data, the remaining
+// bytes being the payload. It avoids copying the payload when its size is known in advance.
+func (env Envelope) WriteHeader(data []byte) {
+	copy(data, EnvelopeMagic)
+	data[4] = env.Version
+	data[5] = uint8(env.Kind)
+	binary.BigEndian.PutUint64(data[6:], env.ParametersHash)
+	data[14] = uint8(env.RingType)
+	data[15] = uint8(env.LogN)
+	data[16] = uint8(env.Level)
+	data[17] = uint8(env.Degree)
+	binary.BigEndian.PutUint64(data[18:], uint64(len(data)-EnvelopeHeaderLen))
+}
+
+// DecodeEnvelope decodes the Envelope of the encoded object data and returns it along with the payload.
+// If data is a legacy encoding, the returned Envelope has Version 0 and the payload is data.
+// It returns an error if the header is malformed or if its version is not supported.
+func DecodeEnvelope(data []byte) (env Envelope, payload []byte, err error) {
+
+	if len(data) < len(EnvelopeMagic) || string(data[:len(EnvelopeMagic)]) != EnvelopeMagic {
+		return Envelope{}, data, nil
+	}
+
+	if len(data) < EnvelopeHeaderLen {
+		return Envelope{}, nil, fmt.Errorf("invalid envelope: too small bytearray")
+	}
+
+	env.Version = data[4]
+	env.Kind = ObjectKind(data[5])
+	env.ParametersHash = binary.BigEndian.Uint64(data[6:])
+	env.RingType = ring.Type(data[14])
+	env.LogN = int(data[15])
+	env.Level = int(data[16])
+	env.Degree = int(data[17])
+
+	if env.Version == 0 || env.Version > EnvelopeVersion {
+		return Envelope{}, nil, fmt.Errorf("invalid envelope: unsupported version %d (current version is %d)", env.Version, EnvelopeVersion)
+	}
+
+	if env.Kind == 0 || env.Kind >= kindEnd {
+		return Envelope{}, nil, fmt.Errorf("invalid envelope: unknown object kind %d", env.Kind)
+	}
+
+	if env.RingType != ring.Standard && env.RingType != ring.ConjugateInvariant && env.RingType != UnspecifiedRingType {
+		return Envelope{}, nil, fmt.Errorf("invalid envelope: unknown ring type %d", env.RingType)
+	}
+
+	if payloadLen := binary.BigEndian.Uint64(data[18:]); uint64(len(data)-EnvelopeHeaderLen) != payloadLen {
+		return Envelope{}, nil, fmt.Errorf("invalid envelope: payload length (%d) does not match the header (%d)", len(data)-EnvelopeHeaderLen, payloadLen)
+	}
+
+	return env, data[EnvelopeHeaderLen:], nil
+}
+
+// OpenEnvelope decodes the Envelope of the encoded object data and returns it along with the payload.
+// It returns an error if the header is malformed or if the object is not of the given kind.
+// Legacy encodings are returned as is with an Envelope of Version 0.
+func OpenEnvelope(data []byte, kind ObjectKind) (env Envelope, payload []byte, err error) {
+
+	if env, payload, err = DecodeEnvelope(data); err != nil {
+		return
+	}
+
+	if !env.IsLegacy() && env.Kind != kind {
+		return Envelope{}, nil, fmt.Errorf("invalid envelope: cannot decode %s as %s", env.Kind, kind)
+	}
+
+	return
+}
+
+// CheckPoly returns an error if the LogN and Level of the Envelope do not match the ones of the decoded
+// polynomial pol, or if its Degree is not degree. Legacy envelopes are not checked.
+func (env Envelope) CheckPoly(pol *ring.Poly, degree int) error {
+
+	if env.IsLegacy() {
+		return nil
+	}
+
+	if want := NewEnvelope(env.Kind, pol, degree); env.LogN != want.LogN || env.Level != want.Level || env.Degree != want.Degree {
+		return fmt.Errorf("invalid envelope: header (LogN=%d, Level=%d, Degree=%d) does not match the decoded %s (LogN=%d, Level=%d, Degree=%d)",
+			env.LogN, env.Level, env.Degree, env.Kind, want.LogN, want.Level, want.Degree)
+	}
+
+	return nil
+}
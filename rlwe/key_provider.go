@@ -0,0 +1,175 @@
+package rlwe
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrMissingRotationKey is the error returned (wrapped) by a RotationKeyProvider when it
+// does not store the rotation key of the requested Galois element.
+var ErrMissingRotationKey = errors.New("rotation key not available")
+
+// RotationKeyProvider is an interface for the objects providing the rotation keys to the evaluators.
+// It allows the rotation keys to be stored outside of the memory (e.g., in a directory or in a file)
+// and to be loaded on demand. The RotationKeySet and the RotationKeyCache are RotationKeyProviders.
+//
+// Implementations must be safe for concurrent use, as they are shared between the shallow copies
+// of the evaluators.
+type RotationKeyProvider interface {
+
+	// GaloisElements returns the Galois elements of the rotation keys that can be provided.
+	GaloisElements() []uint64
+
+	// RotationKey returns the rotation key of the Galois element galEl. The returned error
+	// wraps ErrMissingRotationKey if the provider does not store the requested key.
+	RotationKey(galEl uint64) (*SwitchingKey, error)
+}
+
+// MissingRotationKeyError returns an error wrapping ErrMissingRotationKey for the Galois element galEl.
+func MissingRotationKeyError(galEl uint64) error {
+	return fmt.Errorf("%w: galEl=%d", ErrMissingRotationKey, galEl)
+}
+
+// RotationKeys returns the RotationKeyProvider of the evaluation key, that is Rtks if it is not nil
+// and RtksProvider otherwise. It returns nil if neither is set.
+func (evk EvaluationKey) RotationKeys() RotationKeyProvider {
+	switch {
+	case evk.Rtks != nil:
+		return evk.Rtks
+	case evk.RtksProvider != nil:
+		return evk.RtksProvider
+	default:
+		return nil
+	}
+}
+
+// GaloisElements returns the sorted Galois elements of the rotation keys stored in the set.
+func (rtks *RotationKeySet) GaloisElements() (galEls []uint64) {
+	galEls = make([]uint64, 0, len(rtks.Keys))
+	for galEl := range rtks.Keys {
+		galEls = append(galEls, galEl)
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+	return
+}
+
+// RotationKey returns the rotation key of the Galois element galEl, or an error wrapping
+// ErrMissingRotationKey if the key is not in the set.
+func (rtks *RotationKeySet) RotationKey(galEl uint64) (*SwitchingKey, error) {
+	if rtk, inSet := rtks.GetRotationKey(galEl); inSet {
+		return rtk, nil
+	}
+	return nil, MissingRotationKeyError(galEl)
+}
+
+// RotationKeyCache is a RotationKeyProvider that stores in memory, with a least-recently-used eviction
+// policy, at most a given number of the rotation keys loaded from another RotationKeyProvider.
+// The keys are loaded without holding the lock of the cache, and concurrent requests of a key
+// that is being loaded wait for the ongoing load instead of loading the key again. The loaded keys
+// are checked against the parameters of the cache before being added to it.
+type RotationKeyCache struct {
+	mutex    sync.Mutex
+	params   Parameters
+	provider RotationKeyProvider
+	capacity int
+	lru      *list.List
+	keys     map[uint64]*list.Element
+	loading  map[uint64]*rotationKeyLoad
+}
+
+type rotationKeyCacheEntry struct {
+	galEl uint64
+	rtk   *SwitchingKey
+}
+
+// rotationKeyLoad is an ongoing load of a rotation key from the provider of a RotationKeyCache.
+// done is closed once rtk and err are set.
+type rotationKeyLoad struct {
+	done chan struct{}
+	rtk  *SwitchingKey
+	err  error
+}
+
+// NewRotationKeyCache creates a new RotationKeyCache that keeps in memory at most capacity rotation keys
+// of the parameters params loaded from the provider.
+func NewRotationKeyCache(params Parameters, provider RotationKeyProvider, capacity int) *RotationKeyCache {
+
+	if capacity < 1 {
+		panic("cannot NewRotationKeyCache: capacity must be at least 1")
+	}
+
+	return &RotationKeyCache{
+		params:   params,
+		provider: provider,
+		capacity: capacity,
+		lru:      list.New(),
+		keys:     make(map[uint64]*list.Element, capacity),
+		loading:  make(map[uint64]*rotationKeyLoad),
+	}
+}
+
+// GaloisElements returns the Galois elements of the rotation keys of the underlying provider.
+func (cache *RotationKeyCache) GaloisElements() []uint64 {
+	return cache.provider.GaloisElements()
+}
+
+// RotationKey returns the rotation key of the Galois element galEl, loading it from the underlying provider
+// if it is not in the cache, in which case the least recently used key is evicted if the cache is full.
+// It returns an error if the loaded key does not match the parameters of the cache.
+func (cache *RotationKeyCache) RotationKey(galEl uint64) (*SwitchingKey, error) {
+
+	cache.mutex.Lock()
+
+	if e, inCache := cache.keys[galEl]; inCache {
+		cache.lru.MoveToFront(e)
+		cache.mutex.Unlock()
+		return e.Value.(*rotationKeyCacheEntry).rtk, nil
+	}
+
+	if load, isLoading := cache.loading[galEl]; isLoading {
+		cache.mutex.Unlock()
+		<-load.done
+		return load.rtk, load.err
+	}
+
+	load := &rotationKeyLoad{done: make(chan struct{})}
+	cache.loading[galEl] = load
+	cache.mutex.Unlock()
+
+	defer close(load.done)
+
+	if load.rtk, load.err = cache.provider.RotationKey(galEl); load.err == nil {
+		if load.err = cache.params.CheckSwitchingKey(load.rtk); load.err != nil {
+			load.rtk, load.err = nil, fmt.Errorf("rotation key of Galois element %d: %w", galEl, load.err)
+		}
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	delete(cache.loading, galEl)
+
+	if load.err != nil {
+		return nil, load.err
+	}
+
+	if cache.lru.Len() == cache.capacity {
+		last := cache.lru.Back()
+		delete(cache.keys, last.Value.(*rotationKeyCacheEntry).galEl)
+		cache.lru.Remove(last)
+	}
+
+	cache.keys[galEl] = cache.lru.PushFront(&rotationKeyCacheEntry{galEl: galEl, rtk: load.rtk})
+
+	return load.rtk, nil
+}
+
+// Len returns the number of rotation keys currently in the cache.
+func (cache *RotationKeyCache) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.lru.Len()
+}
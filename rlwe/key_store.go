@@ -0,0 +1,274 @@
+package rlwe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rotationKeyFileExtension is the extension of the files storing the rotation keys in a RotationKeyDirectory.
+const rotationKeyFileExtension = ".rtk"
+
+// RotationKeyDirectory is a RotationKeyProvider that loads the rotation keys from the files of a directory.
+// Each file stores a single rotation key, serialized with SwitchingKey.MarshalBinary, and is named after
+// its Galois element (see WriteRotationKeyDirectory). The keys are read from the disk at each call of
+// RotationKey, hence a RotationKeyDirectory is usually wrapped in a RotationKeyCache.
+type RotationKeyDirectory struct {
+	path   string
+	galEls []uint64
+}
+
+// WriteRotationKeyDirectory writes each rotation key of the set in a separate file of the directory at path,
+// which is created if it does not exist, in the format read by the RotationKeyDirectory.
+func WriteRotationKeyDirectory(path string, rtks *RotationKeySet) (err error) {
+
+	if err = os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+
+	for galEl, rtk := range rtks.Keys {
+
+		var data []byte
+		if data, err = rtk.MarshalBinary(); err != nil {
+			return err
+		}
+
+		if err = ioutil.WriteFile(rotationKeyFileName(path, galEl), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewRotationKeyDirectory creates a new RotationKeyDirectory reading the rotation keys stored in the directory at path.
+// The Galois elements of the available keys are listed from the names of the files at creation.
+func NewRotationKeyDirectory(path string) (*RotationKeyDirectory, error) {
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := &RotationKeyDirectory{path: path}
+
+	for _, file := range files {
+
+		name := file.Name()
+
+		if file.IsDir() || !strings.HasSuffix(name, rotationKeyFileExtension) {
+			continue
+		}
+
+		galEl, err := strconv.ParseUint(strings.TrimSuffix(name, rotationKeyFileExtension), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot NewRotationKeyDirectory: invalid file name %s: %w", name, err)
+		}
+
+		dir.galEls = append(dir.galEls, galEl)
+	}
+
+	sort.Slice(dir.galEls, func(i, j int) bool { return dir.galEls[i] < dir.galEls[j] })
+
+	return dir, nil
+}
+
+// GaloisElements returns the sorted Galois elements of the rotation keys stored in the directory.
+func (dir *RotationKeyDirectory) GaloisElements() []uint64 {
+	return dir.galEls
+}
+
+// RotationKey reads from the directory and returns the rotation key of the Galois element galEl.
+func (dir *RotationKeyDirectory) RotationKey(galEl uint64) (*SwitchingKey, error) {
+
+	data, err := ioutil.ReadFile(rotationKeyFileName(dir.path, galEl))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, MissingRotationKeyError(galEl)
+		}
+		return nil, err
+	}
+
+	rtk := new(SwitchingKey)
+	if err = rtk.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	return rtk, nil
+}
+
+func rotationKeyFileName(path string, galEl uint64) string {
+	return filepath.Join(path, strconv.FormatUint(galEl, 10)+rotationKeyFileExtension)
+}
+
+// RotationKeyReader is a RotationKeyProvider that reads the rotation keys from an io.ReaderAt, for example
+// an *os.File or the content of a memory-mapped file wrapped in a *bytes.Reader, storing rotation keys
+// in the indexed format written by WriteRotationKeys. Only the index is read at creation, and each key is
+// read at each call of RotationKey, hence a RotationKeyReader is usually wrapped in a RotationKeyCache.
+type RotationKeyReader struct {
+	reader io.ReaderAt
+	galEls []uint64
+	index  map[uint64][2]uint64 // offset and size of each key
+}
+
+// rotationKeyIndexEntrySize is the size in bytes of an entry of the index written by WriteRotationKeys:
+// the Galois element, the offset and the size of the key, each as a uint64.
+const rotationKeyIndexEntrySize = 24
+
+// WriteRotationKeys writes the rotation keys of the set on w in the format read by the RotationKeyReader.
+// The format is a header storing the number of keys (uint64) followed by the index of the keys, storing
+// for each key its Galois element, offset and size (3 x uint64), followed by the keys, serialized
+// with SwitchingKey.MarshalBinary. All the integers are big-endian.
+func WriteRotationKeys(w io.Writer, rtks *RotationKeySet) (err error) {
+
+	galEls := rtks.GaloisElements()
+
+	header := make([]byte, 8+rotationKeyIndexEntrySize*len(galEls))
+	binary.BigEndian.PutUint64(header, uint64(len(galEls)))
+
+	offset := uint64(len(header))
+	for i, galEl := range galEls {
+		size := uint64(EnvelopeHeaderLen + rtks.Keys[galEl].GetDataLen(true))
+		entry := header[8+i*rotationKeyIndexEntrySize:]
+		binary.BigEndian.PutUint64(entry, galEl)
+		binary.BigEndian.PutUint64(entry[8:], offset)
+		binary.BigEndian.PutUint64(entry[16:], size)
+		offset += size
+	}
+
+	if _, err = w.Write(header); err != nil {
+		return err
+	}
+
+	for _, galEl := range galEls {
+
+		var data []byte
+		if data, err = rtks.Keys[galEl].MarshalBinary(); err != nil {
+			return err
+		}
+
+		if _, err = w.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewRotationKeyReader creates a new RotationKeyReader reading the rotation keys from r.
+// The size of r must be known: r must have a Size method (as *bytes.Reader and *io.SectionReader),
+// a Stat method (as *os.File) or be an io.Seeker. It returns an error if the index of the keys cannot
+// be read or if it refers to data beyond the end of r.
+func NewRotationKeyReader(r io.ReaderAt) (*RotationKeyReader, error) {
+
+	size, err := readerAtSize(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot NewRotationKeyReader: cannot determine the size of the input: %w", err)
+	}
+
+	buff := make([]byte, 8)
+	if err := readFullAt(r, buff, 0); err != nil {
+		return nil, fmt.Errorf("cannot NewRotationKeyReader: cannot read header: %w", err)
+	}
+
+	nbKeys := binary.BigEndian.Uint64(buff)
+
+	// Bounds the number of keys by the size of the input (at least 8 since the header was read) before allocating the index.
+	if nbKeys > (size-8)/rotationKeyIndexEntrySize {
+		return nil, fmt.Errorf("cannot NewRotationKeyReader: the index of %d keys exceeds the size of the input (%d bytes)", nbKeys, size)
+	}
+
+	buff = make([]byte, rotationKeyIndexEntrySize*nbKeys)
+	if err := readFullAt(r, buff, 8); err != nil {
+		return nil, fmt.Errorf("cannot NewRotationKeyReader: cannot read index: %w", err)
+	}
+
+	reader := &RotationKeyReader{
+		reader: r,
+		galEls: make([]uint64, nbKeys),
+		index:  make(map[uint64][2]uint64, nbKeys),
+	}
+
+	for i := range reader.galEls {
+
+		entry := buff[i*rotationKeyIndexEntrySize:]
+		galEl := binary.BigEndian.Uint64(entry)
+		offset, length := binary.BigEndian.Uint64(entry[8:]), binary.BigEndian.Uint64(entry[16:])
+
+		if offset > size || length > size-offset {
+			return nil, fmt.Errorf("cannot NewRotationKeyReader: the key of galEl=%d exceeds the size of the input (%d bytes)", galEl, size)
+		}
+
+		reader.galEls[i] = galEl
+		reader.index[galEl] = [2]uint64{offset, length}
+	}
+
+	return reader, nil
+}
+
+// GaloisElements returns the sorted Galois elements of the rotation keys stored in the reader.
+func (reader *RotationKeyReader) GaloisElements() []uint64 {
+	return reader.galEls
+}
+
+// RotationKey reads and returns the rotation key of the Galois element galEl.
+func (reader *RotationKeyReader) RotationKey(galEl uint64) (*SwitchingKey, error) {
+
+	entry, inIndex := reader.index[galEl]
+	if !inIndex {
+		return nil, MissingRotationKeyError(galEl)
+	}
+
+	data := make([]byte, entry[1])
+	if err := readFullAt(reader.reader, data, int64(entry[0])); err != nil {
+		return nil, err
+	}
+
+	rtk := new(SwitchingKey)
+	if err := rtk.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	return rtk, nil
+}
+
+// readerAtSize returns the size of r, from its Size or Stat method if it has one, or by seeking its end.
+func readerAtSize(r io.ReaderAt) (uint64, error) {
+
+	var size int64
+
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		size = r.Size()
+	case interface{ Stat() (os.FileInfo, error) }:
+		fi, err := r.Stat()
+		if err != nil {
+			return 0, err
+		}
+		size = fi.Size()
+	case io.Seeker:
+		var err error
+		if size, err = r.Seek(0, io.SeekEnd); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("%T has no Size or Stat method and is not an io.Seeker", r)
+	}
+
+	return uint64(size), nil
+}
+
+// readFullAt reads len(buff) bytes from r at offset off, ignoring the io.EOF
+// that an io.ReaderAt may return when the read ends at the end of the input.
+func readFullAt(r io.ReaderAt, buff []byte, off int64) error {
+	if n, err := r.ReadAt(buff, off); err != nil && !(err == io.EOF && n == len(buff)) {
+		return err
+	}
+	return nil
+}
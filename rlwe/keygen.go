@@ -1,7 +1,6 @@
 package rlwe
 
 import (
-	"math"
 	"math/big"
 
 	"github.com/tuneinsight/lattigo/v3/ring"
@@ -34,7 +33,7 @@ type keyGenerator struct {
 	poolQ            *ring.Poly
 	poolQP           PolyQP
 	ternarySampler   *ring.TernarySampler
-	gaussianSamplerQ *ring.GaussianSampler
+	gaussianSamplerQ ring.ErrorSampler
 	uniformSamplerQ  *ring.UniformSampler
 	uniformSamplerP  *ring.UniformSampler
 }
@@ -53,6 +52,8 @@ func NewKeyGenerator(params Parameters) KeyGenerator {
 	if params.PCount() > 0 {
 		poolQP = params.RingQP().NewPoly()
 		uniformSamplerP = ring.NewUniformSampler(prng, params.RingP())
+	} else if params.Pow2Base() > 0 {
+		poolQP = params.RingQP().NewPoly()
 	}
 
 	return &keyGenerator{
@@ -60,7 +61,7 @@ func NewKeyGenerator(params Parameters) KeyGenerator {
 		poolQ:            params.RingQ().NewPoly(),
 		poolQP:           poolQP,
 		ternarySampler:   ring.NewTernarySamplerWithHammingWeight(prng, params.ringQ, params.h, false),
-		gaussianSamplerQ: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
+		gaussianSamplerQ: NewErrorSampler(params, prng),
 		uniformSamplerQ:  ring.NewUniformSampler(prng, params.RingQ()),
 		uniformSamplerP:  uniformSamplerP,
 	}
@@ -98,7 +99,7 @@ func (keygen *keyGenerator) GenSecretKeyWithHammingWeight(hw int) (sk *SecretKey
 
 // genSecretKeyFromSampler generates a new SecretKey sampled from the provided Sampler.
 func (keygen *keyGenerator) genSecretKeyFromSampler(sampler ring.Sampler) (sk *SecretKey) {
-	sk = new(SecretKey)
+	sk = &SecretKey{ParametersFingerprint: keygen.params.Fingerprint()}
 	if keygen.params.PCount() > 0 {
 		ringQP := keygen.params.RingQP()
 		sk.Value = ringQP.NewPoly()
@@ -109,7 +110,6 @@ func (keygen *keyGenerator) genSecretKeyFromSampler(sampler ring.Sampler) (sk *S
 		ringQP.MFormLvl(levelQ, levelP, sk.Value, sk.Value)
 	} else {
 		ringQ := keygen.params.RingQ()
-		sk = new(SecretKey)
 		sk.Value.Q = ringQ.NewPoly()
 		sampler.Read(sk.Value.Q)
 		ringQ.NTT(sk.Value.Q, sk.Value.Q)
@@ -122,8 +122,6 @@ func (keygen *keyGenerator) genSecretKeyFromSampler(sampler ring.Sampler) (sk *S
 // GenPublicKey generates a new public key from the provided SecretKey.
 func (keygen *keyGenerator) GenPublicKey(sk *SecretKey) (pk *PublicKey) {
 
-	pk = new(PublicKey)
-
 	if keygen.params.PCount() > 0 {
 
 		ringQP := keygen.params.RingQP()
@@ -166,8 +164,8 @@ func (keygen *keyGenerator) GenKeyPair() (sk *SecretKey, pk *PublicKey) {
 // GenRelinKey generates a new EvaluationKey that will be used to relinearize Ciphertexts during multiplication.
 func (keygen *keyGenerator) GenRelinearizationKey(sk *SecretKey, maxDegree int) (evk *RelinearizationKey) {
 
-	if keygen.params.PCount() == 0 {
-		panic("modulus P is empty")
+	if keygen.params.PCount() == 0 && keygen.params.Pow2Base() == 0 {
+		panic("modulus P is empty and Pow2Base is zero")
 	}
 
 	levelQ := keygen.params.QCount() - 1
@@ -245,7 +243,9 @@ func (keygen *keyGenerator) genrotKey(sk PolyQP, galEl uint64, swk *SwitchingKey
 
 	index := ringQ.PermuteNTTIndex(galEl)
 	ringQ.PermuteNTTWithIndexLvl(keygen.params.QCount()-1, skIn.Q, index, skOut.Q)
-	ringQ.PermuteNTTWithIndexLvl(keygen.params.PCount()-1, skIn.P, index, skOut.P)
+	if keygen.params.PCount() > 0 {
+		ringQ.PermuteNTTWithIndexLvl(keygen.params.PCount()-1, skIn.P, index, skOut.P)
+	}
 
 	keygen.genSwitchingKey(skIn.Q, skOut, swk)
 }
@@ -255,7 +255,9 @@ func (keygen *keyGenerator) GenSwitchingKeysForRingSwap(skStd, skConjugateInvari
 
 	skCIMappedToStandard := &SecretKey{Value: keygen.poolQP}
 	keygen.params.RingQ().UnfoldConjugateInvariantToStandard(skConjugateInvariant.Value.Q.Level(), skConjugateInvariant.Value.Q, skCIMappedToStandard.Value.Q)
-	keygen.params.RingQ().UnfoldConjugateInvariantToStandard(skConjugateInvariant.Value.P.Level(), skConjugateInvariant.Value.P, skCIMappedToStandard.Value.P)
+	if skConjugateInvariant.Value.P != nil {
+		keygen.params.RingQ().UnfoldConjugateInvariantToStandard(skConjugateInvariant.Value.P.Level(), skConjugateInvariant.Value.P, skCIMappedToStandard.Value.P)
+	}
 
 	swkConjugateInvariantToStd = keygen.GenSwitchingKey(skCIMappedToStandard, skStd)
 	swkStdToConjugateInvariant = keygen.GenSwitchingKey(skStd, skCIMappedToStandard)
@@ -273,15 +275,22 @@ func (keygen *keyGenerator) GenSwitchingKeysForRingSwap(skStd, skConjugateInvari
 // must be mapped Y^{N/n} using SwitchCiphertextRingDegreeNTT(ctLargeDim, ringQLargeDim, ctSmallDim).
 func (keygen *keyGenerator) GenSwitchingKey(skInput, skOutput *SecretKey) (swk *SwitchingKey) {
 
-	if keygen.params.PCount() == 0 {
-		panic("Cannot GenSwitchingKey: modulus P is empty")
+	if keygen.params.PCount() == 0 && keygen.params.Pow2Base() == 0 {
+		panic("Cannot GenSwitchingKey: modulus P is empty and Pow2Base is zero")
+	}
+
+	levelP := -1
+	if skOutput.Value.P != nil {
+		levelP = skOutput.Value.P.Level()
 	}
 
-	swk = NewSwitchingKey(keygen.params, skOutput.Value.Q.Level(), skOutput.Value.P.Level())
+	swk = NewSwitchingKey(keygen.params, skOutput.Value.Q.Level(), levelP)
 
 	if len(skInput.Value.Q.Coeffs[0]) > len(skOutput.Value.Q.Coeffs[0]) { // N -> n
 		ring.MapSmallDimensionToLargerDimensionNTT(skOutput.Value.Q, keygen.poolQP.Q)
-		ring.MapSmallDimensionToLargerDimensionNTT(skOutput.Value.P, keygen.poolQP.P)
+		if levelP > -1 {
+			ring.MapSmallDimensionToLargerDimensionNTT(skOutput.Value.P, keygen.poolQP.P)
+		}
 		keygen.genSwitchingKey(skInput.Value.Q, keygen.poolQP, swk)
 	} else { // N -> N or n -> N
 		ring.MapSmallDimensionToLargerDimensionNTT(skInput.Value.Q, keygen.poolQ)
@@ -328,68 +337,105 @@ func (keygen *keyGenerator) GenSwitchingKey(skInput, skOutput *SecretKey) (swk *
 
 func (keygen *keyGenerator) genSwitchingKey(skIn *ring.Poly, skOut PolyQP, swk *SwitchingKey) {
 
-	ringQ := keygen.params.RingQ()
 	ringQP := keygen.params.RingQP()
 
-	levelQ := len(swk.Value[0][0].Q.Coeffs) - 1
-	levelP := len(swk.Value[0][0].P.Coeffs) - 1
+	levelQ := swk.LevelQ()
+	levelP := swk.LevelP()
 
-	var pBigInt *big.Int
-	if levelP == keygen.params.PCount()-1 {
-		pBigInt = keygen.params.RingP().ModulusBigint
-	} else {
-		P := keygen.params.RingP().Modulus
-		pBigInt = new(big.Int).SetUint64(P[0])
-		for i := 1; i < levelP+1; i++ {
-			pBigInt.Mul(pBigInt, ring.NewUint(P[i]))
+	for i := range swk.Value {
+
+		swki := swk.Value[i]
+
+		// e
+		keygen.gaussianSamplerQ.ReadLvl(levelQ, swki[0].Q)
+		ringQP.ExtendBasisSmallNormAndCenter(swki[0].Q, levelP, nil, swki[0].P)
+		ringQP.NTTLazyLvl(levelQ, levelP, swki[0], swki[0])
+		ringQP.MFormLvl(levelQ, levelP, swki[0], swki[0])
+
+		// a (since a is uniform, we consider we already sample it in the NTT and Montgomery domain)
+		keygen.uniformSamplerQ.ReadLvl(levelQ, swki[1].Q)
+		if levelP > -1 {
+			keygen.uniformSamplerP.ReadLvl(levelP, swki[1].P)
 		}
+
+		// - a * skOut + e mod QP
+		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, swki[1], skOut, swki[0])
 	}
 
-	// Computes P * skIn
-	ringQ.MulScalarBigintLvl(levelQ, skIn, pBigInt, keygen.poolQ)
+	// (skIn * P * 2^{w*j}) * (q_star * q_tild) - a * skOut + e mod QP
+	addPolyTimesGadgetVector(keygen.params, skIn, swk, 0, keygen.poolQ)
+}
+
+// addPolyTimesGadgetVector adds pt * P * g to the cIndex-th component of the gadget ciphertext ct, where g is the
+// gadget vector of the RNS and power-of-two decomposition (see KeySwitcher.DecomposeNTT), i.e.
+//
+// g[i*DecompPw2()+j] = 2^{w*j} * (q_star * q_tild) mod Q
+//
+// q_prod = prod(q[i*alpha+k])
+// q_star = Q/qprod
+// q_tild = q_star^-1 mod q_prod
+//
+// Therefore : (pt * P * 2^{w*j}) * (q_star * q_tild) = pt*P*2^{w*j} mod q[i*alpha+k], else 0.
+// The plaintext pt must be in the NTT and Montgomery domain and can be buff, which is used as a buffer.
+func addPolyTimesGadgetVector(params Parameters, pt *ring.Poly, ct *SwitchingKey, cIndex int, buff *ring.Poly) {
+
+	ringQ := params.RingQ()
+
+	levelQ := ct.LevelQ()
+	levelP := ct.LevelP()
+
+	// Computes P * pt
+	if levelP > -1 {
+		ringQ.MulScalarBigintLvl(levelQ, pt, params.pBigintLvl(levelP), buff)
+	} else {
+		ring.CopyValuesLvl(levelQ, pt, buff)
+	}
 
-	alpha := levelP + 1
-	beta := int(math.Ceil(float64(levelQ+1) / float64(levelP+1)))
+	alpha := utils.MaxInt(levelP+1, 1)
+	decompRNS := params.DecompRNS(levelQ, levelP)
+	decompPw2 := params.DecompPw2()
 
 	var index int
-	for i := 0; i < beta; i++ {
+	for i := 0; i < decompRNS; i++ {
+		for j := 0; j < decompPw2; j++ {
 
-		// e
-		keygen.gaussianSamplerQ.ReadLvl(levelQ, swk.Value[i][0].Q)
-		ringQP.ExtendBasisSmallNormAndCenter(swk.Value[i][0].Q, levelP, nil, swk.Value[i][0].P)
-		ringQP.NTTLazyLvl(levelQ, levelP, swk.Value[i][0], swk.Value[i][0])
-		ringQP.MFormLvl(levelQ, levelP, swk.Value[i][0], swk.Value[i][0])
+			cij := ct.Value[i*decompPw2+j][cIndex].Q
 
-		// a (since a is uniform, we consider we already sample it in the NTT and Montgomery domain)
-		keygen.uniformSamplerQ.ReadLvl(levelQ, swk.Value[i][1].Q)
-		keygen.uniformSamplerP.ReadLvl(levelP, swk.Value[i][1].P)
-
-		// e + (skIn * P) * (q_star * q_tild) mod QP
-		//
-		// q_prod = prod(q[i*alpha+j])
-		// q_star = Q/qprod
-		// q_tild = q_star^-1 mod q_prod
-		//
-		// Therefore : (skIn * P) * (q_star * q_tild) = sk*P mod q[i*alpha+j], else 0
-		for j := 0; j < alpha; j++ {
-
-			index = i*alpha + j
-
-			// It handles the case where nb pj does not divide nb qi
-			if index >= levelQ+1 {
-				break
-			}
+			for k := 0; k < alpha; k++ {
+
+				index = i*alpha + k
 
-			qi := ringQ.Modulus[index]
-			p0tmp := keygen.poolQ.Coeffs[index]
-			p1tmp := swk.Value[i][0].Q.Coeffs[index]
+				// It handles the case where nb pj does not divide nb qi
+				if index >= levelQ+1 {
+					break
+				}
+
+				qi := ringQ.Modulus[index]
+				p0tmp := buff.Coeffs[index]
+				p1tmp := cij.Coeffs[index]
+
+				for w := 0; w < ringQ.N; w++ {
+					p1tmp[w] = ring.CRed(p1tmp[w]+p0tmp[w], qi)
+				}
 
-			for w := 0; w < ringQ.N; w++ {
-				p1tmp[w] = ring.CRed(p1tmp[w]+p0tmp[w], qi)
+				// pt * P * 2^{w*(j+1)} mod q[i*alpha+k] for the next power-of-two digit
+				if j < decompPw2-1 {
+					ring.MulScalarMontgomeryVec(p0tmp, p0tmp, ring.MForm(1<<params.Pow2Base(), qi, ringQ.BredParams[index]), qi, ringQ.MredParams[index])
+				}
 			}
 		}
+	}
+}
 
-		// (skIn * P) * (q_star * q_tild) - a * skOut + e mod QP
-		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, swk.Value[i][1], skOut, swk.Value[i][0])
+// pBigintLvl returns the product of the moduli of P up to levelP.
+func (p Parameters) pBigintLvl(levelP int) *big.Int {
+	if levelP == p.PCount()-1 {
+		return p.RingP().ModulusBigint
+	}
+	P := p.RingP().Modulus
+	pBigInt := new(big.Int).SetUint64(P[0])
+	for i := 1; i < levelP+1; i++ {
+		pBigInt.Mul(pBigInt, ring.NewUint(P[i]))
 	}
+	return pBigInt
 }
@@ -1,22 +1,31 @@
 package rlwe
 
 import (
-	"math"
+	"fmt"
 )
 
 // SecretKey is a type for generic RLWE secret keys.
 type SecretKey struct {
 	Value PolyQP
+
+	// ParametersFingerprint is the Fingerprint of the parameters of the key, or 0 if it is unknown.
+	ParametersFingerprint uint64
 }
 
 // PublicKey is a type for generic RLWE public keys.
 type PublicKey struct {
 	Value [2]PolyQP
+
+	// ParametersFingerprint is the Fingerprint of the parameters of the key, or 0 if it is unknown.
+	ParametersFingerprint uint64
 }
 
 // SwitchingKey is a type for generic RLWE public switching keys.
 type SwitchingKey struct {
 	Value [][2]PolyQP
+
+	// ParametersFingerprint is the Fingerprint of the parameters of the key, or 0 if it is unknown.
+	ParametersFingerprint uint64
 }
 
 // RelinearizationKey is a type for generic RLWE public relinearization keys. It stores a slice with a
@@ -33,20 +42,22 @@ type RotationKeySet struct {
 }
 
 // EvaluationKey is a type for storing generic RLWE public evaluation keys. An evaluation key is a union
-// of a relinearization key and a set of rotation keys.
+// of a relinearization key and a set of rotation keys. The rotation keys can alternatively be given by a
+// RotationKeyProvider (RtksProvider), which is used by the evaluators if Rtks is nil.
 type EvaluationKey struct {
-	Rlk  *RelinearizationKey
-	Rtks *RotationKeySet
+	Rlk          *RelinearizationKey
+	Rtks         *RotationKeySet
+	RtksProvider RotationKeyProvider
 }
 
 // NewSecretKey generates a new SecretKey with zero values.
 func NewSecretKey(params Parameters) *SecretKey {
-	return &SecretKey{Value: params.RingQP().NewPoly()}
+	return &SecretKey{Value: params.RingQP().NewPoly(), ParametersFingerprint: params.Fingerprint()}
 }
 
 // NewPublicKey returns a new PublicKey with zero values.
 func NewPublicKey(params Parameters) (pk *PublicKey) {
-	return &PublicKey{Value: [2]PolyQP{params.RingQP().NewPoly(), params.RingQP().NewPoly()}}
+	return &PublicKey{Value: [2]PolyQP{params.RingQP().NewPoly(), params.RingQP().NewPoly()}, ParametersFingerprint: params.Fingerprint()}
 }
 
 // Equals checks two PublicKey struct for equality.
@@ -79,9 +90,9 @@ func (rtks *RotationKeySet) GetRotationKey(galoisEl uint64) (*SwitchingKey, bool
 
 // NewSwitchingKey returns a new public switching key with pre-allocated zero-value
 func NewSwitchingKey(params Parameters, levelQ, levelP int) *SwitchingKey {
-	decompSize := int(math.Ceil(float64(levelQ+1) / float64(levelP+1)))
-	swk := new(SwitchingKey)
-	swk.Value = make([][2]PolyQP, int(decompSize))
+	decompSize := params.DecompRNS(levelQ, levelP) * params.DecompPw2()
+	swk := &SwitchingKey{ParametersFingerprint: params.Fingerprint()}
+	swk.Value = make([][2]PolyQP, decompSize)
 	for i := 0; i < decompSize; i++ {
 		swk.Value[i][0] = params.RingQP().NewPolyLvl(levelQ, levelP)
 		swk.Value[i][1] = params.RingQP().NewPolyLvl(levelQ, levelP)
@@ -90,6 +101,19 @@ func NewSwitchingKey(params Parameters, levelQ, levelP int) *SwitchingKey {
 	return swk
 }
 
+// LevelQ returns the level of the modulus Q of the target SwitchingKey.
+func (swk *SwitchingKey) LevelQ() int {
+	return swk.Value[0][0].Q.Level()
+}
+
+// LevelP returns the level of the modulus P of the target SwitchingKey, or -1 if P is empty.
+func (swk *SwitchingKey) LevelP() int {
+	if swk.Value[0][0].P == nil {
+		return -1
+	}
+	return swk.Value[0][0].P.Level()
+}
+
 // NewRelinKey creates a new EvaluationKey with zero values.
 func NewRelinKey(params Parameters, maxRelinDegree int) (evakey *RelinearizationKey) {
 
@@ -109,7 +133,7 @@ func (sk *SecretKey) CopyNew() *SecretKey {
 	if sk == nil {
 		return nil
 	}
-	return &SecretKey{sk.Value.CopyNew()}
+	return &SecretKey{Value: sk.Value.CopyNew(), ParametersFingerprint: sk.ParametersFingerprint}
 }
 
 // CopyNew creates a deep copy of the receiver PublicKey and returns it.
@@ -117,7 +141,7 @@ func (pk *PublicKey) CopyNew() *PublicKey {
 	if pk == nil {
 		return nil
 	}
-	return &PublicKey{[2]PolyQP{pk.Value[0].CopyNew(), pk.Value[1].CopyNew()}}
+	return &PublicKey{Value: [2]PolyQP{pk.Value[0].CopyNew(), pk.Value[1].CopyNew()}, ParametersFingerprint: pk.ParametersFingerprint}
 }
 
 // Equals checks two RelinearizationKeys for equality.
@@ -175,7 +199,7 @@ func (swk *SwitchingKey) CopyNew() *SwitchingKey {
 	if swk == nil || len(swk.Value) == 0 {
 		return nil
 	}
-	swkb := &SwitchingKey{Value: make([][2]PolyQP, len(swk.Value))}
+	swkb := &SwitchingKey{Value: make([][2]PolyQP, len(swk.Value)), ParametersFingerprint: swk.ParametersFingerprint}
 	for i, el := range swk.Value {
 		swkb.Value[i] = [2]PolyQP{el[0].CopyNew(), el[1].CopyNew()}
 	}
@@ -213,3 +237,113 @@ func (rtks *RotationKeySet) Includes(other *RotationKeySet) bool {
 	}
 	return true
 }
+
+// CheckSecretKey returns an error if the secret key sk cannot be used with the target parameters, i.e.,
+// if it was generated with other parameters (when its ParametersFingerprint is known), if its ring degree
+// differs from the one of the parameters, or if it is given at a level lower than the maximum level.
+func (p Parameters) CheckSecretKey(sk *SecretKey) error {
+
+	if sk == nil || sk.Value.Q == nil || len(sk.Value.Q.Coeffs) == 0 {
+		return fmt.Errorf("invalid secret key: missing polynomial")
+	}
+
+	if err := p.checkFingerprint("secret key", sk.ParametersFingerprint); err != nil {
+		return err
+	}
+
+	if sk.Value.Q.Degree() != p.N() {
+		return fmt.Errorf("invalid secret key: ring degree %d does not match the parameters (N=%d)", sk.Value.Q.Degree(), p.N())
+	}
+
+	if sk.Value.Q.Level() < p.MaxLevel() {
+		return fmt.Errorf("invalid secret key: level %d is lower than the maximum level of the parameters (%d)", sk.Value.Q.Level(), p.MaxLevel())
+	}
+
+	return nil
+}
+
+// CheckSwitchingKey returns an error if the switching key swk cannot be used with the target parameters, i.e.,
+// if it was generated with other parameters (when its ParametersFingerprint is known), or if its ring degree,
+// its levels or its decomposition size do not match the ones of the parameters.
+func (p Parameters) CheckSwitchingKey(swk *SwitchingKey) error {
+
+	if swk == nil || len(swk.Value) == 0 || swk.Value[0][0].Q == nil || len(swk.Value[0][0].Q.Coeffs) == 0 {
+		return fmt.Errorf("invalid switching key: missing polynomials")
+	}
+
+	if err := p.checkFingerprint("switching key", swk.ParametersFingerprint); err != nil {
+		return err
+	}
+
+	levelQ, levelP := swk.LevelQ(), swk.LevelP()
+
+	if levelQ < p.MaxLevel() {
+		return fmt.Errorf("invalid switching key: level %d is lower than the maximum level of the parameters (%d)", levelQ, p.MaxLevel())
+	}
+
+	if levelP != p.PCount()-1 {
+		return fmt.Errorf("invalid switching key: level %d modulo P does not match the parameters (%d)", levelP, p.PCount()-1)
+	}
+
+	if decompSize := p.DecompRNS(levelQ, levelP) * p.DecompPw2(); len(swk.Value) != decompSize {
+		return fmt.Errorf("invalid switching key: decomposition size %d does not match the parameters (%d)", len(swk.Value), decompSize)
+	}
+
+	for i := range swk.Value {
+		for j := range swk.Value[i] {
+			if pol := swk.Value[i][j]; !p.isPolyQPAtLevel(pol, levelQ, levelP) {
+				return fmt.Errorf("invalid switching key: malformed element (%d, %d)", i, j)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckEvaluationKey returns an error if the relinearization key or one of the rotation keys of the
+// evaluation key evk cannot be used with the target parameters (see CheckSwitchingKey). The rotation
+// keys of its RtksProvider, which are loaded on demand, are not checked.
+func (p Parameters) CheckEvaluationKey(evk EvaluationKey) error {
+
+	if evk.Rlk != nil {
+		for i, swk := range evk.Rlk.Keys {
+			if err := p.CheckSwitchingKey(swk); err != nil {
+				return fmt.Errorf("relinearization key of degree %d: %w", i+2, err)
+			}
+		}
+	}
+
+	if evk.Rtks != nil {
+		for _, galEl := range evk.Rtks.GaloisElements() {
+			if err := p.CheckSwitchingKey(evk.Rtks.Keys[galEl]); err != nil {
+				return fmt.Errorf("rotation key of Galois element %d: %w", galEl, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkFingerprint returns an error if the fingerprint of an object is known and differs from the one
+// of the target parameters.
+func (p Parameters) checkFingerprint(object string, fingerprint uint64) error {
+	if want := p.Fingerprint(); fingerprint != 0 && fingerprint != want {
+		return fmt.Errorf("invalid %s: generated with other parameters (fingerprint %#016x != %#016x)", object, fingerprint, want)
+	}
+	return nil
+}
+
+// isPolyQPAtLevel returns true if pol is a polynomial of the ring degree of the target parameters at the
+// given levels.
+func (p Parameters) isPolyQPAtLevel(pol PolyQP, levelQ, levelP int) bool {
+
+	if pol.Q == nil || pol.Q.Level() != levelQ || len(pol.Q.Coeffs) == 0 || pol.Q.Degree() != p.N() {
+		return false
+	}
+
+	if levelP == -1 {
+		return pol.P == nil || len(pol.P.Coeffs) == 0
+	}
+
+	return pol.P != nil && pol.P.Level() == levelP && pol.P.Degree() == p.N()
+}
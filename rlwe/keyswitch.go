@@ -1,9 +1,8 @@
 package rlwe
 
 import (
-	"math"
-
 	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/utils"
 )
 
 // KeySwitcher is a struct for RLWE key-switching.
@@ -20,31 +19,45 @@ type keySwitcherBuffer struct {
 	Pool         [6]PolyQP
 	PoolInvNTT   *ring.Poly
 	PoolDecompQP []PolyQP // Memory pool for the basis extension in hoisting
+
+	// Memory pool for the parallel decomposition in the key-switching (only allocated if params.Parallelism() > 1)
+	poolDecompParallel []PolyQP
 }
 
 func newKeySwitcherBuffer(params Parameters) *keySwitcherBuffer {
 
 	buff := new(keySwitcherBuffer)
-	beta := params.Beta()
+	decompSize := params.Beta() * params.DecompPw2()
 	ringQP := params.RingQP()
 
 	buff.Pool = [6]PolyQP{ringQP.NewPoly(), ringQP.NewPoly(), ringQP.NewPoly(), ringQP.NewPoly(), ringQP.NewPoly(), ringQP.NewPoly()}
 
 	buff.PoolInvNTT = params.RingQ().NewPoly()
 
-	buff.PoolDecompQP = make([]PolyQP, beta)
-	for i := 0; i < beta; i++ {
+	buff.PoolDecompQP = make([]PolyQP, decompSize)
+	for i := 0; i < decompSize; i++ {
 		buff.PoolDecompQP[i] = ringQP.NewPoly()
 	}
 
+	if params.Parallelism() > 1 {
+		buff.poolDecompParallel = make([]PolyQP, decompSize)
+		for i := 0; i < decompSize; i++ {
+			buff.poolDecompParallel[i] = ringQP.NewPoly()
+		}
+	}
+
 	return buff
 }
 
 // NewKeySwitcher creates a new KeySwitcher.
+// If P is empty, the key-switching is carried without special modulus and the KeySwitcher
+// has no BasisExtender (see Parameters.WithPow2Base).
 func NewKeySwitcher(params Parameters) *KeySwitcher {
 	ks := new(KeySwitcher)
 	ks.Parameters = &params
-	ks.BasisExtender = ring.NewBasisExtender(params.RingQ(), params.RingP())
+	if params.RingP() != nil {
+		ks.BasisExtender = ring.NewBasisExtender(params.RingQ(), params.RingP())
+	}
 	ks.Decomposer = ring.NewDecomposer(params.RingQ(), params.RingP())
 	ks.keySwitcherBuffer = newKeySwitcherBuffer(params)
 	return ks
@@ -52,11 +65,15 @@ func NewKeySwitcher(params Parameters) *KeySwitcher {
 
 // ShallowCopy creates a copy of a KeySwitcher, only reallocating the memory pool.
 func (ks *KeySwitcher) ShallowCopy() *KeySwitcher {
+	var basisExtender *ring.BasisExtender
+	if ks.BasisExtender != nil {
+		basisExtender = ks.BasisExtender.ShallowCopy()
+	}
 	return &KeySwitcher{
 		Parameters:        ks.Parameters,
 		Decomposer:        ks.Decomposer,
 		keySwitcherBuffer: newKeySwitcherBuffer(*ks.Parameters),
-		BasisExtender:     ks.BasisExtender.ShallowCopy(),
+		BasisExtender:     basisExtender,
 	}
 }
 
@@ -65,7 +82,16 @@ func (ks *KeySwitcher) ShallowCopy() *KeySwitcher {
 func (ks *KeySwitcher) SwitchKeysInPlace(levelQ int, cx *ring.Poly, evakey *SwitchingKey, p0, p1 *ring.Poly) {
 	ks.SwitchKeysInPlaceNoModDown(levelQ, cx, evakey, p0, ks.Pool[1].P, p1, ks.Pool[2].P)
 
-	levelP := len(evakey.Value[0][0].P.Coeffs) - 1
+	levelP := evakey.LevelP()
+
+	// Without P, the result is not scaled by P and is already mod Q (in the NTT domain).
+	if levelP == -1 {
+		if !cx.IsNTT {
+			ks.ringQ.InvNTTLvl(levelQ, p0, p0)
+			ks.ringQ.InvNTTLvl(levelQ, p1, p1)
+		}
+		return
+	}
 
 	if cx.IsNTT {
 		ks.BasisExtender.ModDownQPtoQNTT(levelQ, levelP, p0, ks.Pool[1].P, p0)
@@ -85,7 +111,9 @@ func (ks *KeySwitcher) SwitchKeysInPlace(levelQ int, cx *ring.Poly, evakey *Swit
 // DecomposeNTT applies the full RNS basis decomposition for all q_alpha_i on c2.
 // Expects the IsNTT flag of c2 to correctly reflect the domain of c2.
 // PoolDecompQ and PoolDecompQ are vectors of polynomials (mod Q and mod P) that store the
-// special RNS decomposition of c2 (in the NTT domain)
+// special RNS decomposition of c2 (in the NTT domain). If the parameters have a non-zero
+// Pow2Base, each RNS digit is further decomposed in base 2^Pow2Base and the vector stores
+// DecompRNS(levelQ, levelP) * DecompPw2() polynomials.
 func (ks *KeySwitcher) DecomposeNTT(levelQ, levelP, alpha int, c2 *ring.Poly, PoolDecomp []PolyQP) {
 
 	ringQ := ks.RingQ()
@@ -102,36 +130,59 @@ func (ks *KeySwitcher) DecomposeNTT(levelQ, levelP, alpha int, c2 *ring.Poly, Po
 		ringQ.NTTLvl(levelQ, polyInvNTT, polyNTT)
 	}
 
-	beta := int(math.Ceil(float64(levelQ+1) / float64(levelP+1)))
+	decompSize := ks.DecompRNS(levelQ, levelP) * ks.DecompPw2()
 
-	for i := 0; i < beta; i++ {
+	// The digits are independent and are decomposed in parallel if the parameters allow it.
+	utils.ParallelFor(ks.Parallelism(), decompSize, func(i int) {
 		ks.DecomposeSingleNTT(levelQ, levelP, alpha, i, polyNTT, polyInvNTT, PoolDecomp[i].Q, PoolDecomp[i].P)
-	}
+	})
 }
 
 // DecomposeSingleNTT takes the input polynomial c2 (c2NTT and c2InvNTT, respectively in the NTT and out of the NTT domain)
 // modulo q_alpha_beta, and returns the result on c2QiQ are c2QiP the receiver polynomials
-// respectively mod Q and mod P (in the NTT domain)
+// respectively mod Q and mod P (in the NTT domain).
+// If the parameters have a non-zero Pow2Base, beta indexes the elements of the full decomposition and the method
+// returns the (beta % DecompPw2())-th base 2^Pow2Base digit of c2 modulo q_(beta / DecompPw2()).
 func (ks *KeySwitcher) DecomposeSingleNTT(levelQ, levelP, alpha, beta int, c2NTT, c2InvNTT, c2QiQ, c2QiP *ring.Poly) {
 
 	ringQ := ks.RingQ()
 	ringP := ks.RingP()
 
-	ks.Decomposer.DecomposeAndSplit(levelQ, levelP, alpha, beta, c2InvNTT, c2QiQ, c2QiP)
+	// Without P, each element of the RNS decomposition basis is a single modulus of Q
+	if alpha < 1 {
+		alpha = 1
+	}
 
-	p0idxst := beta * (levelP + 1)
-	p0idxed := p0idxst + 1
+	if pow2Base := ks.Pow2Base(); pow2Base != 0 {
 
-	// c2_qi = cx mod qi mod qi
-	for x := 0; x < levelQ+1; x++ {
-		if p0idxst <= x && x < p0idxed {
-			copy(c2QiQ.Coeffs[x], c2NTT.Coeffs[x])
-		} else {
-			ringQ.NTTSingleLazy(x, c2QiQ.Coeffs[x], c2QiQ.Coeffs[x])
+		decompPw2 := ks.DecompPw2()
+
+		ks.Decomposer.DecomposePow2AndSplit(levelQ, levelP, beta/decompPw2, pow2Base, beta%decompPw2, c2InvNTT, c2QiQ, c2QiP)
+
+		// c2_qi = (cx mod qi) digit j mod qi
+		ringQ.NTTLazyLvl(levelQ, c2QiQ, c2QiQ)
+
+	} else {
+
+		ks.Decomposer.DecomposeAndSplit(levelQ, levelP, alpha, beta, c2InvNTT, c2QiQ, c2QiP)
+
+		p0idxst := beta * alpha
+		p0idxed := p0idxst + 1
+
+		// c2_qi = cx mod qi mod qi
+		for x := 0; x < levelQ+1; x++ {
+			if p0idxst <= x && x < p0idxed {
+				copy(c2QiQ.Coeffs[x], c2NTT.Coeffs[x])
+			} else {
+				ringQ.NTTSingleLazy(x, c2QiQ.Coeffs[x], c2QiQ.Coeffs[x])
+			}
 		}
 	}
+
 	// c2QiP = c2 mod qi mod pj
-	ringP.NTTLazyLvl(levelP, c2QiP, c2QiP)
+	if levelP > -1 {
+		ringP.NTTLazyLvl(levelP, c2QiP, c2QiP)
+	}
 }
 
 // SwitchKeysInPlaceNoModDown applies the key-switch to the polynomial cx :
@@ -166,17 +217,30 @@ func (ks *KeySwitcher) SwitchKeysInPlaceNoModDown(levelQ int, cx *ring.Poly, eva
 
 	reduce = 0
 
-	alpha := len(evakey.Value[0][0].P.Coeffs)
-	levelP := alpha - 1
-	beta := int(math.Ceil(float64(levelQ+1) / float64(levelP+1)))
+	levelP := evakey.LevelP()
+	alpha := levelP + 1
+	decompSize := ks.DecompRNS(levelQ, levelP) * ks.DecompPw2()
 
 	QiOverF := ks.Parameters.QiOverflowMargin(levelQ) >> 1
-	PiOverF := ks.Parameters.PiOverflowMargin(levelP) >> 1
+	PiOverF := ks.piOverflowMarginHalf(levelP)
+
+	// If the parameters allow it, all the digits are first decomposed in parallel and
+	// then accumulated in the same order as in the sequential case.
+	parallel := ks.poolDecompParallel != nil && decompSize > 1
+	if parallel {
+		utils.ParallelFor(ks.Parallelism(), decompSize, func(i int) {
+			ks.DecomposeSingleNTT(levelQ, levelP, alpha, i, cxNTT, cxInvNTT, ks.poolDecompParallel[i].Q, ks.poolDecompParallel[i].P)
+		})
+	}
 
 	// Key switching with CRT decomposition for the Qi
-	for i := 0; i < beta; i++ {
+	for i := 0; i < decompSize; i++ {
 
-		ks.DecomposeSingleNTT(levelQ, levelP, alpha, i, cxNTT, cxInvNTT, c2QP.Q, c2QP.P)
+		if parallel {
+			c2QP = ks.poolDecompParallel[i]
+		} else {
+			ks.DecomposeSingleNTT(levelQ, levelP, alpha, i, cxNTT, cxInvNTT, c2QP.Q, c2QP.P)
+		}
 
 		if i == 0 {
 			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakey.Value[i][0], c2QP, c0QP)
@@ -191,7 +255,7 @@ func (ks *KeySwitcher) SwitchKeysInPlaceNoModDown(levelQ int, cx *ring.Poly, eva
 			ringQ.ReduceLvl(levelQ, c1QP.Q, c1QP.Q)
 		}
 
-		if reduce%PiOverF == PiOverF-1 {
+		if levelP > -1 && reduce%PiOverF == PiOverF-1 {
 			ringP.ReduceLvl(levelP, c0QP.P, c0QP.P)
 			ringP.ReduceLvl(levelP, c1QP.P, c1QP.P)
 		}
@@ -204,7 +268,7 @@ func (ks *KeySwitcher) SwitchKeysInPlaceNoModDown(levelQ int, cx *ring.Poly, eva
 		ringQ.ReduceLvl(levelQ, c1QP.Q, c1QP.Q)
 	}
 
-	if reduce%PiOverF != 0 {
+	if levelP > -1 && reduce%PiOverF != 0 {
 		ringP.ReduceLvl(levelP, c0QP.P, c0QP.P)
 		ringP.ReduceLvl(levelP, c1QP.P, c1QP.P)
 	}
@@ -219,7 +283,12 @@ func (ks *KeySwitcher) KeyswitchHoisted(levelQ int, PoolDecompQP []PolyQP, evake
 
 	ks.KeyswitchHoistedNoModDown(levelQ, PoolDecompQP, evakey, c0Q, c1Q, c0P, c1P)
 
-	levelP := len(evakey.Value[0][0].P.Coeffs) - 1
+	levelP := evakey.LevelP()
+
+	// Without P, the result is not scaled by P and is already mod Q.
+	if levelP == -1 {
+		return
+	}
 
 	// Computes c0Q = c0Q/c0P and c1Q = c1Q/c1P
 	ks.BasisExtender.ModDownQPtoQNTT(levelQ, levelP, c0Q, c0P, c0Q)
@@ -239,16 +308,15 @@ func (ks *KeySwitcher) KeyswitchHoistedNoModDown(levelQ int, PoolDecompQP []Poly
 	c0QP := PolyQP{c0Q, c0P}
 	c1QP := PolyQP{c1Q, c1P}
 
-	alpha := len(evakey.Value[0][0].P.Coeffs)
-	levelP := alpha - 1
-	beta := int(math.Ceil(float64(levelQ+1) / float64(alpha)))
+	levelP := evakey.LevelP()
+	decompSize := ks.DecompRNS(levelQ, levelP) * ks.DecompPw2()
 
 	QiOverF := ks.Parameters.QiOverflowMargin(levelQ) >> 1
-	PiOverF := ks.Parameters.PiOverflowMargin(levelP) >> 1
+	PiOverF := ks.piOverflowMarginHalf(levelP)
 
 	// Key switching with CRT decomposition for the Qi
 	var reduce int
-	for i := 0; i < beta; i++ {
+	for i := 0; i < decompSize; i++ {
 
 		if i == 0 {
 			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakey.Value[i][0], PoolDecompQP[i], c0QP)
@@ -263,7 +331,7 @@ func (ks *KeySwitcher) KeyswitchHoistedNoModDown(levelQ int, PoolDecompQP []Poly
 			ringQ.ReduceLvl(levelQ, c1QP.Q, c1QP.Q)
 		}
 
-		if reduce%PiOverF == PiOverF-1 {
+		if levelP > -1 && reduce%PiOverF == PiOverF-1 {
 			ringP.ReduceLvl(levelP, c0QP.P, c0QP.P)
 			ringP.ReduceLvl(levelP, c1QP.P, c1QP.P)
 		}
@@ -276,8 +344,16 @@ func (ks *KeySwitcher) KeyswitchHoistedNoModDown(levelQ int, PoolDecompQP []Poly
 		ringQ.ReduceLvl(levelQ, c1QP.Q, c1QP.Q)
 	}
 
-	if reduce%PiOverF != 0 {
+	if levelP > -1 && reduce%PiOverF != 0 {
 		ringP.ReduceLvl(levelP, c0QP.P, c0QP.P)
 		ringP.ReduceLvl(levelP, c1QP.P, c1QP.P)
 	}
 }
+
+// piOverflowMarginHalf returns PiOverflowMargin(levelP)/2, or 0 if P is empty.
+func (ks *KeySwitcher) piOverflowMarginHalf(levelP int) int {
+	if levelP == -1 {
+		return 0
+	}
+	return ks.Parameters.PiOverflowMargin(levelP) >> 1
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/tuneinsight/lattigo/v3/ring"
 )
 
-// GetDataLen returns the length in bytes of the target Ciphertext.
+// GetDataLen returns the length in bytes of the target Ciphertext, without its Envelope.
 func (ciphertext *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
 	// MetaData is :
 	// 1 byte : Degree
@@ -23,38 +23,80 @@ func (ciphertext *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
 }
 
 // MarshalBinary encodes a Ciphertext on a byte slice. The total size
-// in byte is 4 + 8* N * numberModuliQ * (degree + 1).
+// in byte is EnvelopeHeaderLen + 1 + (4 + 8 * N * numberModuliQ) * (degree + 1).
 func (ciphertext *Ciphertext) MarshalBinary() (data []byte, err error) {
 
-	data = make([]byte, ciphertext.GetDataLen(true))
+	data = make([]byte, EnvelopeHeaderLen+ciphertext.GetDataLen(true))
+
+	if _, err = ciphertext.encode(data[EnvelopeHeaderLen:]); err != nil {
+		return nil, err
+	}
+
+	env := NewEnvelope(KindCiphertext, ciphertext.Value[0], ciphertext.Degree())
+	env.ParametersHash = ciphertext.ParametersFingerprint
+	env.WriteHeader(data)
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a previously marshaled Ciphertext on the target Ciphertext.
+// It also accepts the legacy encoding without Envelope.
+func (ciphertext *Ciphertext) UnmarshalBinary(data []byte) (err error) {
+
+	var env Envelope
+	if env, data, err = OpenEnvelope(data, KindCiphertext); err != nil {
+		return err
+	}
+
+	var pointer int
+	if pointer, err = ciphertext.decode(data); err != nil {
+		return err
+	}
+
+	if pointer != len(data) {
+		return errors.New("remaining unparsed data")
+	}
+
+	ciphertext.ParametersFingerprint = env.ParametersHash
+
+	return env.CheckPoly(ciphertext.Value[0], ciphertext.Degree())
+}
+
+// encode writes the Ciphertext on data without its Envelope and returns the number of bytes written.
+func (ciphertext *Ciphertext) encode(data []byte) (pointer int, err error) {
 
 	data[0] = uint8(ciphertext.Degree() + 1)
 
-	var pointer, inc int
+	var inc int
 
 	pointer = 1
 
 	for _, el := range ciphertext.Value {
 
 		if inc, err = el.WriteTo(data[pointer:]); err != nil {
-			return nil, err
+			return
 		}
 
 		pointer += inc
 	}
 
-	return data, nil
+	return
 }
 
-// UnmarshalBinary decodes a previously marshaled Ciphertext on the target Ciphertext.
-func (ciphertext *Ciphertext) UnmarshalBinary(data []byte) (err error) {
+// decode decodes a Ciphertext encoded without Envelope and returns the number of bytes read.
+func (ciphertext *Ciphertext) decode(data []byte) (pointer int, err error) {
+
 	if len(data) < 10 { // cf. ciphertext.GetDataLen()
-		return errors.New("too small bytearray")
+		return 0, errors.New("too small bytearray")
+	}
+
+	if data[0] == 0 {
+		return 0, errors.New("invalid ciphertext encoding: the ciphertext must have at least one polynomial")
 	}
 
 	ciphertext.Value = make([]*ring.Poly, uint8(data[0]))
 
-	var pointer, inc int
+	var inc int
 	pointer = 1
 
 	for i := range ciphertext.Value {
@@ -62,48 +104,64 @@ func (ciphertext *Ciphertext) UnmarshalBinary(data []byte) (err error) {
 		ciphertext.Value[i] = new(ring.Poly)
 
 		if inc, err = ciphertext.Value[i].DecodePolyNew(data[pointer:]); err != nil {
-			return err
+			return
 		}
 
 		pointer += inc
 	}
 
-	if pointer != len(data) {
-		return errors.New("remaining unparsed data")
-	}
-
-	return nil
+	return
 }
 
-// GetDataLen returns the length in bytes of the target SecretKey.
+// GetDataLen returns the length in bytes of the target SecretKey, without its Envelope.
 func (sk *SecretKey) GetDataLen(WithMetadata bool) (dataLen int) {
 	return sk.Value.GetDataLen(WithMetadata)
 }
 
 // MarshalBinary encodes a secret key in a byte slice.
 func (sk *SecretKey) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, sk.GetDataLen(true))
-	if _, err = sk.Value.WriteTo(data); err != nil {
+	data = make([]byte, EnvelopeHeaderLen+sk.GetDataLen(true))
+	if _, err = sk.Value.WriteTo(data[EnvelopeHeaderLen:]); err != nil {
 		return nil, err
 	}
+	env := NewEnvelope(KindSecretKey, sk.Value.Q, 0)
+	env.ParametersHash = sk.ParametersFingerprint
+	env.WriteHeader(data)
 	return
 }
 
 // UnmarshalBinary decodes a previously marshaled SecretKey in the target SecretKey.
+// It also accepts the legacy encoding without Envelope.
 func (sk *SecretKey) UnmarshalBinary(data []byte) (err error) {
-	_, err = sk.Value.DecodePolyNew(data)
-	return
+
+	var env Envelope
+	if env, data, err = OpenEnvelope(data, KindSecretKey); err != nil {
+		return err
+	}
+
+	if _, err = sk.Value.DecodePolyNew(data); err != nil {
+		return err
+	}
+
+	if sk.Value.Q == nil {
+		return errors.New("invalid SecretKey encoding: missing polynomial")
+	}
+
+	sk.ParametersFingerprint = env.ParametersHash
+
+	return env.CheckPoly(sk.Value.Q, 0)
 }
 
-// GetDataLen returns the length in bytes of the target PublicKey.
+// GetDataLen returns the length in bytes of the target PublicKey, without its Envelope.
 func (pk *PublicKey) GetDataLen(WithMetadata bool) (dataLen int) {
 	return pk.Value[0].GetDataLen(WithMetadata) + pk.Value[1].GetDataLen(WithMetadata)
 }
 
 // MarshalBinary encodes a PublicKey in a byte slice.
 func (pk *PublicKey) MarshalBinary() (data []byte, err error) {
-	data = make([]byte, pk.GetDataLen(true))
-	var inc, pt int
+	data = make([]byte, EnvelopeHeaderLen+pk.GetDataLen(true))
+	var inc int
+	pt := EnvelopeHeaderLen
 	if inc, err = pk.Value[0].WriteTo(data[pt:]); err != nil {
 		return nil, err
 	}
@@ -113,12 +171,22 @@ func (pk *PublicKey) MarshalBinary() (data []byte, err error) {
 		return nil, err
 	}
 
+	env := NewEnvelope(KindPublicKey, pk.Value[0].Q, 0)
+	env.ParametersHash = pk.ParametersFingerprint
+	env.WriteHeader(data)
+
 	return
 }
 
 // UnmarshalBinary decodes a previously marshaled PublicKey in the target PublicKey.
+// It also accepts the legacy encoding without Envelope.
 func (pk *PublicKey) UnmarshalBinary(data []byte) (err error) {
 
+	var env Envelope
+	if env, data, err = OpenEnvelope(data, KindPublicKey); err != nil {
+		return err
+	}
+
 	var pt, inc int
 	if inc, err = pk.Value[0].DecodePolyNew(data[pt:]); err != nil {
 		return
@@ -129,10 +197,16 @@ func (pk *PublicKey) UnmarshalBinary(data []byte) (err error) {
 		return
 	}
 
-	return
+	if pk.Value[0].Q == nil || pk.Value[1].Q == nil {
+		return errors.New("invalid PublicKey encoding: missing polynomial")
+	}
+
+	pk.ParametersFingerprint = env.ParametersHash
+
+	return env.CheckPoly(pk.Value[0].Q, 0)
 }
 
-// GetDataLen returns the length in bytes of the target EvaluationKey.
+// GetDataLen returns the length in bytes of the target EvaluationKey, without its Envelope.
 func (rlk *RelinearizationKey) GetDataLen(WithMetadata bool) (dataLen int) {
 
 	if WithMetadata {
@@ -149,13 +223,15 @@ func (rlk *RelinearizationKey) GetDataLen(WithMetadata bool) (dataLen int) {
 // MarshalBinary encodes an EvaluationKey key in a byte slice.
 func (rlk *RelinearizationKey) MarshalBinary() (data []byte, err error) {
 
-	var pointer int
+	if len(rlk.Keys) > 0xFF {
+		return nil, errors.New("RelinearizationKey: uint8 overflow on the number of keys")
+	}
 
-	dataLen := rlk.GetDataLen(true)
+	data = make([]byte, EnvelopeHeaderLen+rlk.GetDataLen(true))
 
-	data = make([]byte, dataLen)
+	pointer := EnvelopeHeaderLen
 
-	data[0] = uint8(len(rlk.Keys))
+	data[pointer] = uint8(len(rlk.Keys))
 
 	pointer++
 
@@ -166,12 +242,28 @@ func (rlk *RelinearizationKey) MarshalBinary() (data []byte, err error) {
 		}
 	}
 
+	env := NewEnvelope(KindRelinearizationKey, rlk.envelopePoly(), 0)
+	if len(rlk.Keys) > 0 {
+		env.ParametersHash = rlk.Keys[0].ParametersFingerprint
+	}
+	env.WriteHeader(data)
+
 	return data, nil
 }
 
 // UnmarshalBinary decodes a previously marshaled EvaluationKey in the target EvaluationKey.
+// It also accepts the legacy encoding without Envelope.
 func (rlk *RelinearizationKey) UnmarshalBinary(data []byte) (err error) {
 
+	var env Envelope
+	if env, data, err = OpenEnvelope(data, KindRelinearizationKey); err != nil {
+		return err
+	}
+
+	if len(data) < 1 {
+		return errors.New("too small bytearray")
+	}
+
 	deg := int(data[0])
 
 	rlk.Keys = make([]*SwitchingKey, deg)
@@ -179,17 +271,29 @@ func (rlk *RelinearizationKey) UnmarshalBinary(data []byte) (err error) {
 	pointer := 1
 	var inc int
 	for i := 0; i < deg; i++ {
-		rlk.Keys[i] = new(SwitchingKey)
+		rlk.Keys[i] = &SwitchingKey{ParametersFingerprint: env.ParametersHash}
 		if inc, err = rlk.Keys[i].decode(data[pointer:]); err != nil {
 			return err
 		}
 		pointer += inc
 	}
 
-	return nil
+	if pointer != len(data) {
+		return errors.New("remaining unparsed data")
+	}
+
+	return env.CheckPoly(rlk.envelopePoly(), 0)
+}
+
+// envelopePoly returns the polynomial defining the LogN and Level of the Envelope of the target RelinearizationKey.
+func (rlk *RelinearizationKey) envelopePoly() *ring.Poly {
+	if len(rlk.Keys) == 0 {
+		return nil
+	}
+	return rlk.Keys[0].envelopePoly()
 }
 
-// GetDataLen returns the length in bytes of the target SwitchingKey.
+// GetDataLen returns the length in bytes of the target SwitchingKey, without its Envelope.
 func (swk *SwitchingKey) GetDataLen(WithMetadata bool) (dataLen int) {
 
 	if WithMetadata {
@@ -207,23 +311,48 @@ func (swk *SwitchingKey) GetDataLen(WithMetadata bool) (dataLen int) {
 // MarshalBinary encodes an SwitchingKey in a byte slice.
 func (swk *SwitchingKey) MarshalBinary() (data []byte, err error) {
 
-	data = make([]byte, swk.GetDataLen(true))
+	data = make([]byte, EnvelopeHeaderLen+swk.GetDataLen(true))
 
-	if _, err = swk.encode(0, data); err != nil {
+	if _, err = swk.encode(EnvelopeHeaderLen, data); err != nil {
 		return nil, err
 	}
 
+	env := NewEnvelope(KindSwitchingKey, swk.envelopePoly(), 0)
+	env.ParametersHash = swk.ParametersFingerprint
+	env.WriteHeader(data)
+
 	return data, nil
 }
 
 // UnmarshalBinary decode a previously marshaled SwitchingKey in the target SwitchingKey.
+// It also accepts the legacy encoding without Envelope.
 func (swk *SwitchingKey) UnmarshalBinary(data []byte) (err error) {
 
-	if _, err = swk.decode(data); err != nil {
+	var env Envelope
+	if env, data, err = OpenEnvelope(data, KindSwitchingKey); err != nil {
 		return err
 	}
 
-	return nil
+	var pointer int
+	if pointer, err = swk.decode(data); err != nil {
+		return err
+	}
+
+	if pointer != len(data) {
+		return errors.New("remaining unparsed data")
+	}
+
+	swk.ParametersFingerprint = env.ParametersHash
+
+	return env.CheckPoly(swk.envelopePoly(), 0)
+}
+
+// envelopePoly returns the polynomial defining the LogN and Level of the Envelope of the target SwitchingKey.
+func (swk *SwitchingKey) envelopePoly() *ring.Poly {
+	if len(swk.Value) == 0 {
+		return nil
+	}
+	return swk.Value[0][0].Q
 }
 
 func (swk *SwitchingKey) encode(pointer int, data []byte) (int, error) {
@@ -231,6 +360,10 @@ func (swk *SwitchingKey) encode(pointer int, data []byte) (int, error) {
 	var err error
 	var inc int
 
+	if len(swk.Value) > 0xFF {
+		return pointer, errors.New("SwitchingKey: uint8 overflow on the decomposition size")
+	}
+
 	data[pointer] = uint8(len(swk.Value))
 
 	pointer++
@@ -255,6 +388,10 @@ func (swk *SwitchingKey) encode(pointer int, data []byte) (int, error) {
 
 func (swk *SwitchingKey) decode(data []byte) (pointer int, err error) {
 
+	if len(data) < 1 {
+		return 0, errors.New("too small bytearray")
+	}
+
 	decomposition := int(data[0])
 
 	pointer = 1
@@ -265,13 +402,11 @@ func (swk *SwitchingKey) decode(data []byte) (pointer int, err error) {
 
 	for j := 0; j < decomposition; j++ {
 
-		swk.Value[j][0].Q = new(ring.Poly)
 		if inc, err = swk.Value[j][0].DecodePolyNew(data[pointer:]); err != nil {
 			return
 		}
 		pointer += inc
 
-		swk.Value[j][1].P = new(ring.Poly)
 		if inc, err = swk.Value[j][1].DecodePolyNew(data[pointer:]); err != nil {
 			return
 		}
@@ -281,7 +416,56 @@ func (swk *SwitchingKey) decode(data []byte) (pointer int, err error) {
 	return
 }
 
-// GetDataLen returns the length in bytes of the target RotationKeys.
+// GetDataLen returns the length in bytes of the target RGSWCiphertext, without its Envelope.
+func (ct *RGSWCiphertext) GetDataLen(WithMetadata bool) (dataLen int) {
+	return ct.Value[0].GetDataLen(WithMetadata) + ct.Value[1].GetDataLen(WithMetadata)
+}
+
+// MarshalBinary encodes an RGSWCiphertext in a byte slice.
+func (ct *RGSWCiphertext) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, EnvelopeHeaderLen+ct.GetDataLen(true))
+
+	pointer := EnvelopeHeaderLen
+	for _, swk := range ct.Value {
+		if pointer, err = swk.encode(pointer, data); err != nil {
+			return nil, err
+		}
+	}
+
+	env := NewEnvelope(KindRGSWCiphertext, ct.Value[0].envelopePoly(), 0)
+	env.ParametersHash = ct.Value[0].ParametersFingerprint
+	env.WriteHeader(data)
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a previously marshaled RGSWCiphertext in the target RGSWCiphertext.
+// It also accepts the legacy encoding without Envelope.
+func (ct *RGSWCiphertext) UnmarshalBinary(data []byte) (err error) {
+
+	var env Envelope
+	if env, data, err = OpenEnvelope(data, KindRGSWCiphertext); err != nil {
+		return err
+	}
+
+	var pointer, inc int
+	for i := range ct.Value {
+		ct.Value[i] = &SwitchingKey{ParametersFingerprint: env.ParametersHash}
+		if inc, err = ct.Value[i].decode(data[pointer:]); err != nil {
+			return err
+		}
+		pointer += inc
+	}
+
+	if pointer != len(data) {
+		return errors.New("remaining unparsed data")
+	}
+
+	return env.CheckPoly(ct.Value[0].envelopePoly(), 0)
+}
+
+// GetDataLen returns the length in bytes of the target RotationKeys, without its Envelope.
 func (rtks *RotationKeySet) GetDataLen(WithMetaData bool) (dataLen int) {
 	for _, k := range rtks.Keys {
 		if WithMetaData {
@@ -292,37 +476,56 @@ func (rtks *RotationKeySet) GetDataLen(WithMetaData bool) (dataLen int) {
 	return
 }
 
-// MarshalBinary encodes a RotationKeys struct in a byte slice.
+// MarshalBinary encodes a RotationKeys struct in a byte slice. The keys are
+// written in increasing order of Galois element.
 func (rtks *RotationKeySet) MarshalBinary() (data []byte, err error) {
 
-	data = make([]byte, rtks.GetDataLen(true))
+	data = make([]byte, EnvelopeHeaderLen+rtks.GetDataLen(true))
+
+	pointer := EnvelopeHeaderLen
 
-	pointer := int(0)
+	galEls := rtks.GaloisElements()
 
-	for galEL, key := range rtks.Keys {
+	for _, galEL := range galEls {
 
 		binary.BigEndian.PutUint32(data[pointer:pointer+4], uint32(galEL))
 		pointer += 4
 
-		if pointer, err = key.encode(pointer, data); err != nil {
+		if pointer, err = rtks.Keys[galEL].encode(pointer, data); err != nil {
 			return nil, err
 		}
 	}
 
+	env := NewEnvelope(KindRotationKeySet, rtks.envelopePoly(), 0)
+	if len(galEls) > 0 {
+		env.ParametersHash = rtks.Keys[galEls[0]].ParametersFingerprint
+	}
+	env.WriteHeader(data)
+
 	return data, nil
 }
 
 // UnmarshalBinary decodes a previously marshaled RotationKeys in the target RotationKeys.
+// It also accepts the legacy encoding without Envelope.
 func (rtks *RotationKeySet) UnmarshalBinary(data []byte) (err error) {
 
+	var env Envelope
+	if env, data, err = OpenEnvelope(data, KindRotationKeySet); err != nil {
+		return err
+	}
+
 	rtks.Keys = make(map[uint64]*SwitchingKey)
 
 	for len(data) > 0 {
 
+		if len(data) < 4 {
+			return errors.New("too small bytearray")
+		}
+
 		galEl := uint64(binary.BigEndian.Uint32(data))
 		data = data[4:]
 
-		swk := new(SwitchingKey)
+		swk := &SwitchingKey{ParametersFingerprint: env.ParametersHash}
 		var inc int
 		if inc, err = swk.decode(data); err != nil {
 			return err
@@ -332,5 +535,14 @@ func (rtks *RotationKeySet) UnmarshalBinary(data []byte) (err error) {
 
 	}
 
+	return env.CheckPoly(rtks.envelopePoly(), 0)
+}
+
+// envelopePoly returns the polynomial defining the LogN and Level of the Envelope of the target RotationKeySet,
+// which is the one of the key of smallest Galois element.
+func (rtks *RotationKeySet) envelopePoly() *ring.Poly {
+	if galEls := rtks.GaloisElements(); len(galEls) > 0 {
+		return rtks.Keys[galEls[0]].envelopePoly()
+	}
 	return nil
 }
@@ -0,0 +1,165 @@
+package rlwe
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"math/cmplx"
+
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// NoiseStats stores the log2 of the standard deviation, of the minimum
+// and of the maximum (in absolute value) of the coefficients of an error.
+type NoiseStats struct {
+	Std, Min, Max float64
+}
+
+// Noise stores the statistics of the error of a ciphertext, measured in
+// the coefficient domain and in the canonical embedding.
+type Noise struct {
+	Coefficients       NoiseStats
+	CanonicalEmbedding NoiseStats
+}
+
+func (n Noise) String() string {
+	return fmt.Sprintf(`
+┌─────────┬────────┬────────┬────────┐
+│    Log2 │  STD   │  MIN   │  MAX   │
+├─────────┼────────┼────────┼────────┤
+│ Coeffs  │ %6.2f │ %6.2f │ %6.2f │
+│ Slots   │ %6.2f │ %6.2f │ %6.2f │
+└─────────┴────────┴────────┴────────┘
+`,
+		n.Coefficients.Std, n.Coefficients.Min, n.Coefficients.Max,
+		n.CanonicalEmbedding.Std, n.CanonicalEmbedding.Min, n.CanonicalEmbedding.Max)
+}
+
+// MeasureNoise decrypts the ciphertext with the secret key, subtracts the expected plaintext
+// ptWant and returns the statistics of the resulting error in the coefficient domain and in the
+// canonical embedding. ptWant must be the plaintext as it is encrypted, i.e. already scaled
+// by the scheme (for example Q/t*m for BFV or Delta*m for CKKS), and can be in the NTT domain.
+// The error is measured at level min(ct.Level(), ptWant.Level()).
+// It panics if the secret key cannot be used with the parameters (see Parameters.CheckSecretKey).
+func MeasureNoise(params Parameters, ct *Ciphertext, sk *SecretKey, ptWant *Plaintext) (noise Noise) {
+
+	ringQ := params.RingQ()
+
+	level := utils.MinInt(ct.Level(), ptWant.Level())
+
+	pt := NewPlaintext(params, level)
+	dec, err := NewDecryptor(params, sk)
+	if err != nil {
+		panic(err)
+	}
+	dec.Decrypt(ct, pt)
+
+	want := ringQ.NewPolyLvl(level)
+	if ptWant.Value.IsNTT {
+		ringQ.InvNTTLvl(level, ptWant.Value, want)
+	} else {
+		ring.CopyValuesLvl(level, ptWant.Value, want)
+	}
+
+	ringQ.SubLvl(level, pt.Value, want, pt.Value)
+
+	coeffsBigint := make([]*big.Int, ringQ.N)
+	for i := range coeffsBigint {
+		coeffsBigint[i] = new(big.Int)
+	}
+	ringQ.PolyToBigintCenteredLvl(level, pt.Value, 1, coeffsBigint)
+
+	coeffs := make([]complex128, ringQ.N)
+	tmp := new(big.Float)
+	for i := range coeffs {
+		f, _ := tmp.SetInt(coeffsBigint[i]).Float64()
+		coeffs[i] = complex(f, 0)
+	}
+
+	noise.Coefficients = noiseStats(coeffs)
+
+	if params.RingType() == ring.ConjugateInvariant {
+		coeffs = unfoldConjugateInvariant(coeffs)
+	}
+
+	negacyclicDFT(coeffs)
+
+	noise.CanonicalEmbedding = noiseStats(coeffs)
+
+	return
+}
+
+// noiseStats returns the log2 of the standard deviation, of the minimum
+// and of the maximum of the absolute value of the entries of values.
+func noiseStats(values []complex128) NoiseStats {
+
+	var mean complex128
+	minErr, maxErr := math.Inf(1), 0.0
+	for _, c := range values {
+		mean += c
+		abs := cmplx.Abs(c)
+		minErr = math.Min(minErr, abs)
+		maxErr = math.Max(maxErr, abs)
+	}
+
+	n := float64(len(values))
+	mean /= complex(n, 0)
+
+	var variance float64
+	for _, c := range values {
+		abs := cmplx.Abs(c - mean)
+		variance += abs * abs
+	}
+
+	return NoiseStats{
+		Std: math.Log2(math.Sqrt(variance / n)),
+		Min: math.Log2(minErr),
+		Max: math.Log2(maxErr),
+	}
+}
+
+// unfoldConjugateInvariant maps the coefficients of p0 + sum pi(X^i + X^-i) in Z[X+X^-1]/(X^2N+1)
+// to their representation in Z[X]/(X^2N+1).
+func unfoldConjugateInvariant(coeffs []complex128) (unfolded []complex128) {
+	N := len(coeffs)
+	unfolded = make([]complex128, 2*N)
+	unfolded[0] = coeffs[0]
+	for i := 1; i < N; i++ {
+		unfolded[i] = coeffs[i]
+		unfolded[2*N-i] = -coeffs[i]
+	}
+	return
+}
+
+// negacyclicDFT evaluates in place the polynomial of Z[X]/(X^N+1) given by its coefficients
+// on the N primitive 2N-th roots of unity exp(i*pi*(2k+1)/N), for 0 <= k < N.
+func negacyclicDFT(values []complex128) {
+
+	N := len(values)
+	logN := bits.Len64(uint64(N)) - 1
+
+	// Twists by exp(i*pi*j/N) to map the negacyclic evaluation to a cyclic one.
+	for j := range values {
+		values[j] *= cmplx.Rect(1, math.Pi*float64(j)/float64(N))
+	}
+
+	for i := range values {
+		if j := int(utils.BitReverse64(uint64(i), uint64(logN))); i < j {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	for m := 2; m <= N; m <<= 1 {
+		wm := cmplx.Rect(1, 2*math.Pi/float64(m))
+		for k := 0; k < N; k += m {
+			w := complex(1, 0)
+			for j := 0; j < m>>1; j++ {
+				u, v := values[k+j], w*values[k+j+m>>1]
+				values[k+j], values[k+j+m>>1] = u+v, u-v
+				w *= wm
+			}
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package rlwe
 
 import (
+	"encoding"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/tuneinsight/lattigo/v3/ring"
 	"github.com/tuneinsight/lattigo/v3/utils"
+	"golang.org/x/crypto/blake2b"
 )
 
 // MaxLogN is the log2 of the largest supported polynomial modulus degree.
@@ -39,9 +42,11 @@ const GaloisGen uint64 = 5
 // the Q and P fields to the desired moduli chain, or by setting the LogQ and LogP fields to
 // the desired moduli sizes.
 //
-// Optionally, users may specify the error variance (Sigma) and secrets' density (H) and the ring
-// type (RingType). If left unset, standard default values for these field are substituted at
-// parameter creation (see NewParametersFromLiteral).
+// Optionally, users may specify the error variance (Sigma), the error distribution (ErrorDistribution),
+// secrets' density (H), the ring type (RingType) and the power-of-two decomposition base of the key-switching
+// (Pow2Base). If left unset, standard default values for these field are substituted at parameter creation
+// (see NewParametersFromLiteral). Users may also opt-in for a strict mode by specifying the minimum estimated
+// bit-security that the parameters must achieve (MinSecurity).
 type ParametersLiteral struct {
 	LogN     int
 	Q        []uint64
@@ -51,6 +56,10 @@ type ParametersLiteral struct {
 	Sigma    float64
 	H        int
 	RingType ring.Type
+
+	ErrorDistribution ErrorDistribution `json:",omitempty"`
+	Pow2Base          int               `json:",omitempty"`
+	MinSecurity       int               `json:",omitempty"`
 }
 
 // Parameters represents a set of generic RLWE parameters. Its fields are private and
@@ -64,6 +73,11 @@ type Parameters struct {
 	ringQ    *ring.Ring
 	ringP    *ring.Ring
 	ringType ring.Type
+
+	errorDist ErrorDistribution
+	pow2Base  int
+
+	fingerprint uint64
 }
 
 // NewParameters returns a new set of generic RLWE parameters from the given ring degree logn, moduli q and p, and
@@ -93,7 +107,13 @@ func NewParameters(logn int, q, p []uint64, h int, sigma float64, ringType ring.
 	copy(params.qi, q)
 	copy(params.pi, p)
 
-	return params, params.initRings()
+	if err = params.initRings(); err != nil {
+		return Parameters{}, err
+	}
+
+	params.fingerprint = params.computeFingerprint()
+
+	return params, nil
 }
 
 // NewParametersFromLiteral instantiate a set of generic RLWE parameters from a ParametersLiteral specification.
@@ -108,7 +128,14 @@ func NewParameters(logn int, q, p []uint64, h int, sigma float64, ringType ring.
 // If the error variance is left unset, its value is set to `DefaultSigma`.
 //
 // If the RingType is left unset, the default value is ring.Standard.
-func NewParametersFromLiteral(paramDef ParametersLiteral) (Parameters, error) {
+//
+// If the ErrorDistribution is left unset, the default value is GaussianError.
+//
+// If the Pow2Base is left unset, the key-switching only uses the RNS decomposition (see WithPow2Base).
+//
+// If MinSecurity is set, the method returns an error if the estimated security of the parameters
+// is smaller than MinSecurity bits, up to SecurityTolerance (see EstimatedSecurity).
+func NewParametersFromLiteral(paramDef ParametersLiteral) (params Parameters, err error) {
 
 	if paramDef.H == 0 {
 		paramDef.H = 1 << (paramDef.LogN - 1)
@@ -122,10 +149,9 @@ func NewParametersFromLiteral(paramDef ParametersLiteral) (Parameters, error) {
 
 	switch {
 	case paramDef.Q != nil && paramDef.LogQ == nil && paramDef.P != nil && paramDef.LogP == nil:
-		return NewParameters(paramDef.LogN, paramDef.Q, paramDef.P, paramDef.H, paramDef.Sigma, paramDef.RingType)
+		params, err = NewParameters(paramDef.LogN, paramDef.Q, paramDef.P, paramDef.H, paramDef.Sigma, paramDef.RingType)
 	case paramDef.LogQ != nil && paramDef.Q == nil && paramDef.LogP != nil && paramDef.P == nil:
 		var q, p []uint64
-		var err error
 		switch paramDef.RingType {
 		case ring.Standard:
 			q, p, err = GenModuli(paramDef.LogN, paramDef.LogQ, paramDef.LogP)
@@ -137,10 +163,56 @@ func NewParametersFromLiteral(paramDef ParametersLiteral) (Parameters, error) {
 		if err != nil {
 			return Parameters{}, err
 		}
-		return NewParameters(paramDef.LogN, q, p, paramDef.H, paramDef.Sigma, paramDef.RingType)
+		params, err = NewParameters(paramDef.LogN, q, p, paramDef.H, paramDef.Sigma, paramDef.RingType)
 	default:
 		return Parameters{}, fmt.Errorf("invalid parameter literal")
 	}
+
+	if err != nil {
+		return Parameters{}, err
+	}
+
+	if params, err = params.WithErrorDistribution(paramDef.ErrorDistribution); err != nil {
+		return Parameters{}, err
+	}
+
+	if params, err = params.WithPow2Base(paramDef.Pow2Base); err != nil {
+		return Parameters{}, err
+	}
+
+	if paramDef.MinSecurity != 0 {
+		if err = params.checkSecurity(paramDef.MinSecurity); err != nil {
+			return Parameters{}, err
+		}
+	}
+
+	return params, nil
+}
+
+// WithErrorDistribution returns a copy of the receiver whose error polynomials are sampled from the
+// given distribution. It returns the empty parameters Parameters{} and a non-nil error if the distribution
+// cannot be instantiated with the standard deviation of the receiver.
+func (p Parameters) WithErrorDistribution(ed ErrorDistribution) (Parameters, error) {
+	if err := checkErrorDistribution(ed, p.sigma); err != nil {
+		return Parameters{}, err
+	}
+	p.errorDist = ed
+	p.fingerprint = p.computeFingerprint()
+	return p, nil
+}
+
+// WithPow2Base returns a copy of the receiver whose key-switching further decomposes each RNS digit
+// into digits in base 2^pow2Base. A pow2Base of zero disables the power-of-two decomposition.
+// Since the RNS digits must be single moduli, a non-zero pow2Base requires at most one modulus in P,
+// in which case P can also be left empty. It returns the empty parameters Parameters{} and a non-nil
+// error if the decomposition cannot be instantiated with the moduli of the receiver.
+func (p Parameters) WithPow2Base(pow2Base int) (Parameters, error) {
+	if err := checkPow2Base(pow2Base, p.qi, p.pi); err != nil {
+		return Parameters{}, err
+	}
+	p.pow2Base = pow2Base
+	p.fingerprint = p.computeFingerprint()
+	return p, nil
 }
 
 // StandardParameters returns a RLWE parameter set that corresponds to the
@@ -155,6 +227,7 @@ func (p Parameters) StandardParameters() (pci Parameters, err error) {
 		pci = p
 		pci.logN = p.logN + 1
 		pci.ringType = ring.Standard
+		pci.fingerprint = pci.computeFingerprint()
 		err = pci.initRings()
 	default:
 		err = fmt.Errorf("invalid ring type")
@@ -188,6 +261,23 @@ func (p Parameters) RingQP() *RingQP {
 	return &RingQP{p.ringQ, p.ringP}
 }
 
+// WithParallelism returns a copy of the receiver whose rings split their operations across the given
+// number of workers (see ring.Ring.WithParallelism). The evaluators instantiated from the returned
+// parameters additionally split the gadget decomposition of the key-switching across the workers.
+// The results are identical to the ones obtained without parallelism, which is the default.
+func (p Parameters) WithParallelism(workers int) Parameters {
+	p.ringQ = p.ringQ.WithParallelism(workers)
+	if p.ringP != nil {
+		p.ringP = p.ringP.WithParallelism(workers)
+	}
+	return p
+}
+
+// Parallelism returns the number of workers among which the operations are split.
+func (p Parameters) Parallelism() int {
+	return p.ringQ.Parallelism()
+}
+
 // HammingWeight returns the number of non-zero coefficients in secret-keys.
 func (p Parameters) HammingWeight() int {
 	return p.h
@@ -198,6 +288,17 @@ func (p Parameters) Sigma() float64 {
 	return p.sigma
 }
 
+// ErrorDistribution returns the distribution of the error polynomials.
+func (p Parameters) ErrorDistribution() ErrorDistribution {
+	return p.errorDist
+}
+
+// Pow2Base returns the base 2^w (as w) of the power-of-two decomposition of the key-switching,
+// or zero if the key-switching only uses the RNS decomposition.
+func (p Parameters) Pow2Base() int {
+	return p.pow2Base
+}
+
 // RingType returns the type of the underlying ring.
 func (p Parameters) RingType() ring.Type {
 	return p.ringType
@@ -310,13 +411,30 @@ func (p Parameters) Alpha() int {
 	return p.PCount()
 }
 
-// Beta returns the number of element in the RNS decomposition basis: Ceil(lenQi / lenPi)
+// Beta returns the number of element in the RNS decomposition basis: Ceil(lenQi / lenPi).
+// If P is empty, each modulus of Q is a separate element of the decomposition basis.
 func (p Parameters) Beta() int {
-	if p.Alpha() != 0 {
-		return int(math.Ceil(float64(p.QCount()) / float64(p.Alpha())))
+	return p.DecompRNS(p.QCount()-1, p.PCount()-1)
+}
+
+// DecompRNS returns the number of element in the RNS decomposition basis at the given levels,
+// that is Ceil((levelQ+1) / (levelP+1)), or levelQ+1 if levelP is -1.
+func (p Parameters) DecompRNS(levelQ, levelP int) int {
+	if levelP < 0 {
+		return levelQ + 1
 	}
+	return (levelQ + levelP + 1) / (levelP + 1)
+}
 
-	return 1
+// DecompPw2 returns the number of base 2^Pow2Base digits each element of the RNS decomposition
+// basis is decomposed into: Ceil(max(log2(Qi)) / Pow2Base), or 1 if Pow2Base is zero.
+// The key-switching keys store the Beta() * DecompPw2() elements of the decomposition, the
+// j-th power-of-two digit of the i-th RNS digit being at index i*DecompPw2()+j.
+func (p Parameters) DecompPw2() int {
+	if p.pow2Base == 0 {
+		return 1
+	}
+	return (bits.Len64(utils.MaxSliceUint64(p.qi)) + p.pow2Base - 1) / p.pow2Base
 }
 
 // QiOverflowMargin returns floor(2^64 / max(Qi)), i.e. the number of times elements of Z_max{Qi} can
@@ -368,6 +486,16 @@ func (p Parameters) GaloisElementsForRowInnerSum() (galEls []uint64) {
 	return galEls
 }
 
+// GaloisElementsForExpand returns the list of the galois elements N/2^i + 1 for 0 <= i < logN, which are required to
+// expand a ciphertext into 2^logN ciphertexts that each encrypt one of its first 2^logN coefficients.
+func (p Parameters) GaloisElementsForExpand(logN int) (galEls []uint64) {
+	galEls = make([]uint64, logN)
+	for i := range galEls {
+		galEls[i] = uint64(p.N()>>i) + 1
+	}
+	return
+}
+
 // InverseGaloisElement takes a galois element and returns the galois element
 //  corresponding to the inverse automorphism
 func (p Parameters) InverseGaloisElement(galEl uint64) uint64 {
@@ -382,9 +510,51 @@ func (p Parameters) Equals(other Parameters) bool {
 	res = res && (p.h == other.h)
 	res = res && (p.sigma == other.sigma)
 	res = res && (p.ringType == other.ringType)
+	res = res && (p.errorDist == other.errorDist)
+	res = res && (p.pow2Base == other.pow2Base)
 	return res
 }
 
+// Fingerprint returns a stable 64-bit digest of the parameters, which identifies the parameters that
+// produced an object (e.g., a key) and is never zero. It covers the same fields as Equals: LogN, Q, P, H,
+// Sigma, the ring type, the error distribution and Pow2Base. Two parameter sets are Equals if and only if
+// their fingerprints match, with overwhelming probability. The fingerprint is computed once, when the
+// parameters are instantiated.
+func (p Parameters) Fingerprint() uint64 {
+	if p.fingerprint == 0 {
+		return p.computeFingerprint()
+	}
+	return p.fingerprint
+}
+
+// computeFingerprint computes the Fingerprint of the target parameters.
+func (p Parameters) computeFingerprint() uint64 {
+	b := utils.NewBuffer(make([]byte, 0, 48+(len(p.qi)+len(p.pi))<<3))
+	b.WriteUint8Slice([]byte("lattigo/rlwe.Parameters"))
+	b.WriteUint8(uint8(p.logN))
+	b.WriteUint8(uint8(len(p.qi)))
+	b.WriteUint64Slice(p.qi)
+	b.WriteUint8(uint8(len(p.pi)))
+	b.WriteUint64Slice(p.pi)
+	b.WriteUint64(uint64(p.h))
+	b.WriteUint64(math.Float64bits(p.sigma))
+	b.WriteUint8(uint8(p.ringType))
+	b.WriteUint8(uint8(p.errorDist))
+	b.WriteUint8(uint8(p.pow2Base))
+	return FingerprintDigest(b.Bytes())
+}
+
+// FingerprintDigest returns the 64-bit digest of data used by the Fingerprint methods of the parameters,
+// i.e., the first 8 bytes of its BLAKE2b-256 hash, mapped to 1 if they are all zero since the zero
+// fingerprint denotes unknown parameters.
+func FingerprintDigest(data []byte) uint64 {
+	digest := blake2b.Sum256(data)
+	if fingerprint := binary.BigEndian.Uint64(digest[:8]); fingerprint != 0 {
+		return fingerprint
+	}
+	return 1
+}
+
 // CopyNew makes a deep copy of the receiver and returns it.
 //
 // Deprecated: Parameter is now a read-only struct, except for the UnmarshalBinary method: deep copying should only be
@@ -408,39 +578,80 @@ func (p Parameters) MarshalBinary() ([]byte, error) {
 		return []byte{}, nil
 	}
 
+	// EnvelopeHeaderLen byte : Envelope
 	// 1 byte : logN
 	// 1 byte : #Q
 	// 1 byte : #P
 	// 8 byte : H
 	// 8 byte : sigma
 	// 1 byte : ringType
+	// 1 byte : errorDist
+	// 1 byte : pow2Base
 	// 8 * (#Q) : Q
 	// 8 * (#P) : P
-	b := utils.NewBuffer(make([]byte, 0, p.MarshalBinarySize()))
+	b := utils.NewBuffer(make([]byte, EnvelopeHeaderLen, p.MarshalBinarySize()))
 	b.WriteUint8(uint8(p.logN))
 	b.WriteUint8(uint8(len(p.qi)))
 	b.WriteUint8(uint8(len(p.pi)))
 	b.WriteUint64(uint64(p.h))
 	b.WriteUint64(math.Float64bits(p.sigma))
 	b.WriteUint8(uint8(p.ringType))
+	b.WriteUint8(uint8(p.errorDist))
+	b.WriteUint8(uint8(p.pow2Base))
 	b.WriteUint64Slice(p.qi)
 	b.WriteUint64Slice(p.pi)
-	return b.Bytes(), nil
+	data := b.Bytes()
+	p.envelope(KindParameters).WriteHeader(data)
+	return data, nil
 }
 
 // UnmarshalBinary decodes a []byte into a parameter set struct.
-func (p *Parameters) UnmarshalBinary(data []byte) error {
-	if len(data) < 11 {
+// It also accepts the legacy encodings without Envelope, i.e., the payload of the current encoding and
+// the encoding of the previous versions, which has neither the error distribution nor Pow2Base:
+//
+//	1 byte : logN
+//	1 byte : #Q
+//	1 byte : #P
+//	8 byte : H
+//	8 byte : sigma
+//	1 byte : ringType
+//	8 * (#Q) : Q
+//	8 * (#P) : P
+func (p *Parameters) UnmarshalBinary(data []byte) (err error) {
+
+	var env Envelope
+	if env, data, err = OpenEnvelope(data, KindParameters); err != nil {
+		return err
+	}
+
+	if len(data) < 20 {
 		return fmt.Errorf("invalid rlwe.Parameter serialization")
 	}
-	b := utils.NewBuffer(data)
-	logN := int(b.ReadUint8())
-	lenQ := int(b.ReadUint8())
-	lenP := int(b.ReadUint8())
+
+	lenQ, lenP := int(data[1]), int(data[2])
+
+	// The two layouts are distinguished by their length, which differs modulo 8
+	headerLen := 22
+	if env.IsLegacy() && len(data) == 20+(lenQ+lenP)<<3 {
+		headerLen = 20
+	}
+
+	if len(data) != headerLen+(lenQ+lenP)<<3 {
+		return fmt.Errorf("invalid rlwe.Parameter serialization")
+	}
+
+	b := utils.NewBuffer(data[3:])
+	logN := int(data[0])
 	h := int(b.ReadUint64())
 	sigma := math.Float64frombits(b.ReadUint64())
 	ringType := ring.Type(b.ReadUint8())
 
+	errorDist, pow2Base := GaussianError, 0
+	if headerLen == 22 {
+		errorDist = ErrorDistribution(b.ReadUint8())
+		pow2Base = int(b.ReadUint8())
+	}
+
 	if err := checkSizeParams(logN, lenQ, lenP); err != nil {
 		return err
 	}
@@ -450,19 +661,94 @@ func (p *Parameters) UnmarshalBinary(data []byte) error {
 	b.ReadUint64Slice(qi)
 	b.ReadUint64Slice(pi)
 
-	var err error
-	*p, err = NewParameters(logN, qi, pi, h, sigma, ringType)
-	return err
+	params, err := NewParameters(logN, qi, pi, h, sigma, ringType)
+	if err != nil {
+		return err
+	}
+
+	if params, err = params.WithErrorDistribution(errorDist); err != nil {
+		return err
+	}
+
+	if params, err = params.WithPow2Base(pow2Base); err != nil {
+		return err
+	}
+
+	if env.ParametersHash != 0 && env.ParametersHash != params.Fingerprint() {
+		return fmt.Errorf("invalid rlwe.Parameter serialization: fingerprint does not match the envelope")
+	}
+
+	*p = params
+	return nil
 }
 
 // MarshalBinarySize returns the length of the []byte encoding of the reciever.
 func (p Parameters) MarshalBinarySize() int {
-	return 20 + (len(p.qi)+len(p.pi))<<3
+	return EnvelopeHeaderLen + 22 + (len(p.qi)+len(p.pi))<<3
+}
+
+// envelope returns the Envelope of the given kind of the encoding of the target parameters.
+func (p Parameters) envelope(kind ObjectKind) Envelope {
+	return Envelope{Version: EnvelopeVersion, Kind: kind, ParametersHash: p.Fingerprint(), RingType: p.ringType, LogN: p.logN, Level: p.MaxLevel()}
+}
+
+// CheckEnvelope returns an error if the Envelope of the encoded object data is malformed or does not match
+// the target parameters, i.e., if its parameters hash (when specified) differs from the Fingerprint of
+// the parameters, if its ring type (when specified) or its ring degree differ, or if its level is larger
+// than the maximum level. The parameters hash of encoded bfv and ckks parameters, which is the fingerprint
+// of the scheme parameters, is not checked. Legacy encodings, which have no Envelope, cannot be checked and
+// are accepted.
+func (p Parameters) CheckEnvelope(data []byte) (err error) {
+
+	var env Envelope
+	if env, _, err = DecodeEnvelope(data); err != nil || env.IsLegacy() {
+		return err
+	}
+
+	if env.ParametersHash != 0 && env.Kind != KindBFVParameters && env.Kind != KindCKKSParameters && env.ParametersHash != p.Fingerprint() {
+		return fmt.Errorf("%s does not match the parameters: fingerprint %#016x != %#016x", env.Kind, env.ParametersHash, p.Fingerprint())
+	}
+
+	if env.RingType != UnspecifiedRingType && env.RingType != p.ringType {
+		return fmt.Errorf("%s does not match the parameters: ring type %s != %s", env.Kind, env.RingType, p.ringType)
+	}
+
+	if env.LogN != p.logN {
+		return fmt.Errorf("%s does not match the parameters: LogN %d != %d", env.Kind, env.LogN, p.logN)
+	}
+
+	if env.Level > p.MaxLevel() {
+		return fmt.Errorf("%s does not match the parameters: level %d > MaxLevel %d", env.Kind, env.Level, p.MaxLevel())
+	}
+
+	return nil
+}
+
+// UnmarshalObject checks the Envelope of the encoded object data against the target parameters with
+// CheckEnvelope and decodes it on obj. Unlike obj.UnmarshalBinary, which only checks that the Envelope is
+// consistent with the decoded object, it rejects the objects encoded under other parameters. Since they
+// cannot be checked, legacy encodings without Envelope are rejected.
+func (p Parameters) UnmarshalObject(data []byte, obj encoding.BinaryUnmarshaler) (err error) {
+
+	var env Envelope
+	if env, _, err = DecodeEnvelope(data); err != nil {
+		return err
+	}
+
+	if env.IsLegacy() {
+		return fmt.Errorf("cannot UnmarshalObject: legacy encodings without Envelope cannot be checked against the parameters")
+	}
+
+	if err = p.CheckEnvelope(data); err != nil {
+		return err
+	}
+
+	return obj.UnmarshalBinary(data)
 }
 
 // MarshalJSON returns a JSON representation of this parameter set. See `Marshal` from the `encoding/json` package.
 func (p Parameters) MarshalJSON() ([]byte, error) {
-	return json.Marshal(&ParametersLiteral{LogN: p.logN, Q: p.qi, P: p.pi, H: p.h, Sigma: p.sigma})
+	return json.Marshal(&ParametersLiteral{LogN: p.logN, Q: p.qi, P: p.pi, H: p.h, Sigma: p.sigma, RingType: p.ringType, ErrorDistribution: p.errorDist, Pow2Base: p.pow2Base})
 }
 
 // UnmarshalJSON reads a JSON representation of a parameter set into the receiver Parameter. See `Unmarshal` from the `encoding/json` package.
@@ -529,6 +815,36 @@ func checkSizeParams(logN int, lenQ, lenP int) error {
 	return nil
 }
 
+func checkPow2Base(pow2Base int, q, p []uint64) error {
+	if pow2Base == 0 {
+		return nil
+	}
+	if pow2Base < 0 {
+		return fmt.Errorf("invalid Pow2Base=%d: must be positive", pow2Base)
+	}
+	if len(p) > 1 {
+		return fmt.Errorf("invalid Pow2Base=%d: cannot be used with more than one modulus in P (#P=%d)", pow2Base, len(p))
+	}
+	// The digits are lifted as is in the whole basis QP, hence must be smaller than every modulus.
+	minModulus := q[0]
+	for _, moduli := range [][]uint64{q, p} {
+		for _, qi := range moduli {
+			if qi < minModulus {
+				minModulus = qi
+			}
+		}
+	}
+	if pow2Base >= bits.Len64(minModulus) {
+		return fmt.Errorf("invalid Pow2Base=%d: must be smaller than the bit-size of the smallest modulus (%d)", pow2Base, bits.Len64(minModulus))
+	}
+	// The size of the decomposition is encoded on a single byte by the switching keys.
+	maxLenQ := (bits.Len64(utils.MaxSliceUint64(q)) + pow2Base - 1) / pow2Base
+	if len(q)*maxLenQ > 0xFF {
+		return fmt.Errorf("invalid Pow2Base=%d: the decomposition has more than 255 elements", pow2Base)
+	}
+	return nil
+}
+
 func checkModuliLogSize(logQ, logP []int) error {
 
 	for i, qi := range logQ {
@@ -0,0 +1,299 @@
+package rlwe
+
+import (
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// RGSWCiphertext is a generic type for RGSW ciphertexts. It stores two gadget ciphertexts, with the same
+// decomposition as the SwitchingKey: Value[0] encrypts m * P * g in its first component and Value[1]
+// encrypts m * P * g in its second component, i.e., Value[1] is a gadget encryption of m * s.
+// The polynomials are stored in the NTT and Montgomery domain.
+type RGSWCiphertext struct {
+	Value [2]*SwitchingKey
+}
+
+// NewRGSWCiphertext returns a new RGSWCiphertext with pre-allocated zero values at levelQ and levelP.
+func NewRGSWCiphertext(params Parameters, levelQ, levelP int) *RGSWCiphertext {
+	return &RGSWCiphertext{Value: [2]*SwitchingKey{
+		NewSwitchingKey(params, levelQ, levelP),
+		NewSwitchingKey(params, levelQ, levelP),
+	}}
+}
+
+// LevelQ returns the level of the modulus Q of the target RGSWCiphertext.
+func (ct *RGSWCiphertext) LevelQ() int {
+	return ct.Value[0].LevelQ()
+}
+
+// LevelP returns the level of the modulus P of the target RGSWCiphertext, or -1 if P is empty.
+func (ct *RGSWCiphertext) LevelP() int {
+	return ct.Value[0].LevelP()
+}
+
+// Equals checks two RGSWCiphertexts for equality.
+func (ct *RGSWCiphertext) Equals(other *RGSWCiphertext) bool {
+	if ct == other {
+		return true
+	}
+	return ct.Value[0].Equals(other.Value[0]) && ct.Value[1].Equals(other.Value[1])
+}
+
+// CopyNew creates a deep copy of the target RGSWCiphertext and returns it.
+func (ct *RGSWCiphertext) CopyNew() *RGSWCiphertext {
+	return &RGSWCiphertext{Value: [2]*SwitchingKey{ct.Value[0].CopyNew(), ct.Value[1].CopyNew()}}
+}
+
+// RGSWEncryptor is a struct to encrypt plaintexts into RGSWCiphertexts under a SecretKey.
+type RGSWEncryptor struct {
+	params           Parameters
+	sk               *SecretKey
+	gaussianSamplerQ ring.ErrorSampler
+	uniformSamplerQ  *ring.UniformSampler
+	uniformSamplerP  *ring.UniformSampler
+	poolQ            [2]*ring.Poly
+}
+
+// NewRGSWEncryptor creates a new RGSWEncryptor encrypting under the secret key sk.
+// The parameters must have either a non-empty modulus P or a non-zero Pow2Base.
+func NewRGSWEncryptor(params Parameters, sk *SecretKey) *RGSWEncryptor {
+
+	if params.PCount() == 0 && params.Pow2Base() == 0 {
+		panic("cannot NewRGSWEncryptor: modulus P is empty and Pow2Base is zero")
+	}
+
+	if sk.Value.Q.Degree() != params.N() {
+		panic("cannot NewRGSWEncryptor: sk ring degree does not match params ring degree")
+	}
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	var uniformSamplerP *ring.UniformSampler
+	if params.PCount() > 0 {
+		uniformSamplerP = ring.NewUniformSampler(prng, params.RingP())
+	}
+
+	return &RGSWEncryptor{
+		params:           params,
+		sk:               sk,
+		gaussianSamplerQ: NewErrorSampler(params, prng),
+		uniformSamplerQ:  ring.NewUniformSampler(prng, params.RingQ()),
+		uniformSamplerP:  uniformSamplerP,
+		poolQ:            [2]*ring.Poly{params.RingQ().NewPoly(), params.RingQ().NewPoly()},
+	}
+}
+
+// ShallowCopy creates a shallow copy of the RGSWEncryptor in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers and samplers are reallocated. The receiver and the
+// returned RGSWEncryptors can be used concurrently.
+func (enc *RGSWEncryptor) ShallowCopy() *RGSWEncryptor {
+	return NewRGSWEncryptor(enc.params, enc.sk)
+}
+
+// Encrypt encrypts the plaintext pt into the RGSWCiphertext ct, at the levels of ct.
+// The plaintext must be at a level larger or equal to ct.LevelQ(). If pt is nil, it encrypts zero.
+// For the products of RGSWCiphertexts to have a small noise, the plaintext must have a small norm
+// (e.g., a bit or a monomial).
+func (enc *RGSWEncryptor) Encrypt(pt *Plaintext, ct *RGSWCiphertext) {
+
+	ringQ := enc.params.RingQ()
+	ringQP := enc.params.RingQP()
+
+	levelQ := ct.LevelQ()
+	levelP := ct.LevelP()
+
+	for _, swk := range ct.Value {
+		for i := range swk.Value {
+
+			cti := swk.Value[i]
+
+			// e
+			enc.gaussianSamplerQ.ReadLvl(levelQ, cti[0].Q)
+			ringQP.ExtendBasisSmallNormAndCenter(cti[0].Q, levelP, nil, cti[0].P)
+			ringQP.NTTLazyLvl(levelQ, levelP, cti[0], cti[0])
+			ringQP.MFormLvl(levelQ, levelP, cti[0], cti[0])
+
+			// a (since a is uniform, we consider we already sample it in the NTT and Montgomery domain)
+			enc.uniformSamplerQ.ReadLvl(levelQ, cti[1].Q)
+			if levelP > -1 {
+				enc.uniformSamplerP.ReadLvl(levelP, cti[1].P)
+			}
+
+			// - a * sk + e mod QP
+			ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, cti[1], enc.sk.Value, cti[0])
+		}
+	}
+
+	if pt == nil {
+		return
+	}
+
+	if pt.Value.IsNTT {
+		ring.CopyValuesLvl(levelQ, pt.Value, enc.poolQ[0])
+	} else {
+		ringQ.NTTLvl(levelQ, pt.Value, enc.poolQ[0])
+	}
+
+	ringQ.MFormLvl(levelQ, enc.poolQ[0], enc.poolQ[0])
+
+	addPolyTimesGadgetVector(enc.params, enc.poolQ[0], ct.Value[0], 0, enc.poolQ[1])
+	addPolyTimesGadgetVector(enc.params, enc.poolQ[0], ct.Value[1], 1, enc.poolQ[1])
+}
+
+// ExternalProduct computes the external product between the RLWE ciphertext ctIn of degree 1, encrypting m0,
+// and the RGSWCiphertext rgsw, encrypting m1, and returns on ctOut an RLWE encryption of m0 * m1, in the domain
+// of ctIn. The noise of ctOut is the noise of ctIn multiplied by m1 plus a key-switching noise, and
+// ctIn and ctOut can be the same ciphertext.
+func (ks *KeySwitcher) ExternalProduct(ctIn *Ciphertext, rgsw *RGSWCiphertext, ctOut *Ciphertext) {
+
+	if ctIn.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot ExternalProduct: input and output ciphertexts must be of degree 1")
+	}
+
+	levelQ := utils.MinInt(utils.MinInt(ctIn.Level(), ctOut.Level()), rgsw.LevelQ())
+
+	ks.externalProduct(levelQ, ctIn.Value[0], ctIn.Value[1], rgsw)
+
+	ring.CopyValuesLvl(levelQ, ks.Pool[1].Q, ctOut.Value[0])
+	ring.CopyValuesLvl(levelQ, ks.Pool[2].Q, ctOut.Value[1])
+
+	ctOut.Value[0].IsNTT = ctIn.Value[0].IsNTT
+	ctOut.Value[1].IsNTT = ctIn.Value[0].IsNTT
+
+	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:levelQ+1]
+	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:levelQ+1]
+}
+
+// CMux homomorphically selects ct1 if the RGSWCiphertext ctrl encrypts one and ct0 if it encrypts zero,
+// by computing ct0 + ctrl x (ct1 - ct0), and returns the result on ctOut, in the domain of ct0.
+// The RLWE ciphertexts must be of degree 1 and in the same domain, and ctOut can be ct0 or ct1.
+func (ks *KeySwitcher) CMux(ctrl *RGSWCiphertext, ct0, ct1, ctOut *Ciphertext) {
+
+	if ct0.Degree() != 1 || ct1.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot CMux: input and output ciphertexts must be of degree 1")
+	}
+
+	if ct0.Value[0].IsNTT != ct1.Value[0].IsNTT {
+		panic("cannot CMux: input ciphertexts must be in the same domain")
+	}
+
+	ringQ := ks.RingQ()
+
+	levelQ := utils.MinInt(utils.MinInt(ct0.Level(), ct1.Level()), utils.MinInt(ctOut.Level(), ctrl.LevelQ()))
+
+	// The difference is computed one component at a time in Pool[5].Q, which is not used by the key-switching.
+	diff := ks.Pool[5].Q
+	diff.IsNTT = ct0.Value[0].IsNTT
+
+	ringQ.SubLvl(levelQ, ct1.Value[0], ct0.Value[0], diff)
+	ks.SwitchKeysInPlaceNoModDown(levelQ, diff, ctrl.Value[0], ks.Pool[1].Q, ks.Pool[1].P, ks.Pool[2].Q, ks.Pool[2].P)
+
+	ringQ.SubLvl(levelQ, ct1.Value[1], ct0.Value[1], diff)
+	ks.SwitchKeysInPlaceNoModDown(levelQ, diff, ctrl.Value[1], ks.Pool[3].Q, ks.Pool[3].P, ks.Pool[4].Q, ks.Pool[4].P)
+
+	ks.addAndModDown(levelQ, ctrl.LevelP(), diff.IsNTT)
+
+	ringQ.AddLvl(levelQ, ct0.Value[0], ks.Pool[1].Q, ctOut.Value[0])
+	ringQ.AddLvl(levelQ, ct0.Value[1], ks.Pool[2].Q, ctOut.Value[1])
+
+	ctOut.Value[0].IsNTT = diff.IsNTT
+	ctOut.Value[1].IsNTT = diff.IsNTT
+
+	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:levelQ+1]
+	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:levelQ+1]
+}
+
+// InternalProduct computes the internal product between the RGSWCiphertexts rgsw0, encrypting m0, and rgsw1,
+// encrypting m1, and returns on rgswOut an RGSWCiphertext encrypting m0 * m1, at the levels of rgswOut.
+// Each row of rgswOut is the external product between rgsw0 and the corresponding row of rgsw1, computed
+// directly in the basis of the rows, hence the noise of rgswOut is of the order of the noise of an ExternalProduct.
+// The modulus P of the RGSWCiphertexts must be empty: the gadget decomposition only decomposes the modulus Q,
+// so a row of rgsw1 over QP cannot be multiplied by rgsw0 without dividing it by P.
+// rgswOut can be rgsw1 but not rgsw0.
+func (ks *KeySwitcher) InternalProduct(rgsw0, rgsw1, rgswOut *RGSWCiphertext) {
+
+	if rgswOut == rgsw0 {
+		panic("cannot InternalProduct: rgswOut cannot be rgsw0")
+	}
+
+	if rgsw0.LevelP() != -1 || rgsw1.LevelP() != -1 || rgswOut.LevelP() != -1 {
+		panic("cannot InternalProduct: the modulus P of the RGSWCiphertexts must be empty")
+	}
+
+	ringQ := ks.RingQ()
+
+	levelQ := rgswOut.LevelQ()
+
+	if rgsw1.LevelQ() != levelQ {
+		panic("cannot InternalProduct: rgsw1 and rgswOut must be at the same level")
+	}
+
+	// Each component of a row is taken out of the Montgomery domain in Pool[5].Q, which is not used by the key-switching.
+	c := ks.Pool[5].Q
+	c.IsNTT = true
+
+	for i := range rgsw1.Value {
+		for j := range rgsw1.Value[i].Value {
+
+			rowIn := rgsw1.Value[i].Value[j]
+			rowOut := rgswOut.Value[i].Value[j]
+
+			ringQ.InvMFormLvl(levelQ, rowIn[0].Q, c)
+			ks.SwitchKeysInPlaceNoModDown(levelQ, c, rgsw0.Value[0], ks.Pool[1].Q, ks.Pool[1].P, ks.Pool[2].Q, ks.Pool[2].P)
+
+			ringQ.InvMFormLvl(levelQ, rowIn[1].Q, c)
+			ks.SwitchKeysInPlaceNoModDown(levelQ, c, rgsw0.Value[1], ks.Pool[3].Q, ks.Pool[3].P, ks.Pool[4].Q, ks.Pool[4].P)
+
+			ks.addAndModDown(levelQ, -1, true)
+
+			ringQ.MFormLvl(levelQ, ks.Pool[1].Q, rowOut[0].Q)
+			ringQ.MFormLvl(levelQ, ks.Pool[2].Q, rowOut[1].Q)
+		}
+	}
+}
+
+// externalProduct computes the external product between the RLWE ciphertext (c0, c1) and the RGSWCiphertext
+// rgsw, and returns the result in Pool[1].Q and Pool[2].Q, in the domain of c0.
+func (ks *KeySwitcher) externalProduct(levelQ int, c0, c1 *ring.Poly, rgsw *RGSWCiphertext) {
+	ks.SwitchKeysInPlaceNoModDown(levelQ, c0, rgsw.Value[0], ks.Pool[1].Q, ks.Pool[1].P, ks.Pool[2].Q, ks.Pool[2].P)
+	ks.SwitchKeysInPlaceNoModDown(levelQ, c1, rgsw.Value[1], ks.Pool[3].Q, ks.Pool[3].P, ks.Pool[4].Q, ks.Pool[4].P)
+	ks.addAndModDown(levelQ, rgsw.LevelP(), c0.IsNTT)
+}
+
+// addAndModDown adds Pool[3] and Pool[4] to Pool[1] and Pool[2], and divides the result by P,
+// reducing the basis from QP to Q. The result is returned in the NTT domain if isNTT is true.
+func (ks *KeySwitcher) addAndModDown(levelQ, levelP int, isNTT bool) {
+
+	ringQ := ks.RingQ()
+	ringP := ks.RingP()
+
+	p0, p1 := ks.Pool[1], ks.Pool[2]
+
+	ks.RingQP().AddLvl(levelQ, levelP, p0, ks.Pool[3], p0)
+	ks.RingQP().AddLvl(levelQ, levelP, p1, ks.Pool[4], p1)
+
+	// Without P, the result is not scaled by P and is already mod Q (in the NTT domain).
+	if levelP == -1 {
+		if !isNTT {
+			ringQ.InvNTTLvl(levelQ, p0.Q, p0.Q)
+			ringQ.InvNTTLvl(levelQ, p1.Q, p1.Q)
+		}
+		return
+	}
+
+	if isNTT {
+		ks.BasisExtender.ModDownQPtoQNTT(levelQ, levelP, p0.Q, p0.P, p0.Q)
+		ks.BasisExtender.ModDownQPtoQNTT(levelQ, levelP, p1.Q, p1.P, p1.Q)
+	} else {
+		ringQ.InvNTTLazyLvl(levelQ, p0.Q, p0.Q)
+		ringQ.InvNTTLazyLvl(levelQ, p1.Q, p1.Q)
+		ringP.InvNTTLazyLvl(levelP, p0.P, p0.P)
+		ringP.InvNTTLazyLvl(levelP, p1.P, p1.P)
+
+		ks.BasisExtender.ModDownQPtoQ(levelQ, levelP, p0.Q, p0.P, p0.Q)
+		ks.BasisExtender.ModDownQPtoQ(levelQ, levelP, p1.Q, p1.P, p1.Q)
+	}
+}
@@ -1,6 +1,8 @@
 package rlwe
 
 import (
+	"errors"
+
 	"github.com/tuneinsight/lattigo/v3/ring"
 	"github.com/tuneinsight/lattigo/v3/utils"
 )
@@ -295,7 +297,7 @@ func (r *RingQP) CopyValuesLvl(levelQ, levelP int, p1, p2 PolyQP) {
 }
 
 // ExtendBasisSmallNormAndCenter extends a small-norm polynomial polQ in R_Q to a polynomial
-// polQP in R_QP.
+// polQP in R_QP. The extension to P is skipped if RingP is nil.
 func (r *RingQP) ExtendBasisSmallNormAndCenter(polyInQ *ring.Poly, levelP int, polyOutQ, polyOutP *ring.Poly) {
 	var coeff, Q, QHalf, sign uint64
 	Q = r.RingQ.Modulus[0]
@@ -305,6 +307,10 @@ func (r *RingQP) ExtendBasisSmallNormAndCenter(polyInQ *ring.Poly, levelP int, p
 		polyOutQ.Copy(polyInQ)
 	}
 
+	if r.RingP == nil {
+		return
+	}
+
 	for j := 0; j < r.RingQ.N; j++ {
 
 		coeff = polyInQ.Coeffs[0][j]
@@ -380,6 +386,14 @@ func (p *PolyQP) WriteTo(data []byte) (pt int, err error) {
 // DecodePolyNew decodes the input bytes on the target polyQP.
 func (p *PolyQP) DecodePolyNew(data []byte) (pt int, err error) {
 
+	if len(data) < 2 {
+		return 0, errors.New("invalid PolyQP encoding: too small bytearray")
+	}
+
+	if data[0] > 1 || data[1] > 1 {
+		return 0, errors.New("invalid PolyQP encoding: invalid header")
+	}
+
 	var inc int
 	pt = 2
 
@@ -1,13 +1,23 @@
 package rlwe
 
 import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
 	"math/big"
 	"math/bits"
+	"math/rand"
+	"os"
 	"runtime"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -57,9 +67,15 @@ func TestRLWE(t *testing.T) {
 			testSwitchKeyGen,
 			testEncryptor,
 			testDecryptor,
+			testMeasureNoise,
 			testKeySwitcher,
+			testKeySwitchPow2Base,
 			testKeySwitchDimension,
 			testMarshaller,
+			testEnvelope,
+			testFingerprint,
+			testRotationKeyProvider,
+			testRGSW,
 		} {
 			testSet(kgen, t)
 			runtime.GC()
@@ -67,6 +83,129 @@ func TestRLWE(t *testing.T) {
 	}
 }
 
+func TestRotationDecomposer(t *testing.T) {
+
+	for _, ringType := range []ring.Type{ring.Standard, ring.ConjugateInvariant} {
+
+		params, err := NewParametersFromLiteral(ParametersLiteral{LogN: 10, LogQ: []int{30}, LogP: []int{}, RingType: ringType})
+		require.NoError(t, err)
+
+		modulus := params.rotationsModulus()
+
+		t.Run(fmt.Sprintf("%s/PowersOfTwo", ringType), func(t *testing.T) {
+
+			// The Galois elements that are not column rotations are ignored
+			galEls := []uint64{params.RingQ().NthRoot - 1}
+			for k := 1; k < modulus; k <<= 1 {
+				galEls = append(galEls, params.GaloisElementForColumnRotationBy(k))
+			}
+
+			rd := NewRotationDecomposer(params, galEls)
+
+			for _, k := range []int{0, 1, 3, 7, 63, -63, modulus - 1, modulus + 5, 341} {
+
+				rotations, ok := rd.Decompose(k)
+				require.True(t, ok)
+
+				// The decomposition has at most the Hamming weight of k
+				require.LessOrEqual(t, len(rotations), bits.OnesCount(uint(((k%modulus)+modulus)%modulus)))
+
+				var sum int
+				for _, r := range rotations {
+					require.Equal(t, 0, r&(r-1))
+					sum += r
+				}
+				require.Equal(t, ((k%modulus)+modulus)%modulus, sum%modulus)
+			}
+
+			// Without the negative rotations, the rotation by -1 requires all the powers of two
+			rotations, _ := rd.Decompose(modulus - 1)
+			require.Len(t, rotations, bits.OnesCount(uint(modulus-1)))
+		})
+
+		t.Run(fmt.Sprintf("%s/Unreachable", ringType), func(t *testing.T) {
+			rd := NewRotationDecomposer(params, []uint64{params.GaloisElementForColumnRotationBy(2)})
+			_, ok := rd.Decompose(1)
+			require.False(t, ok)
+			rotations, ok := rd.Decompose(6)
+			require.True(t, ok)
+			require.Equal(t, []int{2, 2, 2}, rotations)
+		})
+
+		t.Run(fmt.Sprintf("%s/RotationsCoveringSet", ringType), func(t *testing.T) {
+
+			rotations := []int{1, 3, 5, 7, 15, 31, 63, -63, 100, 200, 0}
+
+			keys, extra := params.RotationsCoveringSet(rotations)
+			require.Less(t, len(keys), len(rotations))
+
+			galEls := make([]uint64, len(keys))
+			for i, k := range keys {
+				galEls[i] = params.GaloisElementForColumnRotationBy(k)
+			}
+
+			rd := NewRotationDecomposer(params, galEls)
+
+			var total int
+			for _, k := range rotations {
+				decomposition, ok := rd.Decompose(k)
+				require.True(t, ok)
+				if len(decomposition) > 1 {
+					total += len(decomposition) - 1
+				}
+			}
+			require.Equal(t, total, extra)
+
+			// Fewer rotations than signed powers of two are returned as is
+			keys, extra = params.RotationsCoveringSet([]int{3, -modulus + 3})
+			require.Equal(t, []int{3}, keys)
+			require.Equal(t, 0, extra)
+		})
+	}
+}
+
+func TestEstimatedSecurity(t *testing.T) {
+
+	// splits logQ into moduli of at most 60 bits
+	logQi := func(logQ int) (logQi []int) {
+		for ; logQ > 60; logQ -= 60 {
+			logQi = append(logQi, 60)
+		}
+		return append(logQi, logQ)
+	}
+
+	t.Run("HEStandard", func(t *testing.T) {
+		for logN, logQP := range map[int]int{11: 54, 12: 109, 13: 218, 14: 438} {
+			params, err := NewParametersFromLiteral(ParametersLiteral{LogN: logN, LogQ: logQi(logQP), LogP: []int{}, H: 2 << logN / 3})
+			require.NoError(t, err)
+			require.InDelta(t, 128, params.EstimatedSecurity(), 3, "logN=%d, logQP=%d", logN, logQP)
+		}
+	})
+
+	t.Run("Monotonicity", func(t *testing.T) {
+		security := func(logQP, h int, sigma float64) float64 {
+			params, err := NewParametersFromLiteral(ParametersLiteral{LogN: 12, LogQ: logQi(logQP), LogP: []int{}, H: h, Sigma: sigma})
+			require.NoError(t, err)
+			return params.EstimatedSecurity()
+		}
+		require.Greater(t, security(90, 2048, DefaultSigma), security(109, 2048, DefaultSigma))
+		require.Greater(t, security(109, 2048, DefaultSigma), security(109, 64, DefaultSigma))
+		require.Greater(t, security(109, 2048, DefaultSigma), security(109, 2048, 1))
+		require.Less(t, security(300, 2048, DefaultSigma), 64.0)
+	})
+
+	t.Run("MinSecurity", func(t *testing.T) {
+		_, err := NewParametersFromLiteral(ParametersLiteral{LogN: 12, LogQ: logQi(300), LogP: []int{}, MinSecurity: 128})
+		require.True(t, errors.Is(err, ErrInsufficientSecurity))
+
+		_, err = NewParametersFromLiteral(ParametersLiteral{LogN: 12, LogQ: logQi(300), LogP: []int{}})
+		require.NoError(t, err)
+
+		_, err = NewParametersFromLiteral(ParametersLiteral{LogN: 12, LogQ: []int{30, 30}, LogP: []int{30}, MinSecurity: 128})
+		require.NoError(t, err)
+	})
+}
+
 // Returns the ceil(log2) of the sum of the absolute value of all the coefficients
 func log2OfInnerSum(level int, ringQ *ring.Ring, poly *ring.Poly) (logSum int) {
 	sumRNS := make([]uint64, level+1)
@@ -307,6 +446,21 @@ func testEncryptor(kgen KeyGenerator, t *testing.T) {
 		require.GreaterOrEqual(t, 5+params.LogN(), log2OfInnerSum(ciphertext.Level(), ringQ, ciphertext.Value[0]))
 	})
 
+	for _, ed := range []ErrorDistribution{DiscreteGaussianError, CenteredBinomialError} {
+		t.Run(testString(params, fmt.Sprintf("Encrypt/Sk/ErrorDistribution=%s/", ed)), func(t *testing.T) {
+			paramsED, err := params.WithErrorDistribution(ed)
+			require.NoError(t, err)
+			plaintext := NewPlaintext(paramsED, paramsED.MaxLevel())
+			plaintext.Value.IsNTT = true
+			encryptor := NewEncryptor(paramsED, sk)
+			ciphertext := NewCiphertextNTT(paramsED, 1, plaintext.Level())
+			encryptor.Encrypt(plaintext, ciphertext)
+			ringQ.MulCoeffsMontgomeryAndAddLvl(ciphertext.Level(), ciphertext.Value[1], sk.Value.Q, ciphertext.Value[0])
+			ringQ.InvNTTLvl(ciphertext.Level(), ciphertext.Value[0], ciphertext.Value[0])
+			require.GreaterOrEqual(t, 5+params.LogN(), log2OfInnerSum(ciphertext.Level(), ringQ, ciphertext.Value[0]))
+		})
+	}
+
 	t.Run(testString(params, "ShallowCopy/Sk"), func(t *testing.T) {
 		enc1 := NewEncryptor(params, sk)
 		enc2 := enc1.ShallowCopy()
@@ -358,7 +512,8 @@ func testDecryptor(kgen KeyGenerator, t *testing.T) {
 	sk := kgen.GenSecretKey()
 	ringQ := params.RingQ()
 	encryptor := NewEncryptor(params, sk)
-	decryptor := NewDecryptor(params, sk)
+	decryptor, err := NewDecryptor(params, sk)
+	require.NoError(t, err)
 
 	t.Run(testString(params, "Decrypt/MaxLevel"), func(t *testing.T) {
 		plaintext := NewPlaintext(params, params.MaxLevel())
@@ -383,6 +538,57 @@ func testDecryptor(kgen KeyGenerator, t *testing.T) {
 	})
 }
 
+func testMeasureNoise(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+
+	paramsCI, err := NewParametersFromLiteral(ParametersLiteral{
+		LogN:     params.LogN() - 1,
+		Q:        params.Q(),
+		P:        params.P(),
+		H:        params.HammingWeight() >> 1,
+		Sigma:    params.Sigma(),
+		RingType: ring.ConjugateInvariant,
+	})
+	require.NoError(t, err)
+
+	for _, params := range []Parameters{params, paramsCI} {
+
+		t.Run(testString(params, fmt.Sprintf("MeasureNoise/RingType=%s/", params.RingType())), func(t *testing.T) {
+
+			kgen := NewKeyGenerator(params)
+			sk := kgen.GenSecretKey()
+			encryptor := NewEncryptor(params, sk)
+
+			prng, _ := utils.NewPRNG()
+			plaintext := NewPlaintext(params, params.MaxLevel())
+			ring.NewUniformSampler(prng, params.RingQ()).Read(plaintext.Value)
+			plaintext.Value.IsNTT = true
+
+			ciphertext := NewCiphertextNTT(params, 1, plaintext.Level())
+			encryptor.Encrypt(plaintext, ciphertext)
+
+			noise := MeasureNoise(params, ciphertext, sk, plaintext)
+
+			logSigma := math.Log2(params.Sigma())
+
+			// Fresh secret-key encryptions only carry the error e sampled with standard deviation sigma.
+			require.InDelta(t, logSigma, noise.Coefficients.Std, 0.5)
+			require.GreaterOrEqual(t, math.Log2(float64(int(6*params.Sigma())+1)), noise.Coefficients.Max)
+			require.LessOrEqual(t, noise.Coefficients.Min, noise.Coefficients.Max)
+
+			// Each slot is the sum of N (2N for the conjugate invariant ring) errors.
+			logN := float64(params.LogN())
+			if params.RingType() == ring.ConjugateInvariant {
+				logN++
+			}
+
+			require.InDelta(t, logSigma+logN/2, noise.CanonicalEmbedding.Std, 1)
+			require.LessOrEqual(t, noise.CanonicalEmbedding.Min, noise.CanonicalEmbedding.Max)
+		})
+	}
+}
+
 func testKeySwitcher(kgen KeyGenerator, t *testing.T) {
 
 	params := kgen.(*keyGenerator).params
@@ -495,9 +701,149 @@ func testKeySwitcher(kgen KeyGenerator, t *testing.T) {
 			ringQ.InvNTTLvl(ciphertext.Level(), ciphertext.Value[0], ciphertext.Value[0])
 			require.GreaterOrEqual(t, 11+params.LogN(), log2OfInnerSum(ciphertext.Level(), ringQ, ciphertext.Value[0]))
 		})
+
+		// Test that the parallel key-switching yields the same result as the sequential one
+		t.Run(testString(params, "KeySwitch/Parallel/"), func(t *testing.T) {
+			swk := kgen.GenSwitchingKey(sk, skOut)
+			ksPar := NewKeySwitcher(params.WithParallelism(4))
+			require.Equal(t, 4, ksPar.Parallelism())
+
+			ct := NewCiphertextNTT(params, 1, levelQ)
+			encryptor.Encrypt(plaintext, ct)
+
+			ks.SwitchKeysInPlace(levelQ, ct.Value[1], swk, ks.Pool[1].Q, ks.Pool[2].Q)
+			ksPar.SwitchKeysInPlace(levelQ, ct.Value[1], swk, ksPar.Pool[1].Q, ksPar.Pool[2].Q)
+			require.True(t, ringQ.EqualLvl(levelQ, ks.Pool[1].Q, ksPar.Pool[1].Q))
+			require.True(t, ringQ.EqualLvl(levelQ, ks.Pool[2].Q, ksPar.Pool[2].Q))
+
+			ks.DecomposeNTT(levelQ, levelP, alpha, ct.Value[1], ks.PoolDecompQP)
+			ksPar.DecomposeNTT(levelQ, levelP, alpha, ct.Value[1], ksPar.PoolDecompQP)
+			for i := range ks.PoolDecompQP {
+				require.True(t, ringQ.EqualLvl(levelQ, ks.PoolDecompQP[i].Q, ksPar.PoolDecompQP[i].Q))
+				require.True(t, ringP.EqualLvl(levelP, ks.PoolDecompQP[i].P, ksPar.PoolDecompQP[i].P))
+			}
+		})
 	})
 }
 
+func testKeySwitchPow2Base(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+
+	pow2Base := 16
+
+	if params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	t.Run("KeySwitch/Pow2Base/Parameters", func(t *testing.T) {
+		paramsPow2, err := params.WithPow2Base(pow2Base)
+		if params.PCount() > 1 {
+			require.Error(t, err)
+			return
+		}
+		require.NoError(t, err)
+		require.Equal(t, pow2Base, paramsPow2.Pow2Base())
+		require.False(t, params.Equals(paramsPow2))
+
+		bytes, err := paramsPow2.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, paramsPow2.MarshalBinarySize(), len(bytes))
+		var p Parameters
+		require.NoError(t, p.UnmarshalBinary(bytes))
+		require.True(t, paramsPow2.Equals(p))
+
+		data, err := json.Marshal(paramsPow2)
+		require.NoError(t, err)
+		var pJSON Parameters
+		require.NoError(t, json.Unmarshal(data, &pJSON))
+		require.True(t, paramsPow2.Equals(pJSON))
+
+		_, err = params.WithPow2Base(-1)
+		require.Error(t, err)
+		_, err = params.WithPow2Base(64)
+		require.Error(t, err)
+		require.Error(t, checkPow2Base(pow2Base, params.Q(), []uint64{params.P()[0], params.P()[0]}))
+	})
+
+	// Power-of-two decomposition with one modulus in P, and without P.
+	for _, P := range [][]uint64{params.P()[:1], {}} {
+
+		paramsPow2, err := NewParametersFromLiteral(ParametersLiteral{
+			LogN:     params.LogN(),
+			Q:        params.Q(),
+			P:        P,
+			H:        params.HammingWeight(),
+			Sigma:    params.Sigma(),
+			RingType: params.RingType(),
+			Pow2Base: pow2Base,
+		})
+		require.NoError(t, err)
+
+		t.Run(testString(paramsPow2, fmt.Sprintf("KeySwitch/Pow2Base=%d/", pow2Base)), func(t *testing.T) {
+
+			kgen := NewKeyGenerator(paramsPow2)
+			sk := kgen.GenSecretKey()
+			skOut := kgen.GenSecretKey()
+			ks := NewKeySwitcher(paramsPow2)
+
+			ringQ := paramsPow2.RingQ()
+			levelQ := paramsPow2.MaxLevel()
+			levelP := paramsPow2.PCount() - 1
+			decompPw2 := paramsPow2.DecompPw2()
+
+			plaintext := NewPlaintext(paramsPow2, levelQ)
+			plaintext.Value.IsNTT = true
+			encryptor := NewEncryptor(paramsPow2, sk)
+			ciphertext := NewCiphertextNTT(paramsPow2, 1, levelQ)
+			encryptor.Encrypt(plaintext, ciphertext)
+
+			// Checks that the digits recompose c2 mod each qi
+			c2InvNTT := ringQ.NewPolyLvl(levelQ)
+			ringQ.InvNTTLvl(levelQ, ciphertext.Value[1], c2InvNTT)
+			ks.DecomposeNTT(levelQ, levelP, levelP+1, ciphertext.Value[1], ks.PoolDecompQP)
+
+			tmp := ringQ.NewPolyLvl(levelQ)
+			for i := 0; i < paramsPow2.Beta(); i++ {
+				recomposed := make([]uint64, ringQ.N)
+				for j := decompPw2 - 1; j >= 0; j-- {
+					ringQ.ReduceLvl(levelQ, ks.PoolDecompQP[i*decompPw2+j].Q, tmp)
+					ringQ.InvNTTLvl(levelQ, tmp, tmp)
+					for x := range recomposed {
+						require.Less(t, tmp.Coeffs[0][x], uint64(1)<<pow2Base)
+						recomposed[x] = recomposed[x]<<pow2Base + tmp.Coeffs[0][x]
+					}
+				}
+				require.Equal(t, c2InvNTT.Coeffs[i], recomposed)
+			}
+
+			// Checks that Dec(KS(Enc(ct, sk), skOut), skOut) has a small norm
+			swk := kgen.GenSwitchingKey(sk, skOut)
+			require.Equal(t, paramsPow2.Beta()*decompPw2, len(swk.Value))
+			require.Equal(t, levelP, swk.LevelP())
+
+			ks.SwitchKeysInPlace(levelQ, ciphertext.Value[1], swk, ks.Pool[1].Q, ks.Pool[2].Q)
+			ringQ.Add(ciphertext.Value[0], ks.Pool[1].Q, ciphertext.Value[0])
+			ring.CopyValues(ks.Pool[2].Q, ciphertext.Value[1])
+			ringQ.MulCoeffsMontgomeryAndAddLvl(levelQ, ciphertext.Value[1], skOut.Value.Q, ciphertext.Value[0])
+			ringQ.InvNTTLvl(levelQ, ciphertext.Value[0], ciphertext.Value[0])
+
+			// Without P, the error of the key-switching grows with the base of the decomposition
+			log2Bound := 11 + paramsPow2.LogN()
+			if paramsPow2.PCount() == 0 {
+				log2Bound = pow2Base + 2*paramsPow2.LogN() + 5
+			}
+			require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(levelQ, ringQ, ciphertext.Value[0]))
+
+			data, err := swk.MarshalBinary()
+			require.NoError(t, err)
+			resSwitchingKey := new(SwitchingKey)
+			require.NoError(t, resSwitchingKey.UnmarshalBinary(data))
+			require.True(t, swk.Equals(resSwitchingKey))
+		})
+	}
+}
+
 func testKeySwitchDimension(kgen KeyGenerator, t *testing.T) {
 
 	paramsLargeDim := kgen.(*keyGenerator).params
@@ -603,6 +949,47 @@ func testMarshaller(kgen KeyGenerator, t *testing.T) {
 		assert.Equal(t, params.RingQ(), p.RingQ())
 	})
 
+	t.Run("Marshaller/Parameters/Legacy", func(t *testing.T) {
+
+		// Encoding of the bfv.PN12QP109 parameters produced by v3.0.1
+		data, err := hex.DecodeString("0c02010000000000000800400999999999999a000000007ffffec00100000080000160010000000040002001")
+		require.NoError(t, err)
+
+		paramsWant, err := NewParameters(12, []uint64{0x7ffffec001, 0x8000016001}, []uint64{0x40002001}, 2048, 3.2, ring.Standard)
+		require.NoError(t, err)
+
+		var p Parameters
+		require.NoError(t, p.UnmarshalBinary(data))
+		require.True(t, paramsWant.Equals(p))
+		require.Equal(t, paramsWant.Fingerprint(), p.Fingerprint())
+
+		// The legacy layout is only accepted without Envelope
+		require.Error(t, p.UnmarshalBinary(NewEnvelope(KindParameters, nil, 0).Seal(data)))
+		require.Error(t, p.UnmarshalBinary(data[:len(data)-1]))
+	})
+
+	t.Run("Marshaller/Parameters/ErrorDistribution", func(t *testing.T) {
+		paramsED, err := params.WithErrorDistribution(CenteredBinomialError)
+		require.NoError(t, err)
+
+		bytes, err := paramsED.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, paramsED.MarshalBinarySize(), len(bytes))
+		var p Parameters
+		require.NoError(t, p.UnmarshalBinary(bytes))
+		require.True(t, paramsED.Equals(p))
+		require.False(t, params.Equals(p))
+
+		data, err := json.Marshal(paramsED)
+		require.NoError(t, err)
+		var pJSON Parameters
+		require.NoError(t, json.Unmarshal(data, &pJSON))
+		require.Equal(t, CenteredBinomialError, pJSON.ErrorDistribution())
+
+		_, err = NewParametersFromLiteral(ParametersLiteral{LogN: params.LogN(), Q: params.Q(), P: params.P(), Sigma: 6, ErrorDistribution: CenteredBinomialError})
+		require.Error(t, err)
+	})
+
 	t.Run("Marshaller/Parameters/JSON", func(t *testing.T) {
 		// checks that parameters can be marshalled without error
 		data, err := json.Marshal(params)
@@ -616,6 +1003,25 @@ func testMarshaller(kgen KeyGenerator, t *testing.T) {
 		assert.True(t, params.Equals(rlweParams))
 	})
 
+	t.Run("Marshaller/Parameters/JSON/RingType", func(t *testing.T) {
+		paramsCI, err := NewParametersFromLiteral(ParametersLiteral{LogN: params.LogN(), LogQ: []int{50, 40}, LogP: []int{50}, RingType: ring.ConjugateInvariant})
+		require.NoError(t, err)
+
+		data, err := json.Marshal(paramsCI)
+		require.NoError(t, err)
+		var pJSON Parameters
+		require.NoError(t, json.Unmarshal(data, &pJSON))
+		require.True(t, paramsCI.Equals(pJSON))
+		require.Equal(t, ring.ConjugateInvariant, pJSON.RingType())
+
+		// The optional fields are omitted when they take their default value
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &fields))
+		for _, field := range []string{"ErrorDistribution", "Pow2Base", "MinSecurity"} {
+			require.NotContains(t, fields, field)
+		}
+	})
+
 	t.Run(testString(params, "Marshaller/Ciphertext"), func(t *testing.T) {
 
 		prng, _ := utils.NewPRNG()
@@ -728,3 +1134,618 @@ func testMarshaller(kgen KeyGenerator, t *testing.T) {
 		rotationKey.Equals(resRotationKey)
 	})
 }
+
+func testEnvelope(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+
+	sk, pk := kgen.GenKeyPair()
+
+	prng, _ := utils.NewPRNG()
+
+	objects := map[string]encoding.BinaryMarshaler{
+		"Parameters": params,
+		"Ciphertext": NewCiphertextRandom(prng, params, 1, params.MaxLevel()),
+		"Sk":         sk,
+		"Pk":         pk,
+	}
+
+	newObject := map[string]func() encoding.BinaryUnmarshaler{
+		"Parameters":         func() encoding.BinaryUnmarshaler { return new(Parameters) },
+		"Ciphertext":         func() encoding.BinaryUnmarshaler { return new(Ciphertext) },
+		"Sk":                 func() encoding.BinaryUnmarshaler { return NewSecretKey(params) },
+		"Pk":                 func() encoding.BinaryUnmarshaler { return NewPublicKey(params) },
+		"RelinearizationKey": func() encoding.BinaryUnmarshaler { return new(RelinearizationKey) },
+		"RotationKeySet":     func() encoding.BinaryUnmarshaler { return new(RotationKeySet) },
+	}
+
+	if params.PCount() != 0 {
+		objects["RelinearizationKey"] = kgen.GenRelinearizationKey(sk, 1)
+		objects["RotationKeySet"] = kgen.GenRotationKeys([]uint64{params.GaloisElementForColumnRotationBy(1)}, sk)
+	}
+
+	t.Run(testString(params, "Envelope/Header"), func(t *testing.T) {
+		for name, obj := range objects {
+			data, err := obj.MarshalBinary()
+			require.NoError(t, err)
+			env, payload, err := DecodeEnvelope(data)
+			require.NoError(t, err, name)
+			require.False(t, env.IsLegacy())
+			require.Equal(t, name, map[ObjectKind]string{KindParameters: "Parameters", KindCiphertext: "Ciphertext", KindSecretKey: "Sk",
+				KindPublicKey: "Pk", KindRelinearizationKey: "RelinearizationKey", KindRotationKeySet: "RotationKeySet"}[env.Kind])
+			require.Equal(t, params.Fingerprint(), env.ParametersHash, name)
+			require.Equal(t, params.LogN(), env.LogN)
+			require.Equal(t, params.MaxLevel(), env.Level)
+			require.Equal(t, len(data)-EnvelopeHeaderLen, len(payload))
+			require.NoError(t, params.CheckEnvelope(data))
+
+			// The payload length is encoded on 64 bits
+			corrupted := append([]byte{}, data...)
+			corrupted[18] ^= 1
+			_, _, err = DecodeEnvelope(corrupted)
+			require.Error(t, err, name)
+		}
+	})
+
+	t.Run(testString(params, "Envelope/Legacy"), func(t *testing.T) {
+		for name, obj := range objects {
+			data, err := obj.MarshalBinary()
+			require.NoError(t, err)
+
+			// The legacy encodings are the payloads of the envelopes
+			objNew, objLegacy := newObject[name](), newObject[name]()
+			require.NoError(t, objNew.UnmarshalBinary(data), name)
+			require.NoError(t, objLegacy.UnmarshalBinary(data[EnvelopeHeaderLen:]), name)
+			require.NoError(t, params.CheckEnvelope(data[EnvelopeHeaderLen:]))
+
+			// The objects decoded from legacy encodings only differ by their unknown parameters fingerprint
+			dataNew, err := objNew.(encoding.BinaryMarshaler).MarshalBinary()
+			require.NoError(t, err)
+			dataLegacy, err := objLegacy.(encoding.BinaryMarshaler).MarshalBinary()
+			require.NoError(t, err)
+			require.Equal(t, data, dataNew, name)
+			require.Equal(t, data[EnvelopeHeaderLen:], dataLegacy[EnvelopeHeaderLen:], name)
+		}
+	})
+
+	t.Run(testString(params, "Envelope/Kind"), func(t *testing.T) {
+		data, err := objects["Ciphertext"].MarshalBinary()
+		require.NoError(t, err)
+		require.Error(t, NewSecretKey(params).UnmarshalBinary(data))
+		require.Error(t, new(Parameters).UnmarshalBinary(data))
+		require.Error(t, new(RotationKeySet).UnmarshalBinary(data))
+	})
+
+	t.Run(testString(params, "Envelope/CheckEnvelope"), func(t *testing.T) {
+
+		data, err := NewCiphertextRandom(prng, params, 2, 0).MarshalBinary()
+		require.NoError(t, err)
+		require.NoError(t, params.CheckEnvelope(data))
+
+		// Different ring degree
+		paramsOther, err := NewParameters(params.LogN()-1, params.Q(), params.P(), params.HammingWeight(), params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		require.Error(t, paramsOther.CheckEnvelope(data))
+
+		// Level larger than the maximum level
+		data, err = NewCiphertextRandom(prng, params, 1, params.MaxLevel()).MarshalBinary()
+		require.NoError(t, err)
+		paramsOther, err = NewParameters(params.LogN(), params.Q()[:1], params.P(), params.HammingWeight(), params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		require.True(t, params.MaxLevel() == 0 || paramsOther.CheckEnvelope(data) != nil)
+
+		// Different ring type
+		paramsData, err := params.MarshalBinary()
+		require.NoError(t, err)
+		paramsData[14] ^= 1
+		require.Error(t, params.CheckEnvelope(paramsData))
+
+		// Unsupported version
+		data[4] = EnvelopeVersion + 1
+		_, _, err = DecodeEnvelope(data)
+		require.Error(t, err)
+		require.Error(t, params.CheckEnvelope(data))
+		require.Error(t, new(Ciphertext).UnmarshalBinary(data))
+	})
+
+	t.Run(testString(params, "Envelope/UnmarshalObject"), func(t *testing.T) {
+
+		// Parameters with the same ring degree and moduli but another standard deviation of the error distribution
+		paramsOther, err := NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+
+		// The encryptor records the parameters of the ciphertexts
+		ciphertext := &Ciphertext{Value: []*ring.Poly{params.RingQ().NewPoly(), params.RingQ().NewPoly()}}
+		NewEncryptor(params, sk).Encrypt(NewPlaintext(params, params.MaxLevel()), ciphertext)
+		require.Equal(t, params.Fingerprint(), ciphertext.ParametersFingerprint)
+		require.Equal(t, params.Fingerprint(), ciphertext.CopyNew().ParametersFingerprint)
+
+		data, err := ciphertext.MarshalBinary()
+		require.NoError(t, err)
+
+		ciphertextNew := new(Ciphertext)
+		require.NoError(t, params.UnmarshalObject(data, ciphertextNew))
+		require.Equal(t, ciphertext, ciphertextNew)
+
+		// Objects encoded under other parameters with the same ring degree and level are rejected
+		require.Error(t, paramsOther.UnmarshalObject(data, new(Ciphertext)))
+		for name, obj := range objects {
+			data, err := obj.MarshalBinary()
+			require.NoError(t, err)
+			require.NoError(t, params.UnmarshalObject(data, newObject[name]()), name)
+			require.Error(t, paramsOther.UnmarshalObject(data, newObject[name]()), name)
+		}
+
+		// Legacy encodings cannot be checked
+		require.Error(t, params.UnmarshalObject(data[EnvelopeHeaderLen:], new(Ciphertext)))
+	})
+
+	// The truncated and randomly corrupted encodings below stand in for native fuzz targets (testing.F),
+	// which require Go 1.18 while the go.mod of the module targets Go 1.13.
+	t.Run(testString(params, "Envelope/Malformed"), func(t *testing.T) {
+
+		source := rand.New(rand.NewSource(0))
+
+		for name, obj := range objects {
+
+			data, err := obj.MarshalBinary()
+			require.NoError(t, err)
+
+			for _, encoding := range [][]byte{data, data[EnvelopeHeaderLen:]} {
+
+				// Truncated encodings must be rejected
+				cuts := []int{0, 1, 3, 4, len(encoding) - 1}
+				for i := 0; i < EnvelopeHeaderLen+8; i++ {
+					cuts = append(cuts, i)
+				}
+				for i := 0; i < 32; i++ {
+					cuts = append(cuts, source.Intn(len(encoding)))
+				}
+
+				for _, cut := range cuts {
+					if cut == 0 && name == "RotationKeySet" {
+						continue // the legacy encoding of an empty RotationKeySet is empty
+					}
+					require.NotPanics(t, func() {
+						require.Error(t, newObject[name]().UnmarshalBinary(encoding[:cut]), "%s truncated at %d", name, cut)
+					})
+				}
+
+				// Corrupted encodings must not panic
+				for i := 0; i < 64; i++ {
+					corrupted := append([]byte{}, encoding...)
+					pos := source.Intn(utils.MinInt(len(corrupted), EnvelopeHeaderLen+16))
+					if i&1 == 1 {
+						pos = source.Intn(len(corrupted))
+					}
+					corrupted[pos] ^= byte(1 + source.Intn(255))
+					require.NotPanics(t, func() { newObject[name]().UnmarshalBinary(corrupted) }, "%s corrupted at %d", name, pos)
+				}
+			}
+		}
+	})
+}
+
+func testFingerprint(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+
+	// Parameters with the same ring degree but another standard deviation of the error distribution
+	paramsOther, err := NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight(), 2*params.Sigma(), params.RingType())
+	require.NoError(t, err)
+
+	sk := kgen.GenSecretKey()
+	skOther := NewKeyGenerator(paramsOther).GenSecretKey()
+
+	t.Run(testString(params, "Fingerprint/Parameters"), func(t *testing.T) {
+
+		require.NotEqual(t, uint64(0), params.Fingerprint())
+
+		paramsCopy, err := NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight(), params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		require.True(t, params.Equals(paramsCopy))
+		require.Equal(t, params.Fingerprint(), paramsCopy.Fingerprint())
+
+		data, err := params.MarshalBinary()
+		require.NoError(t, err)
+		paramsNew := new(Parameters)
+		require.NoError(t, paramsNew.UnmarshalBinary(data))
+		require.Equal(t, params.Fingerprint(), paramsNew.Fingerprint())
+
+		// Parameters that differ by their Hamming weight or their modulus Q
+		paramsH, err := NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight()/2, params.Sigma(), params.RingType())
+		require.NoError(t, err)
+		paramsQ, err := NewParameters(params.LogN(), params.Q()[:1], params.P(), params.HammingWeight(), params.Sigma(), params.RingType())
+		require.NoError(t, err)
+
+		for _, p := range []Parameters{paramsOther, paramsH, paramsQ} {
+			require.NotEqual(t, params.Fingerprint(), p.Fingerprint())
+		}
+
+		// The cached fingerprint follows the derived parameters
+		for _, p := range []Parameters{params, *paramsNew, paramsOther, paramsH, paramsQ} {
+			require.Equal(t, p.computeFingerprint(), p.Fingerprint())
+		}
+		paramsPow2, err := params.WithPow2Base(params.Pow2Base())
+		require.NoError(t, err)
+		require.Equal(t, params.Fingerprint(), paramsPow2.Fingerprint())
+		paramsDist, err := params.WithErrorDistribution((params.ErrorDistribution() + 1) % 3)
+		if err == nil {
+			require.Equal(t, paramsDist.computeFingerprint(), paramsDist.Fingerprint())
+			require.NotEqual(t, params.Fingerprint(), paramsDist.Fingerprint())
+		}
+
+		// The fingerprint of the parameters is carried by the envelope and checked on decoding
+		binary.BigEndian.PutUint64(data[6:], params.Fingerprint()^1)
+		require.Error(t, new(Parameters).UnmarshalBinary(data))
+		require.Error(t, params.CheckEnvelope(data))
+	})
+
+	t.Run(testString(params, "Fingerprint/Keys"), func(t *testing.T) {
+
+		require.Equal(t, params.Fingerprint(), sk.ParametersFingerprint)
+		require.Equal(t, params.Fingerprint(), sk.CopyNew().ParametersFingerprint)
+		require.NoError(t, params.CheckSecretKey(sk))
+
+		data, err := sk.MarshalBinary()
+		require.NoError(t, err)
+		require.NoError(t, params.CheckEnvelope(data))
+		skNew := new(SecretKey)
+		require.NoError(t, skNew.UnmarshalBinary(data))
+		require.Equal(t, sk.ParametersFingerprint, skNew.ParametersFingerprint)
+
+		// A key of other parameters with the same ring degree is rejected
+		data, err = skOther.MarshalBinary()
+		require.NoError(t, err)
+		require.Error(t, params.CheckEnvelope(data))
+	})
+
+	t.Run(testString(params, "Fingerprint/NewDecryptor"), func(t *testing.T) {
+
+		_, err := NewDecryptor(params, skOther)
+		require.Error(t, err)
+
+		_, err = NewDecryptor(params, nil)
+		require.Error(t, err)
+
+		// A key with an unknown fingerprint is only checked against the shape of the parameters
+		skLegacy := sk.CopyNew()
+		skLegacy.ParametersFingerprint = 0
+		_, err = NewDecryptor(params, skLegacy)
+		require.NoError(t, err)
+
+		skLegacy.Value.Q = ring.NewPoly(params.N()/2, params.QCount())
+		_, err = NewDecryptor(params, skLegacy)
+		require.Error(t, err)
+
+		// WithKey checks the key as NewDecryptor
+		decryptor, err := NewDecryptor(params, sk)
+		require.NoError(t, err)
+		require.Panics(t, func() { decryptor.WithKey(skOther) })
+		require.Panics(t, func() { decryptor.WithKey(skLegacy) })
+		require.NotPanics(t, func() { decryptor.WithKey(sk.CopyNew()) })
+	})
+
+	if params.PCount() == 0 {
+		return
+	}
+
+	t.Run(testString(params, "Fingerprint/CheckEvaluationKey"), func(t *testing.T) {
+
+		rlk := kgen.GenRelinearizationKey(sk, 1)
+		rtks := kgen.GenRotationKeys([]uint64{params.GaloisElementForColumnRotationBy(1)}, sk)
+		require.NoError(t, params.CheckEvaluationKey(EvaluationKey{Rlk: rlk, Rtks: rtks}))
+
+		rlkOther := NewKeyGenerator(paramsOther).GenRelinearizationKey(skOther, 1)
+		require.Error(t, params.CheckEvaluationKey(EvaluationKey{Rlk: rlkOther, Rtks: rtks}))
+
+		rtks.Keys[params.GaloisElementForColumnRotationBy(1)].Value = rtks.Keys[params.GaloisElementForColumnRotationBy(1)].Value[1:]
+		require.Error(t, params.CheckEvaluationKey(EvaluationKey{Rlk: rlk, Rtks: rtks}))
+	})
+}
+
+func testRotationKeyProvider(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+
+	if params.PCount() == 0 {
+		return
+	}
+
+	sk := kgen.GenSecretKey()
+
+	galEls := []uint64{params.GaloisElementForColumnRotationBy(1), params.GaloisElementForColumnRotationBy(2), params.GaloisElementForColumnRotationBy(3)}
+	rtks := kgen.GenRotationKeys(galEls, sk)
+	missing := params.GaloisElementForColumnRotationBy(4)
+
+	verifyProvider := func(t *testing.T, provider RotationKeyProvider) {
+		require.ElementsMatch(t, galEls, provider.GaloisElements())
+		for _, galEl := range galEls {
+			rtk, err := provider.RotationKey(galEl)
+			require.NoError(t, err)
+			require.True(t, rtk.Equals(rtks.Keys[galEl]))
+		}
+		_, err := provider.RotationKey(missing)
+		require.True(t, errors.Is(err, ErrMissingRotationKey))
+	}
+
+	t.Run(testString(params, "RotationKeyProvider/RotationKeySet/"), func(t *testing.T) {
+		verifyProvider(t, rtks)
+	})
+
+	t.Run(testString(params, "RotationKeyProvider/Directory/"), func(t *testing.T) {
+		path, err := ioutil.TempDir("", "rtks")
+		require.NoError(t, err)
+		defer os.RemoveAll(path)
+
+		require.NoError(t, WriteRotationKeyDirectory(path, rtks))
+
+		dir, err := NewRotationKeyDirectory(path)
+		require.NoError(t, err)
+		verifyProvider(t, dir)
+	})
+
+	t.Run(testString(params, "RotationKeyProvider/Reader/"), func(t *testing.T) {
+		buff := new(bytes.Buffer)
+		require.NoError(t, WriteRotationKeys(buff, rtks))
+
+		reader, err := NewRotationKeyReader(bytes.NewReader(buff.Bytes()))
+		require.NoError(t, err)
+		verifyProvider(t, reader)
+
+		_, err = NewRotationKeyReader(bytes.NewReader(buff.Bytes()[:16]))
+		require.Error(t, err)
+
+		// The size of the input must be known
+		_, err = NewRotationKeyReader(struct{ io.ReaderAt }{bytes.NewReader(buff.Bytes())})
+		require.Error(t, err)
+
+		// Number of keys and size of a key beyond the size of the input
+		for _, off := range []int{0, 8 + 16} {
+			data := append([]byte{}, buff.Bytes()...)
+			binary.BigEndian.PutUint64(data[off:], 1<<62)
+			_, err = NewRotationKeyReader(bytes.NewReader(data))
+			require.Error(t, err)
+		}
+	})
+
+	t.Run(testString(params, "RotationKeyProvider/Cache/"), func(t *testing.T) {
+
+		loads := map[uint64]int{}
+		cache := NewRotationKeyCache(params, countingProvider{rtks, loads}, 2)
+		verifyProvider(t, cache)
+		require.Equal(t, 2, cache.Len())
+
+		// galEls[1] and galEls[2] are in the cache, galEls[0] was evicted
+		_, err := cache.RotationKey(galEls[2])
+		require.NoError(t, err)
+		_, err = cache.RotationKey(galEls[1])
+		require.NoError(t, err)
+		require.Equal(t, map[uint64]int{galEls[0]: 1, galEls[1]: 1, galEls[2]: 1, missing: 1}, loads)
+
+		// galEls[0] evicts galEls[2], the least recently used key
+		_, err = cache.RotationKey(galEls[0])
+		require.NoError(t, err)
+		_, err = cache.RotationKey(galEls[1])
+		require.NoError(t, err)
+		_, err = cache.RotationKey(galEls[2])
+		require.NoError(t, err)
+		require.Equal(t, map[uint64]int{galEls[0]: 2, galEls[1]: 1, galEls[2]: 2, missing: 1}, loads)
+	})
+
+	t.Run(testString(params, "RotationKeyProvider/Cache/OtherParameters/"), func(t *testing.T) {
+
+		paramsOther, err := NewParameters(params.LogN(), params.Q(), params.P(), params.HammingWeight(), 2*params.Sigma(), params.RingType())
+		require.NoError(t, err)
+
+		kgenOther := NewKeyGenerator(paramsOther)
+		rtksOther := kgenOther.GenRotationKeys(galEls, kgenOther.GenSecretKey())
+
+		// Keys of other parameters are rejected and are not added to the cache
+		loads := map[uint64]int{}
+		cache := NewRotationKeyCache(params, countingProvider{rtksOther, loads}, 2)
+		for i := 0; i < 2; i++ {
+			_, err = cache.RotationKey(galEls[0])
+			require.Error(t, err)
+			require.False(t, errors.Is(err, ErrMissingRotationKey))
+		}
+		require.Equal(t, 0, cache.Len())
+		require.Equal(t, map[uint64]int{galEls[0]: 2}, loads)
+	})
+
+	t.Run(testString(params, "RotationKeyProvider/Cache/Concurrent/"), func(t *testing.T) {
+
+		provider := &blockingProvider{RotationKeySet: rtks, galEl: galEls[0], loading: make(chan struct{}, 2), release: make(chan struct{}), loads: map[uint64]int{}}
+		cache := NewRotationKeyCache(params, provider, 2)
+
+		// Concurrent requests of a key that is being loaded wait for the same load
+		results := make(chan *SwitchingKey, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				rtk, err := cache.RotationKey(galEls[0])
+				require.NoError(t, err)
+				results <- rtk
+			}()
+		}
+
+		// The cache is not locked while a key is loaded
+		<-provider.loading
+		rtk, err := cache.RotationKey(galEls[1])
+		require.NoError(t, err)
+		require.True(t, rtk.Equals(rtks.Keys[galEls[1]]))
+
+		close(provider.release)
+		for i := 0; i < 2; i++ {
+			require.True(t, (<-results).Equals(rtks.Keys[galEls[0]]))
+		}
+
+		require.Equal(t, map[uint64]int{galEls[0]: 1, galEls[1]: 1}, provider.loads)
+		require.Equal(t, 2, cache.Len())
+	})
+}
+
+// blockingProvider is a RotationKeyProvider counting the number of loads of each key, whose loads
+// of the key of galEl are signaled on loading and block until release is closed.
+type blockingProvider struct {
+	*RotationKeySet
+	galEl   uint64
+	loading chan struct{}
+	release chan struct{}
+	mutex   sync.Mutex
+	loads   map[uint64]int
+}
+
+func (p *blockingProvider) RotationKey(galEl uint64) (*SwitchingKey, error) {
+	p.mutex.Lock()
+	p.loads[galEl]++
+	p.mutex.Unlock()
+	if galEl == p.galEl {
+		p.loading <- struct{}{}
+		<-p.release
+	}
+	return p.RotationKeySet.RotationKey(galEl)
+}
+
+// countingProvider is a RotationKeyProvider counting the number of loads of each key.
+type countingProvider struct {
+	*RotationKeySet
+	loads map[uint64]int
+}
+
+func (p countingProvider) RotationKey(galEl uint64) (*SwitchingKey, error) {
+	p.loads[galEl]++
+	return p.RotationKeySet.RotationKey(galEl)
+}
+
+func testRGSW(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+
+	testParams := []Parameters{}
+
+	if params.PCount() != 0 {
+		testParams = append(testParams, params)
+	}
+
+	// Without P, with the power-of-two decomposition
+	if paramsNoP, err := NewParametersFromLiteral(ParametersLiteral{LogN: params.LogN(), Q: params.Q(), P: []uint64{}, H: params.HammingWeight(), Sigma: params.Sigma(), Pow2Base: 16}); err == nil {
+		testParams = append(testParams, paramsNoP)
+	}
+
+	for _, params := range testParams {
+		testRGSWWithParams(params, t)
+	}
+}
+
+func testRGSWWithParams(params Parameters, t *testing.T) {
+
+	kgen := NewKeyGenerator(params)
+	ringQ := params.RingQ()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	sk := kgen.GenSecretKey()
+	encryptor := NewEncryptor(params, sk)
+	encryptorRGSW := NewRGSWEncryptor(params, sk)
+	ks := NewKeySwitcher(params)
+
+	prng, _ := utils.NewPRNG()
+
+	// Returns an RGSW encryption of X^k
+	encryptMonomial := func(k int) *RGSWCiphertext {
+		pt := NewPlaintext(params, levelQ)
+		for i := range pt.Value.Coeffs {
+			pt.Value.Coeffs[i][k] = 1
+		}
+		ct := NewRGSWCiphertext(params, levelQ, levelP)
+		encryptorRGSW.Encrypt(pt, ct)
+		return ct
+	}
+
+	// Returns a uniform plaintext and its encryption
+	newTestVector := func() (*Plaintext, *Ciphertext) {
+		pt := NewPlaintext(params, levelQ)
+		ring.NewUniformSampler(prng, ringQ).Read(pt.Value)
+		ct := NewCiphertextNTT(params, 1, levelQ)
+		encryptor.Encrypt(pt, ct)
+		return pt, ct
+	}
+
+	// The noise of the products is of the order of the key-switching noise, which grows
+	// with the size of the power-of-two digits if P is empty.
+	noiseBound := float64(params.LogN() + 10)
+	if params.PCount() == 0 {
+		noiseBound += float64(params.Pow2Base())
+	}
+
+	t.Run(testString(params, fmt.Sprintf("RGSW/Pow2Base=%d/", params.Pow2Base())+"ExternalProduct/"), func(t *testing.T) {
+
+		pt, ct := newTestVector()
+
+		ks.ExternalProduct(ct, encryptMonomial(3), ct)
+
+		ptWant := NewPlaintext(params, levelQ)
+		ringQ.MultByMonomial(pt.Value, 3, ptWant.Value)
+
+		require.True(t, ct.Value[0].IsNTT)
+		require.Less(t, MeasureNoise(params, ct, sk, ptWant).Coefficients.Max, noiseBound)
+	})
+
+	t.Run(testString(params, fmt.Sprintf("RGSW/Pow2Base=%d/", params.Pow2Base())+"CMux/"), func(t *testing.T) {
+
+		pt0, ct0 := newTestVector()
+		pt1, ct1 := newTestVector()
+
+		zero := NewRGSWCiphertext(params, levelQ, levelP)
+		encryptorRGSW.Encrypt(nil, zero)
+
+		ctOut := NewCiphertextNTT(params, 1, levelQ)
+
+		ks.CMux(zero, ct0, ct1, ctOut)
+		require.Less(t, MeasureNoise(params, ctOut, sk, pt0).Coefficients.Max, noiseBound)
+
+		ks.CMux(encryptMonomial(0), ct0, ct1, ctOut)
+		require.Less(t, MeasureNoise(params, ctOut, sk, pt1).Coefficients.Max, noiseBound)
+	})
+
+	t.Run(testString(params, fmt.Sprintf("RGSW/Pow2Base=%d/", params.Pow2Base())+"InternalProduct/"), func(t *testing.T) {
+
+		if levelP > -1 {
+			require.Panics(t, func() {
+				ks.InternalProduct(encryptMonomial(2), encryptMonomial(5), NewRGSWCiphertext(params, levelQ, levelP))
+			})
+			return
+		}
+
+		pt, ct := newTestVector()
+
+		rgsw := encryptMonomial(5)
+		ks.InternalProduct(encryptMonomial(2), rgsw, rgsw)
+		ks.ExternalProduct(ct, rgsw, ct)
+
+		ptWant := NewPlaintext(params, levelQ)
+		ringQ.MultByMonomial(pt.Value, 7, ptWant.Value)
+
+		// The rows of rgsw have the noise of an external product, sum_k d_k * e_k, where the d_k are the digits of the
+		// rows of the second operand and the e_k the noise of the first operand. The digits are not centered, so the
+		// noise of the second external product, sum_k (sum_j d'_j * d_{j, k}) * e_k, is dominated by the mean of the
+		// products of the digits: with n = 2 * #digits * N and B = 2^Pow2Base, it is at most n * B^2/4 per coefficient,
+		// and the bound is taken at 8 standard deviations of its product with the noise of the RGSW encryption.
+		n := float64(2 * params.DecompRNS(levelQ, levelP) * params.DecompPw2() * params.N())
+		bound := 8 * math.Sqrt(n) * params.Sigma() * n * math.Exp2(float64(2*params.Pow2Base()-2))
+
+		require.Less(t, MeasureNoise(params, ct, sk, ptWant).Coefficients.Max, math.Log2(bound))
+	})
+
+	t.Run(testString(params, fmt.Sprintf("RGSW/Pow2Base=%d/", params.Pow2Base())+"Marshaller/"), func(t *testing.T) {
+
+		ct := encryptMonomial(1)
+
+		data, err := ct.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, EnvelopeHeaderLen+ct.GetDataLen(true), len(data))
+
+		ctNew := new(RGSWCiphertext)
+		require.NoError(t, ctNew.UnmarshalBinary(data))
+		require.True(t, ct.Equals(ctNew))
+	})
+}
@@ -0,0 +1,150 @@
+package rlwe
+
+import (
+	"sort"
+)
+
+// RotationDecomposer decomposes the column rotations into minimal sequences of the rotations for which a
+// rotation key is available, so that a rotation by any amount can be evaluated with a small set of rotation
+// keys (for example, the power-of-two rotation keys) at the cost of additional key-switches.
+type RotationDecomposer struct {
+	// modulus is the order of the group of column rotations.
+	modulus int
+	// last[k] is the last rotation of a minimal sequence of available rotations summing to k, or 0 if
+	// no sequence sums to k.
+	last []int
+}
+
+// NewRotationDecomposer creates a new RotationDecomposer for the column rotations whose Galois element is in galEls.
+// Galois elements that do not correspond to a column rotation (e.g. the row rotation) are ignored.
+func NewRotationDecomposer(params Parameters, galEls []uint64) *RotationDecomposer {
+
+	modulus := params.rotationsModulus()
+
+	// Maps the Galois elements of the column rotations to their rotation amount
+	rotations := make(map[uint64]int, modulus)
+	galEl := uint64(1)
+	for k := 0; k < modulus; k++ {
+		rotations[galEl] = k
+		galEl = (galEl * GaloisGen) & (params.RingQ().NthRoot - 1)
+	}
+
+	available := []int{}
+	for _, galEl := range galEls {
+		if k, isRotation := rotations[galEl]; isRotation && k != 0 {
+			available = append(available, k)
+		}
+	}
+
+	return newRotationDecomposer(modulus, available)
+}
+
+// newRotationDecomposer computes the minimal sequences of the available rotations reaching each rotation
+// of the group of order modulus with a breadth-first search.
+func newRotationDecomposer(modulus int, available []int) *RotationDecomposer {
+
+	sort.Ints(available)
+
+	rd := &RotationDecomposer{modulus: modulus, last: make([]int, modulus)}
+
+	queue := make([]int, 1, modulus)
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		for _, r := range available {
+			if next := (k + r) % modulus; next != 0 && rd.last[next] == 0 {
+				rd.last[next] = r
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return rd
+}
+
+// Decompose returns a minimal sequence of available rotations whose composition is the rotation by k.
+// The sequence is empty if k is a multiple of the order of the group of column rotations.
+// The second output is false if the rotation by k cannot be obtained from the available rotations.
+func (rd *RotationDecomposer) Decompose(k int) (rotations []int, ok bool) {
+
+	k = ((k % rd.modulus) + rd.modulus) % rd.modulus
+
+	for k != 0 {
+
+		r := rd.last[k]
+
+		if r == 0 {
+			return nil, false
+		}
+
+		rotations = append(rotations, r)
+		k = (k - r + rd.modulus) % rd.modulus
+	}
+
+	return rotations, true
+}
+
+// RotationsCoveringSet returns a small set of rotations whose rotation keys allow to evaluate all the given
+// rotations by composing them with a RotationDecomposer, and the total number of additional key-switches
+// that this incurs compared to generating a rotation key for each of the given rotations.
+// The set is the union of the signed powers of two of the non-adjacent forms of the rotations, unless
+// the given rotations are fewer, in which case they are returned as is.
+func (p Parameters) RotationsCoveringSet(rotations []int) (keys []int, extraKeySwitches int) {
+
+	modulus := p.rotationsModulus()
+
+	distinct := map[int]bool{}
+	for _, k := range rotations {
+		if k = ((k % modulus) + modulus) % modulus; k != 0 {
+			distinct[k] = true
+		}
+	}
+
+	cover := map[int]bool{}
+	for k := range distinct {
+		// Takes the non-adjacent form of lowest weight between k and k - modulus
+		naf, nafNeg := nonAdjacentForm(k), nonAdjacentForm(k-modulus)
+		if len(nafNeg) < len(naf) {
+			naf = nafNeg
+		}
+		for _, r := range naf {
+			cover[((r%modulus)+modulus)%modulus] = true
+		}
+	}
+
+	if len(distinct) <= len(cover) {
+		cover = distinct
+	}
+
+	for k := range cover {
+		keys = append(keys, k)
+	}
+
+	sort.Ints(keys)
+
+	rd := newRotationDecomposer(modulus, keys)
+	for k := range distinct {
+		decomposition, _ := rd.Decompose(k)
+		extraKeySwitches += len(decomposition) - 1
+	}
+
+	return
+}
+
+// rotationsModulus returns the order of the group of column rotations.
+func (p Parameters) rotationsModulus() int {
+	return int(p.RingQ().NthRoot >> 2)
+}
+
+// nonAdjacentForm returns the signed powers of two of the non-adjacent form of k.
+func nonAdjacentForm(k int) (naf []int) {
+	for i := 0; k != 0; i, k = i+1, k>>1 {
+		if k&1 == 1 {
+			// d = 2 - (k mod 4) is 1 or -1
+			d := 2 - (k & 3)
+			naf = append(naf, d<<i)
+			k -= d
+		}
+	}
+	return
+}
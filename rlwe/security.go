@@ -0,0 +1,226 @@
+package rlwe
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInsufficientSecurity is the error returned (wrapped) by NewParametersFromLiteral when the
+// estimated security of the parameters is smaller than their MinSecurity.
+var ErrInsufficientSecurity = errors.New("insecure parameters")
+
+// heStandardMaxLogQP is the maximum bit-size of the modulus QP given by the Homomorphic Encryption
+// Standard (Albrecht et al., 2018, Table 1) for a uniform ternary secret and an error of standard
+// deviation 3.2, indexed by security level and by LogN.
+var heStandardMaxLogQP = []struct {
+	security float64
+	maxLogQP map[int]float64
+}{
+	{128, map[int]float64{10: 27, 11: 54, 12: 109, 13: 218, 14: 438, 15: 881}},
+	{192, map[int]float64{10: 19, 11: 37, 12: 75, 13: 152, 14: 305, 15: 611}},
+	{256, map[int]float64{10: 14, 11: 29, 12: 58, 13: 118, 14: 237, 15: 476}},
+}
+
+// EstimatedSecurity returns an estimate of the classical bit-security of the parameters against
+// lattice reduction attacks (see EstimateSecurity).
+// For the ConjugateInvariant ring, the parameters are estimated as an RLWE instance of dimension N.
+func (p Parameters) EstimatedSecurity() float64 {
+
+	var logQP float64
+	for _, qi := range p.qi {
+		logQP += math.Log2(float64(qi))
+	}
+	for _, pi := range p.pi {
+		logQP += math.Log2(float64(pi))
+	}
+
+	return EstimateSecurity(p.logN, logQP, p.sigma, p.h)
+}
+
+// EstimateSecurity returns an estimate of the classical bit-security of an RLWE instance of ring degree 2^logN,
+// modulus of logQP bits, error standard deviation sigma and secret of Hamming weight h, against lattice
+// reduction attacks. The estimate is the minimum of:
+//
+//   - the Homomorphic Encryption Standard tables, interpolated in 1/logQP, if logN and sigma are
+//     covered by the tables;
+//   - an analytical approximation of the cost models of the lattice estimator for the primal (uSVP)
+//     attack, the dual attack and the hybrid attack (approximated by a drop-and-solve on the zero
+//     coefficients of the sparse secrets), that accounts for logN, logQP, sigma and h.
+//
+// The cost of the lattice reduction is estimated with the BKZ-sieving model 0.292*beta + 16.4 + log2(8*d).
+func EstimateSecurity(logN int, logQP, sigma float64, h int) float64 {
+
+	n := 1 << logN
+
+	if h <= 0 || h > n {
+		h = n
+	}
+
+	// Standard deviation of the secret
+	sigmaS := math.Sqrt(float64(h) / float64(n))
+
+	security := math.Min(securityHybrid(n, logQP, sigma, h), securityDual(n, logQP, sigma, sigmaS))
+
+	if sigma >= DefaultSigma {
+		if table, ok := securityHEStandard(logN, logQP); ok {
+			security = math.Min(security, table)
+		}
+	}
+
+	return security
+}
+
+// SecurityTolerance is the tolerance, in bits, of the MinSecurity check of the parameters. The moduli are primes
+// close to powers of two and the modulus QP of parameters sized after the Homomorphic Encryption Standard tables
+// can exceed the bit-size of the tables by a fraction of a bit, which lowers their estimated security by a small
+// fraction of a bit below the level of the tables (e.g., 127.97 bits for a 218-bit QP with LogN=13).
+const SecurityTolerance = 0.1
+
+// checkSecurity returns an error if the estimated security of the parameters is smaller than minSecurity
+// minus SecurityTolerance.
+func (p Parameters) checkSecurity(minSecurity int) error {
+	if security := p.EstimatedSecurity(); security < float64(minSecurity)-SecurityTolerance {
+		return fmt.Errorf("%w: estimated security of %.1f bits is smaller than MinSecurity=%d", ErrInsufficientSecurity, security, minSecurity)
+	}
+	return nil
+}
+
+// securityHEStandard interpolates the security level of a modulus of logQP bits for a ring degree 2^logN
+// from the Homomorphic Encryption Standard tables. It returns false if logN is not covered by the tables.
+func securityHEStandard(logN int, logQP float64) (float64, bool) {
+
+	if _, ok := heStandardMaxLogQP[0].maxLogQP[logN]; !ok || logQP <= 0 {
+		return 0, false
+	}
+
+	// The security is roughly inversely proportional to logQP: it is linearly
+	// interpolated in 1/logQP between the entries of the tables and extrapolated
+	// proportionally to 1/logQP outside of them.
+	first, last := heStandardMaxLogQP[0], heStandardMaxLogQP[len(heStandardMaxLogQP)-1]
+
+	if logQP >= first.maxLogQP[logN] {
+		return first.security * first.maxLogQP[logN] / logQP, true
+	}
+
+	for i := 1; i < len(heStandardMaxLogQP); i++ {
+		lo, hi := heStandardMaxLogQP[i-1], heStandardMaxLogQP[i]
+		if logQP >= hi.maxLogQP[logN] {
+			x0, x1 := 1/lo.maxLogQP[logN], 1/hi.maxLogQP[logN]
+			return lo.security + (hi.security-lo.security)*(1/logQP-x0)/(x1-x0), true
+		}
+	}
+
+	return last.security * last.maxLogQP[logN] / logQP, true
+}
+
+// securityHybrid returns the estimated cost of the hybrid attack, approximated by guessing that
+// k coefficients of the secret of Hamming weight h are zero and running the primal attack on the
+// remaining n-k coefficients, minimized over k. The case k=0 is the primal attack.
+func securityHybrid(n int, logQ, sigma float64, h int) (security float64) {
+
+	security = math.Inf(1)
+
+	var logProb float64 // log2 of the probability that the k first coefficients of the secret are zero
+
+	for j, k := 0, 0; j < 32; j++ {
+
+		for ; k < n*j/32; k++ {
+			if n-h-k <= 0 {
+				return
+			}
+			logProb += math.Log2(float64(n-h-k) / float64(n-k))
+		}
+
+		security = math.Min(security, securityPrimal(n-k, logQ, sigma, math.Sqrt(float64(h)/float64(n-k)))-logProb)
+	}
+
+	return
+}
+
+// securityPrimal returns the estimated cost of the primal (uSVP) attack on an LWE instance of dimension n,
+// modulus 2^logQ, error standard deviation sigma and secret standard deviation sigmaS, using Kannan's
+// embedding, the rescaling of the secret and the success condition of [ADPS16].
+func securityPrimal(n int, logQ, sigma, sigmaS float64) float64 {
+
+	betaMax := 2 * n
+
+	if primalDimension(n, logQ, sigma, sigmaS, betaMax) == 0 {
+		return math.Inf(1)
+	}
+
+	betaMin := 40
+	for betaMin < betaMax {
+		beta := (betaMin + betaMax) >> 1
+		if primalDimension(n, logQ, sigma, sigmaS, beta) != 0 {
+			betaMax = beta
+		} else {
+			betaMin = beta + 1
+		}
+	}
+
+	return costBKZ(betaMin, primalDimension(n, logQ, sigma, sigmaS, betaMin))
+}
+
+// primalDimension returns the smallest embedding dimension d = m + n + 1 for which BKZ with block-size beta
+// recovers the unique shortest vector, or 0 if no number of samples m <= 2n allows it.
+func primalDimension(n int, logQ, sigma, sigmaS float64, beta int) int {
+
+	logDelta := math.Log2(rootHermiteFactor(beta))
+	logNu := math.Log2(sigma / sigmaS)
+	lhs := math.Log2(sigma) + 0.5*math.Log2(float64(beta))
+
+	for j := 1; j <= 128; j++ {
+		m := 2 * n * j / 128
+		d := m + n + 1
+		if lhs <= float64(2*beta-d)*logDelta+(float64(m)*logQ+float64(n)*logNu)/float64(d) {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// securityDual returns the estimated cost of the dual attack on an LWE instance of dimension n,
+// modulus 2^logQ, error standard deviation sigma and secret standard deviation sigmaS, with the
+// rescaling of the secret and assuming that the sieving outputs 2^(0.2075*beta) short vectors.
+func securityDual(n int, logQ, sigma, sigmaS float64) (security float64) {
+
+	security = math.Inf(1)
+
+	logNu := math.Log2(sigma / sigmaS)
+
+	for j := 1; j <= 64; j++ {
+
+		m := 2 * n * j / 64
+		d := m + n
+
+		for beta := 40; beta < d && costBKZ(beta, d) < security; beta++ {
+
+			// log2 of the standard deviation of the inner product between the short dual vector and the sample
+			logS := math.Log2(sigma) + float64(d)*math.Log2(rootHermiteFactor(beta)) + float64(n)*(logQ-logNu)/float64(d) - logQ
+
+			if logS > 16 {
+				continue
+			}
+
+			// The distinguishing advantage is exp(-2*pi^2*s^2) and requires 1/advantage^2 short vectors.
+			logRepetitions := math.Max(0, 4*math.Pi*math.Pi*math.Exp2(2*logS)/math.Ln2-0.2075*float64(beta))
+
+			security = math.Min(security, costBKZ(beta, d)+logRepetitions)
+		}
+	}
+
+	return
+}
+
+// rootHermiteFactor returns the root Hermite factor achieved by BKZ with block-size beta.
+func rootHermiteFactor(beta int) float64 {
+	b := float64(beta)
+	return math.Pow(math.Pow(math.Pi*b, 1/b)*b/(2*math.Pi*math.E), 1/(2*(b-1)))
+}
+
+// costBKZ returns the log2 of the cost of BKZ with block-size beta on a lattice of dimension d.
+func costBKZ(beta, d int) float64 {
+	return 0.292*float64(beta) + 16.4 + math.Log2(float64(8*d))
+}
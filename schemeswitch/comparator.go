@@ -0,0 +1,398 @@
+package schemeswitch
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/ckks/advanced"
+	"github.com/tuneinsight/lattigo/v3/lut"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// ComparisonParameters is a struct for the parameters of the switching from CKKS to LWE of a Comparator.
+//
+// SlotsToCoeffsParameters are the parameters of the homomorphic decoding that moves the slots into the coefficients
+// before the switching to LWE. CoeffsToSlotsParameters are the parameters of the homomorphic encoding that moves the
+// repacked results of the lookup table back into the slots.
+//
+// The fields LinearTransformType, LogN, LogSlots and Scaling of the EncodingMatrixLiteral are set by NewComparator.
+type ComparisonParameters struct {
+	SlotsToCoeffsParameters advanced.EncodingMatrixLiteral
+	CoeffsToSlotsParameters advanced.EncodingMatrixLiteral
+}
+
+// Rotations returns the list of rotations performed by a Comparator. The repacking also requires the conjugation key.
+func (p *ComparisonParameters) Rotations(LogN, LogSlots int) (rotations []int) {
+
+	ctsParams, stcParams := p.CoeffsToSlotsParameters, p.SlotsToCoeffsParameters
+	ctsParams.LinearTransformType = advanced.CoeffsToSlots
+	stcParams.LinearTransformType = advanced.SlotsToCoeffs
+
+	rotations = []int{}
+
+	// The repacking uses the rotations by 2^i for 0 <= i < LogN-1
+	for i := 0; i < LogN-1; i++ {
+		rotations = append(rotations, 1<<i)
+	}
+
+	for _, k := range append(ctsParams.Rotations(LogN, LogSlots), stcParams.Rotations(LogN, LogSlots)...) {
+		if !utils.IsInSliceInt(k, rotations) {
+			rotations = append(rotations, k)
+		}
+	}
+
+	return
+}
+
+// ComparisonKey is a struct for the keys of a Comparator, see GenComparisonKey.
+type ComparisonKey struct {
+	rlwe.EvaluationKey
+	SwkLWE *rlwe.SwitchingKey
+	Brk    *lut.BlindRotationKey
+}
+
+// GenComparisonKey generates the keys of a Comparator from the secret key sk of paramsCKKS and the secret key skLWE
+// of paramsLWE:
+//
+//   - the rotation keys of cmpParams.Rotations and the conjugation key, under sk,
+//   - the switching key from sk to skLWE,
+//   - the blind-rotation key of skLWE under sk.
+//
+// No relinearization key is needed.
+func GenComparisonKey(paramsCKKS ckks.Parameters, cmpParams ComparisonParameters, sk *rlwe.SecretKey, paramsLWE rlwe.Parameters, skLWE *rlwe.SecretKey) (key *ComparisonKey) {
+	kgen := ckks.NewKeyGenerator(paramsCKKS)
+	return &ComparisonKey{
+		EvaluationKey: rlwe.EvaluationKey{Rtks: kgen.GenRotationKeysForRotations(cmpParams.Rotations(paramsCKKS.LogN(), paramsCKKS.LogSlots()), true, sk)},
+		SwkLWE:        kgen.GenSwitchingKey(sk, skLWE),
+		Brk:           lut.GenBlindRotationKey(paramsCKKS.Parameters, sk, paramsLWE, skLWE),
+	}
+}
+
+// Comparator is a struct that evaluates comparisons, and more generally lookup tables, on the slots of CKKS ciphertexts
+// by switching them to LWE ciphertexts. It stores the plaintext matrices and the keys of the switching, and a memory pool.
+//
+// A CKKS ciphertext is switched to LWE with the homomorphic decoding (SlotsToCoeffs) of ckks/advanced, which moves the
+// real parts of the slots into the coefficients, followed by a key switching to the LWE secret, of smaller ring degree,
+// and the extraction of one LWE ciphertext per slot. The lookup table is evaluated on each LWE ciphertext with the blind
+// rotation of the package lut, under the CKKS secret, and the outputs are repacked into a single ciphertext with
+// automorphisms and moved back into the slots with the homomorphic encoding (CoeffsToSlots) of ckks/advanced.
+//
+// The depth of the input ciphertext is the depth of the SlotsToCoeffsParameters: the input must be at least at the
+// level SlotsToCoeffsParameters.LevelStart and all its levels are consumed. The output is a fresh ciphertext at the
+// level CoeffsToSlotsParameters.LevelStart - CoeffsToSlotsParameters.Depth(true), with the default scale of paramsCKKS,
+// whose level does not depend on the level of the input. The cost is dominated by one blind rotation per slot.
+type Comparator struct {
+	advanced.Evaluator
+	*comparatorBase
+	lutEval *lut.Evaluator
+}
+
+type comparatorBase struct {
+	ComparisonParameters
+	paramsCKKS ckks.Parameters
+	paramsLWE  rlwe.Parameters
+
+	swkLWE *rlwe.SwitchingKey
+	brk    *lut.BlindRotationKey
+
+	stcMatrices advanced.EncodingMatrix
+	ctsMatrices advanced.EncodingMatrix
+
+	// xPow[l-1] is the monomial X^{N/2^l}, in the NTT and Montgomery domains
+	xPow []*ring.Poly
+
+	// invTwoSlots[i] is (2*slots)^-1 mod qi, in the Montgomery domain
+	invTwoSlots []uint64
+}
+
+// NewComparator creates a new Comparator for the parameters paramsCKKS, with LWE ciphertexts of parameters paramsLWE, the
+// comparison parameters cmpParams and the keys key (see GenComparisonKey). paramsCKKS must be of ring type ring.Standard.
+// The moduli Q of paramsLWE must be the first modulus of paramsCKKS and its moduli P must be the first moduli P of
+// paramsCKKS, and its ring degree must be at least twice the number of slots of paramsCKKS. The secret of paramsLWE
+// must be ternary, and its Hamming weight should be small since it increases the error of the modulus switching to 2N.
+func NewComparator(paramsCKKS ckks.Parameters, paramsLWE rlwe.Parameters, cmpParams ComparisonParameters, key *ComparisonKey) (cmp *Comparator, err error) {
+
+	if paramsCKKS.RingType() != ring.Standard {
+		return nil, fmt.Errorf("the ring type of paramsCKKS must be ring.Standard")
+	}
+
+	if paramsLWE.QCount() != 1 || paramsLWE.Q()[0] != paramsCKKS.Q()[0] {
+		return nil, fmt.Errorf("the moduli Q of paramsLWE must be the first modulus of paramsCKKS")
+	}
+
+	if paramsLWE.PCount() == 0 || paramsLWE.PCount() > paramsCKKS.PCount() {
+		return nil, fmt.Errorf("the moduli P of paramsLWE must be the first moduli P of paramsCKKS")
+	}
+
+	for i, pi := range paramsLWE.P() {
+		if pi != paramsCKKS.P()[i] {
+			return nil, fmt.Errorf("the moduli P of paramsLWE must be the first moduli P of paramsCKKS")
+		}
+	}
+
+	if paramsLWE.N() < 2*paramsCKKS.Slots() || paramsLWE.N() > paramsCKKS.N() {
+		return nil, fmt.Errorf("the ring degree of paramsLWE must be between twice the number of slots and the ring degree of paramsCKKS")
+	}
+
+	if cmpParams.CoeffsToSlotsParameters.LevelStart > paramsCKKS.MaxLevel() {
+		return nil, fmt.Errorf("CoeffsToSlotsParameters.LevelStart is larger than the maximum level of paramsCKKS")
+	}
+
+	if key == nil || key.SwkLWE == nil || key.Brk == nil {
+		return nil, fmt.Errorf("invalid comparison key: switching key or blind-rotation key is nil")
+	}
+
+	if len(key.Brk.SkPos) != paramsLWE.N() {
+		return nil, fmt.Errorf("invalid comparison key: the blind-rotation key does not match paramsLWE")
+	}
+
+	cmp = new(Comparator)
+	cmp.comparatorBase = newComparatorBase(paramsCKKS, paramsLWE, cmpParams)
+	cmp.swkLWE, cmp.brk = key.SwkLWE, key.Brk
+
+	if err = cmp.comparatorBase.checkKeys(key.EvaluationKey); err != nil {
+		return nil, fmt.Errorf("invalid comparison key: %w", err)
+	}
+
+	if cmp.Evaluator, err = advanced.NewEvaluator(paramsCKKS, key.EvaluationKey); err != nil {
+		return nil, fmt.Errorf("invalid comparison key: %w", err)
+	}
+
+	cmp.lutEval = lut.NewEvaluator(paramsCKKS.Parameters, paramsLWE)
+
+	return
+}
+
+// ShallowCopy creates a shallow copy of this Comparator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// Comparator can be used concurrently.
+func (cmp *Comparator) ShallowCopy() *Comparator {
+	return &Comparator{
+		Evaluator:      cmp.Evaluator.ShallowCopy(),
+		comparatorBase: cmp.comparatorBase,
+		lutEval:        cmp.lutEval.ShallowCopy(),
+	}
+}
+
+// StepNew returns a new ciphertext whose slots are 1 if the real part of the corresponding slot of ct is larger than
+// or equal to threshold, and 0 otherwise (see EvaluateNew). The sign of the values is obtained with threshold = 0.
+func (cmp *Comparator) StepNew(ct *ckks.Ciphertext, threshold float64) (ctOut *ckks.Ciphertext) {
+	return cmp.EvaluateNew(ct, func(x float64) float64 {
+		if x >= threshold {
+			return 1
+		}
+		return 0
+	})
+}
+
+// EvaluateNew evaluates the function g on the real parts of the slots of ct and returns the result, in the real
+// parts of the slots, on a new ciphertext (see Comparator for its level and scale). The imaginary parts of the slots
+// of ct are ignored, and their real parts must be in (-1, 1). They are rounded to a multiple of 2/N by the blind
+// rotation, with an additional error of about sqrt(h)/N for an LWE secret of Hamming weight h, so g should be constant
+// around the values of ct.
+func (cmp *Comparator) EvaluateNew(ct *ckks.Ciphertext, g func(x float64) float64) (ctOut *ckks.Ciphertext) {
+
+	if ct.Level() < cmp.SlotsToCoeffsParameters.LevelStart {
+		panic("cannot EvaluateNew: ct.Level() < SlotsToCoeffsParameters.LevelStart")
+	}
+
+	paramsCKKS := cmp.paramsCKKS
+	ringQ := paramsCKKS.RingQ()
+	slots := paramsCKKS.Slots()
+
+	ctTmp := ct.CopyNew()
+	cmp.DropLevel(ctTmp, ctTmp.Level()-cmp.SlotsToCoeffsParameters.LevelStart)
+
+	// Cancels the imaginary parts: ct + conj(ct) encrypts the real parts with a doubled scale
+	cmp.Add(ctTmp, cmp.ConjugateNew(ctTmp), ctTmp)
+	ctTmp.Scale *= 2
+
+	// Homomorphic decoding: moves the slots into the coefficients: the real part of the slot k is on the coefficient
+	// bitrev(k) * N/(2*slots), and also on the coefficient N/2 + bitrev(k) * N/(2*slots) for sparse packing
+	ctTmp = cmp.SlotsToCoeffsNew(ctTmp, nil, cmp.stcMatrices)
+	cmp.DropLevel(ctTmp, ctTmp.Level())
+
+	// Integer scaling of the values v to v * Q0/4, so that the phases of the LWE ciphertexts are in [-1/4, 1/4)
+	if scaling := math.Round(paramsCKKS.QiFloat64(0) / (4 * ctTmp.Scale)); scaling > 1 {
+		cmp.ScaleUp(ctTmp, scaling, ctTmp)
+	} else if scaling < 1 {
+		panic("cannot EvaluateNew: the scale of the ciphertext is larger than Q0/4")
+	}
+
+	// Switching to the LWE secret and ring degree
+	cmp.SwitchKeys(ctTmp, cmp.swkLWE, ctTmp)
+
+	ctN := rlwe.NewCiphertext(paramsCKKS.Parameters, 1, 0)
+	ringQ.InvNTTLvl(0, ctTmp.Value[0], ctN.Value[0])
+	ringQ.InvNTTLvl(0, ctTmp.Value[1], ctN.Value[1])
+
+	ctLWE := rlwe.NewCiphertext(cmp.paramsLWE, 1, 0)
+	rlwe.SwitchCiphertextRingDegree(ctN, ctLWE)
+
+	// The coefficients k * N/(2*slots) of ctN are mapped on the coefficients k * n/(2*slots) of ctLWE
+	gap := cmp.paramsLWE.N() / (2 * slots)
+	cts := make([]*lut.LWECiphertext, slots)
+	for k := range cts {
+		cts[k] = lut.ExtractLWE(cmp.paramsLWE, ctLWE, k*gap)
+	}
+
+	// Blind rotations: the phase x * Q0 of each LWE ciphertext is mapped on g(4x) * scale. They are carried out
+	// only at the level at which the CoeffsToSlots starts.
+	scale := paramsCKKS.DefaultScale()
+	level := cmp.CoeffsToSlotsParameters.LevelStart
+	lutPoly := lut.InitLUT(func(x float64) float64 { return g(4 * x) }, scale, ringQ)
+	ctsBR := cmp.lutEval.EvaluateBatchLvl(level, cts, lutPoly, cmp.brk)
+
+	// Repacking: the k-th output is packed on the coefficient k * N/(2*slots), which requires a list of 2*slots
+	// ciphertexts pre-multiplied by (2*slots)^-1. The remaining coefficients are cancelled by the Trace.
+	ctsPack := make([]*ckks.Ciphertext, 2*slots)
+	for k, ctBR := range ctsBR {
+		ctsPack[k] = ckks.NewCiphertext(paramsCKKS, 1, level, scale)
+		for u := range ctsPack[k].Value {
+			for i := 0; i < level+1; i++ {
+				ring.MulScalarMontgomeryVec(ctBR.Value[u].Coeffs[i], ctsPack[k].Value[u].Coeffs[i], cmp.invTwoSlots[i], ringQ.Modulus[i], ringQ.MredParams[i])
+			}
+			ringQ.NTTLvl(level, ctsPack[k].Value[u], ctsPack[k].Value[u])
+		}
+	}
+
+	ctTmp = cmp.pack(ctsPack)
+	cmp.Trace(ctTmp, paramsCKKS.LogSlots(), paramsCKKS.LogN()-1, ctTmp)
+
+	// Copies the coefficients k * N/(2*slots) on the coefficients N/2 + k * N/(2*slots) (product by X^{N/2}), so that
+	// the CoeffsToSlots returns the values on the real parts of the slots for both sparse and full packing
+	cmp.Add(ctTmp, cmp.MultByiNew(ctTmp), ctTmp)
+
+	// Homomorphic encoding: moves the coefficients into the slots
+	ctOut, _ = cmp.CoeffsToSlotsNew(ctTmp, cmp.ctsMatrices)
+
+	return
+}
+
+// pack repacks the constant coefficients of the ciphertexts cts, whose number is a power of two, on the coefficients
+// k * N/len(cts) of a single ciphertext, multiplied by len(cts). Nil ciphertexts are treated as encryptions of zero.
+// The coefficients that are not multiples of N/len(cts) are not cancelled. The ciphertexts cts are modified.
+func (cmp *Comparator) pack(cts []*ckks.Ciphertext) *ckks.Ciphertext {
+
+	if len(cts) == 1 {
+		return cts[0]
+	}
+
+	l := bits.Len(uint(len(cts))) - 1
+
+	even := make([]*ckks.Ciphertext, len(cts)>>1)
+	odd := make([]*ckks.Ciphertext, len(cts)>>1)
+	for i := range even {
+		even[i], odd[i] = cts[2*i], cts[2*i+1]
+	}
+
+	ctEven, ctOdd := cmp.pack(even), cmp.pack(odd)
+
+	if ctEven == nil && ctOdd == nil {
+		return nil
+	}
+
+	if ctEven == nil {
+		ctEven = ckks.NewCiphertext(cmp.paramsCKKS, 1, ctOdd.Level(), ctOdd.Scale)
+	}
+
+	ctTmp := ctEven.CopyNew()
+
+	if ctOdd != nil {
+		// ctOdd * X^{N/2^l}
+		ringQ := cmp.paramsCKKS.RingQ()
+		for u := range ctOdd.Value {
+			ringQ.MulCoeffsMontgomeryLvl(ctOdd.Level(), ctOdd.Value[u], cmp.xPow[l-1], ctOdd.Value[u])
+		}
+
+		cmp.Add(ctEven, ctOdd, ctEven)
+		cmp.Sub(ctTmp, ctOdd, ctTmp)
+	}
+
+	// ctEven + ctOdd * X^{N/2^l} + sigma(ctEven - ctOdd * X^{N/2^l}), where the automorphism sigma maps X^{N/2^l}
+	// to -X^{N/2^l} and fixes X^{N/2^{l-1}}: the conjugation X -> X^{-1} for l = 1 and X -> X^{5^{2^{l-2}}} else.
+	if l == 1 {
+		cmp.Conjugate(ctTmp, ctTmp)
+	} else {
+		cmp.Rotate(ctTmp, 1<<(l-2), ctTmp)
+	}
+
+	cmp.Add(ctEven, ctTmp, ctEven)
+
+	return ctEven
+}
+
+// checkKeys checks if all the necessary rotation keys are present in the evaluation key.
+func (cb *comparatorBase) checkKeys(evk rlwe.EvaluationKey) (err error) {
+
+	rtks := evk.RotationKeys()
+	if rtks == nil {
+		return fmt.Errorf("rotation key is nil")
+	}
+
+	galEls := make(map[uint64]bool)
+	for _, galEl := range rtks.GaloisElements() {
+		galEls[galEl] = true
+	}
+
+	rotMissing := []int{}
+	for _, i := range cb.Rotations(cb.paramsCKKS.LogN(), cb.paramsCKKS.LogSlots()) {
+		if !galEls[cb.paramsCKKS.GaloisElementForColumnRotationBy(i)] {
+			rotMissing = append(rotMissing, i)
+		}
+	}
+
+	if len(rotMissing) != 0 {
+		return fmt.Errorf("rotation key(s) missing: %d", rotMissing)
+	}
+
+	if !galEls[cb.paramsCKKS.GaloisElementForRowRotation()] {
+		return fmt.Errorf("conjugation key missing")
+	}
+
+	return nil
+}
+
+func newComparatorBase(paramsCKKS ckks.Parameters, paramsLWE rlwe.Parameters, cmpParams ComparisonParameters) (cb *comparatorBase) {
+	cb = new(comparatorBase)
+	cb.paramsCKKS = paramsCKKS
+	cb.paramsLWE = paramsLWE
+	cb.ComparisonParameters = cmpParams
+
+	encoder := ckks.NewEncoder(paramsCKKS)
+
+	// SlotsToCoeffs vectors
+	cb.SlotsToCoeffsParameters.LinearTransformType = advanced.SlotsToCoeffs
+	cb.SlotsToCoeffsParameters.LogN = paramsCKKS.LogN()
+	cb.SlotsToCoeffsParameters.LogSlots = paramsCKKS.LogSlots()
+	cb.SlotsToCoeffsParameters.Scaling = 1
+	cb.stcMatrices = advanced.NewHomomorphicEncodingMatrixFromLiteral(cb.SlotsToCoeffsParameters, encoder)
+
+	// CoeffsToSlots vectors, with the cancelling factor for the DFT
+	cb.CoeffsToSlotsParameters.LinearTransformType = advanced.CoeffsToSlots
+	cb.CoeffsToSlotsParameters.LogN = paramsCKKS.LogN()
+	cb.CoeffsToSlotsParameters.LogSlots = paramsCKKS.LogSlots()
+	cb.CoeffsToSlotsParameters.Scaling = 1 / float64(2*paramsCKKS.Slots())
+	cb.ctsMatrices = advanced.NewHomomorphicEncodingMatrixFromLiteral(cb.CoeffsToSlotsParameters, encoder)
+
+	ringQ := paramsCKKS.RingQ()
+	cb.xPow = make([]*ring.Poly, paramsCKKS.LogN())
+	for l := range cb.xPow {
+		cb.xPow[l] = ringQ.NewPoly()
+		for i, qi := range ringQ.Modulus {
+			cb.xPow[l].Coeffs[i][paramsCKKS.N()>>(l+1)] = ring.MForm(1, qi, ringQ.BredParams[i])
+		}
+		ringQ.NTT(cb.xPow[l], cb.xPow[l])
+	}
+
+	cb.invTwoSlots = make([]uint64, len(ringQ.Modulus))
+	for i, qi := range ringQ.Modulus {
+		cb.invTwoSlots[i] = ring.MForm(ring.ModExp(uint64(2*paramsCKKS.Slots()), qi-2, qi), qi, ringQ.BredParams[i])
+	}
+
+	return
+}
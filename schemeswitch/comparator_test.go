@@ -0,0 +1,139 @@
+package schemeswitch
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/ckks/advanced"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// TestComparisonCKKSParams are insecure CKKS parameters with sparse packing for fast testing only.
+var TestComparisonCKKSParams = ckks.ParametersLiteral{
+	LogN:         10,
+	LogSlots:     4,
+	DefaultScale: 1 << 40,
+	Sigma:        rlwe.DefaultSigma,
+	Q: []uint64{
+		0x80000000002001, // 55 Q0 (LWE)
+		0xffffff7801,     // 40 StC and CtS
+		0xffffff7001,     // 40 StC and CtS
+		0x1000000c801,    // 40
+	},
+	P: []uint64{
+		0x1fffffffffe00001, // Pi 61
+	},
+}
+
+// TestComparisonLWEParams are the parameters of the LWE ciphertexts for TestComparisonCKKSParams.
+var TestComparisonLWEParams = rlwe.ParametersLiteral{
+	LogN:  5,
+	Q:     []uint64{0x80000000002001},
+	P:     []uint64{0x1fffffffffe00001},
+	H:     16,
+	Sigma: rlwe.DefaultSigma,
+}
+
+// TestComparisonParams are the comparison parameters for TestComparisonCKKSParams.
+var TestComparisonParams = ComparisonParameters{
+	SlotsToCoeffsParameters: advanced.EncodingMatrixLiteral{
+		LevelStart: 2,
+		BSGSRatio:  2.0,
+		ScalingFactor: [][]float64{
+			{0xffffff7801},
+			{0xffffff7001},
+		},
+	},
+	CoeffsToSlotsParameters: advanced.EncodingMatrixLiteral{
+		LevelStart: 2,
+		BSGSRatio:  2.0,
+		ScalingFactor: [][]float64{
+			{0xffffff7801},
+			{0xffffff7001},
+		},
+	},
+}
+
+func TestComparator(t *testing.T) {
+
+	if runtime.GOARCH == "wasm" {
+		t.Skip("skipping comparison tests for GOARCH=wasm")
+	}
+
+	paramsCKKS, err := ckks.NewParametersFromLiteral(TestComparisonCKKSParams)
+	require.NoError(t, err)
+
+	paramsLWE, err := rlwe.NewParametersFromLiteral(TestComparisonLWEParams)
+	require.NoError(t, err)
+
+	sk := ckks.NewKeyGenerator(paramsCKKS).GenSecretKey()
+	skLWE := rlwe.NewKeyGenerator(paramsLWE).GenSecretKey()
+
+	key := GenComparisonKey(paramsCKKS, TestComparisonParams, sk, paramsLWE, skLWE)
+
+	t.Run(fmt.Sprintf("NewComparator/Incompatible/logN=%d/logNLWE=%d", paramsCKKS.LogN(), paramsLWE.LogN()), func(t *testing.T) {
+		literal := TestComparisonLWEParams
+		literal.Q = []uint64{0x10000140001}
+		paramsLWEInvalid, err := rlwe.NewParametersFromLiteral(literal)
+		require.NoError(t, err)
+		_, err = NewComparator(paramsCKKS, paramsLWEInvalid, TestComparisonParams, key)
+		require.Error(t, err)
+
+		_, err = NewComparator(paramsCKKS, paramsLWE, TestComparisonParams, &ComparisonKey{SwkLWE: key.SwkLWE, Brk: key.Brk})
+		require.Error(t, err)
+	})
+
+	cmp, err := NewComparator(paramsCKKS.WithParallelism(runtime.NumCPU()), paramsLWE, TestComparisonParams, key)
+	require.NoError(t, err)
+
+	encoder := ckks.NewEncoder(paramsCKKS)
+	encryptor := ckks.NewEncryptor(paramsCKKS, sk)
+	decryptor, err := ckks.NewDecryptor(paramsCKKS, sk)
+	require.NoError(t, err)
+
+	for _, threshold := range []float64{0, 0.25} {
+
+		t.Run(fmt.Sprintf("Step/logN=%d/logSlots=%d/logNLWE=%d/threshold=%v", paramsCKKS.LogN(), paramsCKKS.LogSlots(), paramsLWE.LogN(), threshold), func(t *testing.T) {
+
+			// Values in (-0.9, 0.9) at a distance of at least 0.05 from the threshold
+			values := make([]complex128, paramsCKKS.Slots())
+			for i := range values {
+				v := threshold
+				for math.Abs(v-threshold) < 0.05 {
+					v = 1.8*utils.RandFloat64(0, 1) - 0.9
+				}
+				values[i] = complex(v, utils.RandFloat64(-1, 1))
+			}
+
+			ct := encryptor.EncryptNew(encoder.EncodeNew(values, paramsCKKS.MaxLevel(), paramsCKKS.DefaultScale(), paramsCKKS.LogSlots()))
+
+			ctOut := cmp.StepNew(ct, threshold)
+
+			require.Equal(t, TestComparisonParams.CoeffsToSlotsParameters.LevelStart-TestComparisonParams.CoeffsToSlotsParameters.Depth(true), ctOut.Level())
+
+			have := encoder.Decode(decryptor.DecryptNew(ctOut), paramsCKKS.LogSlots())
+
+			for i := range values {
+				want := 0.0
+				if real(values[i]) >= threshold {
+					want = 1
+				}
+				require.InDelta(t, want, real(have[i]), 0.01)
+				require.InDelta(t, 0, imag(have[i]), 0.01)
+			}
+		})
+	}
+
+	t.Run(fmt.Sprintf("ShallowCopy/logN=%d", paramsCKKS.LogN()), func(t *testing.T) {
+		cmpCopy := cmp.ShallowCopy()
+		require.True(t, cmpCopy.comparatorBase == cmp.comparatorBase)
+		require.False(t, cmpCopy.Evaluator == cmp.Evaluator)
+		require.False(t, cmpCopy.lutEval == cmp.lutEval)
+	})
+}
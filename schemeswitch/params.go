@@ -0,0 +1,57 @@
+// Package schemeswitch implements the switching of ciphertexts between the CKKS and BFV schemes, for parameters
+// that share the same ring and whose BFV moduli are the first moduli of the CKKS moduli chain.
+//
+// A CKKS ciphertext is switched to BFV with the homomorphic decoding (SlotsToCoeffs) of ckks/advanced, which moves the
+// slots into the coefficients, followed by an integer scaling to Q/T, the drop of the CKKS moduli that are not part of
+// the BFV moduli and a homomorphic BFV encoding, which moves the coefficients into the BFV slots. A BFV ciphertext is
+// switched to CKKS with a homomorphic BFV decoding, a modulus switching to the first modulus, a modulus raising, the
+// homomorphic encoding (CoeffsToSlots) and the homomorphic modular reduction (EvalMod) of ckks/advanced, as in the CKKS
+// bootstrapping without its final SlotsToCoeffs step.
+//
+// The CKKS slots are mapped on the BFV slots, seen as a 2 x N/2 matrix (see bfv.Encoder): the real part of the i-th
+// slot is mapped on the i-th slot of the first row and its imaginary part on the i-th slot of the second row. The BFV
+// encoding and decoding are dense linear transforms over Z_T, evaluated with rotations of the BFV ciphertexts, that
+// multiply the error of the ciphertexts by about T*N: the switching from CKKS to BFV therefore requires CKKS ciphertexts
+// whose values are integers up to an error much smaller than 1/(T*N), and returns BFV ciphertexts whose error leaves
+// little room for further BFV operations, and that cannot be switched back to CKKS.
+//
+// The package also implements the switching of CKKS ciphertexts to LWE ciphertexts, on which comparisons and other lookup
+// tables are evaluated with the blind rotation of the package lut before the results are switched back to CKKS (see Comparator).
+package schemeswitch
+
+import (
+	"github.com/tuneinsight/lattigo/v3/ckks/advanced"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// Parameters is a struct for the parameters of the scheme switching.
+//
+// SlotsToCoeffsParameters are the parameters of the homomorphic decoding of the switching from CKKS to BFV. It must end
+// above the last level of the BFV moduli, as one level is consumed to match the scale of the ciphertext with Q/T. CoeffsToSlotsParameters and EvalModParameters are the parameters of the
+// homomorphic encoding and modular reduction of the switching from BFV to CKKS. The EvalModParameters.MessageRatio must
+// be at most T/B, where B bounds the centered BFV plaintext coefficients.
+//
+// The fields LinearTransformType, LogN, LogSlots and Scaling of the EncodingMatrixLiteral are set by NewSwitcher.
+type Parameters struct {
+	SlotsToCoeffsParameters advanced.EncodingMatrixLiteral
+	EvalModParameters       advanced.EvalModLiteral
+	CoeffsToSlotsParameters advanced.EncodingMatrixLiteral
+}
+
+// Rotations returns the list of rotations performed during the scheme switching.
+func (p *Parameters) Rotations(LogN, LogSlots int) (rotations []int) {
+
+	ctsParams, stcParams := p.CoeffsToSlotsParameters, p.SlotsToCoeffsParameters
+	ctsParams.LinearTransformType = advanced.CoeffsToSlots
+	stcParams.LinearTransformType = advanced.SlotsToCoeffs
+
+	rotations = []int{}
+
+	for _, k := range append(ctsParams.Rotations(LogN, LogSlots), stcParams.Rotations(LogN, LogSlots)...) {
+		if !utils.IsInSliceInt(k, rotations) {
+			rotations = append(rotations, k)
+		}
+	}
+
+	return
+}
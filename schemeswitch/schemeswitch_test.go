@@ -0,0 +1,233 @@
+package schemeswitch
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/ckks/advanced"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// TestCKKSParams are insecure CKKS parameters for fast testing only.
+var TestCKKSParams = ckks.ParametersLiteral{
+	LogN:         12,
+	LogSlots:     11,
+	DefaultScale: 1 << 55,
+	H:            192,
+	Sigma:        rlwe.DefaultSigma,
+	Q: []uint64{
+		0x10000000006e0001, // 60 Q0 (BFV)
+		0x10000140001,      // 40 (BFV)
+		0x80000000062001,   // 55 Scale matching
+		0x80000000002001,   // 55 StC
+		0x8000000003e001,   // 55 StC
+		0x7ffffffffb4001,   // 55 StC
+		0xfffffffff840001,  // 60 Sine (double angle)
+		0x1000000000860001, // 60 Sine (double angle)
+		0xfffffffff6a0001,  // 60 Sine
+		0x1000000000980001, // 60 Sine
+		0xfffffffff5a0001,  // 60 Sine
+		0x1000000000b00001, // 60 Sine
+		0x1000000000ce0001, // 60 Sine
+		0xfffffffff2a0001,  // 60 Sine
+		0x100000000060001,  // 58 CtS
+		0xfffffffff00001,   // 58 CtS
+		0xffffffffd80001,   // 58 CtS
+	},
+	P: []uint64{
+		0x1fffffffffe00001, // Pi 61
+		0x1fffffffffc80001, // Pi 61
+	},
+}
+
+// TestBFVParams are BFV parameters whose moduli are the first two moduli of TestCKKSParams.
+var TestBFVParams = bfv.ParametersLiteral{
+	LogN:  12,
+	Q:     []uint64{0x10000000006e0001, 0x10000140001},
+	P:     []uint64{0x1fffffffffe00001},
+	H:     192,
+	Sigma: rlwe.DefaultSigma,
+	T:     0x10001,
+}
+
+// TestSwitchingParams are the scheme-switching parameters for TestCKKSParams and TestBFVParams.
+var TestSwitchingParams = Parameters{
+	SlotsToCoeffsParameters: advanced.EncodingMatrixLiteral{
+		LevelStart: 5,
+		BSGSRatio:  16.0,
+		ScalingFactor: [][]float64{
+			{0x80000000002001},
+			{0x8000000003e001},
+			{0x7ffffffffb4001},
+		},
+	},
+	EvalModParameters: advanced.EvalModLiteral{
+		Q:             0x10000000006e0001,
+		LevelStart:    13,
+		SineType:      advanced.Cos1,
+		MessageRatio:  256.0,
+		K:             25,
+		SineDeg:       63,
+		DoubleAngle:   2,
+		ArcSineDeg:    0,
+		ScalingFactor: 1 << 60,
+	},
+	CoeffsToSlotsParameters: advanced.EncodingMatrixLiteral{
+		LevelStart: 16,
+		BSGSRatio:  16.0,
+		ScalingFactor: [][]float64{
+			{0x100000000060001},
+			{0xfffffffff00001},
+			{0xffffffffd80001},
+		},
+	},
+}
+
+func testString(params ckks.Parameters, paramsBFV bfv.Parameters, opname string) string {
+	return fmt.Sprintf("%s/logN=%d/logQ=%d/logQBFV=%d/T=%d",
+		opname,
+		params.LogN(),
+		params.LogQ(),
+		paramsBFV.LogQ(),
+		paramsBFV.RingT().Modulus[0])
+}
+
+func TestSchemeSwitch(t *testing.T) {
+
+	if runtime.GOARCH == "wasm" {
+		t.Skip("skipping scheme-switching tests for GOARCH=wasm")
+	}
+
+	paramsCKKS, err := ckks.NewParametersFromLiteral(TestCKKSParams)
+	require.NoError(t, err)
+
+	paramsBFV, err := bfv.NewParametersFromLiteral(TestBFVParams)
+	require.NoError(t, err)
+
+	t.Run(testString(paramsCKKS, paramsBFV, "NewSwitcher/Incompatible"), func(t *testing.T) {
+		literal := TestBFVParams
+		literal.Q = []uint64{0x10000140001}
+		paramsBFVInvalid, err := bfv.NewParametersFromLiteral(literal)
+		require.NoError(t, err)
+		_, err = NewSwitcher(paramsCKKS, paramsBFVInvalid, TestSwitchingParams, EvaluationKey{})
+		require.Error(t, err)
+	})
+
+	sk := ckks.NewKeyGenerator(paramsCKKS).GenSecretKey()
+	skBFV := SecretKeyBFV(paramsCKKS, paramsBFV, sk)
+
+	evk := GenEvaluationKey(paramsCKKS, paramsBFV, TestSwitchingParams, sk)
+
+	t.Run(testString(paramsCKKS, paramsBFV, "NewSwitcher/MissingBFVKeys"), func(t *testing.T) {
+		_, err = NewSwitcher(paramsCKKS, paramsBFV, TestSwitchingParams, EvaluationKey{CKKS: evk.CKKS})
+		require.Error(t, err)
+	})
+
+	sw, err := NewSwitcher(paramsCKKS, paramsBFV, TestSwitchingParams, evk)
+	require.NoError(t, err)
+
+	T := paramsBFV.T()
+	N := paramsCKKS.N()
+	slots := paramsCKKS.Slots()
+	bound := int64(T / uint64(TestSwitchingParams.EvalModParameters.MessageRatio))
+
+	encoderCKKS := ckks.NewEncoder(paramsCKKS)
+	encryptorCKKS := ckks.NewEncryptor(paramsCKKS, sk)
+	decryptorCKKS, err := ckks.NewDecryptor(paramsCKKS, sk)
+	require.NoError(t, err)
+
+	encoderBFV := bfv.NewEncoder(paramsBFV)
+	encryptorBFV := bfv.NewEncryptor(paramsBFV, skBFV)
+	decryptorBFV, err := bfv.NewDecryptor(paramsBFV, skBFV)
+	require.NoError(t, err)
+
+	// newTestVectorsBFV returns a BFV ciphertext of uniform slots in [-bound, bound].
+	newTestVectorsBFV := func(bound int64) (values []int64, ct *bfv.Ciphertext) {
+		values = make([]int64, N)
+		for i := range values {
+			values[i] = randInt(bound)
+		}
+		pt := bfv.NewPlaintext(paramsBFV)
+		encoderBFV.EncodeInt(values, pt)
+		return values, encryptorBFV.EncryptNew(pt)
+	}
+
+	// verifyTestVectorsCKKS checks that the i-th slot of ct is values[i] + i * values[i+N/2].
+	verifyTestVectorsCKKS := func(values []int64, ct *ckks.Ciphertext, t *testing.T) {
+
+		have := encoderCKKS.Decode(decryptorCKKS.DecryptNew(ct), paramsCKKS.LogSlots())
+
+		// Without the arcsine, the sine approximation of x = m/T has a relative error of about (2*pi*x)^2/6
+		delta := 0.01 + math.Pow(2*math.Pi*float64(bound)/float64(T), 2)/6*float64(bound)
+
+		for i := range have {
+			require.InDelta(t, float64(values[i]), real(have[i]), delta)
+			require.InDelta(t, float64(values[i+N/2]), imag(have[i]), delta)
+		}
+	}
+
+	t.Run(testString(paramsCKKS, paramsBFV, "CKKSToBFV"), func(t *testing.T) {
+
+		values := make([]complex128, slots)
+		for i := range values {
+			values[i] = complex(float64(randInt(bound)), float64(randInt(bound)))
+		}
+
+		ct := encryptorCKKS.EncryptNew(encoderCKKS.EncodeNew(values, paramsCKKS.MaxLevel(), paramsCKKS.DefaultScale(), paramsCKKS.LogSlots()))
+
+		have := encoderBFV.DecodeIntNew(decryptorBFV.DecryptNew(sw.CKKSToBFVNew(ct)))
+
+		for i := range values {
+			require.Equal(t, int64(real(values[i])), have[i])
+			require.Equal(t, int64(imag(values[i])), have[i+N/2])
+		}
+	})
+
+	t.Run(testString(paramsCKKS, paramsBFV, "BFVToCKKS"), func(t *testing.T) {
+
+		values, ct := newTestVectorsBFV(bound)
+
+		verifyTestVectorsCKKS(values, sw.BFVToCKKSNew(ct), t)
+	})
+
+	t.Run(testString(paramsCKKS, paramsBFV, "BFVToCKKS/Mul"), func(t *testing.T) {
+
+		kgenBFV := bfv.NewKeyGenerator(paramsBFV)
+		evaluatorBFV, err := bfv.NewEvaluator(paramsBFV, rlwe.EvaluationKey{Rlk: kgenBFV.GenRelinearizationKey(skBFV, 1)})
+		require.NoError(t, err)
+
+		// Slot-wise product in BFV, whose result must be in the same slots after the switching
+		sqrtBound := int64(math.Sqrt(float64(bound)))
+		values0, ct0 := newTestVectorsBFV(sqrtBound)
+		values1, ct1 := newTestVectorsBFV(sqrtBound)
+
+		ct := evaluatorBFV.RelinearizeNew(evaluatorBFV.MulNew(ct0, ct1))
+
+		values := make([]int64, N)
+		for i := range values {
+			values[i] = values0[i] * values1[i]
+		}
+
+		require.Equal(t, values, encoderBFV.DecodeIntNew(decryptorBFV.DecryptNew(ct)))
+
+		verifyTestVectorsCKKS(values, sw.BFVToCKKSNew(ct), t)
+	})
+
+	t.Run(testString(paramsCKKS, paramsBFV, "ShallowCopy"), func(t *testing.T) {
+		swCopy := sw.ShallowCopy()
+		require.True(t, swCopy.switcherBase == sw.switcherBase)
+		require.False(t, swCopy.Evaluator == sw.Evaluator)
+	})
+}
+
+// randInt returns a uniform integer in [-bound, bound].
+func randInt(bound int64) int64 {
+	return int64(utils.RandUint64()%uint64(2*bound+1)) - bound
+}
@@ -0,0 +1,215 @@
+package schemeswitch
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/utils"
+)
+
+// slotsBFV stores the encoded diagonals of the homomorphic linear transforms between the coefficients and the slots
+// of the BFV plaintexts. The i-th slot of a BFV plaintext m is m(psi^slotExp[i]) mod T, where psi is a primitive 2N-th
+// root of unity modulo T, so that both transforms are evaluated from the powers of psi:
+//
+// coefficients to slots: slot[i] = sum_j N^-1 * psi^(-slotExp[j] * coeffIndex[i]) * slot[j]
+//
+// slots to coefficients: slot[i] = sum_j psi^(slotExp[i] * coeffIndex[j]) * slot[j]
+//
+// where coeffIndex[i] is the index of the coefficient mapped on the i-th slot. The slots are arranged as a 2 x N/2
+// matrix, and each transform has N diagonals, indexed by the rotation k of the columns and by the rotation b of the rows.
+type slotsBFV struct {
+	// coeffsToSlotsDiags[k][b] is the diagonal (b, k) of the coefficients to slots transform (see diagonalsBFV)
+	coeffsToSlotsDiags [][2]*bfv.PlaintextMul
+
+	// slotsToCoeffsDiags[k][b] is the diagonal (b, k) of the slots to coefficients transform (see diagonalsBFV)
+	slotsToCoeffsDiags [][2]*bfv.PlaintextMul
+}
+
+func newSlotsBFV(params bfv.Parameters) (sb *slotsBFV) {
+
+	ringT := params.RingT()
+	T := params.T()
+	N := params.N()
+	logSlots := uint64(params.LogN() - 1)
+
+	encoder := bfv.NewEncoder(params)
+
+	// The i-th slot of the plaintext X is the root psi^slotExp[i]
+	ptRt := bfv.NewPlaintextRingT(params)
+	ptRt.Value.Coeffs[0][1] = 1
+	roots := encoder.DecodeUintNew(ptRt)
+
+	// slotsToCoeffsPows[k] = psi^k mod T and coeffsToSlotsPows[k] = N^-1 * psi^-k mod T
+	slotsToCoeffsPows := make([]uint64, 2*N)
+	coeffsToSlotsPows := make([]uint64, 2*N)
+
+	psi := roots[0]
+	nInv := ring.ModExp(uint64(N), T-2, T)
+	psiInv := ring.ModExp(psi, T-2, T)
+
+	exponents := make(map[uint64]uint64, 2*N)
+
+	slotsToCoeffsPows[0] = 1
+	coeffsToSlotsPows[0] = nInv
+	exponents[1] = 0
+	for k := 1; k < 2*N; k++ {
+		slotsToCoeffsPows[k] = ring.BRed(slotsToCoeffsPows[k-1], psi, T, ringT.BredParams[0])
+		coeffsToSlotsPows[k] = ring.BRed(coeffsToSlotsPows[k-1], psiInv, T, ringT.BredParams[0])
+		exponents[slotsToCoeffsPows[k]] = uint64(k)
+	}
+
+	slotExp := make([]uint64, N)
+	for i, root := range roots {
+		var ok bool
+		if slotExp[i], ok = exponents[root]; !ok {
+			panic(fmt.Errorf("cannot newSlotsBFV: T=%d is not compatible with the slots of the BFV plaintexts", T))
+		}
+	}
+
+	// The real part of the i-th CKKS slot is mapped on the coefficient bitrev(i) and its imaginary part on
+	// the coefficient N/2 + bitrev(i) by the homomorphic decoding.
+	coeffIndex := make([]uint64, N)
+	for i := 0; i < N>>1; i++ {
+		coeffIndex[i] = utils.BitReverse64(uint64(i), logSlots)
+		coeffIndex[i+N>>1] = coeffIndex[i] + uint64(N>>1)
+	}
+
+	sb = new(slotsBFV)
+	sb.coeffsToSlotsDiags = diagonalsBFV(params, encoder, coeffsToSlotsPows, slotExp, coeffIndex, true)
+	sb.slotsToCoeffsDiags = diagonalsBFV(params, encoder, slotsToCoeffsPows, slotExp, coeffIndex, false)
+
+	return
+}
+
+// diagonalsBFV returns the encoded diagonals of the linear transform between the coefficients and the slots of the BFV
+// plaintexts given by the powers pows of psi (see slotsBFV). The diagonal (b, k), for k = j*n1 + i, is pre-rotated by
+// -j*n1 columns for the baby-step giant-step evaluation of linearTransformBFV.
+func diagonalsBFV(params bfv.Parameters, encoder bfv.Encoder, pows, slotExp, coeffIndex []uint64, coeffsToSlots bool) (diags [][2]*bfv.PlaintextMul) {
+
+	N := params.N()
+	slots := N >> 1
+	mask := uint64(2*N - 1)
+	n1, _ := bsgsSplitBFV(params.LogN())
+
+	diags = make([][2]*bfv.PlaintextMul, slots)
+	diag := make([]uint64, N)
+
+	for k := range diags {
+
+		j := k / n1
+
+		for b := 0; b < 2; b++ {
+
+			// Diagonal (b, k) rotated by -j*n1 columns: the output slot (r, c) receives the input slot (r^b, c+k)
+			for r := 0; r < 2; r++ {
+				for c := 0; c < slots; c++ {
+					cc := (c - j*n1 + slots) % slots
+					out := r*slots + cc
+					in := (r^b)*slots + (cc+k)%slots
+					if coeffsToSlots {
+						diag[r*slots+c] = pows[(slotExp[in]*coeffIndex[out])&mask]
+					} else {
+						diag[r*slots+c] = pows[(slotExp[out]*coeffIndex[in])&mask]
+					}
+				}
+			}
+
+			diags[k][b] = bfv.NewPlaintextMul(params)
+			encoder.EncodeUintMul(diag, diags[k][b])
+		}
+	}
+
+	return
+}
+
+// rotationsBFV returns the list of column rotations performed by the homomorphic linear transforms between the
+// coefficients and the slots of the BFV plaintexts, which also perform the row rotation.
+func rotationsBFV(logN int) (rotations []int) {
+	n1, n2 := bsgsSplitBFV(logN)
+	rotations = []int{}
+	for i := 1; i < n1; i++ {
+		rotations = append(rotations, i)
+	}
+	for j := 1; j < n2; j++ {
+		rotations = append(rotations, j*n1)
+	}
+	return
+}
+
+// bsgsSplitBFV returns the number of baby steps and of giant steps of the homomorphic linear transforms between the
+// coefficients and the slots of the BFV plaintexts, which are evaluated on the N/2 columns of each of the two rows.
+func bsgsSplitBFV(logN int) (n1, n2 int) {
+	logSlots := logN - 1
+	n1 = 1 << (logSlots >> 1)
+	n2 = (1 << logSlots) / n1
+	return
+}
+
+// coeffsToSlotsBFV maps the coefficients of the BFV plaintext of ct on its slots (see the package documentation)
+// and returns the result on ctOut.
+func (sw *Switcher) coeffsToSlotsBFV(ct, ctOut *bfv.Ciphertext) {
+	sw.linearTransformBFV(ct, true, ctOut)
+}
+
+// slotsToCoeffsBFV maps the slots of the BFV plaintext of ct on its coefficients (see the package documentation)
+// and returns the result on ctOut.
+func (sw *Switcher) slotsToCoeffsBFV(ct, ctOut *bfv.Ciphertext) {
+	sw.linearTransformBFV(ct, false, ctOut)
+}
+
+// linearTransformBFV evaluates the dense linear transform between the coefficients and the slots of the BFV plaintext
+// of ct with the baby-step giant-step algorithm, on the diagonals precomputed by newSlotsBFV.
+func (sw *Switcher) linearTransformBFV(ct *bfv.Ciphertext, coeffsToSlots bool, ctOut *bfv.Ciphertext) {
+
+	params := sw.paramsBFV
+	ringQ := params.RingQ()
+	n1, n2 := bsgsSplitBFV(params.LogN())
+
+	diags := sw.slotsBFV.slotsToCoeffsDiags
+	if coeffsToSlots {
+		diags = sw.slotsBFV.coeffsToSlotsDiags
+	}
+
+	// Baby steps: the rotations of the columns of ct and of its rotated rows, in the NTT domain
+	babySteps := make([][2]*bfv.Ciphertext, n1)
+	ctRows := sw.evaluatorBFV.RotateRowsNew(ct)
+	for i := range babySteps {
+		for b, ctIn := range []*bfv.Ciphertext{ct, ctRows} {
+			babySteps[i][b] = sw.evaluatorBFV.RotateColumnsNew(ctIn, i)
+			for _, pol := range babySteps[i][b].Value {
+				ringQ.NTT(pol, pol)
+			}
+		}
+	}
+
+	acc := bfv.NewCiphertext(params, 1)
+	inner := bfv.NewCiphertext(params, 1)
+
+	for j := 0; j < n2; j++ {
+
+		inner.Value[0].Zero()
+		inner.Value[1].Zero()
+
+		for i := 0; i < n1; i++ {
+			for b := 0; b < 2; b++ {
+				for u := range inner.Value {
+					ringQ.MulCoeffsMontgomeryAndAdd(babySteps[i][b].Value[u], diags[j*n1+i][b].Value, inner.Value[u])
+				}
+			}
+		}
+
+		for _, pol := range inner.Value {
+			ringQ.InvNTT(pol, pol)
+		}
+
+		// Giant step
+		if j != 0 {
+			sw.evaluatorBFV.RotateColumns(inner, j*n1, inner)
+		}
+
+		sw.evaluatorBFV.Add(acc, inner, acc)
+	}
+
+	ctOut.Copy(acc.El())
+}
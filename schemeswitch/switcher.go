@@ -0,0 +1,379 @@
+package schemeswitch
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v3/bfv"
+	"github.com/tuneinsight/lattigo/v3/ckks"
+	"github.com/tuneinsight/lattigo/v3/ckks/advanced"
+	"github.com/tuneinsight/lattigo/v3/ring"
+	"github.com/tuneinsight/lattigo/v3/rlwe"
+)
+
+// Switcher is a struct that switches ciphertexts between the CKKS and BFV schemes. It stores the plaintext
+// matrices, including the 2N encoded diagonals of the BFV linear transforms, the polynomial approximation and
+// the keys of the scheme switching, and a memory pool.
+type Switcher struct {
+	advanced.Evaluator
+	evaluatorBFV bfv.Evaluator
+	*switcherBase
+}
+
+// EvaluationKey is a struct storing the keys of the scheme switching: the relinearization and rotation keys of
+// the CKKS parameters and the rotation keys of the BFV parameters, under the secret key returned by SecretKeyBFV.
+type EvaluationKey struct {
+	CKKS rlwe.EvaluationKey
+	BFV  *rlwe.RotationKeySet
+}
+
+type switcherBase struct {
+	Parameters
+	paramsCKKS ckks.Parameters
+	paramsBFV  bfv.Parameters
+
+	evalModPoly advanced.EvalModPoly
+	stcMatrices advanced.EncodingMatrix
+	ctsMatrices advanced.EncodingMatrix
+
+	slotsBFV *slotsBFV
+
+	// Factor by which the ciphertext is scaled after the modulus raising
+	evalModScaleUp float64
+
+	// Scale of the values after the EvalMod step
+	evalModScaleOut float64
+}
+
+// NewSwitcher creates a new Switcher between the parameters paramsCKKS and paramsBFV, with the scheme-switching parameters
+// swParams and the evaluation key evk (see GenEvaluationKey). The parameters must share the same ring degree, paramsCKKS must
+// be of ring type ring.Standard with the maximum number of slots, and the moduli of paramsBFV must be the first moduli of paramsCKKS.
+func NewSwitcher(paramsCKKS ckks.Parameters, paramsBFV bfv.Parameters, swParams Parameters, evk EvaluationKey) (sw *Switcher, err error) {
+
+	if err = checkCompatibility(paramsCKKS, paramsBFV); err != nil {
+		return nil, err
+	}
+
+	if swParams.EvalModParameters.SineType == advanced.Sin && swParams.EvalModParameters.DoubleAngle != 0 {
+		return nil, fmt.Errorf("cannot use double angle formula for SineType = Sin -> must use SineType = Cos")
+	}
+
+	if swParams.EvalModParameters.SineType == advanced.Cos1 && swParams.EvalModParameters.SineDeg < 2*(swParams.EvalModParameters.K-1) {
+		return nil, fmt.Errorf("SineType 'advanced.Cos1' uses a minimum degree of 2*(K-1) but EvalMod degree is smaller")
+	}
+
+	if swParams.CoeffsToSlotsParameters.LevelStart-swParams.CoeffsToSlotsParameters.Depth(true) != swParams.EvalModParameters.LevelStart {
+		return nil, fmt.Errorf("starting level and depth of CoeffsToSlotsParameters inconsistent starting level of EvalModParameters")
+	}
+
+	if swParams.SlotsToCoeffsParameters.LevelStart-swParams.SlotsToCoeffsParameters.Depth(true) <= paramsBFV.MaxLevel() {
+		return nil, fmt.Errorf("SlotsToCoeffsParameters must end above the last level of the BFV moduli")
+	}
+
+	if swParams.EvalModParameters.Q != paramsCKKS.RingQ().Modulus[0] {
+		return nil, fmt.Errorf("EvalModParameters.Q must be the first modulus of paramsCKKS")
+	}
+
+	sw = new(Switcher)
+	sw.switcherBase = newSwitcherBase(paramsCKKS, paramsBFV, swParams)
+
+	if err = sw.switcherBase.checkKeys(evk); err != nil {
+		return nil, fmt.Errorf("invalid scheme-switching key: %w", err)
+	}
+
+	if sw.Evaluator, err = advanced.NewEvaluator(paramsCKKS, evk.CKKS); err != nil {
+		return nil, fmt.Errorf("invalid scheme-switching key: %w", err)
+	}
+
+	if sw.evaluatorBFV, err = bfv.NewEvaluator(paramsBFV, rlwe.EvaluationKey{Rtks: evk.BFV}); err != nil {
+		return nil, fmt.Errorf("invalid scheme-switching key: %w", err)
+	}
+
+	return
+}
+
+// ShallowCopy creates a shallow copy of this Switcher in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// Switcher can be used concurrently.
+func (sw *Switcher) ShallowCopy() *Switcher {
+	return &Switcher{
+		Evaluator:    sw.Evaluator.ShallowCopy(),
+		evaluatorBFV: sw.evaluatorBFV.ShallowCopy(),
+		switcherBase: sw.switcherBase,
+	}
+}
+
+// GenEvaluationKey generates, from the secret key sk of paramsCKKS, the relinearization key and the rotation keys
+// required by a Switcher between paramsCKKS and paramsBFV of parameters swParams.
+func GenEvaluationKey(paramsCKKS ckks.Parameters, paramsBFV bfv.Parameters, swParams Parameters, sk *rlwe.SecretKey) EvaluationKey {
+	kgen := ckks.NewKeyGenerator(paramsCKKS)
+	rlk := kgen.GenRelinearizationKey(sk, 1)
+	rtks := kgen.GenRotationKeysForRotations(swParams.Rotations(paramsCKKS.LogN(), paramsCKKS.LogSlots()), true, sk)
+	rtksBFV := bfv.NewKeyGenerator(paramsBFV).GenRotationKeysForRotations(rotationsBFV(paramsBFV.LogN()), true, SecretKeyBFV(paramsCKKS, paramsBFV, sk))
+	return EvaluationKey{CKKS: rlwe.EvaluationKey{Rlk: rlk, Rtks: rtks}, BFV: rtksBFV}
+}
+
+// SecretKeyBFV returns the secret key of paramsBFV that has the same coefficients as the secret key sk of paramsCKKS.
+// The ciphertexts switched from CKKS to BFV are encrypted under this key.
+func SecretKeyBFV(paramsCKKS ckks.Parameters, paramsBFV bfv.Parameters, sk *rlwe.SecretKey) (skBFV *rlwe.SecretKey) {
+
+	ringQ := paramsCKKS.RingQ()
+	q := ringQ.Modulus[0]
+
+	tmp := ringQ.NewPolyLvl(0)
+	ringQ.InvNTTLvl(0, sk.Value.Q, tmp)
+	ringQ.InvMFormLvl(0, tmp, tmp)
+
+	skBFV = rlwe.NewSecretKey(paramsBFV.Parameters)
+
+	ringQP := paramsBFV.RingQP()
+	levelQ, levelP := paramsBFV.QCount()-1, paramsBFV.PCount()-1
+
+	var moduli []uint64
+	var coeffs [][]uint64
+	moduli = append(moduli, ringQP.RingQ.Modulus...)
+	coeffs = append(coeffs, skBFV.Value.Q.Coeffs...)
+	if levelP > -1 {
+		moduli = append(moduli, ringQP.RingP.Modulus...)
+		coeffs = append(coeffs, skBFV.Value.P.Coeffs...)
+	}
+
+	for i, qi := range moduli {
+		for j, c := range tmp.Coeffs[0] {
+			if c > q>>1 {
+				coeffs[i][j] = qi - (q-c)%qi
+			} else {
+				coeffs[i][j] = c % qi
+			}
+		}
+	}
+
+	ringQP.NTTLvl(levelQ, levelP, skBFV.Value, skBFV.Value)
+	ringQP.MFormLvl(levelQ, levelP, skBFV.Value, skBFV.Value)
+
+	return
+}
+
+// CKKSToBFVNew switches the CKKS ciphertext ct to a new BFV ciphertext (see CKKSToBFV).
+func (sw *Switcher) CKKSToBFVNew(ct *ckks.Ciphertext) (ctOut *bfv.Ciphertext) {
+	ctOut = bfv.NewCiphertext(sw.paramsBFV, 1)
+	sw.CKKSToBFV(ct, ctOut)
+	return
+}
+
+// CKKSToBFV switches the CKKS ciphertext ct to a BFV ciphertext of plaintext modulus T, encrypted under the secret
+// key returned by SecretKeyBFV, and returns the result on ctOut. The level of ct must be at least the starting level
+// of the SlotsToCoeffsParameters, and the real and imaginary parts of its slots must be integers, up to an error e.
+// They are reduced modulo T and mapped on the slots of the BFV plaintext (see the package documentation). The linear
+// transform from the coefficients to the slots of the BFV plaintext multiplies e by about T*N, which must remain well
+// below 1/2 for the switching to be exact. The scale of ct must be smaller than Q/(T*B), where Q is the product of the
+// BFV moduli and B bounds the values of ct.
+func (sw *Switcher) CKKSToBFV(ct *ckks.Ciphertext, ctOut *bfv.Ciphertext) {
+
+	if ct.Level() < sw.SlotsToCoeffsParameters.LevelStart {
+		panic("cannot CKKSToBFV: ct.Level() < SlotsToCoeffsParameters.LevelStart")
+	}
+
+	ctTmp := ct.CopyNew()
+	sw.DropLevel(ctTmp, ctTmp.Level()-sw.SlotsToCoeffsParameters.LevelStart)
+
+	// Homomorphic decoding: moves the slots into the coefficients
+	ctTmp = sw.SlotsToCoeffsNew(ctTmp, nil, sw.stcMatrices)
+
+	levelBFV := sw.paramsBFV.MaxLevel()
+	sw.DropLevel(ctTmp, ctTmp.Level()-levelBFV-1)
+
+	// Integer scaling of the message from ctTmp.Scale to Q/T
+	QOverT := new(big.Float).Quo(new(big.Float).SetInt(sw.paramsBFV.RingQ().ModulusBigint), new(big.Float).SetUint64(sw.paramsBFV.T()))
+	scalingFloat := new(big.Float).Quo(QOverT, big.NewFloat(ctTmp.Scale))
+	scaling, _ := scalingFloat.Add(scalingFloat, big.NewFloat(0.5)).Int(nil)
+
+	if scaling.Sign() < 1 {
+		panic("cannot CKKSToBFV: the scale of the ciphertext is larger than Q/T")
+	}
+
+	// Matches the scale of the ciphertext with (Q/T)/scaling, as the rounding of the scaling would otherwise
+	// introduce an error proportional to the message.
+	scale, _ := QOverT.Quo(QOverT, new(big.Float).SetInt(scaling)).Float64()
+	sw.SetScale(ctTmp, scale)
+
+	ringQ := sw.paramsCKKS.RingQ()
+	ctCoeffs := bfv.NewCiphertext(sw.paramsBFV, 1)
+	for i := range ctTmp.Value {
+		ringQ.MulScalarBigintLvl(levelBFV, ctTmp.Value[i], scaling, ctTmp.Value[i])
+		ringQ.InvNTTLvl(levelBFV, ctTmp.Value[i], ctCoeffs.Value[i])
+	}
+
+	// Homomorphic BFV encoding: moves the coefficients into the slots
+	sw.coeffsToSlotsBFV(ctCoeffs, ctOut)
+}
+
+// BFVToCKKSNew switches the BFV ciphertext ct, encrypted under the secret key returned by SecretKeyBFV, to a new CKKS
+// ciphertext encrypted under the secret key of paramsCKKS. The centered slots of the BFV plaintext must be bounded
+// by T/EvalModParameters.MessageRatio, and are mapped on the slots of the CKKS ciphertext (see the package documentation),
+// up to the error of the approximation of the modular reduction, which grows with the ratio between the coefficients and T
+// unless EvalModParameters.ArcSineDeg is set.
+// The returned ciphertext is at the level at which the EvalMod step ends, and its scale is determined by the EvalModParameters.
+func (sw *Switcher) BFVToCKKSNew(ct *bfv.Ciphertext) (ctOut *ckks.Ciphertext) {
+
+	ringQ := sw.paramsCKKS.RingQ()
+	levelBFV := sw.paramsBFV.MaxLevel()
+
+	// Homomorphic BFV decoding: moves the slots into the coefficients
+	ctCoeffs := bfv.NewCiphertext(sw.paramsBFV, 1)
+	sw.slotsToCoeffsBFV(ct, ctCoeffs)
+
+	ctTmp := ckks.NewCiphertext(sw.paramsCKKS, 1, levelBFV, sw.evalModPoly.ScalingFactor()/sw.evalModPoly.MessageRatio())
+
+	// Modulus switching from Q to Q0, in the coefficient domain
+	for i := range ctTmp.Value {
+		ring.CopyValuesLvl(levelBFV, ctCoeffs.Value[i], ctTmp.Value[i])
+		ringQ.DivRoundByLastModulusManyLvl(levelBFV, levelBFV, ctTmp.Value[i], ringQ.NewPolyLvl(levelBFV), ctTmp.Value[i])
+		ctTmp.Value[i].Coeffs = ctTmp.Value[i].Coeffs[:1]
+		ctTmp.Value[i].IsNTT = false
+	}
+
+	// Modulus raising from Q0 to the full CKKS moduli
+	ctTmp = advanced.ModUpFromQ0(sw.paramsCKKS, ctTmp)
+
+	if sw.evalModScaleUp > 1 {
+		sw.ScaleUp(ctTmp, math.Round(sw.evalModScaleUp), ctTmp)
+		ctTmp.Scale = sw.evalModPoly.ScalingFactor() / sw.evalModPoly.MessageRatio()
+	}
+
+	// Homomorphic encoding: moves the coefficients into the slots
+	ctReal, ctImag := sw.CoeffsToSlotsNew(ctTmp, sw.ctsMatrices)
+
+	// Homomorphic modular reduction by Q0
+	ctReal = sw.EvalModNew(ctReal, sw.evalModPoly)
+	ctImag = sw.EvalModNew(ctImag, sw.evalModPoly)
+
+	ctOut = ckks.NewCiphertext(sw.paramsCKKS, 1, ctReal.Level(), sw.evalModScaleOut)
+	ctImag.Scale, ctReal.Scale = ctOut.Scale, ctOut.Scale
+	sw.MultByi(ctImag, ctOut)
+	sw.Add(ctOut, ctReal, ctOut)
+
+	return
+}
+
+// checkKeys checks if all the necessary keys are present in the evaluation key.
+func (sb *switcherBase) checkKeys(evk EvaluationKey) (err error) {
+
+	if evk.CKKS.Rlk == nil {
+		return fmt.Errorf("relinearization key is nil")
+	}
+
+	rtks := evk.CKKS.RotationKeys()
+	if rtks == nil {
+		return fmt.Errorf("rotation key is nil")
+	}
+
+	galEls := make(map[uint64]bool)
+	for _, galEl := range rtks.GaloisElements() {
+		galEls[galEl] = true
+	}
+
+	rotMissing := []int{}
+	for _, i := range sb.Rotations(sb.paramsCKKS.LogN(), sb.paramsCKKS.LogSlots()) {
+		if !galEls[sb.paramsCKKS.GaloisElementForColumnRotationBy(i)] {
+			rotMissing = append(rotMissing, i)
+		}
+	}
+
+	if len(rotMissing) != 0 {
+		return fmt.Errorf("rotation key(s) missing: %d", rotMissing)
+	}
+
+	if !galEls[sb.paramsCKKS.GaloisElementForRowRotation()] {
+		return fmt.Errorf("conjugation key missing")
+	}
+
+	if evk.BFV == nil {
+		return fmt.Errorf("BFV rotation key is nil")
+	}
+
+	galEls = make(map[uint64]bool)
+	for _, galEl := range evk.BFV.GaloisElements() {
+		galEls[galEl] = true
+	}
+
+	rotMissing = []int{}
+	for _, i := range rotationsBFV(sb.paramsBFV.LogN()) {
+		if !galEls[sb.paramsBFV.GaloisElementForColumnRotationBy(i)] {
+			rotMissing = append(rotMissing, i)
+		}
+	}
+
+	if len(rotMissing) != 0 {
+		return fmt.Errorf("BFV rotation key(s) missing: %d", rotMissing)
+	}
+
+	if !galEls[sb.paramsBFV.GaloisElementForRowRotation()] {
+		return fmt.Errorf("BFV row rotation key missing")
+	}
+
+	return nil
+}
+
+// checkCompatibility returns an error if the parameters paramsCKKS and paramsBFV are not compatible for the scheme switching.
+func checkCompatibility(paramsCKKS ckks.Parameters, paramsBFV bfv.Parameters) error {
+
+	if paramsCKKS.RingType() != ring.Standard {
+		return fmt.Errorf("the ring type of paramsCKKS must be ring.Standard")
+	}
+
+	if paramsCKKS.LogN() != paramsBFV.LogN() {
+		return fmt.Errorf("paramsCKKS and paramsBFV must have the same ring degree")
+	}
+
+	if paramsCKKS.LogSlots() != paramsCKKS.MaxLogSlots() {
+		return fmt.Errorf("paramsCKKS must have the maximum number of slots")
+	}
+
+	if paramsBFV.QCount() > paramsCKKS.QCount() {
+		return fmt.Errorf("paramsBFV has more moduli than paramsCKKS")
+	}
+
+	for i, qi := range paramsBFV.Q() {
+		if qi != paramsCKKS.Q()[i] {
+			return fmt.Errorf("the moduli of paramsBFV must be the first moduli of paramsCKKS")
+		}
+	}
+
+	return nil
+}
+
+func newSwitcherBase(paramsCKKS ckks.Parameters, paramsBFV bfv.Parameters, swParams Parameters) (sb *switcherBase) {
+	sb = new(switcherBase)
+	sb.paramsCKKS = paramsCKKS
+	sb.paramsBFV = paramsBFV
+	sb.Parameters = swParams
+
+	sb.evalModPoly = advanced.NewEvalModPolyFromLiteral(swParams.EvalModParameters)
+
+	// If the scale used during the EvalMod step is larger than Q0, the ciphertext is scaled up after the modulus raising,
+	// else the division by Q0 is done (totally or partly) during the CoeffsToSlots step.
+	sb.evalModScaleUp = sb.evalModPoly.ScalingFactor() / math.Exp2(math.Round(math.Log2(paramsCKKS.QiFloat64(0))))
+
+	// The EvalMod step maps the plaintext coefficients (Q0/T) * m mod Q0 on the values m * ScalingFactor * QDiff / T
+	sb.evalModScaleOut = sb.evalModPoly.ScalingFactor() * sb.evalModPoly.QDiff() / float64(paramsBFV.T())
+
+	encoder := ckks.NewEncoder(paramsCKKS)
+
+	sb.CoeffsToSlotsParameters.LinearTransformType = advanced.CoeffsToSlots
+	sb.CoeffsToSlotsParameters.LogN = paramsCKKS.LogN()
+	sb.CoeffsToSlotsParameters.LogSlots = paramsCKKS.LogSlots()
+	sb.CoeffsToSlotsParameters.Scaling = sb.evalModPoly.CoeffsToSlotsScaling(paramsCKKS.QiFloat64(0), paramsCKKS.LogSlots())
+	sb.ctsMatrices = advanced.NewHomomorphicEncodingMatrixFromLiteral(sb.CoeffsToSlotsParameters, encoder)
+
+	sb.SlotsToCoeffsParameters.LinearTransformType = advanced.SlotsToCoeffs
+	sb.SlotsToCoeffsParameters.LogN = paramsCKKS.LogN()
+	sb.SlotsToCoeffsParameters.LogSlots = paramsCKKS.LogSlots()
+	sb.SlotsToCoeffsParameters.Scaling = 1
+	sb.stcMatrices = advanced.NewHomomorphicEncodingMatrixFromLiteral(sb.SlotsToCoeffsParameters, encoder)
+
+	sb.slotsBFV = newSlotsBFV(paramsBFV)
+
+	return
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelTokens bounds the number of goroutines spawned by all the concurrent calls to ParallelFor,
+// including the nested ones, to the value of GOMAXPROCS at the initialization of the package.
+var parallelTokens = make(chan struct{}, runtime.GOMAXPROCS(0))
+
+// ParallelFor calls f(i) for each i in [0, n), splitting the indexes across at most workers
+// goroutines, and returns once all the calls have returned. The calling goroutine processes indexes
+// itself, and additional goroutines are only spawned while the shared bound of ParallelFor is not
+// reached, so that nested calls run inline instead of multiplying the number of goroutines. The
+// indexes are assigned dynamically, so that f must be safe for concurrent calls on distinct indexes.
+// If workers < 2 or n < 2, the calls are made sequentially in the calling goroutine.
+func ParallelFor(workers, n int, f func(i int)) {
+
+	if workers > n {
+		workers = n
+	}
+
+	if workers < 2 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	next := int64(-1)
+	run := func() {
+		for i := int(atomic.AddInt64(&next, 1)); i < n; i = int(atomic.AddInt64(&next, 1)) {
+			f(i)
+		}
+	}
+
+	var wg sync.WaitGroup
+
+spawn:
+	for w := 1; w < workers; w++ {
+		select {
+		case parallelTokens <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-parallelTokens
+					wg.Done()
+				}()
+				run()
+			}()
+		default:
+			break spawn
+		}
+	}
+
+	run()
+	wg.Wait()
+}
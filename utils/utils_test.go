@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -13,3 +14,52 @@ func TestAllDistinct(t *testing.T) {
 	require.False(t, AllDistinct([]uint64{1, 1}))
 	require.False(t, AllDistinct([]uint64{1, 2, 3, 4, 5, 5}))
 }
+
+func TestParallelFor(t *testing.T) {
+
+	t.Run("Indexes", func(t *testing.T) {
+		for _, workers := range []int{0, 1, 4, 64} {
+			calls := make([]int32, 100)
+			ParallelFor(workers, len(calls), func(i int) {
+				atomic.AddInt32(&calls[i], 1)
+			})
+			for _, c := range calls {
+				require.Equal(t, int32(1), c)
+			}
+		}
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		calls := make([]int32, 64)
+		ParallelFor(8, 8, func(i int) {
+			ParallelFor(8, 8, func(j int) {
+				atomic.AddInt32(&calls[i*8+j], 1)
+			})
+		})
+		for _, c := range calls {
+			require.Equal(t, int32(1), c)
+		}
+	})
+
+	t.Run("Bounded", func(t *testing.T) {
+
+		// Holds all the tokens, so that the calls must run in the calling goroutine
+		for i := 0; i < cap(parallelTokens); i++ {
+			parallelTokens <- struct{}{}
+		}
+		defer func() {
+			for i := 0; i < cap(parallelTokens); i++ {
+				<-parallelTokens
+			}
+		}()
+
+		var active, maxActive int32
+		ParallelFor(8, 64, func(i int) {
+			if a := atomic.AddInt32(&active, 1); a > atomic.LoadInt32(&maxActive) {
+				atomic.StoreInt32(&maxActive, a)
+			}
+			atomic.AddInt32(&active, -1)
+		})
+		require.Equal(t, int32(1), maxActive)
+	})
+}